@@ -6,8 +6,6 @@ import (
 	"fmt"
 	"io"
 	"time"
-
-	"github.com/valyala/fastjson"
 )
 
 // Activity Types
@@ -225,6 +223,7 @@ var ActivityTypes = ActivityVocabularyTypes{
 	UndoType,
 	UpdateType,
 	ViewType,
+	PushType,
 }
 
 // HasRecipients is an interface implemented by activities to return their audience
@@ -341,6 +340,21 @@ type Activity struct {
 	// as a form of provenance, or to support future editing by clients.
 	// In general, clients do the conversion from source to content, not the other way around.
 	Source Source `jsonld:"source,omitempty"`
+	// Sensitive marks the content of the object as not safe for all audiences, so it should be hidden
+	// behind its Summary. Like FocalPoint and Blurhash, it is a Mastodon extension to the vocabulary.
+	Sensitive bool `jsonld:"sensitive,omitempty"`
+	// FocalPoint is the point of an image which must stay visible when it is cropped, as x and y
+	// coordinates between -1.0 and 1.0.
+	FocalPoint []float64 `jsonld:"focalPoint,omitempty"`
+	// Blurhash is a compact representation of a blurred placeholder for an image.
+	Blurhash string `jsonld:"blurhash,omitempty"`
+	// Proof holds the Data Integrity proof of the document, like the eddsa-jcs-2022 ones of FEP-8b32.
+	// The proof sets, which hold more than one proof, are kept unchanged in the Extensions.
+	Proof *DataIntegrityProof `jsonld:"proof,omitempty"`
+	// Extensions holds the properties of the document that are not part of the vocabulary known to this package,
+	// like the ones coming from ActivityStreams extensions or custom namespaces.
+	// They are kept as raw JSON values so they can be written back unchanged.
+	Extensions Extensions `jsonld:"-"`
 	// CanReceiveActivities describes one or more entities that either performed or are expected to perform the activity.
 	// Any single activity can have multiple actors. The actor may be specified using an indirect Link.
 	Actor Item `jsonld:"actor,omitempty"`
@@ -364,6 +378,9 @@ type Activity struct {
 	// the object of the activity is the movie added.
 	// When used within a Relationship describes the entity to which the subject is related.
 	Object Item `jsonld:"object,omitempty"`
+	// Signature holds the Linked Data Signature of the activity, like the RsaSignature2017 which Mastodon
+	// attaches to the public activities, so other servers can relay them.
+	Signature *LinkedDataSignature `jsonld:"signature,omitempty"`
 }
 
 // GetType returns the ActivityVocabulary type of the current Activity
@@ -770,8 +787,7 @@ func ActivityNew(id ID, typ ActivityVocabularyType, ob Item) *Activity {
 
 // UnmarshalJSON decodes an incoming JSON document into the receiver object.
 func (a *Activity) UnmarshalJSON(data []byte) error {
-	p := fastjson.Parser{}
-	val, err := p.ParseBytes(data)
+	val, err := parseJSON(data)
 	if err != nil {
 		return err
 	}
@@ -818,14 +834,21 @@ func ToActivity(it LinkOrIRI) (*Activity, error) {
 
 // MarshalJSON encodes the receiver object to a JSON document.
 func (a Activity) MarshalJSON() ([]byte, error) {
-	b := bytes.Buffer{}
-	JSONWrite(&b, '{')
+	return jsonMarshal(a)
+}
+
+func (a Activity) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
+	start := b.Len()
+	JSONWrite(b, '{')
 
-	if !JSONWriteActivityValue(&b, a) {
-		return nil, nil
+	notEmpty := jsonWriteActivityValue(b, a, opts)
+	notEmpty = JSONWriteExtensions(b, a.Extensions, notEmpty, objectProperties, intransitiveActivityProperties, activityProperties) || notEmpty
+	if !notEmpty {
+		b.Truncate(start)
+		return false
 	}
-	JSONWrite(&b, '}')
-	return b.Bytes(), nil
+	JSONWrite(b, '}')
+	return true
 }
 
 // UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
@@ -881,6 +904,12 @@ func mapActivityProperties(mm map[string][]byte, a *Activity) (hasData bool, err
 		}
 		hasData = true
 	}
+	if a.Signature != nil {
+		if mm["signature"], err = a.Signature.GobEncode(); err != nil {
+			return hasData, err
+		}
+		hasData = true
+	}
 	return hasData, err
 }
 
@@ -936,6 +965,9 @@ func (a *Activity) equal(with Activity) bool {
 	if !ItemsEqual(a.Object, with.Object) {
 		return false
 	}
+	if (a.Signature == nil) != (with.Signature == nil) || (a.Signature != nil && *a.Signature != *with.Signature) {
+		return false
+	}
 	return true
 }
 
@@ -5,9 +5,9 @@ import (
 	"encoding/gob"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"time"
-
-	"github.com/valyala/fastjson"
+	"unsafe"
 )
 
 // CanReceiveActivities Types
@@ -26,6 +26,7 @@ var ActorTypes = ActivityVocabularyTypes{
 	OrganizationType,
 	PersonType,
 	ServiceType,
+	RepositoryType,
 }
 
 // CanReceiveActivities is generally one of the ActivityStreams Actor Types, but they don't have to be.
@@ -135,6 +136,21 @@ type Actor struct {
 	// as a form of provenance, or to support future editing by clients.
 	// In general, clients do the conversion from source to content, not the other way around.
 	Source Source `jsonld:"source,omitempty"`
+	// Sensitive marks the content of the object as not safe for all audiences, so it should be hidden
+	// behind its Summary. Like FocalPoint and Blurhash, it is a Mastodon extension to the vocabulary.
+	Sensitive bool `jsonld:"sensitive,omitempty"`
+	// FocalPoint is the point of an image which must stay visible when it is cropped, as x and y
+	// coordinates between -1.0 and 1.0.
+	FocalPoint []float64 `jsonld:"focalPoint,omitempty"`
+	// Blurhash is a compact representation of a blurred placeholder for an image.
+	Blurhash string `jsonld:"blurhash,omitempty"`
+	// Proof holds the Data Integrity proof of the document, like the eddsa-jcs-2022 ones of FEP-8b32.
+	// The proof sets, which hold more than one proof, are kept unchanged in the Extensions.
+	Proof *DataIntegrityProof `jsonld:"proof,omitempty"`
+	// Extensions holds the properties of the document that are not part of the vocabulary known to this package,
+	// like the ones coming from ActivityStreams extensions or custom namespaces.
+	// They are kept as raw JSON values so they can be written back unchanged.
+	Extensions Extensions `jsonld:"-"`
 	// A reference to an [ActivityStreams] OrderedCollection comprised of all the messages received by the actor;
 	// see 5.2 Inbox.
 	Inbox Item `jsonld:"inbox,omitempty"`
@@ -160,6 +176,31 @@ type Actor struct {
 	// A list of supplementary Collections which may be of interest.
 	Streams   ItemCollection `jsonld:"streams,omitempty"`
 	PublicKey PublicKey      `jsonld:"publicKey,omitempty"`
+	// AssertionMethod holds the keys which the actor uses for signing, like the integrity proofs of
+	// its activities. Unlike the PublicKey, it can contain multiple keys, eg: during a key rotation.
+	// See FEP-521a.
+	AssertionMethod []Multikey `jsonld:"assertionMethod,omitempty"`
+	// ManuallyApprovesFollowers shows that the actor reviews the Follow activities it receives, before accepting
+	// them. This, and the properties below, are Mastodon extensions to the vocabulary.
+	ManuallyApprovesFollowers bool `jsonld:"manuallyApprovesFollowers,omitempty"`
+	// Discoverable shows that the actor agrees to be suggested to other users, and listed in directories.
+	Discoverable bool `jsonld:"discoverable,omitempty"`
+	// Indexable shows that the actor agrees to have its public objects indexed by search engines.
+	Indexable bool `jsonld:"indexable,omitempty"`
+	// Featured is the collection of the objects pinned by the actor on its profile.
+	Featured Item `jsonld:"featured,omitempty"`
+	// FeaturedTags is the collection of the Hashtags featured by the actor on its profile.
+	FeaturedTags Item `jsonld:"featuredTags,omitempty"`
+	// MovedTo is the actor this one has moved to. It's set when the account was migrated.
+	MovedTo Item `jsonld:"movedTo,omitempty"`
+	// AlsoKnownAs holds the other actors which belong to the same person, like the ones it has moved from.
+	AlsoKnownAs ItemCollection `jsonld:"alsoKnownAs,omitempty"`
+	// PostingRestrictedToMods shows that only the Moderators of a Group can create new posts in it, while the
+	// other members can still reply to them. This, and the Moderators property, are Lemmy extensions to the
+	// vocabulary, used by the groups of FEP-1b12.
+	PostingRestrictedToMods bool `jsonld:"postingRestrictedToMods,omitempty"`
+	// Moderators is the collection of the actors which moderate the Group.
+	Moderators Item `jsonld:"moderators,omitempty"`
 }
 
 // GetID returns the ID corresponding to the current Actor
@@ -192,8 +233,7 @@ type PublicKey struct {
 }
 
 func (p *PublicKey) UnmarshalJSON(data []byte) error {
-	par := fastjson.Parser{}
-	val, err := par.ParseBytes(data)
+	val, err := parseJSON(data)
 	if err != nil {
 		return err
 	}
@@ -202,26 +242,31 @@ func (p *PublicKey) UnmarshalJSON(data []byte) error {
 }
 
 func (p PublicKey) MarshalJSON() ([]byte, error) {
-	b := bytes.Buffer{}
+	return jsonMarshal(p)
+}
+
+func (p PublicKey) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
 	notEmpty := false
-	JSONWrite(&b, '{')
+	start := b.Len()
+	JSONWrite(b, '{')
 	if v, err := p.ID.MarshalJSON(); err == nil && len(v) > 0 {
-		notEmpty = JSONWriteProp(&b, "id", v, false)
+		notEmpty = JSONWriteProp(b, "id", v, false)
 	}
 	if len(p.Owner) > 0 {
-		notEmpty = JSONWriteIRIProp(&b, "owner", p.Owner, notEmpty) || notEmpty
+		notEmpty = JSONWriteIRIProp(b, "owner", p.Owner, notEmpty) || notEmpty
 	}
 	if len(p.PublicKeyPem) > 0 {
 		if pem, err := json.Marshal(p.PublicKeyPem); err == nil {
-			notEmpty = JSONWriteProp(&b, "publicKeyPem", pem, notEmpty) || notEmpty
+			notEmpty = JSONWriteProp(b, "publicKeyPem", pem, notEmpty) || notEmpty
 		}
 	}
 
 	if !notEmpty {
-		return nil, nil
+		b.Truncate(start)
+		return false
 	}
-	JSONWrite(&b, '}')
-	return b.Bytes(), nil
+	JSONWrite(b, '}')
+	return true
 }
 
 // UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
@@ -351,8 +396,7 @@ func (a *Actor) Clean() Item {
 }
 
 func (a *Actor) UnmarshalJSON(data []byte) error {
-	p := fastjson.Parser{}
-	val, err := p.ParseBytes(data)
+	val, err := parseJSON(data)
 	if err != nil {
 		return err
 	}
@@ -360,51 +404,24 @@ func (a *Actor) UnmarshalJSON(data []byte) error {
 }
 
 func (a Actor) MarshalJSON() ([]byte, error) {
-	b := bytes.Buffer{}
-	notEmpty := false
-	JSONWrite(&b, '{')
+	return jsonMarshal(a)
+}
 
-	_ = OnObject(a, func(o *Object) error {
-		notEmpty = JSONWriteObjectValue(&b, *o)
-		return nil
-	})
-	if a.Inbox != nil {
-		notEmpty = JSONWriteItemProp(&b, "inbox", a.Inbox, notEmpty) || notEmpty
-	}
-	if a.Outbox != nil {
-		notEmpty = JSONWriteItemProp(&b, "outbox", a.Outbox, notEmpty) || notEmpty
-	}
-	if a.Following != nil {
-		notEmpty = JSONWriteItemProp(&b, "following", a.Following, notEmpty) || notEmpty
-	}
-	if a.Followers != nil {
-		notEmpty = JSONWriteItemProp(&b, "followers", a.Followers, notEmpty) || notEmpty
-	}
-	if a.Liked != nil {
-		notEmpty = JSONWriteItemProp(&b, "liked", a.Liked, notEmpty) || notEmpty
-	}
-	if a.PreferredUsername != nil {
-		notEmpty = JSONWriteNaturalLanguageProp(&b, "preferredUsername", a.PreferredUsername, notEmpty) || notEmpty
-	}
-	if a.Endpoints != nil {
-		if v, err := a.Endpoints.MarshalJSON(); err == nil && len(v) > 0 {
-			notEmpty = JSONWriteProp(&b, "endpoints", v, notEmpty) || notEmpty
-		}
-	}
-	if len(a.Streams) > 0 {
-		notEmpty = JSONWriteItemCollectionProp(&b, "streams", a.Streams, false, notEmpty)
-	}
-	if len(a.PublicKey.PublicKeyPem)+len(a.PublicKey.ID) > 0 {
-		if v, err := a.PublicKey.MarshalJSON(); err == nil && len(v) > 0 {
-			notEmpty = JSONWriteProp(&b, "publicKey", v, notEmpty) || notEmpty
-		}
-	}
+func (a Actor) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
+	start := b.Len()
+	JSONWrite(b, '{')
 
+	notEmpty := jsonWriteActorValue(b, a, opts)
+
+	if len(a.Extensions) > 0 {
+		notEmpty = JSONWriteExtensions(b, a.Extensions, notEmpty, objectProperties, actorProperties) || notEmpty
+	}
 	if !notEmpty {
-		return nil, nil
+		b.Truncate(start)
+		return false
 	}
-	JSONWrite(&b, '}')
-	return b.Bytes(), nil
+	JSONWrite(b, '}')
+	return true
 }
 
 func (a Actor) Format(s fmt.State, verb rune) {
@@ -456,8 +473,7 @@ type Endpoints struct {
 
 // UnmarshalJSON decodes an incoming JSON document into the receiver object.
 func (e *Endpoints) UnmarshalJSON(data []byte) error {
-	p := fastjson.Parser{}
-	val, err := p.ParseBytes(data)
+	val, err := parseJSON(data)
 	if err != nil {
 		return err
 	}
@@ -473,36 +489,41 @@ func (e *Endpoints) UnmarshalJSON(data []byte) error {
 
 // MarshalJSON encodes the receiver object to a JSON document.
 func (e Endpoints) MarshalJSON() ([]byte, error) {
-	b := bytes.Buffer{}
+	return jsonMarshal(e)
+}
+
+func (e Endpoints) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
 	notEmpty := false
 
-	JSONWrite(&b, '{')
+	start := b.Len()
+	JSONWrite(b, '{')
 	if e.OauthAuthorizationEndpoint != nil {
-		notEmpty = JSONWriteItemProp(&b, "oauthAuthorizationEndpoint", e.OauthAuthorizationEndpoint, notEmpty)
+		notEmpty = jsonWriteItemProp(b, "oauthAuthorizationEndpoint", e.OauthAuthorizationEndpoint, notEmpty, opts)
 	}
 	if e.OauthTokenEndpoint != nil {
-		notEmpty = JSONWriteItemProp(&b, "oauthTokenEndpoint", e.OauthTokenEndpoint, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "oauthTokenEndpoint", e.OauthTokenEndpoint, notEmpty, opts) || notEmpty
 	}
 	if e.ProvideClientKey != nil {
-		notEmpty = JSONWriteItemProp(&b, "provideClientKey", e.ProvideClientKey, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "provideClientKey", e.ProvideClientKey, notEmpty, opts) || notEmpty
 	}
 	if e.SignClientKey != nil {
-		notEmpty = JSONWriteItemProp(&b, "signClientKey", e.SignClientKey, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "signClientKey", e.SignClientKey, notEmpty, opts) || notEmpty
 	}
 	if e.SharedInbox != nil {
-		notEmpty = JSONWriteItemProp(&b, "sharedInbox", e.SharedInbox, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "sharedInbox", e.SharedInbox, notEmpty, opts) || notEmpty
 	}
 	if e.UploadMedia != nil {
-		notEmpty = JSONWriteItemProp(&b, "uploadMedia", e.UploadMedia, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "uploadMedia", e.UploadMedia, notEmpty, opts) || notEmpty
 	}
 	if e.ProxyURL != NilID {
-		notEmpty = JSONWriteItemProp(&b, "proxyUrl", e.ProxyURL, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "proxyUrl", e.ProxyURL, notEmpty, opts) || notEmpty
 	}
 	if !notEmpty {
-		return nil, nil
+		b.Truncate(start)
+		return false
 	}
-	JSONWrite(&b, '}')
-	return b.Bytes(), nil
+	JSONWrite(b, '}')
+	return true
 }
 
 func tombstoneAsActor(t *Tombstone) (*Actor, error) {
@@ -523,6 +544,10 @@ func ToActor(it LinkOrIRI) (*Actor, error) {
 		return i, nil
 	case Actor:
 		return &i, nil
+	case *Repository:
+		return (*Actor)(unsafe.Pointer(i)), nil
+	case Repository:
+		return (*Actor)(unsafe.Pointer(&i)), nil
 	case *Tombstone:
 		return tombstoneAsActor(i)
 	case Tombstone:
@@ -563,6 +588,36 @@ func (a Actor) equal(with Actor) bool {
 	if !a.PreferredUsername.Equal(with.PreferredUsername) {
 		return false
 	}
+	if !slices.Equal(a.AssertionMethod, with.AssertionMethod) {
+		return false
+	}
+	if a.ManuallyApprovesFollowers != with.ManuallyApprovesFollowers {
+		return false
+	}
+	if a.Discoverable != with.Discoverable {
+		return false
+	}
+	if a.Indexable != with.Indexable {
+		return false
+	}
+	if !ItemsEqual(a.Featured, with.Featured) {
+		return false
+	}
+	if !ItemsEqual(a.FeaturedTags, with.FeaturedTags) {
+		return false
+	}
+	if !ItemsEqual(a.MovedTo, with.MovedTo) {
+		return false
+	}
+	if !ItemsEqual(a.AlsoKnownAs, with.AlsoKnownAs) {
+		return false
+	}
+	if a.PostingRestrictedToMods != with.PostingRestrictedToMods {
+		return false
+	}
+	if !ItemsEqual(a.Moderators, with.Moderators) {
+		return false
+	}
 	return true
 }
 
@@ -631,6 +686,129 @@ func (p *PublicKey) GobDecode(data []byte) error {
 	return nil
 }
 
+// MultikeyType is the type of the public keys in the Multikey format
+const MultikeyType ActivityVocabularyType = "Multikey"
+
+// Multikey holds a public key in the Multikey format, as used by the "assertionMethod" of the actors.
+// The document reference can be found at:
+// https://www.w3.org/TR/controller-document/#multikey
+type Multikey struct {
+	ID                 ID                     `jsonld:"id,omitempty"`
+	Type               ActivityVocabularyType `jsonld:"type,omitempty"`
+	Controller         IRI                    `jsonld:"controller,omitempty"`
+	PublicKeyMultibase string                 `jsonld:"publicKeyMultibase,omitempty"`
+}
+
+func (m *Multikey) UnmarshalJSON(data []byte) error {
+	val, err := parseJSON(data)
+	if err != nil {
+		return err
+	}
+
+	return JSONLoadMultikey(val, m)
+}
+
+func (m Multikey) MarshalJSON() ([]byte, error) {
+	return jsonMarshal(m)
+}
+
+func (m Multikey) writeJSON(b *bytes.Buffer, _ EncodeOptions) bool {
+	notEmpty := false
+	start := b.Len()
+	JSONWrite(b, '{')
+	if v, err := m.ID.MarshalJSON(); err == nil && len(v) > 0 {
+		notEmpty = JSONWriteProp(b, "id", v, false)
+	}
+	if len(m.Type) > 0 {
+		if t, err := json.Marshal(m.Type); err == nil {
+			notEmpty = JSONWriteProp(b, "type", t, notEmpty) || notEmpty
+		}
+	}
+	if len(m.Controller) > 0 {
+		notEmpty = JSONWriteIRIProp(b, "controller", m.Controller, notEmpty) || notEmpty
+	}
+	if len(m.PublicKeyMultibase) > 0 {
+		if mb, err := json.Marshal(m.PublicKeyMultibase); err == nil {
+			notEmpty = JSONWriteProp(b, "publicKeyMultibase", mb, notEmpty) || notEmpty
+		}
+	}
+
+	if !notEmpty {
+		b.Truncate(start)
+		return false
+	}
+	JSONWrite(b, '}')
+	return true
+}
+
+func (m Multikey) GobEncode() ([]byte, error) {
+	var (
+		mm      = make(map[string][]byte)
+		err     error
+		hasData bool
+	)
+	if len(m.ID) > 0 {
+		if mm["id"], err = m.ID.GobEncode(); err != nil {
+			return nil, err
+		}
+		hasData = true
+	}
+	if len(m.Type) > 0 {
+		if mm["type"], err = m.Type.GobEncode(); err != nil {
+			return nil, err
+		}
+		hasData = true
+	}
+	if len(m.Controller) > 0 {
+		if mm["controller"], err = m.Controller.GobEncode(); err != nil {
+			return nil, err
+		}
+		hasData = true
+	}
+	if len(m.PublicKeyMultibase) > 0 {
+		mm["publicKeyMultibase"] = []byte(m.PublicKeyMultibase)
+		hasData = true
+	}
+	if !hasData {
+		return []byte{}, nil
+	}
+	bb := bytes.Buffer{}
+	g := gob.NewEncoder(&bb)
+	if err := g.Encode(mm); err != nil {
+		return nil, err
+	}
+	return bb.Bytes(), nil
+}
+
+func (m *Multikey) GobDecode(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	mm, err := gobDecodeObjectAsMap(data)
+	if err != nil {
+		return err
+	}
+	if raw, ok := mm["id"]; ok {
+		if err = m.ID.GobDecode(raw); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["type"]; ok {
+		if err = m.Type.GobDecode(raw); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["controller"]; ok {
+		if err = m.Controller.GobDecode(raw); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["publicKeyMultibase"]; ok {
+		m.PublicKeyMultibase = string(raw)
+	}
+	return nil
+}
+
 // WithActorFn represents a function type that can be used as a parameter for OnActor helper function
 type WithActorFn func(*Actor) error
 
@@ -3,6 +3,7 @@ package activitypub
 import (
 	"bytes"
 	"reflect"
+	"slices"
 	"testing"
 	"time"
 
@@ -698,3 +699,186 @@ func TestActor_Equals(t *testing.T) {
 		})
 	}
 }
+
+func TestActor_AssertionMethod(t *testing.T) {
+	// NOTE(marius): the actor of the FEP-521a example
+	data := []byte(`{"@context":["https://www.w3.org/ns/activitystreams","https://w3id.org/security/data-integrity/v1"],` +
+		`"id":"https://server.example/users/alice","type":"Person","inbox":"https://server.example/users/alice/inbox",` +
+		`"assertionMethod":[{"id":"https://server.example/users/alice#ed25519-key","type":"Multikey",` +
+		`"controller":"https://server.example/users/alice","publicKeyMultibase":"z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2"}]}`)
+	want := &Actor{
+		ID:    "https://server.example/users/alice",
+		Type:  PersonType,
+		Inbox: IRI("https://server.example/users/alice/inbox"),
+		AssertionMethod: []Multikey{{
+			ID:                 "https://server.example/users/alice#ed25519-key",
+			Type:               MultikeyType,
+			Controller:         "https://server.example/users/alice",
+			PublicKeyMultibase: "z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2",
+		}},
+	}
+
+	it, err := UnmarshalJSON(data)
+	if err != nil {
+		t.Fatalf("UnmarshalJSON() error = %s", err)
+	}
+	if !cmp.Equal(it, want) {
+		t.Fatalf("UnmarshalJSON() got = %s", cmp.Diff(want, it))
+	}
+
+	raw, err := MarshalJSONLD(it)
+	if err != nil {
+		t.Fatalf("MarshalJSONLD() error = %s", err)
+	}
+	if !bytes.Equal(raw, data) {
+		t.Errorf("MarshalJSONLD() got = %s, want %s", raw, data)
+	}
+
+	g, err := GobEncode(it)
+	if err != nil {
+		t.Fatalf("GobEncode() error = %s", err)
+	}
+	fromGob, err := GobDecode(g)
+	if err != nil {
+		t.Fatalf("GobDecode() error = %s", err)
+	}
+	if !cmp.Equal(fromGob, want) {
+		t.Errorf("GobDecode() got = %s", cmp.Diff(want, fromGob))
+	}
+
+	rotated := *want
+	rotated.AssertionMethod = append(slices.Clone(want.AssertionMethod), Multikey{ID: "https://server.example/users/alice#ed25519-key-2"})
+	if want.Equals(&rotated) {
+		t.Errorf("Equals() is true for actors with different assertionMethod keys")
+	}
+
+	single := []byte(`{"id":"https://server.example/users/alice","type":"Person","assertionMethod":{"id":"https://server.example/users/alice#ed25519-key"}}`)
+	if it, err = UnmarshalJSON(single); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %s", err)
+	}
+	_ = OnActor(it, func(a *Actor) error {
+		if len(a.AssertionMethod) != 1 || a.AssertionMethod[0].ID != "https://server.example/users/alice#ed25519-key" {
+			t.Errorf("UnmarshalJSON() assertionMethod = %#v, want the single key", a.AssertionMethod)
+		}
+		return nil
+	})
+}
+
+func TestActor_Mastodon(t *testing.T) {
+	data := []byte(`{"@context":["https://www.w3.org/ns/activitystreams",{"Emoji":"toot:Emoji","Hashtag":"as:Hashtag",` +
+		`"alsoKnownAs":{"@id":"as:alsoKnownAs","@type":"@id"},"blurhash":"toot:blurhash","discoverable":"toot:discoverable",` +
+		`"featured":{"@id":"toot:featured","@type":"@id"},"featuredTags":{"@id":"toot:featuredTags","@type":"@id"},` +
+		`"focalPoint":{"@container":"@list","@id":"toot:focalPoint"},"indexable":"toot:indexable",` +
+		`"manuallyApprovesFollowers":"as:manuallyApprovesFollowers","movedTo":{"@id":"as:movedTo","@type":"@id"},` +
+		`"sensitive":"as:sensitive","toot":"http://joinmastodon.org/ns#"}],` +
+		`"id":"https://mastodon.example/users/alice","type":"Person","tag":[{"type":"Hashtag","name":"#go","href":"https://mastodon.example/tags/go"}],` +
+		`"inbox":"https://mastodon.example/users/alice/inbox","manuallyApprovesFollowers":true,"discoverable":true,"indexable":true,` +
+		`"featured":"https://mastodon.example/users/alice/collections/featured","featuredTags":"https://mastodon.example/users/alice/collections/tags",` +
+		`"movedTo":"https://other.example/users/alice","alsoKnownAs":["https://other.example/users/alice"]}`)
+	want := &Actor{
+		ID:                        "https://mastodon.example/users/alice",
+		Type:                      PersonType,
+		Tag:                       ItemCollection{HashtagNew("https://mastodon.example/tags/go", "#go")},
+		Inbox:                     IRI("https://mastodon.example/users/alice/inbox"),
+		ManuallyApprovesFollowers: true,
+		Discoverable:              true,
+		Indexable:                 true,
+		Featured:                  IRI("https://mastodon.example/users/alice/collections/featured"),
+		FeaturedTags:              IRI("https://mastodon.example/users/alice/collections/tags"),
+		MovedTo:                   IRI("https://other.example/users/alice"),
+		AlsoKnownAs:               ItemCollection{IRI("https://other.example/users/alice")},
+	}
+
+	it, err := UnmarshalJSON(data)
+	if err != nil {
+		t.Fatalf("UnmarshalJSON() error = %s", err)
+	}
+	if !cmp.Equal(it, want) {
+		t.Fatalf("UnmarshalJSON() got = %s", cmp.Diff(want, it))
+	}
+
+	raw, err := MarshalJSONLD(it)
+	if err != nil {
+		t.Fatalf("MarshalJSONLD() error = %s", err)
+	}
+	if !bytes.Equal(raw, data) {
+		t.Errorf("MarshalJSONLD() got = %s, want %s", raw, data)
+	}
+
+	g, err := GobEncode(it)
+	if err != nil {
+		t.Fatalf("GobEncode() error = %s", err)
+	}
+	fromGob, err := GobDecode(g)
+	if err != nil {
+		t.Fatalf("GobDecode() error = %s", err)
+	}
+	if !cmp.Equal(fromGob, want) {
+		t.Errorf("GobDecode() got = %s", cmp.Diff(want, fromGob))
+	}
+
+	moved := *want
+	moved.MovedTo = IRI("https://third.example/users/alice")
+	if want.Equals(&moved) {
+		t.Errorf("Equals() is true for actors which moved to different accounts")
+	}
+	locked := *want
+	locked.ManuallyApprovesFollowers = false
+	if want.Equals(&locked) {
+		t.Errorf("Equals() is true for actors with different manuallyApprovesFollowers")
+	}
+
+	cl := Clone(want).(*Actor)
+	cl.AlsoKnownAs[0] = IRI("https://third.example/users/alice")
+	if want.AlsoKnownAs[0] != IRI("https://other.example/users/alice") {
+		t.Errorf("Clone() alsoKnownAs shares memory with the source")
+	}
+}
+
+func TestActor_Lemmy(t *testing.T) {
+	data := []byte(`{"@context":["https://www.w3.org/ns/activitystreams",{"lemmy":"https://join-lemmy.org/ns#",` +
+		`"moderators":{"@id":"lemmy:moderators","@type":"@id"},"postingRestrictedToMods":"lemmy:postingRestrictedToMods"}],` +
+		`"id":"https://lemmy.example/c/tenforward","type":"Group","followers":"https://lemmy.example/c/tenforward/followers",` +
+		`"postingRestrictedToMods":true,"moderators":"https://lemmy.example/c/tenforward/moderators"}`)
+	want := &Actor{
+		ID:                      "https://lemmy.example/c/tenforward",
+		Type:                    GroupType,
+		Followers:               IRI("https://lemmy.example/c/tenforward/followers"),
+		PostingRestrictedToMods: true,
+		Moderators:              IRI("https://lemmy.example/c/tenforward/moderators"),
+	}
+
+	it, err := UnmarshalJSONStrict(data)
+	if err != nil {
+		t.Fatalf("UnmarshalJSONStrict() error = %s", err)
+	}
+	if !cmp.Equal(it, want) {
+		t.Fatalf("UnmarshalJSONStrict() got = %s", cmp.Diff(want, it))
+	}
+
+	raw, err := MarshalJSONLD(it)
+	if err != nil {
+		t.Fatalf("MarshalJSONLD() error = %s", err)
+	}
+	if !bytes.Equal(raw, data) {
+		t.Errorf("MarshalJSONLD() got = %s, want %s", raw, data)
+	}
+
+	g, err := GobEncode(it)
+	if err != nil {
+		t.Fatalf("GobEncode() error = %s", err)
+	}
+	fromGob, err := GobDecode(g)
+	if err != nil {
+		t.Fatalf("GobDecode() error = %s", err)
+	}
+	if !cmp.Equal(fromGob, want) {
+		t.Errorf("GobDecode() got = %s", cmp.Diff(want, fromGob))
+	}
+
+	open := *want
+	open.PostingRestrictedToMods = false
+	if want.Equals(&open) {
+		t.Errorf("Equals() is true for groups with different postingRestrictedToMods")
+	}
+}
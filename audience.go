@@ -0,0 +1,120 @@
+package activitypub
+
+// AudienceSync is the direction in which SyncAudience copies the recipients between an activity and its object.
+type AudienceSync uint8
+
+const (
+	// AudienceToObject copies the recipients of the activity to its object.
+	AudienceToObject AudienceSync = iota
+	// AudienceToActivity copies the recipients of the object to the activity.
+	AudienceToActivity
+	// AudienceUnion copies the recipients both ways, so the activity and the object end up with the same ones.
+	AudienceUnion
+)
+
+// Addressing holds the recipients of an object or activity, one field for each of the addressing properties.
+type Addressing struct {
+	To       ItemCollection
+	Bto      ItemCollection
+	CC       ItemCollection
+	BCC      ItemCollection
+	Audience ItemCollection
+}
+
+// Empty returns whether the Addressing has no recipients.
+func (a Addressing) Empty() bool {
+	return len(a.To)+len(a.Bto)+len(a.CC)+len(a.BCC)+len(a.Audience) == 0
+}
+
+// AudienceChanges are the recipients that SyncAudience added to the activity and to its object.
+type AudienceChanges struct {
+	// Activity holds the recipients added to the activity.
+	Activity Addressing
+	// Object holds the recipients added to the object or, when the activity has multiple objects, to any of them.
+	Object Addressing
+}
+
+// SyncAudience copies the To, Bto, CC, BCC and Audience recipients between the "it" activity and its object,
+// as the ActivityPub specification requires for the Create activities, in the dir direction.
+// Recipients are only added, never removed, and the Public collection is matched in any of its forms.
+//
+// The object can be any of the types with recipients, including Question or IntransitiveActivity, or an
+// ItemCollection, in which case every one of its objects is synchronised with the activity.
+// Objects which are only IRIs, and intransitive activities, which don't have an object, are left as they are.
+// As the recipients are changed in place, the activity and its objects need to be pointers, as the decoders
+// create them.
+func SyncAudience(it Item, dir AudienceSync) (AudienceChanges, error) {
+	changes := AudienceChanges{}
+	if IsNil(it) || !ActivityTypes.Match(it.GetType()) {
+		return changes, nil
+	}
+	err := OnActivity(it, func(act *Activity) error {
+		objects := make(ItemCollection, 0)
+		for _, ob := range DerefItem(act.Object) {
+			if !IsNil(ob) && !IsIRI(ob) && IsObject(ob) {
+				objects = append(objects, ob)
+			}
+		}
+		return OnObject(act, func(a *Object) error {
+			if dir == AudienceToActivity || dir == AudienceUnion {
+				for _, ob := range objects {
+					err := OnObject(ob, func(o *Object) error {
+						addRecipients(a, o, &changes.Activity)
+						return nil
+					})
+					if err != nil {
+						return err
+					}
+				}
+			}
+			if dir == AudienceToObject || dir == AudienceUnion {
+				for _, ob := range objects {
+					err := OnObject(ob, func(o *Object) error {
+						addRecipients(o, a, &changes.Object)
+						return nil
+					})
+					if err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+	})
+	return changes, err
+}
+
+// addRecipients adds to the "to" object the recipients of the "from" object which it doesn't have already,
+// and records them in added.
+func addRecipients(to, from *Object, added *Addressing) {
+	to.To = appendRecipients(to.To, from.To, &added.To)
+	to.Bto = appendRecipients(to.Bto, from.Bto, &added.Bto)
+	to.CC = appendRecipients(to.CC, from.CC, &added.CC)
+	to.BCC = appendRecipients(to.BCC, from.BCC, &added.BCC)
+	to.Audience = appendRecipients(to.Audience, from.Audience, &added.Audience)
+}
+
+func appendRecipients(col, recipients ItemCollection, added *ItemCollection) ItemCollection {
+	for _, rec := range recipients {
+		if IsNil(rec) || hasRecipient(col, rec) {
+			continue
+		}
+		col = append(col, rec)
+		if !hasRecipient(*added, rec) {
+			*added = append(*added, rec)
+		}
+	}
+	return col
+}
+
+func hasRecipient(col ItemCollection, rec Item) bool {
+	if isPublicNS(rec.GetLink()) {
+		return containsPublic(col)
+	}
+	for _, it := range col {
+		if !IsNil(it) && it.GetLink() == rec.GetLink() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package activitypub
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSyncAudience(t *testing.T) {
+	alice := IRI("https://mastodon.example/users/alice")
+	followers := IRI("https://mastodon.example/users/alice/followers")
+	bob := IRI("https://other.example/users/bob")
+	carol := IRI("https://third.example/users/carol")
+	group := IRI("https://lemmy.example/c/tenforward")
+
+	tests := []struct {
+		name        string
+		act         Item
+		dir         AudienceSync
+		want        Item
+		wantChanges AudienceChanges
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name: "intransitive activity",
+			act:  &IntransitiveActivity{Type: ArriveType, Actor: alice, To: ItemCollection{bob}},
+			dir:  AudienceUnion,
+			want: &IntransitiveActivity{Type: ArriveType, Actor: alice, To: ItemCollection{bob}},
+		},
+		{
+			name: "object IRI",
+			act:  &Activity{Type: CreateType, To: ItemCollection{bob}, Object: IRI("https://mastodon.example/notes/1")},
+			dir:  AudienceUnion,
+			want: &Activity{Type: CreateType, To: ItemCollection{bob}, Object: IRI("https://mastodon.example/notes/1")},
+		},
+		{
+			name: "to object",
+			act: &Activity{
+				Type:   CreateType,
+				To:     ItemCollection{PublicNS},
+				CC:     ItemCollection{followers},
+				BCC:    ItemCollection{carol},
+				Object: &Object{Type: NoteType, To: ItemCollection{IRI("as:Public")}, CC: ItemCollection{bob}},
+			},
+			dir: AudienceToObject,
+			want: &Activity{
+				Type:   CreateType,
+				To:     ItemCollection{PublicNS},
+				CC:     ItemCollection{followers},
+				BCC:    ItemCollection{carol},
+				Object: &Object{Type: NoteType, To: ItemCollection{IRI("as:Public")}, CC: ItemCollection{bob, followers}, BCC: ItemCollection{carol}},
+			},
+			wantChanges: AudienceChanges{
+				Object: Addressing{CC: ItemCollection{followers}, BCC: ItemCollection{carol}},
+			},
+		},
+		{
+			name: "to activity",
+			act: &Activity{
+				Type:   CreateType,
+				Actor:  alice,
+				Object: &Object{Type: NoteType, To: ItemCollection{bob}, Audience: ItemCollection{group}},
+			},
+			dir: AudienceToActivity,
+			want: &Activity{
+				Type:     CreateType,
+				Actor:    alice,
+				To:       ItemCollection{bob},
+				Audience: ItemCollection{group},
+				Object:   &Object{Type: NoteType, To: ItemCollection{bob}, Audience: ItemCollection{group}},
+			},
+			wantChanges: AudienceChanges{
+				Activity: Addressing{To: ItemCollection{bob}, Audience: ItemCollection{group}},
+			},
+		},
+		{
+			name: "union",
+			act: &Activity{
+				Type:   UpdateType,
+				To:     ItemCollection{bob},
+				Bto:    ItemCollection{carol},
+				Object: &Object{Type: NoteType, To: ItemCollection{PublicNS, bob}},
+			},
+			dir: AudienceUnion,
+			want: &Activity{
+				Type:   UpdateType,
+				To:     ItemCollection{bob, PublicNS},
+				Bto:    ItemCollection{carol},
+				Object: &Object{Type: NoteType, To: ItemCollection{PublicNS, bob}, Bto: ItemCollection{carol}},
+			},
+			wantChanges: AudienceChanges{
+				Activity: Addressing{To: ItemCollection{PublicNS}},
+				Object:   Addressing{Bto: ItemCollection{carol}},
+			},
+		},
+		{
+			name: "question",
+			act: &Activity{
+				Type:   CreateType,
+				To:     ItemCollection{PublicNS},
+				Object: &Question{Type: QuestionType, CC: ItemCollection{followers}},
+			},
+			dir: AudienceUnion,
+			want: &Activity{
+				Type:   CreateType,
+				To:     ItemCollection{PublicNS},
+				CC:     ItemCollection{followers},
+				Object: &Question{Type: QuestionType, To: ItemCollection{PublicNS}, CC: ItemCollection{followers}},
+			},
+			wantChanges: AudienceChanges{
+				Activity: Addressing{CC: ItemCollection{followers}},
+				Object:   Addressing{To: ItemCollection{PublicNS}},
+			},
+		},
+		{
+			name: "intransitive activity object",
+			act: &Activity{
+				Type:   AnnounceType,
+				CC:     ItemCollection{followers},
+				Object: &IntransitiveActivity{Type: ArriveType, To: ItemCollection{bob}},
+			},
+			dir: AudienceToObject,
+			want: &Activity{
+				Type:   AnnounceType,
+				CC:     ItemCollection{followers},
+				Object: &IntransitiveActivity{Type: ArriveType, To: ItemCollection{bob}, CC: ItemCollection{followers}},
+			},
+			wantChanges: AudienceChanges{
+				Object: Addressing{CC: ItemCollection{followers}},
+			},
+		},
+		{
+			name: "collection of objects",
+			act: &Activity{
+				Type: CreateType,
+				To:   ItemCollection{followers},
+				Object: ItemCollection{
+					&Object{Type: NoteType, To: ItemCollection{bob}},
+					IRI("https://mastodon.example/notes/2"),
+					&Object{Type: ImageType, To: ItemCollection{carol}},
+				},
+			},
+			dir: AudienceUnion,
+			want: &Activity{
+				Type: CreateType,
+				To:   ItemCollection{followers, bob, carol},
+				Object: ItemCollection{
+					&Object{Type: NoteType, To: ItemCollection{bob, followers, carol}},
+					IRI("https://mastodon.example/notes/2"),
+					&Object{Type: ImageType, To: ItemCollection{carol, followers, bob}},
+				},
+			},
+			wantChanges: AudienceChanges{
+				Activity: Addressing{To: ItemCollection{bob, carol}},
+				Object:   Addressing{To: ItemCollection{followers, carol, bob}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := SyncAudience(tt.act, tt.dir)
+			if err != nil {
+				t.Fatalf("SyncAudience() error = %s", err)
+			}
+			if !cmp.Equal(tt.act, tt.want) {
+				t.Errorf("SyncAudience() got = %s", cmp.Diff(tt.want, tt.act))
+			}
+			if !cmp.Equal(changes, tt.wantChanges) {
+				t.Errorf("SyncAudience() changes = %s", cmp.Diff(tt.wantChanges, changes))
+			}
+		})
+	}
+}
@@ -6,8 +6,6 @@ import (
 	"fmt"
 	"time"
 	"unsafe"
-
-	"github.com/valyala/fastjson"
 )
 
 // CollectionPage is a Collection that contains a large number of items and when it becomes impractical
@@ -106,6 +104,21 @@ type CollectionPage struct {
 	// as a form of provenance, or to support future editing by clients.
 	// In general, clients do the conversion from source to content, not the other way around.
 	Source Source `jsonld:"source,omitempty"`
+	// Sensitive marks the content of the object as not safe for all audiences, so it should be hidden
+	// behind its Summary. Like FocalPoint and Blurhash, it is a Mastodon extension to the vocabulary.
+	Sensitive bool `jsonld:"sensitive,omitempty"`
+	// FocalPoint is the point of an image which must stay visible when it is cropped, as x and y
+	// coordinates between -1.0 and 1.0.
+	FocalPoint []float64 `jsonld:"focalPoint,omitempty"`
+	// Blurhash is a compact representation of a blurred placeholder for an image.
+	Blurhash string `jsonld:"blurhash,omitempty"`
+	// Proof holds the Data Integrity proof of the document, like the eddsa-jcs-2022 ones of FEP-8b32.
+	// The proof sets, which hold more than one proof, are kept unchanged in the Extensions.
+	Proof *DataIntegrityProof `jsonld:"proof,omitempty"`
+	// Extensions holds the properties of the document that are not part of the vocabulary known to this package,
+	// like the ones coming from ActivityStreams extensions or custom namespaces.
+	// They are kept as raw JSON values so they can be written back unchanged.
+	Extensions Extensions `jsonld:"-"`
 	// In a paged Collection, indicates the page that contains the most recently updated member items.
 	Current ObjectOrLink `jsonld:"current,omitempty"`
 	// In a paged Collection, indicates the furthest preceding page of items in the collection.
@@ -194,8 +207,7 @@ func (c CollectionPage) Contains(r Item) bool {
 
 // UnmarshalJSON decodes an incoming JSON document into the receiver object.
 func (c *CollectionPage) UnmarshalJSON(data []byte) error {
-	p := fastjson.Parser{}
-	val, err := p.ParseBytes(data)
+	val, err := parseJSON(data)
 	if err != nil {
 		return err
 	}
@@ -204,41 +216,49 @@ func (c *CollectionPage) UnmarshalJSON(data []byte) error {
 
 // MarshalJSON encodes the receiver object to a JSON document.
 func (c CollectionPage) MarshalJSON() ([]byte, error) {
-	b := bytes.Buffer{}
+	return jsonMarshal(c)
+}
+
+func (c CollectionPage) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
 	notEmpty := false
-	JSONWrite(&b, '{')
+	start := b.Len()
+	JSONWrite(b, '{')
 
 	_ = OnObject(c, func(o *Object) error {
-		notEmpty = JSONWriteObjectValue(&b, *o)
+		notEmpty = jsonWriteObjectValue(b, *o, opts)
 		return nil
 	})
 	if c.PartOf != nil {
-		notEmpty = JSONWriteItemProp(&b, "partOf", c.PartOf, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "partOf", c.PartOf, notEmpty, opts) || notEmpty
 	}
 	if c.Current != nil {
-		notEmpty = JSONWriteItemProp(&b, "current", c.Current, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "current", c.Current, notEmpty, opts) || notEmpty
 	}
 	if c.First != nil {
-		notEmpty = JSONWriteItemProp(&b, "first", c.First, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "first", c.First, notEmpty, opts) || notEmpty
 	}
 	if c.Last != nil {
-		notEmpty = JSONWriteItemProp(&b, "last", c.Last, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "last", c.Last, notEmpty, opts) || notEmpty
 	}
 	if c.Next != nil {
-		notEmpty = JSONWriteItemProp(&b, "next", c.Next, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "next", c.Next, notEmpty, opts) || notEmpty
 	}
 	if c.Prev != nil {
-		notEmpty = JSONWriteItemProp(&b, "prev", c.Prev, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "prev", c.Prev, notEmpty, opts) || notEmpty
 	}
-	notEmpty = JSONWriteIntProp(&b, "totalItems", int64(c.TotalItems), notEmpty) || notEmpty
+	notEmpty = JSONWriteIntProp(b, "totalItems", int64(c.TotalItems), notEmpty) || notEmpty
 	if c.Items != nil {
-		notEmpty = JSONWriteItemCollectionProp(&b, "items", c.Items, false, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemCollectionProp(b, "items", c.Items, false, notEmpty, opts) || notEmpty
+	}
+	if len(c.Extensions) > 0 {
+		notEmpty = JSONWriteExtensions(b, c.Extensions, notEmpty, objectProperties, collectionProperties, collectionPageProperties) || notEmpty
 	}
 	if !notEmpty {
-		return nil, nil
+		b.Truncate(start)
+		return false
 	}
-	JSONWrite(&b, '}')
-	return b.Bytes(), nil
+	JSONWrite(b, '}')
+	return true
 }
 
 // UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
@@ -1,6 +1,10 @@
 package activitypub
 
-import "github.com/go-ap/errors"
+import (
+	"slices"
+
+	"github.com/go-ap/errors"
+)
 
 func CopyOrderedCollectionPageProperties(to, from *OrderedCollectionPage) (*OrderedCollectionPage, error) {
 	to.PartOf = replaceIfItem(to.PartOf, from.PartOf)
@@ -99,6 +103,17 @@ func CopyObjectProperties(to, from *Object) (*Object, error) {
 		to.Duration = from.Duration
 	}
 	to.Source = replaceIfSource(to.Source, from.Source)
+	to.Sensitive = from.Sensitive
+	if len(from.FocalPoint) > 0 {
+		to.FocalPoint = from.FocalPoint
+	}
+	if len(from.Blurhash) > 0 {
+		to.Blurhash = from.Blurhash
+	}
+	if from.Proof != nil {
+		to.Proof = from.Proof
+	}
+	to.Extensions = replaceIfExtensions(to.Extensions, from.Extensions)
 	return to, nil
 }
 
@@ -208,6 +223,18 @@ func UpdatePersonProperties(to, from *Actor) (*Actor, error) {
 	to.Liked = replaceIfItem(to.Liked, from.Liked)
 	to.PreferredUsername = replaceIfNaturalLanguageValues(to.PreferredUsername, from.PreferredUsername)
 	to.PublicKey = replaceIfPublicKey(to.PublicKey, from.PublicKey)
+	if from.AssertionMethod != nil {
+		to.AssertionMethod = from.AssertionMethod
+	}
+	to.ManuallyApprovesFollowers = from.ManuallyApprovesFollowers
+	to.Discoverable = from.Discoverable
+	to.Indexable = from.Indexable
+	to.Featured = replaceIfItem(to.Featured, from.Featured)
+	to.FeaturedTags = replaceIfItem(to.FeaturedTags, from.FeaturedTags)
+	to.MovedTo = replaceIfItem(to.MovedTo, from.MovedTo)
+	to.AlsoKnownAs = replaceIfItemCollection(to.AlsoKnownAs, from.AlsoKnownAs)
+	to.PostingRestrictedToMods = from.PostingRestrictedToMods
+	to.Moderators = replaceIfItem(to.Moderators, from.Moderators)
 	return to, nil
 }
 
@@ -240,6 +267,17 @@ func replaceIfSource(to, from Source) Source {
 	return to
 }
 
+func replaceIfExtensions(to, from Extensions) Extensions {
+	if len(from) == 0 {
+		return to
+	}
+	result := to.Clone()
+	for k, v := range from {
+		result.Set(k, v)
+	}
+	return result
+}
+
 func replaceIfPublicKey(to, from PublicKey) PublicKey {
 	if from.ID != to.ID {
 		return from
@@ -285,12 +323,30 @@ func Clone(it Item) Item {
 	case Place:
 		t := ob
 		n = &t
+	case *Profile:
+		t := *ob
+		n = &t
+	case Profile:
+		t := ob
+		n = &t
 	case *Relationship:
 		t := *ob
 		n = &t
 	case Relationship:
 		t := ob
 		n = &t
+	case *Repository:
+		t := *ob
+		n = &t
+	case Repository:
+		t := ob
+		n = &t
+	case *Ticket:
+		t := *ob
+		n = &t
+	case Ticket:
+		t := ob
+		n = &t
 	case *Tombstone:
 		t := *ob
 		n = &t
@@ -346,5 +402,19 @@ func Clone(it Item) Item {
 		t := ob
 		n = &t
 	}
+	if IsObject(n) {
+		_ = OnObject(n, func(o *Object) error {
+			o.Extensions = o.Extensions.Clone()
+			o.FocalPoint = slices.Clone(o.FocalPoint)
+			o.Proof = o.Proof.Clone()
+			return nil
+		})
+	}
+	if a, ok := n.(*Actor); ok {
+		a.AlsoKnownAs = slices.Clone(a.AlsoKnownAs)
+	}
+	if r, ok := n.(*Repository); ok {
+		r.AlsoKnownAs = slices.Clone(r.AlsoKnownAs)
+	}
 	return n
 }
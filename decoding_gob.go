@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/gob"
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -83,6 +84,48 @@ func unmapActorProperties(mm map[string][]byte, a *Actor) error {
 			return err
 		}
 	}
+	if raw, ok := mm["assertionMethod"]; ok {
+		if err = gob.NewDecoder(bytes.NewReader(raw)).Decode(&a.AssertionMethod); err != nil {
+			return err
+		}
+	}
+	if _, ok := mm["manuallyApprovesFollowers"]; ok {
+		a.ManuallyApprovesFollowers = true
+	}
+	if _, ok := mm["discoverable"]; ok {
+		a.Discoverable = true
+	}
+	if _, ok := mm["indexable"]; ok {
+		a.Indexable = true
+	}
+	if raw, ok := mm["featured"]; ok {
+		if a.Featured, err = gobDecodeItem(raw); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["featuredTags"]; ok {
+		if a.FeaturedTags, err = gobDecodeItem(raw); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["movedTo"]; ok {
+		if a.MovedTo, err = gobDecodeItem(raw); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["alsoKnownAs"]; ok {
+		if a.AlsoKnownAs, err = gobDecodeItems(raw); err != nil {
+			return err
+		}
+	}
+	if _, ok := mm["postingRestrictedToMods"]; ok {
+		a.PostingRestrictedToMods = true
+	}
+	if raw, ok := mm["moderators"]; ok {
+		if a.Moderators, err = gobDecodeItem(raw); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -133,6 +176,12 @@ func unmapActivityProperties(mm map[string][]byte, act *Activity) error {
 			return err
 		}
 	}
+	if raw, ok := mm["signature"]; ok {
+		act.Signature = new(LinkedDataSignature)
+		if err = act.Signature.GobDecode(raw); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -164,9 +213,11 @@ func unmapLinkProperties(mm map[string][]byte, l *Link) error {
 		}
 	}
 	if raw, ok := mm["name"]; ok {
-		if err := l.Name.GobDecode(raw); err != nil {
+		name, err := gobDecodeNaturalLanguageValues(raw)
+		if err != nil {
 			return err
 		}
+		l.Name = name
 	}
 	if raw, ok := mm["rel"]; ok {
 		if err := l.Rel.GobDecode(raw); err != nil {
@@ -183,6 +234,11 @@ func unmapLinkProperties(mm map[string][]byte, l *Link) error {
 			return err
 		}
 	}
+	if raw, ok := mm["extensions"]; ok {
+		if err := l.Extensions.GobDecode(raw); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -363,6 +419,28 @@ func unmapObjectProperties(mm map[string][]byte, o *Object) error {
 			return err
 		}
 	}
+	if _, ok := mm["sensitive"]; ok {
+		o.Sensitive = true
+	}
+	if raw, ok := mm["focalPoint"]; ok {
+		if err := gob.NewDecoder(bytes.NewReader(raw)).Decode(&o.FocalPoint); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["blurhash"]; ok {
+		o.Blurhash = string(raw)
+	}
+	if raw, ok := mm["proof"]; ok {
+		o.Proof = new(DataIntegrityProof)
+		if err := o.Proof.GobDecode(raw); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["extensions"]; ok {
+		if err := o.Extensions.GobDecode(raw); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -436,20 +514,30 @@ func gobDecodeItem(data []byte) (Item, error) {
 		if err != nil {
 			return nil, err
 		}
+		def, registered := RegisteredType(typ)
+		if registered && def.GobDecode != nil {
+			return it, def.GobDecode(mm["gob"], it)
+		}
+		if registered && len(def.Base) == 0 {
+			return nil, fmt.Errorf("unable to gob decode %s items without a GobDecode function", def.Type)
+		}
+		if registered {
+			typ = def.Base
+		}
 		switch {
 		//case typ.Match(IRIType):
 		case ActivityVocabularyTypes{NilType, ObjectType, ArticleType, AudioType, DocumentType, EventType,
-			ImageType, NoteType, PageType, VideoType}.Match(typ):
+			ImageType, NoteType, PageType, VideoType, EmojiType, PatchType, CommitType, BranchType}.Match(typ):
 			err = OnObject(it, func(ob *Object) error {
 				return unmapObjectProperties(mm, ob)
 			})
-		case ActivityVocabularyTypes{LinkType, MentionType}.Match(typ):
+		case ActivityVocabularyTypes{LinkType, MentionType, HashtagType}.Match(typ):
 			err = OnLink(it, func(l *Link) error {
 				return unmapLinkProperties(mm, l)
 			})
 		case ActivityVocabularyTypes{ActivityType, AcceptType, AddType, AnnounceType, BlockType, CreateType, DeleteType, DislikeType,
 			FlagType, FollowType, IgnoreType, InviteType, JoinType, LeaveType, LikeType, ListenType, MoveType, OfferType,
-			RejectType, ReadType, RemoveType, TentativeRejectType, TentativeAcceptType, UndoType, UpdateType, ViewType}.Match(typ):
+			RejectType, ReadType, RemoveType, TentativeRejectType, TentativeAcceptType, UndoType, UpdateType, ViewType, PushType}.Match(typ):
 			err = OnActivity(it, func(act *Activity) error {
 				return unmapActivityProperties(mm, act)
 			})
@@ -461,6 +549,18 @@ func gobDecodeItem(data []byte) (Item, error) {
 			err = OnActor(it, func(a *Actor) error {
 				return unmapActorProperties(mm, a)
 			})
+		case ActivityVocabularyTypes{RelationshipType, TicketDependencyType}.Match(typ):
+			err = OnRelationship(it, func(r *Relationship) error {
+				return unmapRelationshipProperties(mm, r)
+			})
+		case RepositoryType.Match(typ):
+			err = OnRepository(it, func(r *Repository) error {
+				return unmapRepositoryProperties(mm, r)
+			})
+		case TicketType.Match(typ):
+			err = OnTicket(it, func(t *Ticket) error {
+				return unmapTicketProperties(mm, t)
+			})
 		}
 		return it, err
 	}
@@ -662,6 +762,64 @@ func unmapRelationshipProperties(mm map[string][]byte, r *Relationship) error {
 	return nil
 }
 
+func unmapRepositoryProperties(mm map[string][]byte, r *Repository) error {
+	err := OnActor(r, func(a *Actor) error {
+		return unmapActorProperties(mm, a)
+	})
+	if err != nil {
+		return err
+	}
+	if raw, ok := mm["team"]; ok {
+		if r.Team, err = gobDecodeItem(raw); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["forks"]; ok {
+		if r.Forks, err = gobDecodeItem(raw); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["ticketsTrackedBy"]; ok {
+		if r.TicketsTrackedBy, err = gobDecodeItem(raw); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["cloneUri"]; ok {
+		if r.CloneURI, err = gobDecodeItem(raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unmapTicketProperties(mm map[string][]byte, t *Ticket) error {
+	err := OnObject(t, func(ob *Object) error {
+		return unmapObjectProperties(mm, ob)
+	})
+	if err != nil {
+		return err
+	}
+	if _, ok := mm["isResolved"]; ok {
+		t.IsResolved = true
+	}
+	if raw, ok := mm["resolvedBy"]; ok {
+		if t.ResolvedBy, err = gobDecodeItem(raw); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["resolved"]; ok {
+		if err = t.Resolved.GobDecode(raw); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["assignedTo"]; ok {
+		if t.AssignedTo, err = gobDecodeItem(raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func unmapTombstoneProperties(mm map[string][]byte, t *Tombstone) error {
 	err := OnObject(t, func(ob *Object) error {
 		return unmapObjectProperties(mm, ob)
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"net/url"
 	"reflect"
+	"slices"
 	"strings"
 	"time"
 
+	"git.sr.ht/~mariusor/go-xsd-duration"
 	"github.com/valyala/fastjson"
 )
 
@@ -19,10 +21,12 @@ var (
 )
 
 // ItemTyperFunc will return an instance of a struct that implements activitypub.Item
-// The default for this package is GetItemByType but can be overwritten
+// The default for this package is GetItemByType but can be overwritten.
+// Packages which only add types to the vocabulary should use RegisterType instead.
 var ItemTyperFunc TyperFn = GetItemByType
 
-// JSONItemUnmarshal can be set externally to populate a custom object based on its type
+// JSONItemUnmarshal can be set externally to populate a custom object based on its type.
+// Packages which only add types to the vocabulary should use RegisterType instead.
 var JSONItemUnmarshal JSONUnmarshalerFn = nil
 
 // IsNotEmpty checks if an object is empty
@@ -91,6 +95,21 @@ func JSONGetFloat(val *fastjson.Value, prop string) float64 {
 	return f
 }
 
+// JSONGetFloats loads the prop property as a list of numbers, skipping the values which are not numbers.
+func JSONGetFloats(val *fastjson.Value, prop string) []float64 {
+	arr := val.GetArray(prop)
+	if len(arr) == 0 {
+		return nil
+	}
+	ff := make([]float64, 0, len(arr))
+	for _, v := range arr {
+		if f, err := v.Float64(); err == nil {
+			ff = append(ff, f)
+		}
+	}
+	return ff
+}
+
 func JSONGetString(val *fastjson.Value, prop string) string {
 	if !val.Exists(prop) {
 		return ""
@@ -115,22 +134,35 @@ func JSONGetBoolean(val *fastjson.Value, prop string) bool {
 	return t
 }
 
+// JSONGetNaturalLanguageField loads the prop property, merged with its language map variant, eg: "content"
+// and "contentMap".
 func JSONGetNaturalLanguageField(val *fastjson.Value, prop string) NaturalLanguageValues {
 	n := make(NaturalLanguageValues)
 	if val == nil {
 		return n
 	}
 	v := val.Get(prop)
-	if v == nil {
+	m := val.Get(prop + "Map")
+	if v == nil && m == nil {
 		return nil
 	}
+	if v != nil {
+		jsonLoadNaturalLanguageValue(v, n)
+	}
+	if m != nil && m.Type() == fastjson.TypeObject {
+		jsonLoadNaturalLanguageValue(m, n)
+	}
+	return n
+}
+
+func jsonLoadNaturalLanguageValue(v *fastjson.Value, n NaturalLanguageValues) {
 	switch v.Type() {
 	case fastjson.TypeObject:
 		ob, _ := v.Object()
 		ob.Visit(func(key []byte, v *fastjson.Value) {
 			cont := Content{}
 			ref := MakeRef(key)
-			if err := cont.UnmarshalJSON(v.GetStringBytes()); err == nil {
+			if err := cont.UnmarshalText(v.GetStringBytes()); err == nil {
 				if ref != NilLangRef || len(cont) > 0 {
 					n[ref] = cont
 				}
@@ -141,8 +173,6 @@ func JSONGetNaturalLanguageField(val *fastjson.Value, prop string) NaturalLangua
 			n[DefaultLang] = raw
 		}
 	}
-
-	return n
 }
 
 func JSONGetTime(val *fastjson.Value, prop string) time.Time {
@@ -160,13 +190,33 @@ func JSONGetTime(val *fastjson.Value, prop string) time.Time {
 
 func JSONGetDuration(val *fastjson.Value, prop string) time.Duration {
 	if str := val.Get(prop).GetStringBytes(); len(str) > 0 {
-		// TODO(marius): this needs to be replaced to be compatible with xsd:duration
-		d, _ := time.ParseDuration(string(str))
+		var d time.Duration
+		_ = xsd.Unmarshal(str, &d)
 		return d
 	}
 	return 0
 }
 
+// JSONGetExtensions returns the properties of the val JSON object which are not in the known list,
+// as raw JSON values.
+func JSONGetExtensions(val *fastjson.Value, known ...string) Extensions {
+	if val == nil || val.Type() != fastjson.TypeObject {
+		return nil
+	}
+	ob, err := val.Object()
+	if err != nil {
+		return nil
+	}
+	var e Extensions
+	ob.Visit(func(key []byte, v *fastjson.Value) {
+		if slices.Contains(known, string(key)) {
+			return
+		}
+		e.Set(string(key), v.MarshalTo(nil))
+	})
+	return e
+}
+
 func JSONGetPublicKey(val *fastjson.Value, prop string) PublicKey {
 	key := PublicKey{}
 	if val == nil {
@@ -216,45 +266,62 @@ func JSONLoadItem(val *fastjson.Value) (Item, error) {
 	}
 	var empty = func(i Item) bool { return !IsNotEmpty(i) }
 
+	def, registered := RegisteredType(typ)
+	custom := registered && def.LoadJSON != nil
+	base := typ
+	if registered && len(def.Base) > 0 {
+		base = def.Base
+	}
+
 	// NOTE(marius): see note for the [GetItemByType] switch, the same applies here.
 	switch {
-	case CollectionType.Match(typ):
+	case custom:
+		err = def.LoadJSON(val, i)
+	case CollectionType.Match(base):
 		err = OnCollection(i, func(c *Collection) error {
 			return JSONLoadCollection(val, c)
 		})
-	case OrderedCollectionType.Match(typ):
+	case OrderedCollectionType.Match(base):
 		err = OnOrderedCollection(i, func(c *OrderedCollection) error {
 			return JSONLoadOrderedCollection(val, c)
 		})
-	case CollectionPageType.Match(typ):
+	case CollectionPageType.Match(base):
 		err = OnCollectionPage(i, func(p *CollectionPage) error {
 			return JSONLoadCollectionPage(val, p)
 		})
-	case OrderedCollectionPageType.Match(typ):
+	case OrderedCollectionPageType.Match(base):
 		err = OnOrderedCollectionPage(i, func(p *OrderedCollectionPage) error {
 			return JSONLoadOrderedCollectionPage(val, p)
 		})
-	case PlaceType.Match(typ):
+	case PlaceType.Match(base):
 		err = OnPlace(i, func(p *Place) error {
 			return JSONLoadPlace(val, p)
 		})
-	case ProfileType.Match(typ):
+	case ProfileType.Match(base):
 		err = OnProfile(i, func(p *Profile) error {
 			return JSONLoadProfile(val, p)
 		})
-	case RelationshipType.Match(typ):
+	case ActivityVocabularyTypes{RelationshipType, TicketDependencyType}.Match(base):
 		err = OnRelationship(i, func(r *Relationship) error {
 			return JSONLoadRelationship(val, r)
 		})
-	case TombstoneType.Match(typ):
+	case RepositoryType.Match(base):
+		err = OnRepository(i, func(r *Repository) error {
+			return JSONLoadRepository(val, r)
+		})
+	case TicketType.Match(base):
+		err = OnTicket(i, func(t *Ticket) error {
+			return JSONLoadTicket(val, t)
+		})
+	case TombstoneType.Match(base):
 		err = OnTombstone(i, func(t *Tombstone) error {
 			return JSONLoadTombstone(val, t)
 		})
-	case QuestionType.Match(typ):
+	case QuestionType.Match(base):
 		err = OnQuestion(i, func(q *Question) error {
 			return JSONLoadQuestion(val, q)
 		})
-	case NilType.Match(typ):
+	case NilType.Match(base):
 		if looksLikeALink(val) {
 			// NOTE(marius): this handles Links without a type
 			return JSONLoadLink(val)
@@ -263,11 +330,12 @@ func JSONLoadItem(val *fastjson.Value) (Item, error) {
 			// NOTE(marius): this handles Tags which usually don't have types
 			return JSONLoadObject(val, ob)
 		})
-	case ActivityVocabularyTypes{ObjectType, ArticleType, AudioType, DocumentType, EventType, ImageType, NoteType, PageType, VideoType}.Match(typ):
+	case ActivityVocabularyTypes{ObjectType, ArticleType, AudioType, DocumentType, EventType, ImageType, NoteType, PageType, VideoType, EmojiType,
+		PatchType, CommitType, BranchType}.Match(base):
 		err = OnObject(i, func(ob *Object) error {
 			return JSONLoadObject(val, ob)
 		})
-	case ActivityVocabularyTypes{LinkType, MentionType}.Match(typ):
+	case ActivityVocabularyTypes{LinkType, MentionType, HashtagType}.Match(base):
 		// NOTE(marius): if we have a clear link type, we override
 		i = new(Link)
 		err = OnLink(i, func(l *Link) error {
@@ -275,15 +343,15 @@ func JSONLoadItem(val *fastjson.Value) (Item, error) {
 		})
 	case ActivityVocabularyTypes{ActivityType, AcceptType, AddType, AnnounceType, BlockType, CreateType, DeleteType, DislikeType,
 		FlagType, FollowType, IgnoreType, InviteType, JoinType, LeaveType, LikeType, ListenType, MoveType, OfferType,
-		RejectType, ReadType, RemoveType, TentativeRejectType, TentativeAcceptType, UndoType, UpdateType, ViewType}.Match(typ):
+		RejectType, ReadType, RemoveType, TentativeRejectType, TentativeAcceptType, UndoType, UpdateType, ViewType, PushType}.Match(base):
 		err = OnActivity(i, func(act *Activity) error {
 			return JSONLoadActivity(val, act)
 		})
-	case ActivityVocabularyTypes{IntransitiveActivityType, ArriveType, TravelType}.Match(typ):
+	case ActivityVocabularyTypes{IntransitiveActivityType, ArriveType, TravelType}.Match(base):
 		err = OnIntransitiveActivity(i, func(act *IntransitiveActivity) error {
 			return JSONLoadIntransitiveActivity(val, act)
 		})
-	case ActivityVocabularyTypes{ActorType, ApplicationType, GroupType, OrganizationType, PersonType, ServiceType}.Match(typ):
+	case ActivityVocabularyTypes{ActorType, ApplicationType, GroupType, OrganizationType, PersonType, ServiceType}.Match(base):
 		err = OnActor(i, func(a *Actor) error {
 			return JSONLoadActor(val, a)
 		})
@@ -296,7 +364,7 @@ func JSONLoadItem(val *fastjson.Value) (Item, error) {
 	if err != nil {
 		return nil, err
 	}
-	if empty(i) {
+	if !custom && empty(i) {
 		return nil, nil
 	}
 
@@ -407,7 +475,7 @@ func JSONGetItems(val *fastjson.Value, prop string) ItemCollection {
 			}
 		}
 	case fastjson.TypeObject:
-		if i := JSONGetItem(val, prop); i != nil {
+		if i, _ := JSONLoadItem(val); i != nil {
 			_ = it.Append(i)
 		}
 	case fastjson.TypeString:
@@ -437,8 +505,7 @@ func UnmarshalJSON(data []byte) (Item, error) {
 	if len(data) == 0 {
 		return nil, nil
 	}
-	p := fastjson.Parser{}
-	val, err := p.ParseBytes(data)
+	val, err := parseJSON(data)
 	if err != nil {
 		return nil, err
 	}
@@ -449,6 +516,9 @@ func GetItemByType(typ Typer) (Item, error) {
 	if typ == nil {
 		typ = NilType
 	}
+	if def, ok := RegisteredType(typ); ok {
+		return def.newItem()
+	}
 	switch {
 	case ActivityVocabularyTypes{CollectionType}.Match(typ):
 		return &Collection{Type: typ}, nil
@@ -462,19 +532,24 @@ func GetItemByType(typ Typer) (Item, error) {
 		return &Place{Type: typ}, nil
 	case ActivityVocabularyTypes{ProfileType}.Match(typ):
 		return &Profile{Type: typ}, nil
-	case ActivityVocabularyTypes{RelationshipType}.Match(typ):
+	case ActivityVocabularyTypes{RelationshipType, TicketDependencyType}.Match(typ):
 		return &Relationship{Type: typ}, nil
+	case ActivityVocabularyTypes{RepositoryType}.Match(typ):
+		return &Repository{Type: typ}, nil
+	case ActivityVocabularyTypes{TicketType}.Match(typ):
+		return &Ticket{Type: typ}, nil
 	case ActivityVocabularyTypes{TombstoneType}.Match(typ):
 		return &Tombstone{Type: typ}, nil
 	case ActivityVocabularyTypes{QuestionType}.Match(typ):
 		return &Question{Type: typ}, nil
-	case ActivityVocabularyTypes{ObjectType, ArticleType, AudioType, DocumentType, EventType, ImageType, NoteType, PageType, VideoType}.Match(typ):
+	case ActivityVocabularyTypes{ObjectType, ArticleType, AudioType, DocumentType, EventType, ImageType, NoteType, PageType, VideoType, EmojiType,
+		PatchType, CommitType, BranchType}.Match(typ):
 		return ObjectNew(typ), nil
-	case ActivityVocabularyTypes{LinkType, MentionType}.Match(typ):
+	case ActivityVocabularyTypes{LinkType, MentionType, HashtagType}.Match(typ):
 		return &Link{Type: typ}, nil
 	case ActivityVocabularyTypes{ActivityType, AcceptType, AddType, AnnounceType, BlockType, CreateType, DeleteType, DislikeType,
 		FlagType, FollowType, IgnoreType, InviteType, JoinType, LeaveType, LikeType, ListenType, MoveType, OfferType,
-		RejectType, ReadType, RemoveType, TentativeRejectType, TentativeAcceptType, UndoType, UpdateType, ViewType}.Match(typ):
+		RejectType, ReadType, RemoveType, TentativeRejectType, TentativeAcceptType, UndoType, UpdateType, ViewType, PushType}.Match(typ):
 		return &Activity{Type: typ}, nil
 	case ActivityVocabularyTypes{IntransitiveActivityType, ArriveType, TravelType}.Match(typ):
 		return &IntransitiveActivity{Type: typ}, nil
@@ -540,6 +615,14 @@ func JSONLoadObject(val *fastjson.Value, o *Object) error {
 	o.Likes = JSONGetItem(val, "likes")
 	o.Shares = JSONGetItem(val, "shares")
 	o.Source = GetAPSource(val)
+	o.Sensitive = JSONGetBoolean(val, "sensitive")
+	o.FocalPoint = JSONGetFloats(val, "focalPoint")
+	o.Blurhash = JSONGetString(val, "blurhash")
+	o.Proof = JSONGetDataIntegrityProof(val, "proof")
+	o.Extensions = JSONGetExtensions(val, objectProperties...)
+	if o.Proof != nil {
+		o.Extensions = o.Extensions.without("proof")
+	}
 	return nil
 }
 
@@ -549,25 +632,38 @@ func JSONLoadIntransitiveActivity(val *fastjson.Value, i *IntransitiveActivity)
 	i.Result = JSONGetItem(val, "result")
 	i.Origin = JSONGetItem(val, "origin")
 	i.Instrument = JSONGetItem(val, "instrument")
-	return OnObject(i, func(o *Object) error {
+	if err := OnObject(i, func(o *Object) error {
 		return JSONLoadObject(val, o)
-	})
+	}); err != nil {
+		return err
+	}
+	i.Extensions = i.Extensions.without(intransitiveActivityProperties...)
+	return nil
 }
 
 func JSONLoadActivity(val *fastjson.Value, a *Activity) error {
 	a.Object = JSONGetItem(val, "object")
-	return OnIntransitiveActivity(a, func(i *IntransitiveActivity) error {
+	a.Signature = JSONGetLinkedDataSignature(val, "signature")
+	if err := OnIntransitiveActivity(a, func(i *IntransitiveActivity) error {
 		return JSONLoadIntransitiveActivity(val, i)
-	})
+	}); err != nil {
+		return err
+	}
+	a.Extensions = a.Extensions.without(activityProperties...)
+	return nil
 }
 
 func JSONLoadQuestion(val *fastjson.Value, q *Question) error {
 	q.OneOf = JSONGetItem(val, "oneOf")
 	q.AnyOf = JSONGetItem(val, "anyOf")
 	q.Closed = JSONGetBoolean(val, "closed")
-	return OnIntransitiveActivity(q, func(i *IntransitiveActivity) error {
+	if err := OnIntransitiveActivity(q, func(i *IntransitiveActivity) error {
 		return JSONLoadIntransitiveActivity(val, i)
-	})
+	}); err != nil {
+		return err
+	}
+	q.Extensions = q.Extensions.without(questionProperties...)
+	return nil
 }
 
 func JSONLoadActor(val *fastjson.Value, a *Actor) error {
@@ -580,9 +676,23 @@ func JSONLoadActor(val *fastjson.Value, a *Actor) error {
 	a.Endpoints = JSONGetActorEndpoints(val, "endpoints")
 	a.Streams = JSONGetItems(val, "streams")
 	a.PublicKey = JSONGetPublicKey(val, "publicKey")
-	return OnObject(a, func(o *Object) error {
+	a.AssertionMethod = JSONGetMultikeys(val, "assertionMethod")
+	a.ManuallyApprovesFollowers = JSONGetBoolean(val, "manuallyApprovesFollowers")
+	a.Discoverable = JSONGetBoolean(val, "discoverable")
+	a.Indexable = JSONGetBoolean(val, "indexable")
+	a.Featured = JSONGetItem(val, "featured")
+	a.FeaturedTags = JSONGetItem(val, "featuredTags")
+	a.MovedTo = JSONGetItem(val, "movedTo")
+	a.AlsoKnownAs = JSONGetItems(val, "alsoKnownAs")
+	a.PostingRestrictedToMods = JSONGetBoolean(val, "postingRestrictedToMods")
+	a.Moderators = JSONGetItem(val, "moderators")
+	if err := OnObject(a, func(o *Object) error {
 		return JSONLoadObject(val, o)
-	})
+	}); err != nil {
+		return err
+	}
+	a.Extensions = a.Extensions.without(actorProperties...)
+	return nil
 }
 
 func JSONLoadCollection(val *fastjson.Value, c *Collection) error {
@@ -591,18 +701,26 @@ func JSONLoadCollection(val *fastjson.Value, c *Collection) error {
 	c.Last = JSONGetItem(val, "last")
 	c.TotalItems = uint(JSONGetInt(val, "totalItems"))
 	c.Items = JSONGetItems(val, "items")
-	return OnObject(c, func(o *Object) error {
+	if err := OnObject(c, func(o *Object) error {
 		return JSONLoadObject(val, o)
-	})
+	}); err != nil {
+		return err
+	}
+	c.Extensions = c.Extensions.without(collectionProperties...)
+	return nil
 }
 
 func JSONLoadCollectionPage(val *fastjson.Value, c *CollectionPage) error {
 	c.Next = JSONGetItem(val, "next")
 	c.Prev = JSONGetItem(val, "prev")
 	c.PartOf = JSONGetItem(val, "partOf")
-	return OnCollection(c, func(c *Collection) error {
+	if err := OnCollection(c, func(c *Collection) error {
 		return JSONLoadCollection(val, c)
-	})
+	}); err != nil {
+		return err
+	}
+	c.Extensions = c.Extensions.without(collectionPageProperties...)
+	return nil
 }
 
 func JSONLoadOrderedCollection(val *fastjson.Value, c *OrderedCollection) error {
@@ -611,9 +729,13 @@ func JSONLoadOrderedCollection(val *fastjson.Value, c *OrderedCollection) error
 	c.Last = JSONGetItem(val, "last")
 	c.TotalItems = uint(JSONGetInt(val, "totalItems"))
 	c.OrderedItems = JSONGetItems(val, "orderedItems")
-	return OnObject(c, func(o *Object) error {
+	if err := OnObject(c, func(o *Object) error {
 		return JSONLoadObject(val, o)
-	})
+	}); err != nil {
+		return err
+	}
+	c.Extensions = c.Extensions.without(orderedCollectionProperties...)
+	return nil
 }
 
 func JSONLoadOrderedCollectionPage(val *fastjson.Value, c *OrderedCollectionPage) error {
@@ -621,9 +743,13 @@ func JSONLoadOrderedCollectionPage(val *fastjson.Value, c *OrderedCollectionPage
 	c.Prev = JSONGetItem(val, "prev")
 	c.PartOf = JSONGetItem(val, "partOf")
 	c.StartIndex = uint(JSONGetInt(val, "startIndex"))
-	return OnOrderedCollection(c, func(c *OrderedCollection) error {
+	if err := OnOrderedCollection(c, func(c *OrderedCollection) error {
 		return JSONLoadOrderedCollection(val, c)
-	})
+	}); err != nil {
+		return err
+	}
+	c.Extensions = c.Extensions.without(orderedCollectionPageProperties...)
+	return nil
 }
 
 func JSONLoadPlace(val *fastjson.Value, p *Place) error {
@@ -633,33 +759,77 @@ func JSONLoadPlace(val *fastjson.Value, p *Place) error {
 	p.Longitude = JSONGetFloat(val, "longitude")
 	p.Radius = JSONGetInt(val, "radius")
 	p.Units = JSONGetString(val, "units")
-	return OnObject(p, func(o *Object) error {
+	if err := OnObject(p, func(o *Object) error {
 		return JSONLoadObject(val, o)
-	})
+	}); err != nil {
+		return err
+	}
+	p.Extensions = p.Extensions.without(placeProperties...)
+	return nil
 }
 
 func JSONLoadProfile(val *fastjson.Value, p *Profile) error {
 	p.Describes = JSONGetItem(val, "describes")
-	return OnObject(p, func(o *Object) error {
+	if err := OnObject(p, func(o *Object) error {
 		return JSONLoadObject(val, o)
-	})
+	}); err != nil {
+		return err
+	}
+	p.Extensions = p.Extensions.without(profileProperties...)
+	return nil
+}
+
+func JSONLoadRepository(val *fastjson.Value, r *Repository) error {
+	r.Team = JSONGetItem(val, "team")
+	r.Forks = JSONGetItem(val, "forks")
+	r.TicketsTrackedBy = JSONGetItem(val, "ticketsTrackedBy")
+	r.CloneURI = JSONGetURIItem(val, "cloneUri")
+	if err := OnActor(r, func(a *Actor) error {
+		return JSONLoadActor(val, a)
+	}); err != nil {
+		return err
+	}
+	r.Extensions = r.Extensions.without(repositoryProperties...)
+	return nil
+}
+
+func JSONLoadTicket(val *fastjson.Value, t *Ticket) error {
+	t.IsResolved = JSONGetBoolean(val, "isResolved")
+	t.ResolvedBy = JSONGetItem(val, "resolvedBy")
+	t.Resolved = JSONGetTime(val, "resolved")
+	t.AssignedTo = JSONGetItem(val, "assignedTo")
+	if err := OnObject(t, func(o *Object) error {
+		return JSONLoadObject(val, o)
+	}); err != nil {
+		return err
+	}
+	t.Extensions = t.Extensions.without(ticketProperties...)
+	return nil
 }
 
 func JSONLoadRelationship(val *fastjson.Value, r *Relationship) error {
 	r.Subject = JSONGetItem(val, "subject")
 	r.Object = JSONGetItem(val, "object")
 	r.Relationship = JSONGetItem(val, "relationship")
-	return OnObject(r, func(o *Object) error {
+	if err := OnObject(r, func(o *Object) error {
 		return JSONLoadObject(val, o)
-	})
+	}); err != nil {
+		return err
+	}
+	r.Extensions = r.Extensions.without(relationshipProperties...)
+	return nil
 }
 
 func JSONLoadTombstone(val *fastjson.Value, t *Tombstone) error {
 	t.FormerType = ActivityVocabularyType(JSONGetString(val, "formerType"))
 	t.Deleted = JSONGetTime(val, "deleted")
-	return OnObject(t, func(o *Object) error {
+	if err := OnObject(t, func(o *Object) error {
 		return JSONLoadObject(val, o)
-	})
+	}); err != nil {
+		return err
+	}
+	t.Extensions = t.Extensions.without(tombstoneProperties...)
+	return nil
 }
 
 func jsonLoadToLink(val *fastjson.Value, l *Link) error {
@@ -689,6 +859,7 @@ func jsonLoadToLink(val *fastjson.Value, l *Link) error {
 			l.Rel = rr
 		}
 	}
+	l.Extensions = JSONGetExtensions(val, linkProperties...)
 	return nil
 }
 
@@ -697,6 +868,43 @@ func JSONLoadLink(val *fastjson.Value) (Item, error) {
 	return l, jsonLoadToLink(val, l)
 }
 
+func JSONLoadMultikey(val *fastjson.Value, m *Multikey) error {
+	m.ID = JSONGetID(val)
+	m.Type = ActivityVocabularyType(val.GetStringBytes("type"))
+	m.Controller = JSONGetIRI(val, "controller")
+	if mb := val.GetStringBytes("publicKeyMultibase"); len(mb) > 0 {
+		m.PublicKeyMultibase = string(mb)
+	}
+	return nil
+}
+
+// JSONGetMultikeys loads the Multikey objects of the prop property, which can be a single object or an array.
+// The values which are not objects, like references to keys of other documents, are skipped.
+func JSONGetMultikeys(val *fastjson.Value, prop string) []Multikey {
+	if val == nil {
+		return nil
+	}
+	val = val.Get(prop)
+	if val == nil {
+		return nil
+	}
+	values := []*fastjson.Value{val}
+	if val.Type() == fastjson.TypeArray {
+		values = val.GetArray()
+	}
+	var keys []Multikey
+	for _, v := range values {
+		if v.Type() != fastjson.TypeObject {
+			continue
+		}
+		k := Multikey{}
+		if err := JSONLoadMultikey(v, &k); err == nil {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
 func JSONLoadPublicKey(val *fastjson.Value, p *PublicKey) error {
 	p.ID = JSONGetID(val)
 	p.Owner = JSONGetIRI(val, "owner")
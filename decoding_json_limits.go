@@ -0,0 +1,286 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/go-ap/errors"
+	"github.com/valyala/fastjson"
+)
+
+// DecodeLimits are the bounds enforced when decoding untrusted JSON documents, like the bodies of
+// requests to an inbox. A zero value for any of them means there is no limit.
+type DecodeLimits struct {
+	// MaxDepth is the maximum number of objects and arrays nested in each other, the document itself included.
+	MaxDepth int
+	// MaxArrayLength is the maximum number of elements of any array.
+	MaxArrayLength int
+	// MaxStringLength is the maximum length in bytes of any string, property names included.
+	MaxStringLength int
+	// MaxDocumentSize is the maximum size in bytes of the JSON document.
+	MaxDocumentSize int
+}
+
+// DefaultDecodeLimits are limits which should accommodate the documents exchanged between ActivityPub servers.
+var DefaultDecodeLimits = DecodeLimits{
+	MaxDepth:        32,
+	MaxArrayLength:  1000,
+	MaxStringLength: 256 * 1024,
+	MaxDocumentSize: 1024 * 1024,
+}
+
+var (
+	// ErrMaxDepth is the cause for documents which are nested deeper than DecodeLimits.MaxDepth
+	ErrMaxDepth = errors.Newf("maximum nesting depth exceeded")
+	// ErrMaxArrayLength is the cause for arrays which are longer than DecodeLimits.MaxArrayLength
+	ErrMaxArrayLength = errors.Newf("maximum array length exceeded")
+	// ErrMaxStringLength is the cause for strings which are longer than DecodeLimits.MaxStringLength
+	ErrMaxStringLength = errors.Newf("maximum string length exceeded")
+	// ErrMaxDocumentSize is the cause for documents which are larger than DecodeLimits.MaxDocumentSize
+	ErrMaxDocumentSize = errors.Newf("maximum document size exceeded")
+)
+
+// JSONDecodeLimits are the limits enforced by UnmarshalJSON, UnmarshalJSONStrict, UnmarshalJSONLD, the
+// UnmarshalJSON methods of the types of the package, and the StreamDecoder.
+// The zero value doesn't enforce any limit, so it should be set, eg: to DefaultDecodeLimits, when decoding
+// documents from untrusted sources.
+var JSONDecodeLimits DecodeLimits
+
+// parseJSON parses the data JSON document, after checking that it doesn't go over the JSONDecodeLimits.
+func parseJSON(data []byte) (*fastjson.Value, error) {
+	return JSONDecodeLimits.parse(data)
+}
+
+func (l DecodeLimits) parse(data []byte) (*fastjson.Value, error) {
+	if err := l.Scan(data); err != nil {
+		return nil, err
+	}
+	p := fastjson.Parser{}
+	return p.ParseBytes(data)
+}
+
+// UnmarshalJSONWithLimits works like UnmarshalJSON, but it returns an *Error, without parsing the document,
+// if it goes over any of the limits.
+func UnmarshalJSONWithLimits(data []byte, l DecodeLimits) (Item, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	val, err := l.parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return JSONUnmarshalToItem(val), nil
+}
+
+// ReadJSONWithLimits reads a JSON document from r and decodes it with UnmarshalJSONWithLimits.
+// It stops reading as soon as the document goes over DecodeLimits.MaxDocumentSize.
+func ReadJSONWithLimits(r io.Reader, l DecodeLimits) (Item, error) {
+	if l.MaxDocumentSize > 0 {
+		// NOTE(marius): we read one byte more than the limit, so we know when the document is larger
+		r = io.LimitReader(r, int64(l.MaxDocumentSize)+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalJSONWithLimits(data, l)
+}
+
+// Scan verifies, without parsing the data JSON document, that it doesn't go over any of the limits.
+// It returns nil, or an *Error for the first problem found, with a JSON Pointer (RFC 6901) to the offending
+// value as its Path, which is empty for the document size.
+//
+// The syntax of the document is not validated, the problems with it are left for the parser to report.
+func (l DecodeLimits) Scan(data []byte) error {
+	if l.MaxDocumentSize > 0 && len(data) > l.MaxDocumentSize {
+		return limitErr(ErrMaxDocumentSize, l.MaxDocumentSize, nil)
+	}
+	if l.MaxDepth <= 0 && l.MaxArrayLength <= 0 && l.MaxStringLength <= 0 {
+		return nil
+	}
+	stack := make([]limitFrame, 0, 8)
+	expectKey := false
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; c {
+		case ' ', '\t', '\r', '\n', ':':
+		case ',':
+			expectKey = len(stack) > 0 && !stack[len(stack)-1].array
+		case '}', ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			expectKey = false
+		case '"':
+			end, n := jsonScanString(data, i)
+			if expectKey {
+				stack[len(stack)-1].key = data[i:end]
+				expectKey = false
+			} else if err := l.scanValue(stack); err != nil {
+				return err
+			}
+			if l.MaxStringLength > 0 && n > l.MaxStringLength {
+				return limitErr(ErrMaxStringLength, l.MaxStringLength, stack)
+			}
+			i = end - 1
+		case '{', '[':
+			if err := l.scanValue(stack); err != nil {
+				return err
+			}
+			if l.MaxDepth > 0 && len(stack) >= l.MaxDepth {
+				return limitErr(ErrMaxDepth, l.MaxDepth, stack)
+			}
+			stack = append(stack, limitFrame{array: c == '[', index: -1})
+			expectKey = c == '{'
+		default:
+			if err := l.scanValue(stack); err != nil {
+				return err
+			}
+			// NOTE(marius): the numbers and the literals end at the next delimiter
+			for i+1 < len(data) && !isJSONDelimiter(data[i+1]) {
+				i++
+			}
+		}
+	}
+	return nil
+}
+
+// limitFrame is an object or an array which is open at the current position of Scan.
+type limitFrame struct {
+	array bool
+	// key is the raw name of the current property of an object
+	key []byte
+	// index is the index of the current element of an array
+	index int
+}
+
+// scanValue counts a new value in the array which contains it, if there is one.
+func (l DecodeLimits) scanValue(stack []limitFrame) error {
+	if len(stack) == 0 || !stack[len(stack)-1].array {
+		return nil
+	}
+	top := &stack[len(stack)-1]
+	top.index++
+	if l.MaxArrayLength > 0 && top.index >= l.MaxArrayLength {
+		return limitErr(ErrMaxArrayLength, l.MaxArrayLength, stack[:len(stack)-1])
+	}
+	return nil
+}
+
+// jsonScanString returns the position after the end of the JSON string starting at data[start],
+// and the length in bytes of its decoded value.
+func jsonScanString(data []byte, start int) (int, int) {
+	n := 0
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '"':
+			return i + 1, n
+		case '\\':
+			if i+1 < len(data) && data[i+1] == 'u' {
+				// NOTE(marius): each half of a surrogate pair counts for two of the four bytes of its UTF-8 encoding
+				if r, _ := jcsEscapedRune(data[i+2:]); utf16.IsSurrogate(r) {
+					n += 2
+				} else {
+					n += utf8.RuneLen(r)
+				}
+				i += 5
+				continue
+			}
+			i++
+		}
+		n++
+	}
+	return len(data), n
+}
+
+func isJSONDelimiter(c byte) bool {
+	switch c {
+	case ',', ']', '}', ' ', '\t', '\r', '\n':
+		return true
+	}
+	return false
+}
+
+// limitPath returns the JSON Pointer to the current value of the stack.
+// NOTE(marius): we build the path only when there's an error, so scanning valid documents doesn't allocate for it.
+func limitPath(stack []limitFrame) string {
+	s := strings.Builder{}
+	for _, f := range stack {
+		token := strconv.Itoa(f.index)
+		if !f.array {
+			token = ""
+			_ = json.Unmarshal(f.key, &token)
+		}
+		s.WriteString(jsonPointer("", token))
+	}
+	return s.String()
+}
+
+func limitErr(reason error, limit int, stack []limitFrame) *Error {
+	return &Error{Path: limitPath(stack), Reason: reason, Cause: errors.Newf("limit %d", limit)}
+}
+
+// Check verifies that the val JSON document, which was already parsed, doesn't go over any of the limits,
+// except for the document size. It returns nil or an *Error for the first problem found.
+func (l DecodeLimits) Check(val *fastjson.Value) error {
+	if val == nil {
+		return nil
+	}
+	if err := l.check(val, 1); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l DecodeLimits) check(val *fastjson.Value, depth int) *Error {
+	switch val.Type() {
+	case fastjson.TypeObject:
+		if l.MaxDepth > 0 && depth > l.MaxDepth {
+			return limitErr(ErrMaxDepth, l.MaxDepth, nil)
+		}
+		var err *Error
+		o, _ := val.Object()
+		o.Visit(func(key []byte, v *fastjson.Value) {
+			if err != nil {
+				return
+			}
+			if l.MaxStringLength > 0 && len(key) > l.MaxStringLength {
+				err = limitErr(ErrMaxStringLength, l.MaxStringLength, nil)
+			} else {
+				err = l.check(v, depth+1)
+			}
+			if err != nil {
+				err.Path = limitErrorPath(string(key), err.Path)
+			}
+		})
+		return err
+	case fastjson.TypeArray:
+		if l.MaxDepth > 0 && depth > l.MaxDepth {
+			return limitErr(ErrMaxDepth, l.MaxDepth, nil)
+		}
+		arr, _ := val.Array()
+		if l.MaxArrayLength > 0 && len(arr) > l.MaxArrayLength {
+			return limitErr(ErrMaxArrayLength, l.MaxArrayLength, nil)
+		}
+		for i, v := range arr {
+			if err := l.check(v, depth+1); err != nil {
+				err.Path = limitErrorPath(strconv.Itoa(i), err.Path)
+				return err
+			}
+		}
+	case fastjson.TypeString:
+		if l.MaxStringLength > 0 && len(val.GetStringBytes()) > l.MaxStringLength {
+			return limitErr(ErrMaxStringLength, l.MaxStringLength, nil)
+		}
+	}
+	return nil
+}
+
+// limitErrorPath prepends the token to the path of an error found deeper in the document.
+// NOTE(marius): we build the path only when there's an error, so checking valid documents doesn't allocate.
+func limitErrorPath(token, path string) string {
+	return jsonPointer("", token) + path
+}
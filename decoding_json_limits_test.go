@@ -0,0 +1,259 @@
+package activitypub
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/valyala/fastjson"
+)
+
+func nestedObjects(depth int) string {
+	return strings.Repeat(`{"type":"Create","object":`, depth) + `"https://example.com"` + strings.Repeat("}", depth)
+}
+
+func TestUnmarshalJSONWithLimits(t *testing.T) {
+	limits := DecodeLimits{
+		MaxDepth:        4,
+		MaxArrayLength:  3,
+		MaxStringLength: 16,
+		MaxDocumentSize: 512,
+	}
+	tests := []struct {
+		name     string
+		data     string
+		want     Item
+		wantErr  error
+		wantPath string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "within limits",
+			data: `{"type":"Create","to":["https://a.com","https://b.com"],"object":{"type":"Note","tag":[{"type":"Mention"}]}}`,
+			want: &Activity{
+				Type:   CreateType,
+				To:     ItemCollection{IRI("https://a.com"), IRI("https://b.com")},
+				Object: &Object{Type: NoteType, Tag: ItemCollection{&Mention{Type: MentionType}}},
+			},
+		},
+		{
+			name:     "nested too deep",
+			data:     nestedObjects(5),
+			wantErr:  ErrMaxDepth,
+			wantPath: "/object/object/object/object",
+		},
+		{
+			name:     "nested arrays too deep",
+			data:     `{"tag":[[[[]]]]}`,
+			wantErr:  ErrMaxDepth,
+			wantPath: "/tag/0/0/0",
+		},
+		{
+			name:     "array too long",
+			data:     `{"type":"Note","tag":["https://a.com","https://b.com","https://c.com","https://d.com"]}`,
+			wantErr:  ErrMaxArrayLength,
+			wantPath: "/tag",
+		},
+		{
+			name:     "string too long",
+			data:     `{"type":"Note","to":["https://a.com"],"content":"this is a long string"}`,
+			wantErr:  ErrMaxStringLength,
+			wantPath: "/content",
+		},
+		{
+			name:     "property name too long",
+			data:     `{"type":"Note","a/very~long~property":true}`,
+			wantErr:  ErrMaxStringLength,
+			wantPath: "/a~1very~0long~0property",
+		},
+		{
+			name:    "document too large",
+			data:    `{"type":"Note","tag":"` + strings.Repeat(" ", 512) + `"}`,
+			wantErr: ErrMaxDocumentSize,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalJSONWithLimits([]byte(tt.data), limits)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("UnmarshalJSONWithLimits() error = %s", err)
+				}
+				if !cmp.Equal(got, tt.want) {
+					t.Errorf("UnmarshalJSONWithLimits() = %s", cmp.Diff(tt.want, got))
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSONWithLimits() error = %v, want %s", err, tt.wantErr)
+			}
+			var lErr *Error
+			if !errors.As(err, &lErr) {
+				t.Fatalf("UnmarshalJSONWithLimits() error is %T, want *Error", err)
+			}
+			if lErr.Path != tt.wantPath {
+				t.Errorf("Error.Path = %q, want %q", lErr.Path, tt.wantPath)
+			}
+			if got != nil {
+				t.Errorf("UnmarshalJSONWithLimits() returned %v with error", got)
+			}
+		})
+	}
+}
+
+type readCounter struct {
+	io.Reader
+	read int
+}
+
+func (r *readCounter) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.read += n
+	return n, err
+}
+
+func TestReadJSONWithLimits(t *testing.T) {
+	limits := DefaultDecodeLimits
+	limits.MaxDocumentSize = 1024
+
+	r := readCounter{Reader: strings.NewReader(`{"type":"Note","content":"` + strings.Repeat("a", 1<<20) + `"}`)}
+	_, err := ReadJSONWithLimits(&r, limits)
+	if !errors.Is(err, ErrMaxDocumentSize) {
+		t.Fatalf("ReadJSONWithLimits() error = %v, want %s", err, ErrMaxDocumentSize)
+	}
+	if r.read > limits.MaxDocumentSize+1 {
+		t.Errorf("ReadJSONWithLimits() read %d bytes, over the limit of %d", r.read, limits.MaxDocumentSize)
+	}
+
+	it, err := ReadJSONWithLimits(strings.NewReader(nestedObjects(10)), limits)
+	if err != nil {
+		t.Fatalf("ReadJSONWithLimits() error = %s", err)
+	}
+	if it.GetType() != CreateType {
+		t.Errorf("ReadJSONWithLimits() got type %s, want %s", it.GetType(), CreateType)
+	}
+}
+
+func TestDecodeLimits_Scan(t *testing.T) {
+	limits := DecodeLimits{MaxDepth: 4, MaxArrayLength: 3, MaxStringLength: 6}
+	tests := []struct {
+		name     string
+		data     string
+		wantErr  error
+		wantPath string
+	}{
+		{
+			name: "within limits",
+			data: `{"a":[1, -2.5e3, true],"b":{"c":"\u00e9\ud83d\ude00"}, "d":null}`,
+		},
+		{
+			// NOTE(marius): the document is not valid JSON, and it's never parsed
+			name:     "unterminated arrays",
+			data:     strings.Repeat("[", 1<<20),
+			wantErr:  ErrMaxDepth,
+			wantPath: "/0/0/0/0",
+		},
+		{
+			name:     "unterminated string",
+			data:     `{"content":"` + strings.Repeat("a", 1<<20),
+			wantErr:  ErrMaxStringLength,
+			wantPath: "/content",
+		},
+		{
+			name:     "literals",
+			data:     `{"a":{"b":[true,false,null,1]}}`,
+			wantErr:  ErrMaxArrayLength,
+			wantPath: "/a/b",
+		},
+		{
+			name:     "escaped string",
+			data:     `["\u00e9\u00e9\u00e9\u00e9"]`,
+			wantErr:  ErrMaxStringLength,
+			wantPath: "/0",
+		},
+		{
+			name:     "escaped property name",
+			data:     `{"a":{"\/\"\u00e9":[[{}]]}}`,
+			wantErr:  ErrMaxDepth,
+			wantPath: "/a/~1\"é/0/0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limits.Scan([]byte(tt.data))
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("Scan() error = %v, want %v", err, tt.wantErr)
+			}
+			var lErr *Error
+			if errors.As(err, &lErr) && lErr.Path != tt.wantPath {
+				t.Errorf("Error.Path = %q, want %q", lErr.Path, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestJSONDecodeLimits(t *testing.T) {
+	defer func(l DecodeLimits) { JSONDecodeLimits = l }(JSONDecodeLimits)
+	JSONDecodeLimits = DecodeLimits{MaxDepth: 2}
+
+	data := []byte(nestedObjects(3))
+	if _, err := UnmarshalJSON(data); !errors.Is(err, ErrMaxDepth) {
+		t.Errorf("UnmarshalJSON() error = %v, want %s", err, ErrMaxDepth)
+	}
+	if _, err := UnmarshalJSONStrict(data); !errors.Is(err, ErrMaxDepth) {
+		t.Errorf("UnmarshalJSONStrict() error = %v, want %s", err, ErrMaxDepth)
+	}
+	if _, err := UnmarshalJSONLD(data, nil); !errors.Is(err, ErrMaxDepth) {
+		t.Errorf("UnmarshalJSONLD() error = %v, want %s", err, ErrMaxDepth)
+	}
+	a := Activity{}
+	if err := a.UnmarshalJSON(data); !errors.Is(err, ErrMaxDepth) {
+		t.Errorf("Activity.UnmarshalJSON() error = %v, want %s", err, ErrMaxDepth)
+	}
+	if _, err := NewStreamDecoder(strings.NewReader(`[` + nestedObjects(3) + `]`)).Next(); !errors.Is(err, ErrMaxDepth) {
+		t.Errorf("StreamDecoder.Next() error = %v, want %s", err, ErrMaxDepth)
+	}
+
+	it, err := UnmarshalJSON([]byte(nestedObjects(2)))
+	if err != nil {
+		t.Fatalf("UnmarshalJSON() error = %s", err)
+	}
+	if it.GetType() != CreateType {
+		t.Errorf("UnmarshalJSON() got type %s, want %s", it.GetType(), CreateType)
+	}
+}
+
+func BenchmarkDecodeLimits_Scan(b *testing.B) {
+	raw, err := MarshalJSON(mockOutboxPage(100))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		if err = DefaultDecodeLimits.Scan(raw); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeLimits_Check(b *testing.B) {
+	raw, err := MarshalJSON(mockOutboxPage(100))
+	if err != nil {
+		b.Fatal(err)
+	}
+	p := fastjson.Parser{}
+	val, err := p.ParseBytes(raw)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		if err = DefaultDecodeLimits.Check(val); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
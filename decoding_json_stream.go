@@ -0,0 +1,322 @@
+package activitypub
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"iter"
+
+	"github.com/go-ap/errors"
+	"github.com/valyala/fastjson"
+)
+
+// ErrInvalidStream is the cause of the errors returned by the StreamDecoder for malformed JSON input.
+var ErrInvalidStream = errors.Newf("invalid JSON stream")
+
+type streamState int
+
+const (
+	streamStart streamState = iota
+	streamMembers
+	streamItems
+	streamArray
+	streamValues
+)
+
+// StreamDecoder reads ActivityPub items from an io.Reader one at a time, without loading the whole
+// document in memory.
+//
+// If the input is an object with an "items" or "orderedItems" array, like an exported outbox, the decoder
+// returns the elements of the array, and the rest of the properties are available from Envelope.
+// If the input is a top level array, the decoder returns its elements.
+// Otherwise, the input is considered a stream of JSON values, like newline delimited JSON (NDJSON)
+// activity logs, and every value is returned as an item.
+//
+// The DecodeLimits apply to each of the items, and to the envelope, as if they were separate documents,
+// so the number of items in the stream, and the size of the input, are not limited.
+type StreamDecoder struct {
+	r        *bufio.Reader
+	limits   DecodeLimits
+	state    streamState
+	envelope bytes.Buffer
+	members  int
+	hasItems bool
+	err      error
+}
+
+// NewStreamDecoder returns a StreamDecoder reading from r, which enforces the JSONDecodeLimits.
+func NewStreamDecoder(r io.Reader) *StreamDecoder {
+	return NewStreamDecoderWithLimits(r, JSONDecodeLimits)
+}
+
+// NewStreamDecoderWithLimits returns a StreamDecoder reading from r, which enforces the l DecodeLimits.
+// The items are read only up to DecodeLimits.MaxDocumentSize, so the larger ones are not loaded in memory.
+func NewStreamDecoderWithLimits(r io.Reader, l DecodeLimits) *StreamDecoder {
+	d := StreamDecoder{r: bufio.NewReader(r), limits: l}
+	d.envelope.WriteByte('{')
+	return &d
+}
+
+// Next returns the next item in the stream. At the end of the stream it returns io.EOF.
+//
+// Values which can't be loaded, eg: objects with unknown types, are returned as nil items, so the caller
+// can decide if it skips them.
+func (d *StreamDecoder) Next() (Item, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	it, err := d.next()
+	if err != nil {
+		d.err = err
+	}
+	return it, err
+}
+
+// All returns an iterator over the remaining items in the stream. It stops at the end of the stream,
+// or after yielding the first error.
+func (d *StreamDecoder) All() iter.Seq2[Item, error] {
+	return func(yield func(Item, error) bool) {
+		for {
+			it, err := d.Next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(it, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Envelope returns the collection containing the streamed items, with all its properties except for
+// "items" and "orderedItems". The properties found after the items are available only once they have
+// all been read.
+func (d *StreamDecoder) Envelope() (Item, error) {
+	if d.members == 0 {
+		return nil, nil
+	}
+	raw := append(bytes.Clone(d.envelope.Bytes()), '}')
+	return d.load(raw)
+}
+
+func (d *StreamDecoder) next() (Item, error) {
+	for {
+		switch d.state {
+		case streamStart:
+			c, err := d.peek()
+			if err != nil {
+				return nil, err
+			}
+			switch c {
+			case '{':
+				_, _ = d.r.ReadByte()
+				d.state = streamMembers
+			case '[':
+				_, _ = d.r.ReadByte()
+				d.state = streamArray
+			default:
+				d.state = streamValues
+			}
+		case streamMembers:
+			it, done, err := d.nextMember()
+			if err != nil || done {
+				return it, err
+			}
+		case streamItems, streamArray:
+			c, err := d.peek()
+			if err != nil {
+				return nil, d.unexpected(err)
+			}
+			if c == ']' {
+				_, _ = d.r.ReadByte()
+				if d.state == streamArray {
+					d.state = streamValues
+				} else {
+					d.state = streamMembers
+				}
+				continue
+			}
+			if c == ',' {
+				_, _ = d.r.ReadByte()
+			}
+			raw, err := d.readValue()
+			if err != nil {
+				return nil, d.unexpected(err)
+			}
+			return d.load(raw)
+		case streamValues:
+			if _, err := d.peek(); err != nil {
+				return nil, err
+			}
+			raw, err := d.readValue()
+			if err != nil {
+				return nil, d.unexpected(err)
+			}
+			return d.load(raw)
+		}
+	}
+}
+
+// nextMember reads the next property of the top level object. It reports done when it returns an item.
+func (d *StreamDecoder) nextMember() (Item, bool, error) {
+	c, err := d.peek()
+	if err != nil {
+		return nil, false, d.unexpected(err)
+	}
+	switch c {
+	case '}':
+		_, _ = d.r.ReadByte()
+		d.state = streamValues
+		if !d.hasItems {
+			// NOTE(marius): the object didn't have any items, so it is an item itself
+			raw := append(bytes.Clone(d.envelope.Bytes()), '}')
+			d.envelope.Truncate(1)
+			d.members = 0
+			it, err := d.load(raw)
+			return it, true, err
+		}
+		return nil, false, nil
+	case ',':
+		_, _ = d.r.ReadByte()
+		if c, err = d.peek(); err != nil {
+			return nil, false, d.unexpected(err)
+		}
+	}
+	if c != '"' {
+		return nil, false, errors.Annotatef(ErrInvalidStream, "expected property name, found %q", c)
+	}
+	rawKey, err := d.readValue()
+	if err != nil {
+		return nil, false, d.unexpected(err)
+	}
+	if c, err = d.peek(); err != nil || c != ':' {
+		return nil, false, errors.Annotatef(ErrInvalidStream, "expected ':' after property name %s", rawKey)
+	}
+	_, _ = d.r.ReadByte()
+	if c, err = d.peek(); err != nil {
+		return nil, false, d.unexpected(err)
+	}
+
+	var key string
+	_ = json.Unmarshal(rawKey, &key)
+	if c == '[' && (key == "items" || key == "orderedItems") {
+		_, _ = d.r.ReadByte()
+		d.state = streamItems
+		d.hasItems = true
+		return nil, false, nil
+	}
+	raw, err := d.readValue()
+	if err != nil {
+		return nil, false, d.unexpected(err)
+	}
+	if d.members > 0 {
+		d.envelope.WriteByte(',')
+	}
+	d.envelope.Write(rawKey)
+	d.envelope.WriteByte(':')
+	d.envelope.Write(raw)
+	d.members++
+	if max := d.limits.MaxDocumentSize; max > 0 && d.envelope.Len() >= max {
+		return nil, false, limitErr(ErrMaxDocumentSize, max, nil)
+	}
+	return nil, false, nil
+}
+
+func (d *StreamDecoder) load(raw []byte) (Item, error) {
+	if err := d.limits.Scan(raw); err != nil {
+		return nil, err
+	}
+	val, err := fastjson.ParseBytes(raw)
+	if err != nil {
+		return nil, errors.Annotatef(err, "unable to parse item")
+	}
+	return JSONUnmarshalToItem(val), nil
+}
+
+// peek returns the next byte which is not whitespace, without consuming it.
+func (d *StreamDecoder) peek() (byte, error) {
+	for {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return c, d.r.UnreadByte()
+	}
+}
+
+func (d *StreamDecoder) unexpected(err error) error {
+	if err == io.EOF {
+		return errors.Annotatef(ErrInvalidStream, "unexpected end of input")
+	}
+	return err
+}
+
+// readValue reads a single JSON value, which can't be larger than the DecodeLimits.MaxDocumentSize.
+func (d *StreamDecoder) readValue() ([]byte, error) {
+	return readJSONValue(d.r, d.limits.MaxDocumentSize)
+}
+
+// readJSONValue reads a single JSON value from r, without validating it.
+// It stops with an error when the value is larger than max bytes, if max is not zero.
+func readJSONValue(r *bufio.Reader, max int) ([]byte, error) {
+	buf := bytes.Buffer{}
+	depth := 0
+	inString := false
+	escaped := false
+	for {
+		if max > 0 && buf.Len() > max {
+			return nil, limitErr(ErrMaxDocumentSize, max, nil)
+		}
+		c, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF && depth == 0 && !inString && buf.Len() > 0 {
+				return buf.Bytes(), nil
+			}
+			return nil, err
+		}
+		if inString {
+			buf.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+				if depth == 0 {
+					return buf.Bytes(), nil
+				}
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth == 0 {
+				// NOTE(marius): the end of the parent value, which ends a number or literal
+				return buf.Bytes(), r.UnreadByte()
+			}
+			depth--
+			if depth == 0 {
+				buf.WriteByte(c)
+				return buf.Bytes(), nil
+			}
+		case ',', ' ', '\t', '\r', '\n':
+			if depth == 0 {
+				if buf.Len() == 0 {
+					continue
+				}
+				return buf.Bytes(), r.UnreadByte()
+			}
+		}
+		buf.WriteByte(c)
+	}
+}
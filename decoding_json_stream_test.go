@@ -0,0 +1,210 @@
+package activitypub
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStreamDecoder(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		want         []Item
+		wantEnvelope Item
+		wantErr      error
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "ordered collection",
+			data: `{"@context":"https://www.w3.org/ns/activitystreams","id":"https://example.com/outbox","type":"OrderedCollection","totalItems":2,
+"orderedItems":[
+	{"id":"https://example.com/2","type":"Create","actor":"https://example.com/~jdoe"},
+	"https://example.com/1"
+],
+"first":"https://example.com/outbox?page=1"}`,
+			want: []Item{
+				&Activity{ID: "https://example.com/2", Type: CreateType, Actor: IRI("https://example.com/~jdoe")},
+				IRI("https://example.com/1"),
+			},
+			wantEnvelope: &OrderedCollection{
+				ID:         "https://example.com/outbox",
+				Type:       OrderedCollectionType,
+				TotalItems: 2,
+				First:      IRI("https://example.com/outbox?page=1"),
+			},
+		},
+		{
+			name: "collection with empty items",
+			data: `{"id":"https://example.com/likes","type":"Collection","items":[]}`,
+			wantEnvelope: &Collection{
+				ID:   "https://example.com/likes",
+				Type: CollectionType,
+			},
+		},
+		{
+			name: "ndjson",
+			data: `{"id":"https://example.com/1","type":"Follow","actor":"https://example.com/~jdoe","object":"https://example.com/~alice"}
+{"id":"https://example.com/2","type":"Like","actor":"https://example.com/~jdoe","object":"https://example.com/3"}
+
+{"id":"https://example.com/4","type":"Note","content":"test, with \"quotes\" and ]}"}
+`,
+			want: []Item{
+				&Activity{ID: "https://example.com/1", Type: FollowType, Actor: IRI("https://example.com/~jdoe"), Object: IRI("https://example.com/~alice")},
+				&Activity{ID: "https://example.com/2", Type: LikeType, Actor: IRI("https://example.com/~jdoe"), Object: IRI("https://example.com/3")},
+				&Object{ID: "https://example.com/4", Type: NoteType, Content: DefaultNaturalLanguage(`test, with "quotes" and ]}`)},
+			},
+		},
+		{
+			name: "array",
+			data: ` [ "https://example.com/1" , {"id":"https://example.com/2","type":"Note"} ] `,
+			want: []Item{
+				IRI("https://example.com/1"),
+				&Object{ID: "https://example.com/2", Type: NoteType},
+			},
+		},
+		{
+			name: "truncated",
+			data: `{"type":"OrderedCollection","orderedItems":["https://example.com/1",{"id":"https://example.com/2"`,
+			want: []Item{
+				IRI("https://example.com/1"),
+			},
+			wantErr: ErrInvalidStream,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewStreamDecoder(strings.NewReader(tt.data))
+			got := make([]Item, 0)
+			var err error
+			for it, e := range dec.All() {
+				if e != nil {
+					err = e
+					break
+				}
+				got = append(got, it)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("StreamDecoder error = %v, want %v", err, tt.wantErr)
+			}
+			if len(tt.want) == 0 && len(got) == 0 {
+				got = nil
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("StreamDecoder items = %s", cmp.Diff(tt.want, got))
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if _, err = dec.Next(); err != io.EOF {
+				t.Errorf("StreamDecoder.Next() after the end = %v, want EOF", err)
+			}
+			env, err := dec.Envelope()
+			if err != nil {
+				t.Fatalf("StreamDecoder.Envelope() error = %s", err)
+			}
+			if !cmp.Equal(env, tt.wantEnvelope) {
+				t.Errorf("StreamDecoder.Envelope() = %s", cmp.Diff(tt.wantEnvelope, env))
+			}
+		})
+	}
+}
+
+func TestStreamDecoderWithLimits(t *testing.T) {
+	limits := DecodeLimits{MaxDepth: 2, MaxDocumentSize: 128}
+	tests := []struct {
+		name    string
+		data    string
+		want    []Item
+		wantErr error
+	}{
+		{
+			name: "within limits",
+			data: `{"type":"OrderedCollection","orderedItems":[{"type":"Create","object":{"type":"Note"}},"https://example.com/1"]}`,
+			want: []Item{
+				&Activity{Type: CreateType, Object: &Object{Type: NoteType}},
+				IRI("https://example.com/1"),
+			},
+		},
+		{
+			name:    "item too deep",
+			data:    `[{"type":"Note"},` + nestedObjects(3) + `]`,
+			want:    []Item{&Object{Type: NoteType}},
+			wantErr: ErrMaxDepth,
+		},
+		{
+			name:    "item too large",
+			data:    `{"type":"Note"}` + "\n" + `{"type":"Note","content":"` + strings.Repeat("a", 1<<20) + `"}`,
+			want:    []Item{&Object{Type: NoteType}},
+			wantErr: ErrMaxDocumentSize,
+		},
+		{
+			name:    "envelope too large",
+			data:    `{"type":"OrderedCollection","summary":"` + strings.Repeat("a", 128) + `","orderedItems":[]}`,
+			wantErr: ErrMaxDocumentSize,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := readCounter{Reader: strings.NewReader(tt.data)}
+			dec := NewStreamDecoderWithLimits(&r, limits)
+			got := make([]Item, 0)
+			var err error
+			for it, e := range dec.All() {
+				if err = e; err != nil {
+					break
+				}
+				got = append(got, it)
+			}
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("StreamDecoder error = %v, want %v", err, tt.wantErr)
+			}
+			if len(tt.want) == 0 {
+				tt.want = []Item{}
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("StreamDecoder got = %s", cmp.Diff(tt.want, got))
+			}
+			if r.read > 64*1024 {
+				t.Errorf("StreamDecoder read %d bytes, over the limits", r.read)
+			}
+		})
+	}
+}
+
+func TestStreamDecoder_largeCollection(t *testing.T) {
+	const count = 10000
+	r, w := io.Pipe()
+	go func() {
+		_, _ = io.WriteString(w, `{"type":"OrderedCollection","totalItems":10000,"orderedItems":[`)
+		for i := range count {
+			if i > 0 {
+				_, _ = io.WriteString(w, ",")
+			}
+			_, _ = fmt.Fprintf(w, `{"id":"https://example.com/%d","type":"Note"}`, i)
+		}
+		_, _ = io.WriteString(w, `]}`)
+		_ = w.Close()
+	}()
+
+	dec := NewStreamDecoder(r)
+	i := 0
+	for it, err := range dec.All() {
+		if err != nil {
+			t.Fatalf("StreamDecoder error = %s", err)
+		}
+		if want := IRI(fmt.Sprintf("https://example.com/%d", i)); it.GetLink() != want {
+			t.Fatalf("StreamDecoder item %d = %s, want %s", i, it.GetLink(), want)
+		}
+		i++
+	}
+	if i != count {
+		t.Errorf("StreamDecoder returned %d items, want %d", i, count)
+	}
+}
@@ -0,0 +1,404 @@
+package activitypub
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.sr.ht/~mariusor/go-xsd-duration"
+	"github.com/go-ap/errors"
+	"github.com/valyala/fastjson"
+	"golang.org/x/text/language"
+)
+
+var (
+	// ErrInvalidJSON is the cause for documents which can't be parsed as JSON
+	ErrInvalidJSON = errors.Newf("invalid JSON document")
+	// ErrInvalidDateTime is the cause for properties which can't be parsed as xsd:dateTime
+	ErrInvalidDateTime = errors.Newf("invalid xsd:dateTime")
+	// ErrInvalidDuration is the cause for properties which can't be parsed as xsd:duration
+	ErrInvalidDuration = errors.Newf("invalid xsd:duration")
+	// ErrInvalidNonNegativeInteger is the cause for properties which are not xsd:nonNegativeInteger
+	ErrInvalidNonNegativeInteger = errors.Newf("invalid xsd:nonNegativeInteger")
+	// ErrInvalidFloat is the cause for properties which are not xsd:float
+	ErrInvalidFloat = errors.Newf("invalid xsd:float")
+	// ErrInvalidBoolean is the cause for properties which are not xsd:boolean
+	ErrInvalidBoolean = errors.Newf("invalid xsd:boolean")
+	// ErrInvalidString is the cause for properties which are not xsd:string
+	ErrInvalidString = errors.Newf("invalid xsd:string")
+	// ErrInvalidIRI is the cause for properties which are not absolute IRIs
+	ErrInvalidIRI = errors.Newf("invalid IRI")
+	// ErrInvalidLangTag is the cause for properties which are not valid BCP47 language tags
+	ErrInvalidLangTag = errors.Newf("invalid BCP47 language tag")
+	// ErrInvalidNaturalLanguageValue is the cause for properties which are neither strings nor language maps
+	ErrInvalidNaturalLanguageValue = errors.Newf("invalid natural language value")
+	// ErrInvalidItem is the cause for properties which are neither IRIs, nor objects, nor arrays of those
+	ErrInvalidItem = errors.Newf("invalid object or link")
+	// ErrInvalidType is the cause for "type" properties which are neither strings nor arrays of strings
+	ErrInvalidType = errors.Newf("invalid type")
+	// ErrUnknownType is the cause for "type" values which are not known to the package
+	ErrUnknownType = errors.Newf("unknown type")
+	// ErrMissingProperty is the cause for required properties which are missing
+	ErrMissingProperty = errors.Newf("missing property")
+)
+
+var (
+	strictTimeProperties = []string{"published", "updated", "startTime", "endTime", "deleted", "resolved"}
+
+	strictNonNegativeIntegerProperties = []string{"totalItems", "startIndex", "height", "width", "radius"}
+
+	strictFloatProperties = []string{"accuracy", "altitude", "latitude", "longitude"}
+
+	strictNaturalLanguageProperties = []string{"name", "content", "summary", "preferredUsername"}
+
+	strictBooleanProperties = []string{
+		"sensitive", "manuallyApprovesFollowers", "discoverable", "indexable", "isResolved", "postingRestrictedToMods",
+	}
+
+	strictStringProperties = []string{"mediaType", "units", "blurhash"}
+
+	strictIRIProperties = []string{"id", "href", "proxyUrl"}
+
+	strictItemProperties = []string{
+		"attachment", "attributedTo", "audience", "context", "generator", "icon", "image", "inReplyTo",
+		"location", "preview", "replies", "tag", "url", "to", "bto", "cc", "bcc", "likes", "shares",
+		"actor", "target", "result", "origin", "instrument", "object", "oneOf", "anyOf",
+		"inbox", "outbox", "following", "followers", "liked", "streams",
+		"current", "first", "last", "items", "orderedItems", "next", "prev", "partOf",
+		"describes", "subject", "relationship", "featured", "featuredTags", "movedTo", "alsoKnownAs", "moderators",
+		"team", "forks", "ticketsTrackedBy", "cloneUri",
+		"uploadMedia", "oauthAuthorizationEndpoint", "oauthTokenEndpoint", "provideClientKey", "signClientKey", "sharedInbox",
+	}
+)
+
+// UnmarshalJSONStrict works like UnmarshalJSON, but it first validates the JSON document, and refuses to
+// load it if any of the properties known to the package have invalid values.
+//
+// Contrary to the permissive decoder, it doesn't stop at the first problem: the returned error is a
+// Errors list containing every problem found, each with a JSON Pointer to the offending property.
+func UnmarshalJSONStrict(data []byte) (Item, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if err := JSONDecodeLimits.Scan(data); err != nil {
+		return nil, err
+	}
+	p := fastjson.Parser{}
+	val, err := p.ParseBytes(data)
+	if err != nil {
+		return nil, Errors{{Reason: ErrInvalidJSON, Cause: err}}
+	}
+	if err = JSONValidate(val); err != nil {
+		return nil, err
+	}
+	return JSONUnmarshalToItem(val), nil
+}
+
+// JSONValidate verifies that the properties known to the package have valid values in the val JSON document.
+// It returns nil, or an Errors list with all the problems found.
+//
+// Unknown types are reported only for the document itself and for the "object" of activities, as
+// objects in other positions, like Mastodon's PropertyValue in "attachment",
+// commonly use types from other vocabularies.
+func JSONValidate(val *fastjson.Value) error {
+	errs := make(Errors, 0)
+	jsonValidateItemValue(val, "", true, &errs)
+	return errs.orNil()
+}
+
+func jsonPointer(parent, token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+	return parent + "/" + token
+}
+
+func appendDecodeError(errs *Errors, path string, err error) {
+	*errs = append(*errs, &Error{Path: path, Reason: err})
+}
+
+func isAbsoluteIRI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.IsAbs()
+}
+
+func strictKnownType(typ ActivityVocabularyType) bool {
+	if JSONItemUnmarshal != nil {
+		// NOTE(marius): when a custom unmarshal function is set, we can't know which types it supports
+		return true
+	}
+	return slices.Contains(Types, typ) || slices.Contains(GenericTypes, typ)
+}
+
+func jsonValidateItemValue(val *fastjson.Value, path string, knownTypes bool, errs *Errors) {
+	switch val.Type() {
+	case fastjson.TypeNull:
+	case fastjson.TypeString:
+		if !isAbsoluteIRI(string(val.GetStringBytes())) {
+			appendDecodeError(errs, path, ErrInvalidIRI)
+		}
+	case fastjson.TypeArray:
+		for i, v := range val.GetArray() {
+			if v.Type() == fastjson.TypeArray {
+				appendDecodeError(errs, jsonPointer(path, strconv.Itoa(i)), ErrInvalidItem)
+				continue
+			}
+			jsonValidateItemValue(v, jsonPointer(path, strconv.Itoa(i)), knownTypes, errs)
+		}
+	case fastjson.TypeObject:
+		jsonValidateObject(val, path, knownTypes, errs)
+	default:
+		appendDecodeError(errs, path, ErrInvalidItem)
+	}
+}
+
+func jsonValidateTypes(val *fastjson.Value, path string, knownTypes bool, errs *Errors) {
+	validate := func(v *fastjson.Value, path string) {
+		if v.Type() != fastjson.TypeString {
+			appendDecodeError(errs, path, ErrInvalidType)
+			return
+		}
+		if typ := ActivityVocabularyType(v.GetStringBytes()); knownTypes && !strictKnownType(typ) {
+			appendDecodeError(errs, path, errors.Annotatef(ErrUnknownType, "%s", typ))
+		}
+	}
+	switch val.Type() {
+	case fastjson.TypeString:
+		validate(val, path)
+	case fastjson.TypeArray:
+		for i, v := range val.GetArray() {
+			validate(v, jsonPointer(path, strconv.Itoa(i)))
+		}
+	default:
+		appendDecodeError(errs, path, ErrInvalidType)
+	}
+}
+
+func jsonValidateNaturalLanguageValue(val *fastjson.Value, path string, errs *Errors) {
+	switch val.Type() {
+	case fastjson.TypeString:
+	case fastjson.TypeObject:
+		ob, _ := val.Object()
+		ob.Visit(func(key []byte, v *fastjson.Value) {
+			if _, err := language.Parse(string(key)); err != nil {
+				appendDecodeError(errs, jsonPointer(path, string(key)), ErrInvalidLangTag)
+			}
+			if v.Type() != fastjson.TypeString {
+				appendDecodeError(errs, jsonPointer(path, string(key)), ErrInvalidString)
+			}
+		})
+	default:
+		appendDecodeError(errs, path, ErrInvalidNaturalLanguageValue)
+	}
+}
+
+func jsonValidatePublicKey(val *fastjson.Value, path string, errs *Errors) {
+	if val.Type() != fastjson.TypeObject {
+		appendDecodeError(errs, path, ErrInvalidItem)
+		return
+	}
+	if !val.Exists("id") {
+		appendDecodeError(errs, jsonPointer(path, "id"), ErrMissingProperty)
+	}
+	if owner := val.Get("owner"); owner != nil {
+		if owner.Type() != fastjson.TypeString || !isAbsoluteIRI(string(owner.GetStringBytes())) {
+			appendDecodeError(errs, jsonPointer(path, "owner"), ErrInvalidIRI)
+		}
+	}
+	if pem := val.Get("publicKeyPem"); pem != nil && pem.Type() != fastjson.TypeString {
+		appendDecodeError(errs, jsonPointer(path, "publicKeyPem"), ErrInvalidString)
+	}
+	if id := val.Get("id"); id != nil {
+		jsonValidateProperty("id", id, jsonPointer(path, "id"), false, errs)
+	}
+}
+
+func jsonValidateMultikeys(val *fastjson.Value, path string, errs *Errors) {
+	values := []*fastjson.Value{val}
+	paths := []string{path}
+	if val.Type() == fastjson.TypeArray {
+		values = val.GetArray()
+		paths = paths[:0]
+		for i := range values {
+			paths = append(paths, jsonPointer(path, strconv.Itoa(i)))
+		}
+	}
+	for i, v := range values {
+		if v.Type() == fastjson.TypeString {
+			if !isAbsoluteIRI(string(v.GetStringBytes())) {
+				appendDecodeError(errs, paths[i], ErrInvalidIRI)
+			}
+			continue
+		}
+		if v.Type() != fastjson.TypeObject {
+			appendDecodeError(errs, paths[i], ErrInvalidItem)
+			continue
+		}
+		if !v.Exists("id") {
+			appendDecodeError(errs, jsonPointer(paths[i], "id"), ErrMissingProperty)
+		}
+		if id := v.Get("id"); id != nil {
+			jsonValidateProperty("id", id, jsonPointer(paths[i], "id"), false, errs)
+		}
+		if c := v.Get("controller"); c != nil {
+			if c.Type() != fastjson.TypeString || !isAbsoluteIRI(string(c.GetStringBytes())) {
+				appendDecodeError(errs, jsonPointer(paths[i], "controller"), ErrInvalidIRI)
+			}
+		}
+		if mb := v.Get("publicKeyMultibase"); mb != nil && mb.Type() != fastjson.TypeString {
+			appendDecodeError(errs, jsonPointer(paths[i], "publicKeyMultibase"), ErrInvalidString)
+		}
+	}
+}
+
+func jsonValidateSignature(val *fastjson.Value, path string, errs *Errors) {
+	if val.Type() != fastjson.TypeObject {
+		appendDecodeError(errs, path, ErrInvalidItem)
+		return
+	}
+	if creator := val.Get("creator"); creator != nil {
+		if creator.Type() != fastjson.TypeString || !isAbsoluteIRI(string(creator.GetStringBytes())) {
+			appendDecodeError(errs, jsonPointer(path, "creator"), ErrInvalidIRI)
+		}
+	}
+	if created := val.Get("created"); created != nil {
+		if _, err := time.Parse(time.RFC3339, string(created.GetStringBytes())); created.Type() != fastjson.TypeString || err != nil {
+			appendDecodeError(errs, jsonPointer(path, "created"), ErrInvalidDateTime)
+		}
+	}
+	if sv := val.Get("signatureValue"); sv != nil && sv.Type() != fastjson.TypeString {
+		appendDecodeError(errs, jsonPointer(path, "signatureValue"), ErrInvalidString)
+	}
+}
+
+func jsonValidateSource(val *fastjson.Value, path string, errs *Errors) {
+	if val.Type() != fastjson.TypeObject {
+		appendDecodeError(errs, path, ErrInvalidItem)
+		return
+	}
+	if content := val.Get("content"); content != nil {
+		jsonValidateNaturalLanguageValue(content, jsonPointer(path, "content"), errs)
+	}
+	if mt := val.Get("mediaType"); mt != nil && mt.Type() != fastjson.TypeString {
+		appendDecodeError(errs, jsonPointer(path, "mediaType"), ErrInvalidString)
+	}
+}
+
+func jsonValidateProperty(key string, v *fastjson.Value, path string, knownTypes bool, errs *Errors) {
+	switch {
+	case key == "type":
+		jsonValidateTypes(v, path, knownTypes, errs)
+	case key == "object":
+		jsonValidateItemValue(v, path, knownTypes, errs)
+	case key == "formerType":
+		if v.Type() != fastjson.TypeString {
+			appendDecodeError(errs, path, ErrInvalidType)
+		}
+	case key == "duration":
+		var d time.Duration
+		if v.Type() != fastjson.TypeString || xsd.Unmarshal(v.GetStringBytes(), &d) != nil {
+			appendDecodeError(errs, path, ErrInvalidDuration)
+		}
+	case key == "closed":
+		// NOTE(marius): closed can be an xsd:dateTime, an xsd:boolean, or an Object/Link
+		switch v.Type() {
+		case fastjson.TypeTrue, fastjson.TypeFalse:
+		case fastjson.TypeString:
+			if _, err := time.Parse(time.RFC3339, string(v.GetStringBytes())); err != nil {
+				jsonValidateItemValue(v, path, false, errs)
+			}
+		default:
+			jsonValidateItemValue(v, path, false, errs)
+		}
+	case key == "hrefLang":
+		if _, err := language.Parse(string(v.GetStringBytes())); v.Type() != fastjson.TypeString || err != nil {
+			appendDecodeError(errs, path, ErrInvalidLangTag)
+		}
+	case key == "rel":
+		switch v.Type() {
+		case fastjson.TypeString:
+		case fastjson.TypeArray:
+			for i, r := range v.GetArray() {
+				if r.Type() != fastjson.TypeString {
+					appendDecodeError(errs, jsonPointer(path, strconv.Itoa(i)), ErrInvalidString)
+				}
+			}
+		default:
+			appendDecodeError(errs, path, ErrInvalidString)
+		}
+	case key == "publicKey":
+		jsonValidatePublicKey(v, path, errs)
+	case key == "assertionMethod":
+		jsonValidateMultikeys(v, path, errs)
+	case key == "signature":
+		jsonValidateSignature(v, path, errs)
+	case key == "source":
+		jsonValidateSource(v, path, errs)
+	case key == "endpoints":
+		jsonValidateItemValue(v, path, false, errs)
+	case key == "focalPoint":
+		if v.Type() != fastjson.TypeArray {
+			appendDecodeError(errs, path, ErrInvalidFloat)
+			return
+		}
+		for i, f := range v.GetArray() {
+			if f.Type() != fastjson.TypeNumber {
+				appendDecodeError(errs, jsonPointer(path, strconv.Itoa(i)), ErrInvalidFloat)
+			}
+		}
+	case slices.Contains(strictBooleanProperties, key):
+		if v.Type() != fastjson.TypeTrue && v.Type() != fastjson.TypeFalse {
+			appendDecodeError(errs, path, ErrInvalidBoolean)
+		}
+	case slices.Contains(strictTimeProperties, key):
+		if v.Type() != fastjson.TypeString {
+			appendDecodeError(errs, path, ErrInvalidDateTime)
+			return
+		}
+		if _, err := time.Parse(time.RFC3339, string(v.GetStringBytes())); err != nil {
+			appendDecodeError(errs, path, ErrInvalidDateTime)
+		}
+	case slices.Contains(strictNonNegativeIntegerProperties, key):
+		if v.Type() != fastjson.TypeNumber {
+			appendDecodeError(errs, path, ErrInvalidNonNegativeInteger)
+			return
+		}
+		if _, err := v.Uint64(); err != nil {
+			appendDecodeError(errs, path, ErrInvalidNonNegativeInteger)
+		}
+	case slices.Contains(strictFloatProperties, key):
+		if v.Type() != fastjson.TypeNumber {
+			appendDecodeError(errs, path, ErrInvalidFloat)
+		}
+	case slices.Contains(strictNaturalLanguageProperties, key):
+		jsonValidateNaturalLanguageValue(v, path, errs)
+	case slices.Contains(strictNaturalLanguageProperties, strings.TrimSuffix(key, "Map")):
+		if v.Type() != fastjson.TypeObject {
+			appendDecodeError(errs, path, ErrInvalidNaturalLanguageValue)
+			return
+		}
+		jsonValidateNaturalLanguageValue(v, path, errs)
+	case slices.Contains(strictStringProperties, key):
+		if v.Type() != fastjson.TypeString {
+			appendDecodeError(errs, path, ErrInvalidString)
+		}
+	case slices.Contains(strictIRIProperties, key):
+		if v.Type() != fastjson.TypeString || !isAbsoluteIRI(string(v.GetStringBytes())) {
+			appendDecodeError(errs, path, ErrInvalidIRI)
+		}
+	case slices.Contains(strictItemProperties, key):
+		jsonValidateItemValue(v, path, false, errs)
+	}
+}
+
+func jsonValidateObject(val *fastjson.Value, path string, knownTypes bool, errs *Errors) {
+	ob, err := val.Object()
+	if err != nil {
+		appendDecodeError(errs, path, ErrInvalidItem)
+		return
+	}
+	ob.Visit(func(key []byte, v *fastjson.Value) {
+		jsonValidateProperty(string(key), v, jsonPointer(path, string(key)), knownTypes, errs)
+	})
+}
@@ -0,0 +1,185 @@
+package activitypub
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnmarshalJSONStrict(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		want      Item
+		wantPaths []string
+		wantErrs  []error
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "valid note",
+			data: `{"id":"https://example.com/1","type":"Note","published":"2024-01-02T03:04:05Z","to":["https://www.w3.org/ns/activitystreams#Public"]}`,
+			want: &Object{
+				ID:        "https://example.com/1",
+				Type:      NoteType,
+				Published: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				To:        ItemCollection{PublicNS},
+			},
+		},
+		{
+			name: "valid duration",
+			data: `{"type":"Video","duration":"PT5M"}`,
+			want: &Object{Type: VideoType, Duration: 5 * time.Minute},
+		},
+		{
+			name:      "go duration is not xsd:duration",
+			data:      `{"type":"Video","duration":"5m"}`,
+			wantPaths: []string{"/duration"},
+			wantErrs:  []error{ErrInvalidDuration},
+		},
+		{
+			name: "note with hashtag",
+			data: `{"type":"Create","actor":"https://example.com/~jdoe","object":{"type":"Note","tag":[{"type":"Hashtag","href":"https://example.com/tags/go","name":"#go"}]}}`,
+			want: &Activity{
+				Type:  CreateType,
+				Actor: IRI("https://example.com/~jdoe"),
+				Object: &Object{
+					Type: NoteType,
+					Tag: ItemCollection{
+						&Link{Type: HashtagType, Href: "https://example.com/tags/go", Name: DefaultNaturalLanguage("#go")},
+					},
+				},
+			},
+		},
+		{
+			name: "note with Mastodon properties",
+			data: `{"type":"Image","sensitive":true,"focalPoint":[-0.5,0.25],"blurhash":"UBL_:rOpGG-o","tag":[{"type":"Emoji","id":"https://example.com/emoji/1","name":":go:"}]}`,
+			want: &Object{
+				Type:       ImageType,
+				Sensitive:  true,
+				FocalPoint: []float64{-0.5, 0.25},
+				Blurhash:   "UBL_:rOpGG-o",
+				Tag: ItemCollection{
+					&Object{Type: EmojiType, ID: "https://example.com/emoji/1", Name: DefaultNaturalLanguage(":go:")},
+				},
+			},
+		},
+		{
+			name:      "invalid Mastodon properties",
+			data:      `{"type":"Person","sensitive":"yes","discoverable":1,"focalPoint":[0,"1"],"blurhash":2,"movedTo":"jdoe"}`,
+			wantPaths: []string{"/sensitive", "/discoverable", "/focalPoint/1", "/blurhash", "/movedTo"},
+			wantErrs:  []error{ErrInvalidBoolean, ErrInvalidBoolean, ErrInvalidFloat, ErrInvalidString, ErrInvalidIRI},
+		},
+		{
+			name: "actor with assertionMethod",
+			data: `{"id":"https://example.com/~jdoe","type":"Person","assertionMethod":[{"id":"https://example.com/~jdoe#key","type":"Multikey","controller":"https://example.com/~jdoe","publicKeyMultibase":"z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2"}]}`,
+			want: &Actor{
+				ID:   "https://example.com/~jdoe",
+				Type: PersonType,
+				AssertionMethod: []Multikey{{
+					ID:                 "https://example.com/~jdoe#key",
+					Type:               MultikeyType,
+					Controller:         "https://example.com/~jdoe",
+					PublicKeyMultibase: "z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2",
+				}},
+			},
+		},
+		{
+			name:      "invalid assertionMethod",
+			data:      `{"id":"https://example.com/~jdoe","type":"Person","assertionMethod":[{"controller":"jdoe","publicKeyMultibase":1},42]}`,
+			wantPaths: []string{"/assertionMethod/0/id", "/assertionMethod/0/controller", "/assertionMethod/0/publicKeyMultibase", "/assertionMethod/1"},
+			wantErrs:  []error{ErrMissingProperty, ErrInvalidIRI, ErrInvalidString, ErrInvalidItem},
+		},
+		{
+			name:      "invalid signature",
+			data:      `{"type":"Create","actor":"https://example.com/~jdoe","signature":{"type":"RsaSignature2017","creator":"main-key","created":"yesterday","signatureValue":1}}`,
+			wantPaths: []string{"/signature/creator", "/signature/created", "/signature/signatureValue"},
+			wantErrs:  []error{ErrInvalidIRI, ErrInvalidDateTime, ErrInvalidString},
+		},
+		{
+			name:      "unknown type of activity object",
+			data:      `{"type":"Create","actor":"https://example.com/~jdoe","object":{"type":"ChatMessage"}}`,
+			wantPaths: []string{"/object/type"},
+			wantErrs:  []error{ErrUnknownType},
+		},
+		{
+			name:      "invalid json",
+			data:      `{"id":`,
+			wantPaths: []string{""},
+		},
+		{
+			name:      "invalid nested published",
+			data:      `{"type":"Create","actor":"https://example.com/~jdoe","object":{"type":"Note","published":"yesterday"}}`,
+			wantPaths: []string{"/object/published"},
+			wantErrs:  []error{ErrInvalidDateTime},
+		},
+		{
+			name:      "unknown type",
+			data:      `{"type":"ChatMessage","id":"https://example.com/chat/1"}`,
+			wantPaths: []string{"/type"},
+			wantErrs:  []error{ErrUnknownType},
+		},
+		{
+			name:      "every problem is reported",
+			data:      `{"id":"not an iri","type":"OrderedCollection","totalItems":-1,"duration":"5 minutes","name":12,"orderedItems":["https://example.com/1",42]}`,
+			wantPaths: []string{"/id", "/totalItems", "/duration", "/name", "/orderedItems/1"},
+			wantErrs:  []error{ErrInvalidIRI, ErrInvalidNonNegativeInteger, ErrInvalidDuration, ErrInvalidNaturalLanguageValue, ErrInvalidItem},
+		},
+		{
+			name:      "escaped pointer tokens",
+			data:      `{"type":"Note","contentMap":{"en/US~1":"test"}}`,
+			wantPaths: []string{"/contentMap/en~1US~01"},
+			wantErrs:  []error{ErrInvalidLangTag},
+		},
+		{
+			name:      "invalid public key",
+			data:      `{"type":"Person","publicKey":{"owner":"jdoe","publicKeyPem":1}}`,
+			wantPaths: []string{"/publicKey/id", "/publicKey/owner", "/publicKey/publicKeyPem"},
+			wantErrs:  []error{ErrMissingProperty, ErrInvalidIRI, ErrInvalidString},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalJSONStrict([]byte(tt.data))
+			if len(tt.wantPaths) == 0 {
+				if err != nil {
+					t.Fatalf("UnmarshalJSONStrict() unexpected error = %s", err)
+				}
+				if !cmp.Equal(got, tt.want) {
+					t.Errorf("UnmarshalJSONStrict() got = %s", cmp.Diff(tt.want, got))
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("UnmarshalJSONStrict() expected errors for %v, got nil", tt.wantPaths)
+			}
+			if got != nil {
+				t.Errorf("UnmarshalJSONStrict() expected nil item on error, got %v", got)
+			}
+			var decErrs Errors
+			if !errors.As(err, &decErrs) {
+				t.Fatalf("UnmarshalJSONStrict() error %T is not an Errors list", err)
+			}
+			paths := make([]string, 0, len(decErrs))
+			for _, e := range decErrs {
+				paths = append(paths, e.Path)
+			}
+			if !cmp.Equal(paths, tt.wantPaths) {
+				t.Errorf("UnmarshalJSONStrict() error paths = %s", cmp.Diff(tt.wantPaths, paths))
+			}
+			for i, want := range tt.wantErrs {
+				if !errors.Is(decErrs[i], want) {
+					t.Errorf("UnmarshalJSONStrict() error %q is not %q", decErrs[i], want)
+				}
+				for _, other := range tt.wantErrs {
+					if other != want && errors.Is(decErrs[i], other) {
+						t.Errorf("UnmarshalJSONStrict() error %q should not match %q", decErrs[i], other)
+					}
+				}
+			}
+		})
+	}
+}
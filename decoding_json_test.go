@@ -461,7 +461,44 @@ func TestJSONGetID(t *testing.T) {
 }
 
 func TestJSONGetNaturalLanguageField(t *testing.T) {
-	t.Skipf("TODO")
+	tests := []struct {
+		name string
+		data string
+		want NaturalLanguageValues
+	}{
+		{
+			name: "missing",
+			data: `{}`,
+		},
+		{
+			name: "string",
+			data: `{"content":"test"}`,
+			want: DefaultNaturalLanguage("test"),
+		},
+		{
+			name: "language map",
+			data: `{"content":{"en":"test","fr":"teste"}}`,
+			want: NaturalLanguageValuesNew(RefValue(MakeRef([]byte("en")), "test"), RefValue(MakeRef([]byte("fr")), "teste")),
+		},
+		{
+			name: "map property",
+			data: `{"contentMap":{"en":"test","fr":"teste"}}`,
+			want: NaturalLanguageValuesNew(RefValue(MakeRef([]byte("en")), "test"), RefValue(MakeRef([]byte("fr")), "teste")),
+		},
+		{
+			name: "string and map property",
+			data: `{"content":"test","contentMap":{"fr":"teste"}}`,
+			want: NaturalLanguageValuesNew(DefaultLangRef("test"), RefValue(MakeRef([]byte("fr")), "teste")),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := JSONGetNaturalLanguageField(fastjson.MustParse(tt.data), "content")
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("JSONGetNaturalLanguageField() got = %s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
 }
 
 func TestJSONGetString(t *testing.T) {
@@ -0,0 +1,493 @@
+package activitypub
+
+import (
+	"embed"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-ap/errors"
+	"github.com/valyala/fastjson"
+)
+
+// ContextLoader loads the JSON-LD context documents referenced by IRI in the "@context" of a document.
+type ContextLoader interface {
+	LoadContext(iri IRI) ([]byte, error)
+}
+
+// ContextLoaderFn is a function that implements the ContextLoader interface.
+type ContextLoaderFn func(iri IRI) ([]byte, error)
+
+// LoadContext calls the fn function.
+func (fn ContextLoaderFn) LoadContext(iri IRI) ([]byte, error) {
+	return fn(iri)
+}
+
+// OfflineContextLoader is a ContextLoader that never goes to the network. It serves the context documents
+// it holds, falling back to the bundled ActivityStreams, security and identity contexts.
+type OfflineContextLoader map[IRI][]byte
+
+// DefaultContextLoader is the ContextLoader used by UnmarshalJSONLD when none is passed.
+var DefaultContextLoader ContextLoader = OfflineContextLoader(nil)
+
+// ErrContextNotFound is returned when a context document can't be loaded.
+var ErrContextNotFound = errors.Newf("JSON-LD context not found")
+
+//go:embed contexts/*.jsonld
+var bundledContexts embed.FS
+
+var bundledContextFiles = map[IRI]string{
+	ActivityBaseURI:                                        "contexts/activitystreams.jsonld",
+	"http://www.w3.org/ns/activitystreams":                 "contexts/activitystreams.jsonld",
+	"https://www.w3.org/ns/activitystreams.jsonld":         "contexts/activitystreams.jsonld",
+	SecurityContextURI:                                     "contexts/security-v1.jsonld",
+	"https://w3id.org/security/v1.jsonld":                  "contexts/security-v1.jsonld",
+	"https://web-payments.org/contexts/security-v1.jsonld": "contexts/security-v1.jsonld",
+	IdentityContextURI:                                     "contexts/identity-v1.jsonld",
+	"https://w3id.org/identity/v1.jsonld":                  "contexts/identity-v1.jsonld",
+}
+
+// LoadContext returns the context document for iri.
+func (o OfflineContextLoader) LoadContext(iri IRI) ([]byte, error) {
+	if doc, ok := o[iri]; ok {
+		return doc, nil
+	}
+	if name, ok := bundledContextFiles[iri]; ok {
+		return bundledContexts.ReadFile(name)
+	}
+	return nil, errors.Annotatef(ErrContextNotFound, "%s", iri)
+}
+
+// maxContextDepth limits how many context documents can be loaded from other context documents.
+const maxContextDepth = 8
+
+type jsonldTerm struct {
+	id        string
+	typ       string
+	container string
+}
+
+type jsonldContext struct {
+	vocab string
+	base  string
+	terms map[string]jsonldTerm
+}
+
+func (c jsonldContext) clone() jsonldContext {
+	terms := make(map[string]jsonldTerm, len(c.terms))
+	for k, v := range c.terms {
+		terms[k] = v
+	}
+	return jsonldContext{vocab: c.vocab, base: c.base, terms: terms}
+}
+
+// resolveIRI resolves the s relative IRI against the "@base" of the context, and returns false if it can't.
+func (c jsonldContext) resolveIRI(s string) (string, bool) {
+	if strings.HasPrefix(s, "_:") || isAbsoluteIRI(s) {
+		return s, true
+	}
+	if len(c.base) == 0 {
+		return s, false
+	}
+	base, err := url.Parse(c.base)
+	if err != nil {
+		return s, false
+	}
+	ref, err := url.Parse(s)
+	if err != nil {
+		return s, false
+	}
+	s = base.ResolveReference(ref).String()
+	return s, isAbsoluteIRI(s)
+}
+
+// expandIRI resolves the s term, compact IRI or keyword alias to an absolute IRI, or to a JSON-LD keyword.
+func (c jsonldContext) expandIRI(s string, vocab bool) string {
+	for range maxContextDepth {
+		if strings.HasPrefix(s, "@") {
+			return s
+		}
+		if t, ok := c.terms[s]; ok && vocab && t.id != s {
+			s = t.id
+			continue
+		}
+		if prefix, suffix, ok := strings.Cut(s, ":"); ok {
+			if prefix == "_" || strings.HasPrefix(suffix, "//") {
+				return s
+			}
+			if t, ok := c.terms[prefix]; ok {
+				s = t.id + suffix
+				continue
+			}
+			return s
+		}
+		if vocab && len(c.vocab) > 0 && c.vocab != "_:" {
+			return c.vocab + s
+		}
+		return s
+	}
+	return s
+}
+
+type jsonldContextProcessor struct {
+	loader ContextLoader
+	loaded map[IRI]*fastjson.Value
+}
+
+func (p *jsonldContextProcessor) process(c jsonldContext, val *fastjson.Value, depth int) (jsonldContext, error) {
+	if depth > maxContextDepth {
+		return c, errors.Newf("JSON-LD context nesting is too deep")
+	}
+	switch val.Type() {
+	case fastjson.TypeNull:
+		return jsonldContext{terms: make(map[string]jsonldTerm)}, nil
+	case fastjson.TypeArray:
+		var err error
+		for _, v := range val.GetArray() {
+			if c, err = p.process(c, v, depth); err != nil {
+				return c, err
+			}
+		}
+		return c, nil
+	case fastjson.TypeString:
+		iri := IRI(val.GetStringBytes())
+		doc, ok := p.loaded[iri]
+		if !ok {
+			raw, err := p.loader.LoadContext(iri)
+			if err != nil {
+				return c, err
+			}
+			if doc, err = fastjson.ParseBytes(raw); err != nil {
+				return c, errors.Annotatef(err, "invalid JSON-LD context %s", iri)
+			}
+			p.loaded[iri] = doc
+		}
+		if ctx := doc.Get("@context"); ctx != nil {
+			return p.process(c, ctx, depth+1)
+		}
+		return c, nil
+	case fastjson.TypeObject:
+		c = c.clone()
+		ob, _ := val.Object()
+		ob.Visit(func(key []byte, v *fastjson.Value) {
+			k := string(key)
+			switch k {
+			case "@vocab":
+				c.vocab = string(v.GetStringBytes())
+			case "@base":
+				// NOTE(marius): a relative "@base" is resolved against the current one, and null removes it
+				base := ""
+				if v.Type() == fastjson.TypeString {
+					base, _ = c.resolveIRI(string(v.GetStringBytes()))
+				}
+				c.base = base
+			case "@language", "@version", "@protected", "@propagate", "@import", "@direction":
+			default:
+				switch v.Type() {
+				case fastjson.TypeNull:
+					delete(c.terms, k)
+				case fastjson.TypeString:
+					c.terms[k] = jsonldTerm{id: string(v.GetStringBytes())}
+				case fastjson.TypeObject:
+					t := jsonldTerm{
+						id:        string(v.GetStringBytes("@id")),
+						typ:       string(v.GetStringBytes("@type")),
+						container: string(v.GetStringBytes("@container")),
+					}
+					if len(t.id) == 0 {
+						t.id = k
+					}
+					c.terms[k] = t
+				}
+			}
+		})
+		// NOTE(marius): the term definitions can use prefixes defined later in the same context,
+		// so we expand them only after all of them have been loaded.
+		for k, t := range c.terms {
+			t.id = c.expandIRI(t.id, true)
+			if len(t.typ) > 0 {
+				t.typ = c.expandIRI(t.typ, true)
+			}
+			c.terms[k] = t
+		}
+		return c, nil
+	}
+	return c, errors.Newf("invalid JSON-LD context value %s", val.Type())
+}
+
+// knownTerms maps the expanded IRIs of the properties and types known to the package to their names.
+type knownTerms struct {
+	// base is the active context for documents without a "@context"
+	base     jsonldContext
+	terms    map[string]string
+	langMaps map[string]string
+	lists    map[string]string
+}
+
+var (
+	knownTermsOnce sync.Once
+	knownTermsVal  knownTerms
+)
+
+// jsonldKnownTerms builds the reverse mapping from the bundled ActivityStreams and security contexts.
+func jsonldKnownTerms() knownTerms {
+	knownTermsOnce.Do(func() {
+		knownTermsVal = knownTerms{
+			terms:    make(map[string]string),
+			langMaps: make(map[string]string),
+			lists:    make(map[string]string),
+		}
+		p := jsonldContextProcessor{loader: OfflineContextLoader(nil), loaded: make(map[IRI]*fastjson.Value)}
+		base := jsonldContext{terms: make(map[string]jsonldTerm)}
+		bundled := fastjson.MustParse(`["` + string(ActivityBaseURI) + `","` + string(SecurityContextURI) + `"]`)
+		base, _ = p.process(base, bundled, 0)
+		knownTermsVal.base = base
+
+		// NOTE(marius): we iterate the names in order, so the mapping is stable if multiple terms have the same IRI
+		names := make([]string, 0, len(base.terms))
+		for name := range base.terms {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			t := base.terms[name]
+			if strings.HasPrefix(t.id, "@") || strings.HasSuffix(t.id, "#") || strings.HasSuffix(t.id, "/") {
+				continue
+			}
+			m := knownTermsVal.terms
+			switch t.container {
+			case "@language":
+				m = knownTermsVal.langMaps
+			case "@list":
+				m = knownTermsVal.lists
+			}
+			if _, ok := m[t.id]; !ok {
+				m[t.id] = name
+			}
+		}
+		// NOTE(marius): the ActivityStreams context uses "hreflang", but we load "hrefLang"
+		knownTermsVal.terms[string(ActivityBaseURI)+"#hreflang"] = "hrefLang"
+	})
+	return knownTermsVal
+}
+
+// JSONCompact returns a copy of the val JSON-LD document in which the properties and the types are
+// named like in the ActivityStreams context, so it can be loaded by JSONLoadItem.
+//
+// It resolves the terms using the "@context" of the document, and of its nested objects, which allows
+// loading documents using prefixed terms ("as:content"), full IRIs as property names, custom aliases
+// of terms or keywords, or the expanded JSON-LD form. Properties which are not known to the package
+// keep their original names. The context documents referenced by IRI are loaded with the loader.
+func JSONCompact(val *fastjson.Value, loader ContextLoader) (*fastjson.Value, error) {
+	if loader == nil {
+		loader = DefaultContextLoader
+	}
+	c := jsonldCompactor{
+		p:     jsonldContextProcessor{loader: loader, loaded: make(map[IRI]*fastjson.Value)},
+		known: jsonldKnownTerms(),
+	}
+	return c.value(c.known.base, jsonldTerm{}, val)
+}
+
+type jsonldCompactor struct {
+	p     jsonldContextProcessor
+	a     fastjson.Arena
+	known knownTerms
+}
+
+// value compacts the val JSON-LD value of a property with the def definition.
+func (c *jsonldCompactor) value(ctx jsonldContext, def jsonldTerm, val *fastjson.Value) (*fastjson.Value, error) {
+	v, _, err := c.propertyValue(ctx, def, val)
+	return v, err
+}
+
+// propertyValue compacts the val JSON-LD value of a property with the def definition, and reports
+// if the result is a language map.
+func (c *jsonldCompactor) propertyValue(ctx jsonldContext, def jsonldTerm, val *fastjson.Value) (*fastjson.Value, bool, error) {
+	switch val.Type() {
+	case fastjson.TypeArray:
+		arr := val.GetArray()
+		if len(arr) == 1 {
+			return c.propertyValue(ctx, def, arr[0])
+		}
+		if langMap := c.languageMap(arr); langMap != nil {
+			return langMap, true, nil
+		}
+		res := c.a.NewArray()
+		for i, v := range arr {
+			cv, err := c.value(ctx, def, v)
+			if err != nil {
+				return nil, false, err
+			}
+			res.SetArrayItem(i, cv)
+		}
+		return res, false, nil
+	case fastjson.TypeObject:
+		if v := val.Get("@value"); v != nil {
+			if lang := val.GetStringBytes("@language"); len(lang) > 0 {
+				res := c.a.NewObject()
+				res.Set(string(lang), v)
+				return res, true, nil
+			}
+			return v, false, nil
+		}
+		if v := val.Get("@list"); v != nil {
+			return c.list(ctx, def, v)
+		}
+		if v := val.Get("@set"); v != nil {
+			return c.propertyValue(ctx, def, v)
+		}
+		node, err := c.node(ctx, val)
+		return node, false, err
+	case fastjson.TypeString:
+		if def.typ == "@id" || def.typ == "@vocab" {
+			return c.a.NewString(ctx.expandIRI(string(val.GetStringBytes()), def.typ == "@vocab")), false, nil
+		}
+	}
+	return val, false, nil
+}
+
+// list compacts the items of a "@list" value, which always stays an array.
+func (c *jsonldCompactor) list(ctx jsonldContext, def jsonldTerm, val *fastjson.Value) (*fastjson.Value, bool, error) {
+	if val.Type() != fastjson.TypeArray {
+		return c.propertyValue(ctx, def, val)
+	}
+	res := c.a.NewArray()
+	for i, v := range val.GetArray() {
+		cv, err := c.value(ctx, def, v)
+		if err != nil {
+			return nil, false, err
+		}
+		res.SetArrayItem(i, cv)
+	}
+	return res, false, nil
+}
+
+// languageMap returns a language map if all the arr values are language tagged strings.
+func (c *jsonldCompactor) languageMap(arr []*fastjson.Value) *fastjson.Value {
+	for _, v := range arr {
+		if v.Type() != fastjson.TypeObject || v.Get("@value") == nil || len(v.GetStringBytes("@language")) == 0 {
+			return nil
+		}
+	}
+	res := c.a.NewObject()
+	for _, v := range arr {
+		res.Set(string(v.GetStringBytes("@language")), v.Get("@value"))
+	}
+	return res
+}
+
+// types compacts the values of a "type" property to the names of the types known to the package.
+func (c *jsonldCompactor) types(ctx jsonldContext, val *fastjson.Value) *fastjson.Value {
+	compact := func(v *fastjson.Value) *fastjson.Value {
+		if v.Type() != fastjson.TypeString {
+			return v
+		}
+		if name, ok := c.known.terms[ctx.expandIRI(string(v.GetStringBytes()), true)]; ok {
+			return c.a.NewString(name)
+		}
+		return v
+	}
+	if val.Type() != fastjson.TypeArray {
+		return compact(val)
+	}
+	arr := val.GetArray()
+	if len(arr) == 1 {
+		return compact(arr[0])
+	}
+	res := c.a.NewArray()
+	for i, v := range arr {
+		res.SetArrayItem(i, compact(v))
+	}
+	return res
+}
+
+// node compacts a JSON-LD node object.
+func (c *jsonldCompactor) node(ctx jsonldContext, val *fastjson.Value) (*fastjson.Value, error) {
+	var err error
+	if local := val.Get("@context"); local != nil {
+		if ctx, err = c.p.process(ctx, local, 0); err != nil {
+			return nil, err
+		}
+	}
+	ob, _ := val.Object()
+	if ob.Len() == 1 && val.Exists("@id") {
+		// NOTE(marius): a node reference is loaded as an IRI
+		return c.a.NewString(ctx.expandIRI(string(val.GetStringBytes("@id")), false)), nil
+	}
+
+	res := c.a.NewObject()
+	ob.Visit(func(key []byte, v *fastjson.Value) {
+		if err != nil {
+			return
+		}
+		k := string(key)
+		if k == "@context" {
+			return
+		}
+		iri := ctx.expandIRI(k, true)
+		switch iri {
+		case "@id":
+			res.Set("id", c.a.NewString(ctx.expandIRI(string(v.GetStringBytes()), false)))
+			return
+		case "@type":
+			res.Set("type", c.types(ctx, v))
+			return
+		}
+		def := ctx.terms[k]
+		def.id = iri
+		if def.typ == "" {
+			// NOTE(marius): prefixed terms and full IRIs get their value types from the known terms
+			if name, ok := c.known.terms[iri]; ok {
+				def.typ = c.known.base.terms[name].typ
+			}
+		}
+		var (
+			cv      *fastjson.Value
+			langMap bool
+		)
+		if cv, langMap, err = c.propertyValue(ctx, def, v); err != nil {
+			return
+		}
+		name := k
+		if n, ok := c.known.langMaps[iri]; ok && (langMap || def.container == "@language") {
+			name = n
+		} else if n, ok = c.known.lists[iri]; ok && (def.container == "@list" || v.Exists("0", "@list")) {
+			name = n
+		} else if n, ok = c.known.terms[iri]; ok {
+			name = n
+		}
+		res.Set(name, cv)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Exists("items") && !res.Exists("orderedItems") {
+		// NOTE(marius): both "items" and "orderedItems" are "as:items", the difference is made by the type
+		ordered := ActivityVocabularyTypes{OrderedCollectionType, OrderedCollectionPageType}
+		if typ := JSONGetTypes(res); typ != nil && ordered.Match(typ) {
+			res.Set("orderedItems", res.Get("items"))
+			res.Del("items")
+		}
+	}
+	return res, nil
+}
+
+// UnmarshalJSONLD works like UnmarshalJSON, but it takes into account the "@context" of the document, so
+// documents which don't use the bare ActivityStreams terms load into the same fields. See JSONCompact.
+//
+// If loader is nil, the DefaultContextLoader is used, which only knows the bundled contexts.
+func UnmarshalJSONLD(data []byte, loader ContextLoader) (Item, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	val, err := parseJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	if val, err = JSONCompact(val, loader); err != nil {
+		return nil, err
+	}
+	return JSONUnmarshalToItem(val), nil
+}
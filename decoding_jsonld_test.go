@@ -0,0 +1,147 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnmarshalJSONLD(t *testing.T) {
+	note := &Object{
+		ID:           "https://example.com/1",
+		Type:         NoteType,
+		Content:      DefaultNaturalLanguage("test"),
+		AttributedTo: IRI("https://example.com/~jdoe"),
+		To:           ItemCollection{PublicNS},
+	}
+	customCtx := `{"@context":{"as":"https://www.w3.org/ns/activitystreams#","body":"as:content","author":{"@id":"as:attributedTo","@type":"@id"}}}`
+	loader := OfflineContextLoader{"https://example.com/ns": []byte(customCtx)}
+
+	tests := []struct {
+		name    string
+		data    string
+		loader  ContextLoader
+		want    Item
+		wantErr error
+	}{
+		{
+			name: "compacted",
+			data: `{"@context":"https://www.w3.org/ns/activitystreams","id":"https://example.com/1","type":"Note","content":"test","attributedTo":"https://example.com/~jdoe","to":"https://www.w3.org/ns/activitystreams#Public"}`,
+			want: note,
+		},
+		{
+			name: "without context",
+			data: `{"id":"https://example.com/1","type":"Note","content":"test","attributedTo":"https://example.com/~jdoe","to":"as:Public"}`,
+			want: note,
+		},
+		{
+			name: "prefixed terms",
+			data: `{"@context":"https://www.w3.org/ns/activitystreams","@id":"https://example.com/1","@type":"as:Note","as:content":"test","as:attributedTo":"https://example.com/~jdoe","as:to":"as:Public"}`,
+			want: note,
+		},
+		{
+			name: "full IRIs",
+			data: `{"@id":"https://example.com/1","@type":"https://www.w3.org/ns/activitystreams#Note","https://www.w3.org/ns/activitystreams#content":"test","https://www.w3.org/ns/activitystreams#attributedTo":{"@id":"https://example.com/~jdoe"},"https://www.w3.org/ns/activitystreams#to":{"@id":"https://www.w3.org/ns/activitystreams#Public"}}`,
+			want: note,
+		},
+		{
+			name: "aliased terms",
+			data: `{"@context":{"as":"https://www.w3.org/ns/activitystreams#","ident":"@id","kind":"@type","body":"as:content","author":{"@id":"as:attributedTo","@type":"@id"},"audience":{"@id":"as:to","@type":"@id"}},"ident":"https://example.com/1","kind":"as:Note","body":"test","author":"https://example.com/~jdoe","audience":"as:Public"}`,
+			want: note,
+		},
+		{
+			name:   "remote context from the loader",
+			data:   `{"@context":["https://www.w3.org/ns/activitystreams","https://example.com/ns"],"id":"https://example.com/1","type":"Note","body":"test","author":"https://example.com/~jdoe","to":"https://www.w3.org/ns/activitystreams#Public"}`,
+			loader: loader,
+			want:   note,
+		},
+		{
+			name:    "missing remote context",
+			data:    `{"@context":"https://example.com/ns","id":"https://example.com/1","type":"Note"}`,
+			wantErr: ErrContextNotFound,
+		},
+		{
+			name: "expanded",
+			data: `[{"@id":"https://example.com/2","@type":["https://www.w3.org/ns/activitystreams#Create"],"https://www.w3.org/ns/activitystreams#actor":[{"@id":"https://example.com/~jdoe"}],"https://www.w3.org/ns/activitystreams#object":[{"@id":"https://example.com/1","@type":["https://www.w3.org/ns/activitystreams#Note"],"https://www.w3.org/ns/activitystreams#content":[{"@value":"test","@language":"en"},{"@value":"teste","@language":"fr"}],"https://www.w3.org/ns/activitystreams#published":[{"@value":"2024-01-02T03:04:05Z","@type":"http://www.w3.org/2001/XMLSchema#dateTime"}]}]}]`,
+			want: &Activity{
+				ID:    "https://example.com/2",
+				Type:  CreateType,
+				Actor: IRI("https://example.com/~jdoe"),
+				Object: &Object{
+					ID:        "https://example.com/1",
+					Type:      NoteType,
+					Content:   NaturalLanguageValuesNew(RefValue(MakeRef([]byte("en")), "test"), RefValue(MakeRef([]byte("fr")), "teste")),
+					Published: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				},
+			},
+		},
+		{
+			name: "expanded ordered collection",
+			data: `{"@id":"https://example.com/outbox","@type":"https://www.w3.org/ns/activitystreams#OrderedCollection","https://www.w3.org/ns/activitystreams#items":[{"@list":[{"@id":"https://example.com/1"},{"@id":"https://example.com/2"}]}]}`,
+			want: &OrderedCollection{
+				ID:           "https://example.com/outbox",
+				Type:         OrderedCollectionType,
+				OrderedItems: ItemCollection{IRI("https://example.com/1"), IRI("https://example.com/2")},
+			},
+		},
+		{
+			name: "extension properties keep their names",
+			data: `{"@context":["https://www.w3.org/ns/activitystreams",{"litepub":"http://litepub.social/ns#","directMessage":"litepub:directMessage"}],"id":"https://example.com/1","type":"Note","directMessage":true}`,
+			want: &Object{
+				ID:         "https://example.com/1",
+				Type:       NoteType,
+				Extensions: Extensions{"directMessage": json.RawMessage(`true`)},
+			},
+		},
+		{
+			name: "actor public key",
+			data: `{"@context":["https://www.w3.org/ns/activitystreams","https://w3id.org/security/v1"],"id":"https://example.com/~jdoe","type":"Person","inbox":"https://example.com/~jdoe/inbox","sec:publicKey":{"@id":"https://example.com/~jdoe#main-key","sec:owner":"https://example.com/~jdoe","sec:publicKeyPem":"PEM"}}`,
+			want: &Actor{
+				ID:    "https://example.com/~jdoe",
+				Type:  PersonType,
+				Inbox: IRI("https://example.com/~jdoe/inbox"),
+				PublicKey: PublicKey{
+					ID:           "https://example.com/~jdoe#main-key",
+					Owner:        "https://example.com/~jdoe",
+					PublicKeyPem: "PEM",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalJSONLD([]byte(tt.data), tt.loader)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("UnmarshalJSONLD() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnmarshalJSONLD() error = %s", err)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("UnmarshalJSONLD() got = %s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestOfflineContextLoader_LoadContext(t *testing.T) {
+	for _, iri := range []IRI{ActivityBaseURI, SecurityContextURI} {
+		doc, err := OfflineContextLoader(nil).LoadContext(iri)
+		if err != nil {
+			t.Fatalf("LoadContext(%s) error = %s", iri, err)
+		}
+		if !json.Valid(doc) {
+			t.Errorf("LoadContext(%s) returned an invalid JSON document", iri)
+		}
+	}
+	custom := OfflineContextLoader{ActivityBaseURI: []byte(`{"@context":{}}`)}
+	if doc, _ := custom.LoadContext(ActivityBaseURI); string(doc) != `{"@context":{}}` {
+		t.Errorf("LoadContext() didn't return the custom document, got %s", doc)
+	}
+}
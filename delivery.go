@@ -0,0 +1,226 @@
+package activitypub
+
+import (
+	"github.com/go-ap/errors"
+)
+
+// RecipientResolver loads the collections and actors which are referenced by IRI in the recipients of an
+// activity, so PlanDelivery can expand them to inboxes.
+//
+// For collections, the resolver can return the collection with its items, or, for paged collections, with
+// a First page, which then gets resolved together with the Next pages.
+type RecipientResolver interface {
+	Resolve(iri IRI) (Item, error)
+}
+
+// RecipientResolverFn is a function which implements the RecipientResolver interface.
+type RecipientResolverFn func(iri IRI) (Item, error)
+
+// Resolve calls fn.
+func (fn RecipientResolverFn) Resolve(iri IRI) (Item, error) {
+	return fn(iri)
+}
+
+// Delivery is an inbox that an activity needs to be delivered to, together with the actors which
+// receive it through that inbox.
+type Delivery struct {
+	// Inbox is the sharedInbox of the Recipients, or their inbox, when they don't have a shared one.
+	Inbox IRI
+	// Recipients are the IRIs of the actors which receive the activity through the Inbox.
+	Recipients IRIs
+}
+
+// MaxRecipientDepth is the number of collections nested in each other that PlanDelivery expands, like
+// the followers of a Group addressed through another collection.
+var MaxRecipientDepth = 3
+
+// maxCollectionPages is the number of pages that PlanDelivery loads for one collection.
+//
+// NOTE(marius): the visited pages stop the loops between pages, but a server could still return new
+// Next pages indefinitely.
+const maxCollectionPages = 1000
+
+var (
+	// ErrRecipientDepth is the cause for the collections nested deeper than MaxRecipientDepth.
+	ErrRecipientDepth = errors.Newf("maximum recipient collection depth exceeded")
+	// ErrRecipientUnresolved is the cause for the recipients which couldn't be loaded.
+	ErrRecipientUnresolved = errors.Newf("unable to resolve recipient")
+	// ErrRecipientNoInbox is the cause for the actors which don't have an inbox.
+	ErrRecipientNoInbox = errors.Newf("recipient has no inbox")
+)
+
+// PlanDelivery expands the To, Bto, CC, BCC and Audience recipients of the act activity to the inboxes
+// the activity must be delivered to.
+//
+// The collections, like the followers of the sender, are expanded to their items, and the IRIs of the
+// collections and actors are loaded with the res RecipientResolver. The Public collection and the actor
+// of the activity are skipped, and the recipients which share a sharedInbox get a single Delivery.
+// Collections and actors are only visited once, which stops the cycles between collections.
+//
+// The recipients which can't be resolved, or which don't have an inbox, don't stop the planning.
+// They are returned as Errors, with their IRI as the Path, together with the Deliveries for the other recipients.
+func PlanDelivery(act Item, res RecipientResolver) ([]Delivery, error) {
+	if IsNil(act) {
+		return nil, nil
+	}
+	p := deliveryPlanner{
+		res:     res,
+		visited: make(map[IRI]struct{}),
+		inboxes: make(map[IRI]int),
+	}
+	err := OnIntransitiveActivity(act, func(a *IntransitiveActivity) error {
+		for _, sender := range DerefItem(a.Actor) {
+			if !IsNil(sender) {
+				p.visited[sender.GetLink()] = struct{}{}
+			}
+		}
+		for _, recipients := range []ItemCollection{a.To, a.Bto, a.CC, a.BCC, a.Audience} {
+			for _, rec := range recipients {
+				p.add(rec, 0)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p.deliveries, p.errs.orNil()
+}
+
+type deliveryPlanner struct {
+	res        RecipientResolver
+	visited    map[IRI]struct{}
+	inboxes    map[IRI]int
+	deliveries []Delivery
+	errs       Errors
+}
+
+func (p *deliveryPlanner) add(rec Item, depth int) {
+	if IsNil(rec) || isPublicNS(rec.GetLink()) {
+		return
+	}
+	iri := rec.GetLink()
+	if len(iri) > 0 {
+		if _, ok := p.visited[iri]; ok {
+			return
+		}
+		p.visited[iri] = struct{}{}
+	}
+
+	if IsItemCollection(rec) {
+		// NOTE(marius): the ItemCollections are only wrappers around the recipients, so they don't get nested
+		for _, it := range DerefItem(rec) {
+			p.add(it, depth)
+		}
+		return
+	}
+	if IsIRI(rec) || (!CollectionTypes.Match(rec.GetType()) && !hasInbox(rec)) {
+		var err *Error
+		if rec, err = p.resolve(iri); err != nil {
+			p.errs = append(p.errs, err)
+			return
+		}
+	}
+	if CollectionTypes.Match(rec.GetType()) {
+		if depth >= MaxRecipientDepth {
+			p.errs = append(p.errs, pathErr(iri.String(), ErrRecipientDepth, nil))
+			return
+		}
+		p.addCollection(rec, depth+1)
+		return
+	}
+	p.addActor(rec)
+}
+
+func (p *deliveryPlanner) resolve(iri IRI) (Item, *Error) {
+	if p.res == nil {
+		return nil, pathErr(iri.String(), ErrRecipientUnresolved, nil)
+	}
+	it, err := p.res.Resolve(iri)
+	if err != nil {
+		return nil, pathErr(iri.String(), ErrRecipientUnresolved, err)
+	}
+	if IsNil(it) || IsIRI(it) {
+		return nil, pathErr(iri.String(), ErrRecipientUnresolved, nil)
+	}
+	return it, nil
+}
+
+func (p *deliveryPlanner) addCollection(col Item, depth int) {
+	for range maxCollectionPages {
+		var next Item
+		_ = OnCollectionIntf(col, func(c CollectionInterface) error {
+			for _, it := range c.Collection() {
+				p.add(it, depth)
+			}
+			return nil
+		})
+		switch {
+		case ActivityVocabularyTypes{CollectionType, OrderedCollectionType}.Match(col.GetType()):
+			_ = OnCollection(col, func(c *Collection) error {
+				if len(c.Items) == 0 {
+					next = c.First
+				}
+				return nil
+			})
+		case ActivityVocabularyTypes{CollectionPageType, OrderedCollectionPageType}.Match(col.GetType()):
+			_ = OnCollectionPage(col, func(c *CollectionPage) error {
+				next = c.Next
+				return nil
+			})
+		}
+		if IsNil(next) {
+			return
+		}
+		iri := next.GetLink()
+		if len(iri) > 0 {
+			if _, ok := p.visited[iri]; ok {
+				return
+			}
+			p.visited[iri] = struct{}{}
+		}
+		if IsIRI(next) {
+			var err *Error
+			if next, err = p.resolve(iri); err != nil {
+				p.errs = append(p.errs, err)
+				return
+			}
+		}
+		col = next
+	}
+}
+
+func (p *deliveryPlanner) addActor(it Item) {
+	var inbox, shared IRI
+	_ = OnActor(it, func(a *Actor) error {
+		if !IsNil(a.Inbox) {
+			inbox = a.Inbox.GetLink()
+		}
+		if a.Endpoints != nil && !IsNil(a.Endpoints.SharedInbox) {
+			shared = a.Endpoints.SharedInbox.GetLink()
+		}
+		return nil
+	})
+	if len(inbox) == 0 {
+		p.errs = append(p.errs, pathErr(it.GetLink().String(), ErrRecipientNoInbox, nil))
+		return
+	}
+	if len(shared) > 0 {
+		inbox = shared
+	}
+	if i, ok := p.inboxes[inbox]; ok {
+		p.deliveries[i].Recipients = append(p.deliveries[i].Recipients, it.GetLink())
+		return
+	}
+	p.inboxes[inbox] = len(p.deliveries)
+	p.deliveries = append(p.deliveries, Delivery{Inbox: inbox, Recipients: IRIs{it.GetLink()}})
+}
+
+func hasInbox(it Item) bool {
+	inbox := false
+	_ = OnActor(it, func(a *Actor) error {
+		inbox = !IsNil(a.Inbox)
+		return nil
+	})
+	return inbox
+}
@@ -0,0 +1,253 @@
+package activitypub
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func mockDeliveryActor(id IRI, sharedInbox IRI) *Actor {
+	a := &Actor{ID: id, Type: PersonType, Inbox: id.AddPath("inbox"), Followers: id.AddPath("followers")}
+	if len(sharedInbox) > 0 {
+		a.Endpoints = &Endpoints{SharedInbox: sharedInbox}
+	}
+	return a
+}
+
+type mockRecipientResolver map[IRI]Item
+
+func (m mockRecipientResolver) Resolve(iri IRI) (Item, error) {
+	it, ok := m[iri]
+	if !ok {
+		return nil, fmt.Errorf("not found %s", iri)
+	}
+	return it, nil
+}
+
+func TestPlanDelivery(t *testing.T) {
+	alice := mockDeliveryActor("https://mastodon.example/users/alice", "https://mastodon.example/inbox")
+	bob := mockDeliveryActor("https://mastodon.example/users/bob", "https://mastodon.example/inbox")
+	carol := mockDeliveryActor("https://other.example/users/carol", "")
+	dave := mockDeliveryActor("https://third.example/users/dave", "https://third.example/inbox")
+
+	res := mockRecipientResolver{
+		alice.GetLink(): alice,
+		bob.GetLink():   bob,
+		carol.GetLink(): carol,
+		alice.Followers.GetLink(): &OrderedCollection{
+			ID:           ID(alice.Followers.GetLink()),
+			Type:         OrderedCollectionType,
+			OrderedItems: ItemCollection{bob.GetLink(), carol.GetLink(), alice.GetLink()},
+		},
+		// NOTE(marius): paged collection, with a Next page that points back to the first one
+		bob.Followers.GetLink(): &Collection{
+			ID:    ID(bob.Followers.GetLink()),
+			Type:  CollectionType,
+			First: IRI("https://mastodon.example/users/bob/followers?page=1"),
+		},
+		"https://mastodon.example/users/bob/followers?page=1": &CollectionPage{
+			ID:    "https://mastodon.example/users/bob/followers?page=1",
+			Type:  CollectionPageType,
+			Items: ItemCollection{carol.GetLink()},
+			Next:  IRI("https://mastodon.example/users/bob/followers?page=2"),
+		},
+		"https://mastodon.example/users/bob/followers?page=2": &CollectionPage{
+			ID:    "https://mastodon.example/users/bob/followers?page=2",
+			Type:  CollectionPageType,
+			Items: ItemCollection{dave},
+			Next:  IRI("https://mastodon.example/users/bob/followers?page=1"),
+		},
+		// NOTE(marius): collections which contain each other
+		"https://mastodon.example/lists/1": &Collection{
+			ID:    "https://mastodon.example/lists/1",
+			Type:  CollectionType,
+			Items: ItemCollection{IRI("https://mastodon.example/lists/2"), bob.GetLink()},
+		},
+		"https://mastodon.example/lists/2": &Collection{
+			ID:    "https://mastodon.example/lists/2",
+			Type:  CollectionType,
+			Items: ItemCollection{IRI("https://mastodon.example/lists/1"), carol.GetLink()},
+		},
+		"https://mastodon.example/lists/3": &Collection{
+			ID:    "https://mastodon.example/lists/3",
+			Type:  CollectionType,
+			Items: ItemCollection{IRI("https://mastodon.example/lists/4")},
+		},
+		"https://mastodon.example/lists/4": &Collection{
+			ID:    "https://mastodon.example/lists/4",
+			Type:  CollectionType,
+			Items: ItemCollection{IRI("https://mastodon.example/lists/5")},
+		},
+		"https://mastodon.example/lists/5": &Collection{
+			ID:    "https://mastodon.example/lists/5",
+			Type:  CollectionType,
+			Items: ItemCollection{IRI("https://mastodon.example/lists/6")},
+		},
+		"https://mastodon.example/lists/6": &Collection{
+			ID:    "https://mastodon.example/lists/6",
+			Type:  CollectionType,
+			Items: ItemCollection{dave},
+		},
+		"https://mastodon.example/notes/1": &Object{ID: "https://mastodon.example/notes/1", Type: NoteType},
+	}
+
+	tests := []struct {
+		name    string
+		act     Item
+		res     RecipientResolver
+		want    []Delivery
+		wantErr error
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name: "public to followers",
+			act: &Activity{
+				Type:  CreateType,
+				Actor: alice.GetLink(),
+				To:    ItemCollection{PublicNS},
+				CC:    ItemCollection{alice.Followers},
+			},
+			res: res,
+			want: []Delivery{
+				{Inbox: "https://mastodon.example/inbox", Recipients: IRIs{bob.GetLink()}},
+				{Inbox: carol.Inbox.GetLink(), Recipients: IRIs{carol.GetLink()}},
+			},
+		},
+		{
+			name: "question",
+			act: &Question{
+				Type:  QuestionType,
+				Actor: alice.GetLink(),
+				To:    ItemCollection{PublicNS},
+				CC:    ItemCollection{alice.Followers},
+			},
+			res: res,
+			want: []Delivery{
+				{Inbox: "https://mastodon.example/inbox", Recipients: IRIs{bob.GetLink()}},
+				{Inbox: carol.Inbox.GetLink(), Recipients: IRIs{carol.GetLink()}},
+			},
+		},
+		{
+			name: "arrive",
+			act: &Arrive{
+				Type:  ArriveType,
+				Actor: alice.GetLink(),
+				To:    ItemCollection{carol.GetLink()},
+			},
+			res: res,
+			want: []Delivery{
+				{Inbox: carol.Inbox.GetLink(), Recipients: IRIs{carol.GetLink()}},
+			},
+		},
+		{
+			name: "shared inbox for all the fields",
+			act: &Activity{
+				Type:     CreateType,
+				Actor:    dave,
+				To:       ItemCollection{alice.GetLink()},
+				Bto:      ItemCollection{carol},
+				BCC:      ItemCollection{bob.GetLink()},
+				Audience: ItemCollection{IRI("as:Public"), alice.GetLink()},
+			},
+			res: res,
+			want: []Delivery{
+				{Inbox: "https://mastodon.example/inbox", Recipients: IRIs{alice.GetLink(), bob.GetLink()}},
+				{Inbox: carol.Inbox.GetLink(), Recipients: IRIs{carol.GetLink()}},
+			},
+		},
+		{
+			name: "paged collection",
+			act: &Activity{
+				Type:  AnnounceType,
+				Actor: alice.GetLink(),
+				To:    ItemCollection{bob.Followers},
+			},
+			res: res,
+			want: []Delivery{
+				{Inbox: carol.Inbox.GetLink(), Recipients: IRIs{carol.GetLink()}},
+				{Inbox: "https://third.example/inbox", Recipients: IRIs{dave.GetLink()}},
+			},
+		},
+		{
+			name: "cycle",
+			act: &Activity{
+				Type:  CreateType,
+				Actor: alice.GetLink(),
+				To:    ItemCollection{IRI("https://mastodon.example/lists/1")},
+			},
+			res: res,
+			want: []Delivery{
+				{Inbox: carol.Inbox.GetLink(), Recipients: IRIs{carol.GetLink()}},
+				{Inbox: "https://mastodon.example/inbox", Recipients: IRIs{bob.GetLink()}},
+			},
+		},
+		{
+			name: "too deep",
+			act: &Activity{
+				Type:  CreateType,
+				Actor: alice.GetLink(),
+				To:    ItemCollection{carol.GetLink(), IRI("https://mastodon.example/lists/3")},
+			},
+			res: res,
+			want: []Delivery{
+				{Inbox: carol.Inbox.GetLink(), Recipients: IRIs{carol.GetLink()}},
+			},
+			wantErr: ErrRecipientDepth,
+		},
+		{
+			name: "unresolved",
+			act: &Activity{
+				Type:  CreateType,
+				Actor: alice.GetLink(),
+				To:    ItemCollection{IRI("https://gone.example/users/eve"), carol.GetLink()},
+			},
+			res: res,
+			want: []Delivery{
+				{Inbox: carol.Inbox.GetLink(), Recipients: IRIs{carol.GetLink()}},
+			},
+			wantErr: ErrRecipientUnresolved,
+		},
+		{
+			name: "without resolver",
+			act: &Activity{
+				Type:  CreateType,
+				Actor: alice.GetLink(),
+				To:    ItemCollection{carol, bob.GetLink()},
+			},
+			want: []Delivery{
+				{Inbox: carol.Inbox.GetLink(), Recipients: IRIs{carol.GetLink()}},
+			},
+			wantErr: ErrRecipientUnresolved,
+		},
+		{
+			name: "not an actor",
+			act: &Activity{
+				Type:  CreateType,
+				Actor: alice.GetLink(),
+				To:    ItemCollection{IRI("https://mastodon.example/notes/1")},
+			},
+			res:     res,
+			wantErr: ErrRecipientNoInbox,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PlanDelivery(tt.act, tt.res)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("PlanDelivery() error = %v, want %v", err, tt.wantErr)
+			}
+			for _, other := range []error{ErrRecipientDepth, ErrRecipientUnresolved, ErrRecipientNoInbox} {
+				if other != tt.wantErr && errors.Is(err, other) {
+					t.Errorf("PlanDelivery() error = %v, matches %v", err, other)
+				}
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("PlanDelivery() got = %s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
@@ -112,17 +112,27 @@ func gobEncodeItem(it Item) ([]byte, error) {
 			return err
 		})
 	}
-	if IsObject(it) {
+	def, registered := RegisteredType(it.GetType())
+	if registered && def.GobEncode != nil {
+		return gobEncodeRegistered(def, it)
+	}
+	if registered && len(def.Base) == 0 {
+		return nil, errors.Newf("unable to gob encode %s items without a GobEncode function", def.Type)
+	}
+	if IsObject(it) || IsLink(it) || registered {
 		typ := it.GetType()
 		if typ == nil {
 			typ = NilType
 		}
+		if registered {
+			typ = def.Base
+		}
 		switch {
-		case IRIType.Match(it.GetType()):
+		case IRIType.Match(typ):
 			var bytes []byte
 			bytes, err = it.(IRI).GobEncode()
 			b.Write(bytes)
-		case CollectionType.Match(it.GetType()):
+		case CollectionType.Match(typ):
 			err = OnCollection(it, func(c *Collection) error {
 				bytes, err := c.GobEncode()
 				b.Write(bytes)
@@ -158,12 +168,24 @@ func gobEncodeItem(it Item) ([]byte, error) {
 				b.Write(bytes)
 				return err
 			})
-		case RelationshipType.Match(typ):
+		case ActivityVocabularyTypes{RelationshipType, TicketDependencyType}.Match(typ):
 			err = OnRelationship(it, func(r *Relationship) error {
 				bytes, err := r.GobEncode()
 				b.Write(bytes)
 				return err
 			})
+		case RepositoryType.Match(typ):
+			err = OnRepository(it, func(r *Repository) error {
+				bytes, err := r.GobEncode()
+				b.Write(bytes)
+				return err
+			})
+		case TicketType.Match(typ):
+			err = OnTicket(it, func(t *Ticket) error {
+				bytes, err := t.GobEncode()
+				b.Write(bytes)
+				return err
+			})
 		case TombstoneType.Match(typ):
 			err = OnTombstone(it, func(t *Tombstone) error {
 				bytes, err := t.GobEncode()
@@ -176,13 +198,14 @@ func gobEncodeItem(it Item) ([]byte, error) {
 				b.Write(bytes)
 				return err
 			})
-		case ActivityVocabularyTypes{NilType, ObjectType, ArticleType, AudioType, DocumentType, EventType, ImageType, NoteType, PageType, VideoType}.Match(typ):
+		case ActivityVocabularyTypes{NilType, ObjectType, ArticleType, AudioType, DocumentType, EventType, ImageType, NoteType, PageType, VideoType, EmojiType,
+			PatchType, CommitType, BranchType}.Match(typ):
 			err = OnObject(it, func(ob *Object) error {
 				bytes, err := ob.GobEncode()
 				b.Write(bytes)
 				return err
 			})
-		case ActivityVocabularyTypes{LinkType, MentionType}.Match(typ):
+		case ActivityVocabularyTypes{LinkType, MentionType, HashtagType}.Match(typ):
 			// TODO(marius): this shouldn't work, as Link does not implement Item? (or rather, should not)
 			err = OnLink(it, func(l *Link) error {
 				bytes, err := l.GobEncode()
@@ -191,7 +214,7 @@ func gobEncodeItem(it Item) ([]byte, error) {
 			})
 		case ActivityVocabularyTypes{ActivityType, AcceptType, AddType, AnnounceType, BlockType, CreateType, DeleteType, DislikeType,
 			FlagType, FollowType, IgnoreType, InviteType, JoinType, LeaveType, LikeType, ListenType, MoveType, OfferType,
-			RejectType, ReadType, RemoveType, TentativeRejectType, TentativeAcceptType, UndoType, UpdateType, ViewType}.Match(typ):
+			RejectType, ReadType, RemoveType, TentativeRejectType, TentativeAcceptType, UndoType, UpdateType, ViewType, PushType}.Match(typ):
 			err = OnActivity(it, func(act *Activity) error {
 				bytes, err := act.GobEncode()
 				b.Write(bytes)
@@ -214,6 +237,21 @@ func gobEncodeItem(it Item) ([]byte, error) {
 	return b.Bytes(), err
 }
 
+// gobEncodeRegistered encodes the it item with the GobEncode function of its TypeDefinition.
+//
+// NOTE(marius): the encoded item is stored next to its type, so gobDecodeItem knows which GobDecode function
+// to use, as it can't tell the type from the data of the extension.
+func gobEncodeRegistered(def TypeDefinition, it Item) ([]byte, error) {
+	raw, err := def.GobEncode(it)
+	if err != nil {
+		return nil, err
+	}
+	mm := map[string][]byte{"type": []byte(def.Type), "gob": raw}
+	b := bytes.Buffer{}
+	err = gob.NewEncoder(&b).Encode(mm)
+	return b.Bytes(), err
+}
+
 func gobEncodeTypes(typ Typer) ([]byte, error) {
 	if tt, ok := typ.(gob.GobEncoder); ok {
 		return tt.GobEncode()
@@ -433,6 +471,34 @@ func mapObjectProperties(mm map[string][]byte, o *Object) (hasData bool, err err
 		}
 		hasData = true
 	}
+	if o.Sensitive {
+		mm["sensitive"] = []byte("true")
+		hasData = true
+	}
+	if len(o.FocalPoint) > 0 {
+		b := bytes.Buffer{}
+		if err = gob.NewEncoder(&b).Encode(o.FocalPoint); err != nil {
+			return hasData, err
+		}
+		mm["focalPoint"] = b.Bytes()
+		hasData = true
+	}
+	if len(o.Blurhash) > 0 {
+		mm["blurhash"] = []byte(o.Blurhash)
+		hasData = true
+	}
+	if o.Proof != nil {
+		if mm["proof"], err = o.Proof.GobEncode(); err != nil {
+			return hasData, err
+		}
+		hasData = true
+	}
+	if len(o.Extensions) > 0 {
+		if mm["extensions"], err = o.Extensions.GobEncode(); err != nil {
+			return hasData, err
+		}
+		hasData = true
+	}
 
 	return hasData, nil
 }
@@ -502,6 +568,60 @@ func mapActorProperties(mm map[string][]byte, a *Actor) (hasData bool, err error
 		}
 		hasData = true
 	}
+	if len(a.AssertionMethod) > 0 {
+		b := bytes.Buffer{}
+		if err = gob.NewEncoder(&b).Encode(a.AssertionMethod); err != nil {
+			return hasData, err
+		}
+		mm["assertionMethod"] = b.Bytes()
+		hasData = true
+	}
+	if a.ManuallyApprovesFollowers {
+		mm["manuallyApprovesFollowers"] = []byte("true")
+		hasData = true
+	}
+	if a.Discoverable {
+		mm["discoverable"] = []byte("true")
+		hasData = true
+	}
+	if a.Indexable {
+		mm["indexable"] = []byte("true")
+		hasData = true
+	}
+	if a.Featured != nil {
+		if mm["featured"], err = gobEncodeItem(a.Featured); err != nil {
+			return hasData, err
+		}
+		hasData = true
+	}
+	if a.FeaturedTags != nil {
+		if mm["featuredTags"], err = gobEncodeItem(a.FeaturedTags); err != nil {
+			return hasData, err
+		}
+		hasData = true
+	}
+	if a.MovedTo != nil {
+		if mm["movedTo"], err = gobEncodeItem(a.MovedTo); err != nil {
+			return hasData, err
+		}
+		hasData = true
+	}
+	if len(a.AlsoKnownAs) > 0 {
+		if mm["alsoKnownAs"], err = gobEncodeItems(a.AlsoKnownAs); err != nil {
+			return hasData, err
+		}
+		hasData = true
+	}
+	if a.PostingRestrictedToMods {
+		mm["postingRestrictedToMods"] = []byte("true")
+		hasData = true
+	}
+	if a.Moderators != nil {
+		if mm["moderators"], err = gobEncodeItem(a.Moderators); err != nil {
+			return hasData, err
+		}
+		hasData = true
+	}
 	return hasData, err
 }
 
@@ -673,6 +793,12 @@ func mapLinkProperties(mm map[string][]byte, l Link) (hasData bool, err error) {
 		}
 		hasData = true
 	}
+	if len(l.Extensions) > 0 {
+		if mm["extensions"], err = l.Extensions.GobEncode(); err != nil {
+			return
+		}
+		hasData = true
+	}
 	return
 }
 
@@ -758,6 +884,68 @@ func mapRelationshipProperties(mm map[string][]byte, r Relationship) (hasData bo
 	return
 }
 
+func mapRepositoryProperties(mm map[string][]byte, r Repository) (hasData bool, err error) {
+	err = OnActor(r, func(a *Actor) error {
+		hasData, err = mapActorProperties(mm, a)
+		return err
+	})
+	if r.Team != nil {
+		if mm["team"], err = gobEncodeItem(r.Team); err != nil {
+			return
+		}
+		hasData = true
+	}
+	if r.Forks != nil {
+		if mm["forks"], err = gobEncodeItem(r.Forks); err != nil {
+			return
+		}
+		hasData = true
+	}
+	if r.TicketsTrackedBy != nil {
+		if mm["ticketsTrackedBy"], err = gobEncodeItem(r.TicketsTrackedBy); err != nil {
+			return
+		}
+		hasData = true
+	}
+	if r.CloneURI != nil {
+		if mm["cloneUri"], err = gobEncodeItem(r.CloneURI); err != nil {
+			return
+		}
+		hasData = true
+	}
+	return
+}
+
+func mapTicketProperties(mm map[string][]byte, t Ticket) (hasData bool, err error) {
+	err = OnObject(t, func(o *Object) error {
+		hasData, err = mapObjectProperties(mm, o)
+		return err
+	})
+	if t.IsResolved {
+		mm["isResolved"] = []byte("true")
+		hasData = true
+	}
+	if t.ResolvedBy != nil {
+		if mm["resolvedBy"], err = gobEncodeItem(t.ResolvedBy); err != nil {
+			return hasData, err
+		}
+		hasData = true
+	}
+	if !t.Resolved.IsZero() {
+		if mm["resolved"], err = t.Resolved.GobEncode(); err != nil {
+			return hasData, err
+		}
+		hasData = true
+	}
+	if t.AssignedTo != nil {
+		if mm["assignedTo"], err = gobEncodeItem(t.AssignedTo); err != nil {
+			return hasData, err
+		}
+		hasData = true
+	}
+	return
+}
+
 func mapTombstoneProperties(mm map[string][]byte, t Tombstone) (hasData bool, err error) {
 	err = OnObject(t, func(o *Object) error {
 		hasData, err = mapObjectProperties(mm, o)
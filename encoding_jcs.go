@@ -0,0 +1,274 @@
+package activitypub
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/go-ap/errors"
+)
+
+// ErrInvalidCanonicalJSON is returned when a JSON document can't be represented in the
+// JSON Canonicalization Scheme: it has duplicate property names, invalid UTF-8, lone UTF-16 surrogates,
+// or numbers which are not finite IEEE 754 double precision values.
+var ErrInvalidCanonicalJSON = errors.Newf("invalid JSON for canonicalization")
+
+// MarshalJCS encodes the "it" Item to JSON using the JSON Canonicalization Scheme (RFC 8785), so equivalent
+// items always produce the same bytes. This is the encoding to use for content digests, deduplication keys,
+// or data integrity proofs.
+func MarshalJCS(it LinkOrIRI) ([]byte, error) {
+	raw, err := MarshalJSON(it)
+	if err != nil {
+		return nil, err
+	}
+	return JSONCanonicalize(raw)
+}
+
+// JSONCanonicalize transforms the data JSON document to its RFC 8785 canonical form: no insignificant
+// whitespace, object properties sorted by the UTF-16 code units of their names, numbers serialized like
+// ECMAScript does, and strings using the shortest escaping.
+func JSONCanonicalize(data []byte) ([]byte, error) {
+	if !utf8.Valid(data) {
+		return nil, errors.Annotatef(ErrInvalidCanonicalJSON, "invalid UTF-8")
+	}
+	if err := jcsCheckSurrogates(data); err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	b := bytes.Buffer{}
+	b.Grow(len(data))
+	if err := jcsWriteValue(&b, dec); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.Annotatef(ErrInvalidCanonicalJSON, "unexpected data after the JSON document")
+	}
+	return b.Bytes(), nil
+}
+
+// jcsCheckSurrogates returns an error for the "\u" escapes of the data JSON document which are lone UTF-16
+// surrogates, as RFC 8785 requires.
+//
+// NOTE(marius): encoding/json replaces the lone surrogates with U+FFFD, so they have to be found before decoding.
+func jcsCheckSurrogates(data []byte) error {
+	for i := 0; i < len(data); i++ {
+		if data[i] != '\\' {
+			continue
+		}
+		i++
+		if i >= len(data) || data[i] != 'u' {
+			continue
+		}
+		r, ok := jcsEscapedRune(data[i+1:])
+		if !ok {
+			// NOTE(marius): the invalid escapes are reported by the decoder
+			continue
+		}
+		i += 4
+		if !utf16.IsSurrogate(r) {
+			continue
+		}
+		if r < 0xdc00 && i+2 < len(data) && data[i+1] == '\\' && data[i+2] == 'u' {
+			if lo, ok := jcsEscapedRune(data[i+3:]); ok && lo >= 0xdc00 && lo <= 0xdfff {
+				i += 6
+				continue
+			}
+		}
+		return errors.Annotatef(ErrInvalidCanonicalJSON, "lone UTF-16 surrogate \\u%04x", r)
+	}
+	return nil
+}
+
+// jcsEscapedRune decodes the four hexadecimal digits at the start of b.
+func jcsEscapedRune(b []byte) (rune, bool) {
+	if len(b) < 4 {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(string(b[:4]), 16, 16)
+	if err != nil {
+		return 0, false
+	}
+	return rune(v), true
+}
+
+func jcsWriteValue(b *bytes.Buffer, dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '[' {
+			return jcsWriteArray(b, dec)
+		}
+		return jcsWriteObject(b, dec)
+	case string:
+		jcsWriteString(b, v)
+	case json.Number:
+		n, err := jcsFormatNumber(string(v))
+		if err != nil {
+			return err
+		}
+		JSONWriteS(b, n)
+	case bool:
+		JSONWriteS(b, strconv.FormatBool(v))
+	case nil:
+		JSONWriteS(b, "null")
+	}
+	return nil
+}
+
+func jcsWriteArray(b *bytes.Buffer, dec *json.Decoder) error {
+	JSONWrite(b, '[')
+	for i := 0; dec.More(); i++ {
+		if i > 0 {
+			JSONWriteComma(b)
+		}
+		if err := jcsWriteValue(b, dec); err != nil {
+			return err
+		}
+	}
+	// NOTE(marius): consume the closing delimiter
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	JSONWrite(b, ']')
+	return nil
+}
+
+type jcsMember struct {
+	name  string
+	key   []uint16
+	value []byte
+}
+
+func jcsWriteObject(b *bytes.Buffer, dec *json.Decoder) error {
+	members := make([]jcsMember, 0)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name := tok.(string)
+		val := bytes.Buffer{}
+		if err = jcsWriteValue(&val, dec); err != nil {
+			return err
+		}
+		members = append(members, jcsMember{name: name, key: utf16.Encode([]rune(name)), value: val.Bytes()})
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	// NOTE(marius): RFC 8785 sorts the property names by their UTF-16 code units, not by code points
+	slices.SortFunc(members, func(a, b jcsMember) int {
+		return slices.Compare(a.key, b.key)
+	})
+	JSONWrite(b, '{')
+	for i, m := range members {
+		if i > 0 {
+			if slices.Equal(members[i-1].key, m.key) {
+				return errors.Annotatef(ErrInvalidCanonicalJSON, "duplicate property %q", m.name)
+			}
+			JSONWriteComma(b)
+		}
+		jcsWriteString(b, m.name)
+		JSONWrite(b, ':')
+		JSONWrite(b, m.value...)
+	}
+	JSONWrite(b, '}')
+	return nil
+}
+
+const jcsHex = "0123456789abcdef"
+
+// jcsWriteString writes s as a JSON string, escaping only the characters which must be escaped.
+func jcsWriteString(b *bytes.Buffer, s string) {
+	JSONWrite(b, '"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"':
+			JSONWriteS(b, `\"`)
+		case '\\':
+			JSONWriteS(b, `\\`)
+		case '\b':
+			JSONWriteS(b, `\b`)
+		case '\f':
+			JSONWriteS(b, `\f`)
+		case '\n':
+			JSONWriteS(b, `\n`)
+		case '\r':
+			JSONWriteS(b, `\r`)
+		case '\t':
+			JSONWriteS(b, `\t`)
+		default:
+			if c < 0x20 {
+				JSONWriteS(b, `\u00`)
+				JSONWrite(b, jcsHex[c>>4], jcsHex[c&0xf])
+				continue
+			}
+			JSONWrite(b, c)
+		}
+	}
+	JSONWrite(b, '"')
+}
+
+// jcsFormatNumber serializes the n JSON number like ECMAScript's Number.prototype.toString does.
+func jcsFormatNumber(n string) (string, error) {
+	f, err := strconv.ParseFloat(n, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", errors.Annotatef(ErrInvalidCanonicalJSON, "number %s is not a finite double", n)
+	}
+	if f == 0 {
+		// NOTE(marius): this includes -0
+		return "0", nil
+	}
+	sign := ""
+	if f < 0 {
+		sign = "-"
+		f = -f
+	}
+	// NOTE(marius): Go's shortest representation has the same digits as the ECMAScript one,
+	// only the notation is different.
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	e, _ := strconv.Atoi(exp)
+	k := len(digits)
+	pos := e + 1
+
+	s := strings.Builder{}
+	s.WriteString(sign)
+	switch {
+	case k <= pos && pos <= 21:
+		s.WriteString(digits)
+		s.WriteString(strings.Repeat("0", pos-k))
+	case 0 < pos && pos <= 21:
+		s.WriteString(digits[:pos])
+		s.WriteByte('.')
+		s.WriteString(digits[pos:])
+	case -6 < pos && pos <= 0:
+		s.WriteString("0.")
+		s.WriteString(strings.Repeat("0", -pos))
+		s.WriteString(digits)
+	default:
+		s.WriteByte(digits[0])
+		if k > 1 {
+			s.WriteByte('.')
+			s.WriteString(digits[1:])
+		}
+		s.WriteByte('e')
+		if pos-1 >= 0 {
+			s.WriteByte('+')
+		}
+		s.WriteString(strconv.Itoa(pos - 1))
+	}
+	return s.String(), nil
+}
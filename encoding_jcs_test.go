@@ -0,0 +1,200 @@
+package activitypub
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+)
+
+// The test vectors are from RFC 8785, sections 3.2.2, 3.2.3 and Appendix B.
+
+func TestJSONCanonicalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "RFC 8785 section 3.2.2",
+			data: `{
+  "numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`,
+			want: `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+		},
+		{
+			name: "RFC 8785 section 3.2.3",
+			data: `{
+  "\u20ac": "Euro Sign",
+  "\r": "Carriage Return",
+  "\ufb33": "Hebrew Letter Dalet With Dagesh",
+  "1": "One",
+  "\ud83d\ude00": "Emoji: Grinning Face",
+  "\u0080": "Control",
+  "\u00f6": "Latin Small Letter O With Diaeresis"
+}`,
+			want: "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"ö\":\"Latin Small Letter O With Diaeresis\",\"€\":\"Euro Sign\",\"😀\":\"Emoji: Grinning Face\",\"דּ\":\"Hebrew Letter Dalet With Dagesh\"}",
+		},
+		{
+			name: "nested",
+			data: ` { "b" : [ { "d" : 1 , "c" : { } } , [ ] ] , "a" : "" } `,
+			want: `{"a":"","b":[{"c":{},"d":1},[]]}`,
+		},
+		{
+			name: "control characters",
+			data: `"\u0001\b\t\u001f"`,
+			want: `"\u0001\b\t\u001f"`,
+		},
+		{
+			name:    "duplicate property",
+			data:    `{"a":1,"a":2}`,
+			wantErr: true,
+		},
+		{
+			name:    "number out of range",
+			data:    `[1e400]`,
+			wantErr: true,
+		},
+		{
+			name:    "trailing data",
+			data:    `{} {}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONCanonicalize([]byte(tt.data))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("JSONCanonicalize() expected error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("JSONCanonicalize() error = %s", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("JSONCanonicalize() got = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJCSFormatNumber(t *testing.T) {
+	tests := []struct {
+		ieee string
+		want string
+	}{
+		{ieee: "0000000000000000", want: "0"},
+		{ieee: "8000000000000000", want: "0"},
+		{ieee: "0000000000000001", want: "5e-324"},
+		{ieee: "8000000000000001", want: "-5e-324"},
+		{ieee: "7fefffffffffffff", want: "1.7976931348623157e+308"},
+		{ieee: "ffefffffffffffff", want: "-1.7976931348623157e+308"},
+		{ieee: "4340000000000000", want: "9007199254740992"},
+		{ieee: "c340000000000000", want: "-9007199254740992"},
+		{ieee: "4430000000000000", want: "295147905179352830000"},
+		{ieee: "44b52d02c7e14af5", want: "9.999999999999997e+22"},
+		{ieee: "44b52d02c7e14af6", want: "1e+23"},
+		{ieee: "44b52d02c7e14af7", want: "1.0000000000000001e+23"},
+		{ieee: "444b1ae4d6e2ef4e", want: "999999999999999700000"},
+		{ieee: "444b1ae4d6e2ef4f", want: "999999999999999900000"},
+		{ieee: "444b1ae4d6e2ef50", want: "1e+21"},
+		{ieee: "3eb0c6f7a0b5ed8c", want: "9.999999999999997e-7"},
+		{ieee: "3eb0c6f7a0b5ed8d", want: "0.000001"},
+		{ieee: "41b3de4355555553", want: "333333333.3333332"},
+		{ieee: "41b3de4355555554", want: "333333333.33333325"},
+		{ieee: "41b3de4355555555", want: "333333333.3333333"},
+		{ieee: "41b3de4355555556", want: "333333333.3333334"},
+		{ieee: "41b3de4355555557", want: "333333333.33333343"},
+		{ieee: "becbf647612f3696", want: "-0.0000033333333333333333"},
+		{ieee: "43143ff3c1cb0959", want: "1424953923781206.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ieee, func(t *testing.T) {
+			bits, err := strconv.ParseUint(tt.ieee, 16, 64)
+			if err != nil {
+				t.Fatalf("invalid test vector %s", tt.ieee)
+			}
+			n := strconv.FormatFloat(math.Float64frombits(bits), 'g', -1, 64)
+			got, err := jcsFormatNumber(n)
+			if err != nil {
+				t.Fatalf("jcsFormatNumber(%s) error = %s", n, err)
+			}
+			if got != tt.want {
+				t.Errorf("jcsFormatNumber(%s) = %s, want %s", n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalJCS(t *testing.T) {
+	ob := &Object{
+		ID:      "https://example.com/1",
+		Type:    NoteType,
+		Content: NaturalLanguageValuesNew(RefValue(MakeRef([]byte("fr")), "teste"), RefValue(MakeRef([]byte("en")), "test")),
+		To:      ItemCollection{PublicNS},
+	}
+	want := `{"contentMap":{"en":"test","fr":"teste"},"id":"https://example.com/1","to":["https://www.w3.org/ns/activitystreams#Public"],"type":"Note"}`
+	for range 10 {
+		got, err := MarshalJCS(Clone(ob))
+		if err != nil {
+			t.Fatalf("MarshalJCS() error = %s", err)
+		}
+		if string(got) != want {
+			t.Fatalf("MarshalJCS() got = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestJSONCanonicalize_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "invalid UTF-8",
+			data: "\"\xff\"",
+		},
+		{
+			name: "lone high surrogate",
+			data: `"\ud83d"`,
+		},
+		{
+			name: "lone low surrogate",
+			data: `{"name":"\ude00"}`,
+		},
+		{
+			name: "high surrogate followed by another escape",
+			data: `["\ud83d\u0041"]`,
+		},
+		{
+			name: "swapped surrogates",
+			data: `{"\ude00\ud83d":1}`,
+		},
+		{
+			name: "lone surrogate after an escaped backslash",
+			data: `"\\\ud83d"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := JSONCanonicalize([]byte(tt.data))
+			if !errors.Is(err, ErrInvalidCanonicalJSON) {
+				t.Errorf("JSONCanonicalize() error = %v, want %s", err, ErrInvalidCanonicalJSON)
+			}
+		})
+	}
+
+	// NOTE(marius): an escaped backslash followed by "ud83d" is not an escape
+	got, err := JSONCanonicalize([]byte(`"\\ud83d\ud83d\ude00"`))
+	if err != nil {
+		t.Fatalf("JSONCanonicalize() error = %s", err)
+	}
+	if want := `"\\ud83d😀"`; string(got) != want {
+		t.Errorf("JSONCanonicalize() = %s, want %s", got, want)
+	}
+}
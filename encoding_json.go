@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"git.sr.ht/~mariusor/go-xsd-duration"
+	"github.com/go-ap/errors"
 	"github.com/go-ap/jsonld"
 )
 
@@ -55,14 +58,29 @@ func JSONWriteValue(b *bytes.Buffer, s []byte) (notEmpty bool) {
 }
 
 func JSONWriteNaturalLanguageProp(b *bytes.Buffer, n string, nl NaturalLanguageValues, needsComma bool) (notEmpty bool) {
+	return jsonWriteNaturalLanguageProp(b, n, nl, needsComma, EncodeOptions{})
+}
+
+func jsonWriteNaturalLanguageProp(b *bytes.Buffer, n string, nl NaturalLanguageValues, needsComma bool, opts EncodeOptions) (notEmpty bool) {
 	l := nl.Count()
-	if l > 1 {
+	if l > 1 || (l == 1 && opts.LanguageMaps) {
 		n += "Map"
 	}
-	if v, err := nl.MarshalJSON(); err == nil && len(v) > 0 {
-		return JSONWriteProp(b, n, v, needsComma)
+	return jsonWriteProp(b, n, nl, needsComma, opts)
+}
+
+// jsonWriteProp writes the "n" property with the value written by w, and reports if anything was written.
+func jsonWriteProp(b *bytes.Buffer, n string, w jsonWriter, needsComma bool, opts EncodeOptions) (notEmpty bool) {
+	start := b.Len()
+	if needsComma {
+		JSONWriteComma(b)
 	}
-	return false
+	JSONWritePropName(b, n)
+	if !w.writeJSON(b, opts) {
+		b.Truncate(start)
+		return false
+	}
+	return true
 }
 
 func JSONWriteStringProp(b *bytes.Buffer, n string, s string, needsComma bool) (notEmpty bool) {
@@ -109,17 +127,258 @@ func JSONWriteIRIProp(b *bytes.Buffer, n string, i LinkOrIRI, needsComma bool) (
 }
 
 func JSONWriteItemProp(b *bytes.Buffer, n string, i Item, needsComma bool) (notEmpty bool) {
+	return jsonWriteItemProp(b, n, i, needsComma, EncodeOptions{})
+}
+
+func jsonWriteItemProp(b *bytes.Buffer, n string, i Item, needsComma bool, opts EncodeOptions) (notEmpty bool) {
 	if i == nil {
 		return notEmpty
 	}
-	if im, ok := i.(json.Marshaler); ok {
-		v, err := im.MarshalJSON()
+	start := b.Len()
+	if needsComma {
+		JSONWriteComma(b)
+	}
+	JSONWritePropName(b, n)
+	if ok, err := jsonWritePropValue(b, n, i, opts); err != nil || !ok {
+		b.Truncate(start)
+		return false
+	}
+	return true
+}
+
+// jsonWritePropValue writes the i value of the n property, with the shape requested by opts.
+func jsonWritePropValue(b *bytes.Buffer, n string, i Item, opts EncodeOptions) (notEmpty bool, err error) {
+	i = opts.nested(n, i)
+	if !opts.MultiValueArrays || !isMultiValuedProperty(n) {
+		return jsonWriteItem(b, i, opts)
+	}
+	col, ok := i.(ItemCollection)
+	if !ok {
+		col = ItemCollection{i}
+	}
+	return jsonWriteItemCollectionValue(b, col, false, opts), nil
+}
+
+// EncodeOptions change the shape of the JSON documents written by MarshalJSONWithOptions.
+// The zero value produces the same output as MarshalJSON.
+type EncodeOptions struct {
+	// Prefix and Indent are used for indenting the output like json.MarshalIndent does,
+	// while keeping the order of the properties. When both are empty the output is compact.
+	Prefix string
+	Indent string
+	// LanguageMaps writes natural language values always as maps, eg: "contentMap",
+	// even when they contain a single value.
+	LanguageMaps bool
+	// SingleItemArrays writes collections with a single element as arrays, instead of as the element itself.
+	SingleItemArrays bool
+	// MultiValueArrays writes the properties which can have multiple values, eg: "to", "tag" or "attachment",
+	// always as arrays, even when they hold a single value.
+	MultiValueArrays bool
+	// Nested is the way the objects nested in the properties of other objects are written.
+	Nested NestedShape
+}
+
+// NestedShape describes how the objects referenced by the properties of other objects are written.
+type NestedShape uint8
+
+const (
+	// NestedAsIs writes the nested values as they are: objects are embedded and IRIs are written as strings.
+	NestedAsIs NestedShape = iota
+	// NestedAsIRIs writes the nested objects as their IRIs.
+	// Links and the objects without an ID can't be referenced, so they are still embedded.
+	NestedAsIRIs
+	// NestedEmbedded writes the nested IRIs as objects containing only their "id".
+	// The Public collection and the properties which hold URLs, eg: "url" or "sharedInbox", are kept as IRIs.
+	NestedEmbedded
+)
+
+// multiValuedProperties are the properties which the ActivityStreams vocabulary doesn't define as functional.
+var multiValuedProperties = []string{
+	"actor", "anyOf", "attachment", "attributedTo", "audience", "bcc", "bto", "cc", "context", "generator",
+	"icon", "image", "inReplyTo", "instrument", "location", "object", "oneOf", "origin", "preview",
+	"relationship", "result", "tag", "target", "to", "url",
+}
+
+// iriValuedProperties are the properties which hold URLs, and not references to other objects.
+var iriValuedProperties = []string{
+	"href", "id", "oauthAuthorizationEndpoint", "oauthTokenEndpoint", "provideClientKey", "proxyUrl",
+	"sharedInbox", "signClientKey", "uploadMedia", "url",
+}
+
+func isMultiValuedProperty(n string) bool {
+	return slices.Contains(multiValuedProperties, n)
+}
+
+// reshapes checks if the values of the n property need to change shape for the Nested option.
+func (opts EncodeOptions) reshapes(n string) bool {
+	return opts.Nested != NestedAsIs && n != "" && !slices.Contains(iriValuedProperties, n)
+}
+
+// nested returns the i value of the n property with the shape requested by the Nested option.
+func (opts EncodeOptions) nested(n string, i Item) Item {
+	if !opts.reshapes(n) {
+		return i
+	}
+	if col, ok := i.(ItemCollection); ok {
+		return opts.nestedCollection(n, col)
+	}
+	if s, ok := opts.nestedItem(i); ok {
+		return s
+	}
+	return i
+}
+
+// nestedCollection returns the col value of the n property with the shape requested by the Nested option.
+// NOTE(marius): the collection is copied only if any of its elements changes, so we don't allocate for the
+// collections which already have the requested shape.
+func (opts EncodeOptions) nestedCollection(n string, col ItemCollection) ItemCollection {
+	if !opts.reshapes(n) {
+		return col
+	}
+	var shaped ItemCollection
+	for idx, it := range col {
+		s, changed := opts.nestedItem(it)
+		if !changed {
+			continue
+		}
+		if shaped == nil {
+			shaped = slices.Clone(col)
+		}
+		shaped[idx] = s
+	}
+	if shaped == nil {
+		return col
+	}
+	return shaped
+}
+
+// nestedItem returns the new shape of "it" and true, or nil and false if it doesn't need to change.
+func (opts EncodeOptions) nestedItem(it Item) (Item, bool) {
+	if IsNil(it) {
+		return nil, false
+	}
+	switch opts.Nested {
+	case NestedAsIRIs:
+		if IsIRI(it) || IsLink(it) || IsItemCollection(it) {
+			return nil, false
+		}
+		if iri := it.GetLink(); iri != "" {
+			return iri, true
+		}
+	case NestedEmbedded:
+		if iri, ok := it.(IRI); ok && !isPublicNS(iri) {
+			return &Object{ID: iri}, true
+		}
+	}
+	return nil, false
+}
+
+// MarshalJSONWithOptions encodes "it" to JSON with the shape described by the opts EncodeOptions.
+func MarshalJSONWithOptions(it LinkOrIRI, opts EncodeOptions) ([]byte, error) {
+	if raw, ok, err := jsonMarshalRegistered(it); ok {
 		if err != nil {
-			return false
+			return nil, err
 		}
-		return JSONWriteProp(b, n, v, needsComma)
+		return jsonIndent(raw, opts)
 	}
-	return notEmpty
+	w, ok := it.(jsonWriter)
+	if !ok || IsNil(it) {
+		// NOTE(marius): for types which don't belong to this package we can only indent their encoding
+		raw, err := MarshalJSON(it)
+		if err != nil {
+			return nil, err
+		}
+		return jsonIndent(raw, opts)
+	}
+	b := getJSONBuffer()
+	defer putJSONBuffer(b)
+	if !w.writeJSON(b, opts) {
+		return nil, errors.Newf("unable to encode empty %T", it)
+	}
+	return jsonIndent(b.Bytes(), opts)
+}
+
+// MarshalIndent is like MarshalJSON, but the output is indented like json.MarshalIndent does,
+// while keeping the order of the properties.
+func MarshalIndent(it LinkOrIRI, prefix, indent string) ([]byte, error) {
+	return MarshalJSONWithOptions(it, EncodeOptions{Prefix: prefix, Indent: indent})
+}
+
+// jsonIndent returns a copy of the raw JSON document, indented according to opts, after validating it.
+func jsonIndent(raw []byte, opts EncodeOptions) ([]byte, error) {
+	out := bytes.Buffer{}
+	out.Grow(len(raw))
+	var err error
+	if opts.Prefix == "" && opts.Indent == "" {
+		err = json.Compact(&out, raw)
+	} else {
+		err = json.Indent(&out, raw, opts.Prefix, opts.Indent)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// jsonWriter is implemented by the types which can write their JSON encoding directly to a buffer.
+// When writeJSON returns false, it must leave the buffer unchanged.
+type jsonWriter interface {
+	writeJSON(b *bytes.Buffer, opts EncodeOptions) (notEmpty bool)
+}
+
+var jsonBufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// jsonBufferMaxPooledSize is the capacity over which a buffer is not returned to the pool,
+// so a single very large document doesn't keep its memory around.
+const jsonBufferMaxPooledSize = 1024 * 1024
+
+func getJSONBuffer() *bytes.Buffer {
+	return jsonBufferPool.Get().(*bytes.Buffer)
+}
+
+func putJSONBuffer(b *bytes.Buffer) {
+	if b.Cap() > jsonBufferMaxPooledSize {
+		return
+	}
+	b.Reset()
+	jsonBufferPool.Put(b)
+}
+
+// jsonMarshal returns the JSON encoding of w, using a pooled buffer for writing it.
+func jsonMarshal(w jsonWriter) ([]byte, error) {
+	b := getJSONBuffer()
+	defer putJSONBuffer(b)
+	if !w.writeJSON(b, EncodeOptions{}) {
+		return nil, nil
+	}
+	return bytes.Clone(b.Bytes()), nil
+}
+
+// jsonWriteItem writes the JSON encoding of "it" to b. For the types of this package the encoding is
+// written directly, otherwise we fall back to their MarshalJSON method.
+func jsonWriteItem(b *bytes.Buffer, it LinkOrIRI, opts EncodeOptions) (notEmpty bool, err error) {
+	if v, ok, err := jsonMarshalRegistered(it); ok {
+		if err != nil {
+			return false, err
+		}
+		return JSONWriteValue(b, v), nil
+	}
+	if w, ok := it.(jsonWriter); ok {
+		return w.writeJSON(b, opts), nil
+	}
+	im, ok := it.(json.Marshaler)
+	if !ok {
+		return false, nil
+	}
+	v, err := im.MarshalJSON()
+	if err != nil {
+		return false, err
+	}
+	return JSONWriteValue(b, v), nil
 }
 
 func byteInsertAt(raw []byte, b byte, p int) []byte {
@@ -151,46 +410,34 @@ func JSONWriteStringValue(b *bytes.Buffer, s string) (notEmpty bool) {
 }
 
 func JSONWriteItemCollectionValue(b *bytes.Buffer, col ItemCollection, compact bool) (notEmpty bool) {
+	return jsonWriteItemCollectionValue(b, col, compact, EncodeOptions{})
+}
+
+func jsonWriteItemCollectionValue(b *bytes.Buffer, col ItemCollection, compact bool, opts EncodeOptions) (notEmpty bool) {
 	if len(col) == 0 {
 		return notEmpty
 	}
-	if len(col) == 1 && compact {
-		it := col[0]
-		im, ok := it.(json.Marshaler)
-		if !ok {
-			return false
-		}
-		v, err := im.MarshalJSON()
-		if err != nil {
-			return false
-		}
-		if len(v) == 0 {
-			return false
-		}
-		JSONWrite(b, v...)
-		return true
-	}
-	writeCommaIfNotEmpty := func(notEmpty bool) {
-		if notEmpty {
-			JSONWrite(b, ',')
-		}
+	if len(col) == 1 && compact && !opts.SingleItemArrays {
+		ok, err := jsonWriteItem(b, col[0], opts)
+		return err == nil && ok
 	}
+	start := b.Len()
 	JSONWrite(b, '[')
 	skipComma := true
 	for _, it := range col {
-		im, ok := it.(json.Marshaler)
-		if !ok {
-			continue
+		itemStart := b.Len()
+		if !skipComma {
+			JSONWriteComma(b)
 		}
-		v, err := im.MarshalJSON()
+		ok, err := jsonWriteItem(b, it, opts)
 		if err != nil {
+			b.Truncate(start)
 			return false
 		}
-		if len(v) == 0 {
+		if !ok {
+			b.Truncate(itemStart)
 			continue
 		}
-		writeCommaIfNotEmpty(!skipComma)
-		JSONWrite(b, v...)
 		skipComma = false
 	}
 	JSONWrite(b, ']')
@@ -198,23 +445,31 @@ func JSONWriteItemCollectionValue(b *bytes.Buffer, col ItemCollection, compact b
 }
 
 func JSONWriteItemCollectionProp(b *bytes.Buffer, n string, col ItemCollection, compact, needsComma bool) (notEmpty bool) {
+	return jsonWriteItemCollectionProp(b, n, col, compact, needsComma, EncodeOptions{})
+}
+
+func jsonWriteItemCollectionProp(b *bytes.Buffer, n string, col ItemCollection, compact, needsComma bool, opts EncodeOptions) (notEmpty bool) {
 	if len(col) == 0 {
 		return notEmpty
 	}
+	start := b.Len()
 	if needsComma {
 		JSONWriteComma(b)
 	}
-	success := JSONWritePropName(b, n) && JSONWriteItemCollectionValue(b, col, compact)
+	col = opts.nestedCollection(n, col)
+	success := JSONWritePropName(b, n) && jsonWriteItemCollectionValue(b, col, compact, opts)
 	if !success {
-		_ = b.UnreadByte()
+		b.Truncate(start)
 	}
 	return success
 }
 
 func JSONWriteObjectValue(b *bytes.Buffer, o Object) (notEmpty bool) {
-	if v, err := o.ID.MarshalJSON(); err == nil && len(v) > 0 {
-		notEmpty = JSONWriteProp(b, "id", v, false)
-	}
+	return jsonWriteObjectValue(b, o, EncodeOptions{})
+}
+
+func jsonWriteObjectValue(b *bytes.Buffer, o Object, opts EncodeOptions) (notEmpty bool) {
+	notEmpty = jsonWriteItemProp(b, "id", o.ID, false, opts)
 	if HasTypes(o) {
 		notEmpty = JSONWriteTypes(b, "type", o.Type, notEmpty) || notEmpty
 	}
@@ -222,64 +477,64 @@ func JSONWriteObjectValue(b *bytes.Buffer, o Object) (notEmpty bool) {
 		notEmpty = JSONWriteProp(b, "mediaType", v, notEmpty) || notEmpty
 	}
 	if len(o.Name) > 0 {
-		notEmpty = JSONWriteNaturalLanguageProp(b, "name", o.Name, notEmpty) || notEmpty
+		notEmpty = jsonWriteNaturalLanguageProp(b, "name", o.Name, notEmpty, opts) || notEmpty
 	}
 	if len(o.Summary) > 0 {
-		notEmpty = JSONWriteNaturalLanguageProp(b, "summary", o.Summary, notEmpty) || notEmpty
+		notEmpty = jsonWriteNaturalLanguageProp(b, "summary", o.Summary, notEmpty, opts) || notEmpty
 	}
 	if len(o.Content) > 0 {
-		notEmpty = JSONWriteNaturalLanguageProp(b, "content", o.Content, notEmpty) || notEmpty
+		notEmpty = jsonWriteNaturalLanguageProp(b, "content", o.Content, notEmpty, opts) || notEmpty
 	}
 	if o.Attachment != nil {
-		notEmpty = JSONWriteItemProp(b, "attachment", o.Attachment, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "attachment", o.Attachment, notEmpty, opts) || notEmpty
 	}
 	if o.AttributedTo != nil {
-		notEmpty = JSONWriteItemProp(b, "attributedTo", o.AttributedTo, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "attributedTo", o.AttributedTo, notEmpty, opts) || notEmpty
 	}
 	if o.Audience != nil {
-		notEmpty = JSONWriteItemProp(b, "audience", o.Audience, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "audience", o.Audience, notEmpty, opts) || notEmpty
 	}
 	if o.Context != nil {
-		notEmpty = JSONWriteItemProp(b, "context", o.Context, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "context", o.Context, notEmpty, opts) || notEmpty
 	}
 	if o.Generator != nil {
-		notEmpty = JSONWriteItemProp(b, "generator", o.Generator, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "generator", o.Generator, notEmpty, opts) || notEmpty
 	}
 	if o.Icon != nil {
-		notEmpty = JSONWriteItemProp(b, "icon", o.Icon, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "icon", o.Icon, notEmpty, opts) || notEmpty
 	}
 	if o.Image != nil {
-		notEmpty = JSONWriteItemProp(b, "image", o.Image, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "image", o.Image, notEmpty, opts) || notEmpty
 	}
 	if o.InReplyTo != nil {
-		notEmpty = JSONWriteItemProp(b, "inReplyTo", o.InReplyTo, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "inReplyTo", o.InReplyTo, notEmpty, opts) || notEmpty
 	}
 	if o.Location != nil {
-		notEmpty = JSONWriteItemProp(b, "location", o.Location, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "location", o.Location, notEmpty, opts) || notEmpty
 	}
 	if o.Preview != nil {
-		notEmpty = JSONWriteItemProp(b, "preview", o.Preview, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "preview", o.Preview, notEmpty, opts) || notEmpty
 	}
 	if o.Replies != nil {
-		notEmpty = JSONWriteItemProp(b, "replies", o.Replies, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "replies", o.Replies, notEmpty, opts) || notEmpty
 	}
 	if o.Tag != nil {
-		notEmpty = JSONWriteItemCollectionProp(b, "tag", o.Tag, false, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemCollectionProp(b, "tag", o.Tag, false, notEmpty, opts) || notEmpty
 	}
 	if o.URL != nil {
-		notEmpty = JSONWriteItemProp(b, "url", o.URL, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "url", o.URL, notEmpty, opts) || notEmpty
 	}
 	if o.To != nil {
-		notEmpty = JSONWriteItemCollectionProp(b, "to", o.To, false, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemCollectionProp(b, "to", o.To, false, notEmpty, opts) || notEmpty
 	}
 	if o.Bto != nil {
-		notEmpty = JSONWriteItemCollectionProp(b, "bto", o.Bto, false, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemCollectionProp(b, "bto", o.Bto, false, notEmpty, opts) || notEmpty
 	}
 	if o.CC != nil {
-		notEmpty = JSONWriteItemCollectionProp(b, "cc", o.CC, false, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemCollectionProp(b, "cc", o.CC, false, notEmpty, opts) || notEmpty
 	}
 	if o.BCC != nil {
-		notEmpty = JSONWriteItemCollectionProp(b, "bcc", o.BCC, false, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemCollectionProp(b, "bcc", o.BCC, false, notEmpty, opts) || notEmpty
 	}
 	if !o.Published.IsZero() {
 		notEmpty = JSONWriteTimeProp(b, "published", o.Published, notEmpty) || notEmpty
@@ -299,87 +554,194 @@ func JSONWriteObjectValue(b *bytes.Buffer, o Object) (notEmpty bool) {
 		notEmpty = JSONWriteDurationProp(b, "duration", o.Duration, notEmpty) || notEmpty
 	}
 	if o.Likes != nil {
-		notEmpty = JSONWriteItemProp(b, "likes", o.Likes, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "likes", o.Likes, notEmpty, opts) || notEmpty
 	}
 	if o.Shares != nil {
-		notEmpty = JSONWriteItemProp(b, "shares", o.Shares, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "shares", o.Shares, notEmpty, opts) || notEmpty
 	}
-	if v, err := o.Source.MarshalJSON(); err == nil && len(v) > 0 {
-		notEmpty = JSONWriteProp(b, "source", v, notEmpty) || notEmpty
+	notEmpty = jsonWriteProp(b, "source", o.Source, notEmpty, opts) || notEmpty
+	if o.Sensitive {
+		notEmpty = JSONWriteProp(b, "sensitive", []byte("true"), notEmpty) || notEmpty
+	}
+	if len(o.FocalPoint) > 0 {
+		if fp, err := json.Marshal(o.FocalPoint); err == nil {
+			notEmpty = JSONWriteProp(b, "focalPoint", fp, notEmpty) || notEmpty
+		}
+	}
+	if len(o.Blurhash) > 0 {
+		if bh, err := json.Marshal(o.Blurhash); err == nil {
+			notEmpty = JSONWriteProp(b, "blurhash", bh, notEmpty) || notEmpty
+		}
+	}
+	if o.Proof != nil {
+		notEmpty = jsonWriteProp(b, "proof", o.Proof, notEmpty, opts) || notEmpty
 	}
 	return notEmpty
 }
 
 func JSONWriteActivityValue(b *bytes.Buffer, a Activity) (notEmpty bool) {
+	return jsonWriteActivityValue(b, a, EncodeOptions{})
+}
+
+func jsonWriteActivityValue(b *bytes.Buffer, a Activity, opts EncodeOptions) (notEmpty bool) {
 	_ = OnIntransitiveActivity(a, func(i *IntransitiveActivity) error {
 		if i == nil {
 			return nil
 		}
-		notEmpty = JSONWriteIntransitiveActivityValue(b, *i) || notEmpty
+		notEmpty = jsonWriteIntransitiveActivityValue(b, *i, opts) || notEmpty
 		return nil
 	})
 	if a.Object != nil {
-		notEmpty = JSONWriteItemProp(b, "object", a.Object, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "object", a.Object, notEmpty, opts) || notEmpty
+	}
+	if a.Signature != nil {
+		if sig, err := a.Signature.MarshalJSON(); err == nil && len(sig) > 0 {
+			notEmpty = JSONWriteProp(b, "signature", sig, notEmpty) || notEmpty
+		}
 	}
 	return notEmpty
 }
 
 func JSONWriteIntransitiveActivityValue(b *bytes.Buffer, i IntransitiveActivity) (notEmpty bool) {
+	return jsonWriteIntransitiveActivityValue(b, i, EncodeOptions{})
+}
+
+func jsonWriteActorValue(b *bytes.Buffer, a Actor, opts EncodeOptions) (notEmpty bool) {
+	_ = OnObject(a, func(o *Object) error {
+		notEmpty = jsonWriteObjectValue(b, *o, opts)
+		return nil
+	})
+	if a.Inbox != nil {
+		notEmpty = jsonWriteItemProp(b, "inbox", a.Inbox, notEmpty, opts) || notEmpty
+	}
+	if a.Outbox != nil {
+		notEmpty = jsonWriteItemProp(b, "outbox", a.Outbox, notEmpty, opts) || notEmpty
+	}
+	if a.Following != nil {
+		notEmpty = jsonWriteItemProp(b, "following", a.Following, notEmpty, opts) || notEmpty
+	}
+	if a.Followers != nil {
+		notEmpty = jsonWriteItemProp(b, "followers", a.Followers, notEmpty, opts) || notEmpty
+	}
+	if a.Liked != nil {
+		notEmpty = jsonWriteItemProp(b, "liked", a.Liked, notEmpty, opts) || notEmpty
+	}
+	if a.PreferredUsername != nil {
+		notEmpty = jsonWriteNaturalLanguageProp(b, "preferredUsername", a.PreferredUsername, notEmpty, opts) || notEmpty
+	}
+	if a.Endpoints != nil {
+		notEmpty = jsonWriteProp(b, "endpoints", a.Endpoints, notEmpty, opts) || notEmpty
+	}
+	if len(a.Streams) > 0 {
+		notEmpty = jsonWriteItemCollectionProp(b, "streams", a.Streams, false, notEmpty, opts)
+	}
+	if len(a.PublicKey.PublicKeyPem)+len(a.PublicKey.ID) > 0 {
+		notEmpty = jsonWriteProp(b, "publicKey", a.PublicKey, notEmpty, opts) || notEmpty
+	}
+	if len(a.AssertionMethod) > 0 {
+		keys := bytes.Buffer{}
+		JSONWrite(&keys, '[')
+		for _, k := range a.AssertionMethod {
+			if keys.Len() > 1 {
+				JSONWriteComma(&keys)
+			}
+			if !k.writeJSON(&keys, opts) && keys.Len() > 1 {
+				keys.Truncate(keys.Len() - 1)
+			}
+		}
+		JSONWrite(&keys, ']')
+		if keys.Len() > 2 {
+			notEmpty = JSONWriteProp(b, "assertionMethod", keys.Bytes(), notEmpty) || notEmpty
+		}
+	}
+	if a.ManuallyApprovesFollowers {
+		notEmpty = JSONWriteProp(b, "manuallyApprovesFollowers", []byte("true"), notEmpty) || notEmpty
+	}
+	if a.Discoverable {
+		notEmpty = JSONWriteProp(b, "discoverable", []byte("true"), notEmpty) || notEmpty
+	}
+	if a.Indexable {
+		notEmpty = JSONWriteProp(b, "indexable", []byte("true"), notEmpty) || notEmpty
+	}
+	if a.Featured != nil {
+		notEmpty = jsonWriteItemProp(b, "featured", a.Featured, notEmpty, opts) || notEmpty
+	}
+	if a.FeaturedTags != nil {
+		notEmpty = jsonWriteItemProp(b, "featuredTags", a.FeaturedTags, notEmpty, opts) || notEmpty
+	}
+	if a.MovedTo != nil {
+		notEmpty = jsonWriteItemProp(b, "movedTo", a.MovedTo, notEmpty, opts) || notEmpty
+	}
+	if len(a.AlsoKnownAs) > 0 {
+		notEmpty = jsonWriteItemCollectionProp(b, "alsoKnownAs", a.AlsoKnownAs, false, notEmpty, opts) || notEmpty
+	}
+	if a.PostingRestrictedToMods {
+		notEmpty = JSONWriteProp(b, "postingRestrictedToMods", []byte("true"), notEmpty) || notEmpty
+	}
+	if a.Moderators != nil {
+		notEmpty = jsonWriteItemProp(b, "moderators", a.Moderators, notEmpty, opts) || notEmpty
+	}
+	return notEmpty
+}
+
+func jsonWriteIntransitiveActivityValue(b *bytes.Buffer, i IntransitiveActivity, opts EncodeOptions) (notEmpty bool) {
 	_ = OnObject(i, func(o *Object) error {
 		if o == nil {
 			return nil
 		}
-		notEmpty = JSONWriteObjectValue(b, *o) || notEmpty
+		notEmpty = jsonWriteObjectValue(b, *o, opts) || notEmpty
 		return nil
 	})
 	if i.Actor != nil {
-		notEmpty = JSONWriteItemProp(b, "actor", i.Actor, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "actor", i.Actor, notEmpty, opts) || notEmpty
 	}
 	if i.Target != nil {
-		notEmpty = JSONWriteItemProp(b, "target", i.Target, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "target", i.Target, notEmpty, opts) || notEmpty
 	}
 	if i.Result != nil {
-		notEmpty = JSONWriteItemProp(b, "result", i.Result, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "result", i.Result, notEmpty, opts) || notEmpty
 	}
 	if i.Origin != nil {
-		notEmpty = JSONWriteItemProp(b, "origin", i.Origin, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "origin", i.Origin, notEmpty, opts) || notEmpty
 	}
 	if i.Instrument != nil {
-		notEmpty = JSONWriteItemProp(b, "instrument", i.Instrument, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "instrument", i.Instrument, notEmpty, opts) || notEmpty
 	}
 	return notEmpty
 }
 
 func JSONWriteQuestionValue(b *bytes.Buffer, q Question) (notEmpty bool) {
+	return jsonWriteQuestionValue(b, q, EncodeOptions{})
+}
+
+func jsonWriteQuestionValue(b *bytes.Buffer, q Question, opts EncodeOptions) (notEmpty bool) {
 	_ = OnIntransitiveActivity(q, func(i *IntransitiveActivity) error {
 		if i == nil {
 			return nil
 		}
-		notEmpty = JSONWriteIntransitiveActivityValue(b, *i) || notEmpty
+		notEmpty = jsonWriteIntransitiveActivityValue(b, *i, opts) || notEmpty
 		return nil
 	})
 	if q.OneOf != nil {
-		notEmpty = JSONWriteItemProp(b, "oneOf", q.OneOf, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "oneOf", q.OneOf, notEmpty, opts) || notEmpty
 	}
 	if q.AnyOf != nil {
-		notEmpty = JSONWriteItemProp(b, "anyOf", q.AnyOf, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "anyOf", q.AnyOf, notEmpty, opts) || notEmpty
 	}
 	notEmpty = JSONWriteBoolProp(b, "closed", q.Closed, notEmpty) || notEmpty
 	return notEmpty
 }
 
 func JSONWriteTypes(b *bytes.Buffer, n string, ty Typer, needsComma bool) (notEmpty bool) {
-	typ := ty.AsTypes()
-	if v, err := typ.MarshalJSON(); err == nil && len(v) > 0 {
-		notEmpty = JSONWriteProp(b, n, v, needsComma)
-	}
-	return true
+	return jsonWriteProp(b, n, ty.AsTypes(), needsComma, EncodeOptions{})
 }
 
 func JSONWriteLinkValue(b *bytes.Buffer, l Link) (notEmpty bool) {
-	if v, err := l.ID.MarshalJSON(); err == nil && len(v) > 0 {
-		notEmpty = JSONWriteProp(b, "id", v, false)
-	}
+	return jsonWriteLinkValue(b, l, EncodeOptions{})
+}
+
+func jsonWriteLinkValue(b *bytes.Buffer, l Link, opts EncodeOptions) (notEmpty bool) {
+	notEmpty = jsonWriteItemProp(b, "id", l.ID, false, opts)
 	if HasTypes(l) {
 		notEmpty = JSONWriteTypes(b, "type", l.Type, notEmpty) || notEmpty
 	}
@@ -387,7 +749,7 @@ func JSONWriteLinkValue(b *bytes.Buffer, l Link) (notEmpty bool) {
 		notEmpty = JSONWriteProp(b, "mediaType", v, notEmpty) || notEmpty
 	}
 	if len(l.Name) > 0 {
-		notEmpty = JSONWriteNaturalLanguageProp(b, "name", l.Name, notEmpty) || notEmpty
+		notEmpty = jsonWriteNaturalLanguageProp(b, "name", l.Name, notEmpty, opts) || notEmpty
 	}
 	if v, err := l.Rel.MarshalJSON(); err == nil && len(v) > 0 {
 		notEmpty = JSONWriteProp(b, "rel", v, notEmpty) || notEmpty
@@ -399,17 +761,49 @@ func JSONWriteLinkValue(b *bytes.Buffer, l Link) (notEmpty bool) {
 		notEmpty = JSONWriteIntProp(b, "width", int64(l.Width), notEmpty) || notEmpty
 	}
 	if l.Preview != nil {
-		notEmpty = JSONWriteItemProp(b, "rel", l.Preview, notEmpty) || notEmpty
-	}
-	if v, err := l.Href.MarshalJSON(); err == nil && len(v) > 0 {
-		notEmpty = JSONWriteProp(b, "href", v, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "rel", l.Preview, notEmpty, opts) || notEmpty
 	}
+	notEmpty = jsonWriteItemProp(b, "href", l.Href, notEmpty, opts) || notEmpty
 	if l.HrefLang.Valid() {
 		notEmpty = JSONWriteStringProp(b, "hrefLang", l.HrefLang.String(), notEmpty) || notEmpty
 	}
+	if len(l.Extensions) > 0 {
+		notEmpty = JSONWriteExtensions(b, l.Extensions, notEmpty, linkProperties) || notEmpty
+	}
 	return notEmpty
 }
 
+// JSONWriteExtensions writes the extension properties in the order of their names, so the output is stable.
+//
+// The properties with names present in the known lists are skipped, so the value of a field of the
+// encoded type always wins over an extension with the same name, and we never write duplicate keys.
+func JSONWriteExtensions(b *bytes.Buffer, e Extensions, needsComma bool, known ...[]string) (notEmpty bool) {
+	for _, n := range e.Keys() {
+		if isKnownProperty(n, known...) {
+			continue
+		}
+		notEmpty = jsonWriteRawProp(b, n, e[n], needsComma || notEmpty) || notEmpty
+	}
+	return notEmpty
+}
+
+// jsonWriteRawProp writes a property with the raw JSON value v, removing its insignificant whitespace,
+// so the output is the same as the one of MarshalJSON.
+func jsonWriteRawProp(b *bytes.Buffer, n string, v []byte, needsComma bool) (notEmpty bool) {
+	if len(v) == 0 {
+		return false
+	}
+	if needsComma {
+		JSONWriteComma(b)
+	}
+	JSONWritePropName(b, n)
+	if err := json.Compact(b, v); err != nil {
+		// NOTE(marius): json.Compact doesn't write anything on errors, so we keep the value as it was
+		JSONWrite(b, v...)
+	}
+	return true
+}
+
 func JSONWriteActivityVocabularyTypes(b *bytes.Buffer, t ActivityVocabularyTypes) (notEmpty bool) {
 	if b == nil {
 		return notEmpty
@@ -438,5 +832,23 @@ func JSONWriteActivityVocabularyTypes(b *bytes.Buffer, t ActivityVocabularyTypes
 
 // MarshalJSON represents just a wrapper for the jsonld.Marshal function
 func MarshalJSON(it LinkOrIRI) ([]byte, error) {
+	if v, ok, err := jsonMarshalRegistered(it); ok {
+		return v, err
+	}
 	return jsonld.Marshal(it)
 }
+
+// jsonMarshalRegistered encodes the it item with the MarshalJSON function of its registered TypeDefinition,
+// if it has one.
+func jsonMarshalRegistered(it LinkOrIRI) ([]byte, bool, error) {
+	ob, ok := it.(Item)
+	if !ok || IsNil(ob) {
+		return nil, false, nil
+	}
+	def, ok := RegisteredType(ob.GetType())
+	if !ok || def.MarshalJSON == nil {
+		return nil, false, nil
+	}
+	v, err := def.MarshalJSON(ob)
+	return v, true, err
+}
@@ -0,0 +1,194 @@
+package activitypub
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"github.com/go-ap/errors"
+)
+
+// encoderFlushSize is the size after which the Encoder writes its buffer to the underlying io.Writer.
+const encoderFlushSize = 32 * 1024
+
+// Encoder writes the JSON encoding of ActivityPub items to an io.Writer.
+//
+// The output is the same as the one of MarshalJSON, but the items of collections and collection
+// pages are written to the io.Writer as they get encoded, so a large collection page doesn't need to
+// be built in memory first. Unlike json.Encoder, it doesn't write a new line after every item.
+type Encoder struct {
+	w    io.Writer
+	opts EncodeOptions
+}
+
+// NewEncoder returns an Encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// SetOptions changes the shape of the values written by the Encoder. The indentation options are ignored,
+// as the Encoder always writes compact output.
+func (e *Encoder) SetOptions(opts EncodeOptions) {
+	e.opts = opts
+}
+
+// Encode writes the JSON encoding of "it" to the underlying io.Writer.
+//
+// If an error occurs while encoding an item of a collection, part of the collection could already have been
+// written to the io.Writer.
+func (e *Encoder) Encode(it LinkOrIRI) error {
+	b := getJSONBuffer()
+	defer putJSONBuffer(b)
+
+	if err := e.encode(b, it); err != nil {
+		return err
+	}
+	return e.flush(b)
+}
+
+func (e *Encoder) flush(b *bytes.Buffer) error {
+	if b.Len() == 0 {
+		return nil
+	}
+	_, err := e.w.Write(b.Bytes())
+	b.Reset()
+	return err
+}
+
+func (e *Encoder) encode(b *bytes.Buffer, it LinkOrIRI) error {
+	if it == nil {
+		JSONWriteS(b, "null")
+		return nil
+	}
+	if v := reflect.ValueOf(it); v.Kind() == reflect.Pointer && v.IsNil() {
+		JSONWriteS(b, "null")
+		return nil
+	}
+
+	switch c := it.(type) {
+	case OrderedCollectionPage:
+		return e.encode(b, &c)
+	case *OrderedCollectionPage:
+		env := *c
+		env.OrderedItems, env.Extensions = nil, nil
+		return e.encodeCollection(b, env, "orderedItems", c.OrderedItems, c.Extensions, objectProperties, orderedCollectionProperties, orderedCollectionPageProperties)
+	case OrderedCollection:
+		return e.encode(b, &c)
+	case *OrderedCollection:
+		env := *c
+		env.OrderedItems, env.Extensions = nil, nil
+		return e.encodeCollection(b, env, "orderedItems", c.OrderedItems, c.Extensions, objectProperties, orderedCollectionProperties)
+	case CollectionPage:
+		return e.encode(b, &c)
+	case *CollectionPage:
+		env := *c
+		env.Items, env.Extensions = nil, nil
+		return e.encodeCollection(b, env, "items", c.Items, c.Extensions, objectProperties, collectionProperties, collectionPageProperties)
+	case Collection:
+		return e.encode(b, &c)
+	case *Collection:
+		env := *c
+		env.Items, env.Extensions = nil, nil
+		return e.encodeCollection(b, env, "items", c.Items, c.Extensions, objectProperties, collectionProperties)
+	case ItemCollection:
+		if len(c) > 1 {
+			// NOTE(marius): single element collections are encoded as the element itself,
+			// so we leave them to the generic code path
+			JSONWrite(b, '[')
+			if err := e.encodeItems(b, c); err != nil {
+				return err
+			}
+			JSONWrite(b, ']')
+			return nil
+		}
+	}
+
+	if w, ok := it.(jsonWriter); ok {
+		start := b.Len()
+		if !w.writeJSON(b, e.opts) {
+			return errors.Newf("unable to encode empty %T", it)
+		}
+		return validJSON(b.Bytes()[start:])
+	}
+	m, ok := it.(json.Marshaler)
+	if !ok {
+		JSONWriteS(b, "null")
+		return nil
+	}
+	raw, err := m.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return json.Compact(b, raw)
+}
+
+// encodeCollection writes the env collection, which is expected not to have items or extensions, then
+// the "items" and the extensions, in the same order as their MarshalJSON methods do.
+func (e *Encoder) encodeCollection(b *bytes.Buffer, env jsonWriter, n string, items ItemCollection, ext Extensions, known ...[]string) error {
+	start := b.Len()
+	notEmpty := env.writeJSON(b, e.opts)
+	if notEmpty {
+		if err := validJSON(b.Bytes()[start:]); err != nil {
+			return err
+		}
+		// NOTE(marius): we remove the closing brace of the envelope, and continue with the rest of the properties
+		b.Truncate(b.Len() - 1)
+	} else {
+		JSONWrite(b, '{')
+	}
+	if len(items) > 0 {
+		if notEmpty {
+			JSONWriteComma(b)
+		}
+		JSONWritePropName(b, n)
+		JSONWrite(b, '[')
+		if err := e.encodeItems(b, e.opts.nestedCollection(n, items)); err != nil {
+			return err
+		}
+		JSONWrite(b, ']')
+		notEmpty = true
+	}
+	JSONWriteExtensions(b, ext, notEmpty, known...)
+	JSONWrite(b, '}')
+	return nil
+}
+
+// encodeItems writes the elements of col separated by commas, and flushes the buffer to the io.Writer
+// when it grows over encoderFlushSize.
+func (e *Encoder) encodeItems(b *bytes.Buffer, col ItemCollection) error {
+	notEmpty := false
+	for _, it := range col {
+		start := b.Len()
+		if notEmpty {
+			JSONWriteComma(b)
+		}
+		ok, err := jsonWriteItem(b, it, e.opts)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			b.Truncate(start)
+			continue
+		}
+		if err = validJSON(b.Bytes()[start:]); err != nil {
+			return err
+		}
+		notEmpty = true
+		if b.Len() >= encoderFlushSize {
+			if err = e.flush(b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validJSON checks the output of the writers, which don't validate the values they write, eg: for extensions.
+func validJSON(raw []byte) error {
+	raw = bytes.TrimPrefix(raw, []byte{','})
+	if !json.Valid(raw) {
+		return errors.Newf("invalid JSON encoding: %s", raw)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package activitypub
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func mockOutboxPage(count int) *OrderedCollectionPage {
+	p := &OrderedCollectionPage{
+		ID:         "https://example.com/outbox?page=1",
+		Type:       OrderedCollectionPageType,
+		PartOf:     IRI("https://example.com/outbox"),
+		Next:       IRI("https://example.com/outbox?page=2"),
+		TotalItems: uint(count),
+		Extensions: Extensions{
+			"orderedItems": []byte(`["https://example.com/ignored"]`),
+			"test":         []byte(`{ "spaces" : [ 1, 2 ] }`),
+		},
+	}
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range count {
+		p.OrderedItems = append(p.OrderedItems, &Activity{
+			ID:        ID(fmt.Sprintf("https://example.com/activities/%d", i)),
+			Type:      CreateType,
+			Actor:     IRI("https://example.com/~jdoe"),
+			Published: published,
+			To:        ItemCollection{PublicNS},
+			Object: &Object{
+				ID:      ID(fmt.Sprintf("https://example.com/objects/%d", i)),
+				Type:    NoteType,
+				Content: DefaultNaturalLanguage(fmt.Sprintf("Note number %d", i)),
+			},
+		})
+	}
+	return p
+}
+
+func TestEncoder_Encode(t *testing.T) {
+	tests := []struct {
+		name string
+		it   LinkOrIRI
+	}{
+		{
+			name: "nil",
+			it:   nil,
+		},
+		{
+			name: "nil object",
+			it:   (*Object)(nil),
+		},
+		{
+			name: "iri",
+			it:   IRI("https://example.com"),
+		},
+		{
+			name: "object",
+			it:   &Object{ID: "https://example.com/1", Type: NoteType, Extensions: Extensions{"test": []byte(`true`)}},
+		},
+		{
+			name: "object with language map",
+			it: &Object{
+				ID:   "https://example.com/1",
+				Type: NoteType,
+				Content: NaturalLanguageValuesNew(
+					RefValue(MakeRef([]byte("ro")), "test"),
+					RefValue(MakeRef([]byte("en")), "test"),
+					RefValue(MakeRef([]byte("fr")), "teste"),
+				),
+				Extensions: Extensions{"test": []byte(`{ "spaces" : true }`)},
+			},
+		},
+		{
+			name: "item collection with one item",
+			it:   ItemCollection{IRI("https://example.com/1")},
+		},
+		{
+			name: "item collection",
+			it:   ItemCollection{IRI("https://example.com/1"), &Object{ID: "https://example.com/2"}, &Object{}},
+		},
+		{
+			name: "empty collection",
+			it:   &Collection{},
+		},
+		{
+			name: "collection",
+			it: Collection{
+				ID:    "https://example.com/followers",
+				Type:  CollectionType,
+				First: IRI("https://example.com/followers?page=1"),
+				Items: ItemCollection{IRI("https://example.com/~jdoe")},
+			},
+		},
+		{
+			name: "collection page",
+			it: &CollectionPage{
+				Type:       CollectionPageType,
+				Items:      ItemCollection{IRI("https://example.com/~jdoe"), IRI("https://example.com/~alice")},
+				Extensions: Extensions{"test": []byte(`"test"`)},
+			},
+		},
+		{
+			name: "ordered collection",
+			it: &OrderedCollection{
+				ID:           "https://example.com/outbox",
+				Type:         OrderedCollectionType,
+				OrderedItems: ItemCollection{},
+				Extensions:   Extensions{"test": []byte(`1`)},
+			},
+		},
+		{
+			name: "ordered collection page",
+			it:   mockOutboxPage(10),
+		},
+		{
+			name: "large ordered collection page",
+			it:   mockOutboxPage(2000),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := MarshalJSON(tt.it)
+			if err != nil {
+				t.Fatalf("MarshalJSON() error = %s", err)
+			}
+			w := writeCounter{}
+			if err = NewEncoder(&w).Encode(tt.it); err != nil {
+				t.Fatalf("Encode() error = %s", err)
+			}
+			if !bytes.Equal(w.Bytes(), want) {
+				t.Errorf("Encode() got = %s\nwant %s", w.Bytes(), want)
+			}
+			if w.Len() > encoderFlushSize && w.writes < 2 {
+				t.Errorf("Encode() wrote %d bytes in %d calls, expected incremental writes", w.Len(), w.writes)
+			}
+		})
+	}
+}
+
+func TestEncoder_Encode_mocks(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("tests", "mocks", "*.json"))
+	if err != nil {
+		t.Fatalf("unable to find mocks: %s", err)
+	}
+	for _, f := range files {
+		t.Run(filepath.Base(f), func(t *testing.T) {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				t.Fatalf("unable to read mock: %s", err)
+			}
+			it, err := UnmarshalJSON(data)
+			if err != nil || it == nil {
+				t.Skipf("unable to load mock: %v", err)
+			}
+			want, err := MarshalJSON(it)
+			if err != nil {
+				t.Fatalf("MarshalJSON() error = %s", err)
+			}
+			got := bytes.Buffer{}
+			if err = NewEncoder(&got).Encode(it); err != nil {
+				t.Fatalf("Encode() error = %s", err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("Encode() got = %s\nwant %s", got.Bytes(), want)
+			}
+		})
+	}
+}
+
+type writeCounter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *writeCounter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func BenchmarkMarshalJSON_OrderedCollectionPage(b *testing.B) {
+	p := mockOutboxPage(100)
+	b.ReportAllocs()
+	for b.Loop() {
+		raw, err := MarshalJSON(p)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, _ = io.Discard.Write(raw)
+	}
+}
+
+func BenchmarkEncoder_OrderedCollectionPage(b *testing.B) {
+	p := mockOutboxPage(100)
+	enc := NewEncoder(io.Discard)
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := enc.Encode(p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestEncoder_SetOptions(t *testing.T) {
+	it := mockOutboxPage(3)
+	it.OrderedItems = append(it.OrderedItems, ItemCollection{IRI("https://example.com/1")})
+
+	for _, opts := range []EncodeOptions{
+		{LanguageMaps: true, SingleItemArrays: true},
+		{MultiValueArrays: true, Nested: NestedAsIRIs},
+		{MultiValueArrays: true, Nested: NestedEmbedded},
+	} {
+		want, err := MarshalJSONWithOptions(it, opts)
+		if err != nil {
+			t.Fatalf("MarshalJSONWithOptions() error = %s", err)
+		}
+		got := bytes.Buffer{}
+		enc := NewEncoder(&got)
+		enc.SetOptions(opts)
+		if err = enc.Encode(it); err != nil {
+			t.Fatalf("Encode() error = %s", err)
+		}
+		if !bytes.Equal(got.Bytes(), want) {
+			t.Errorf("Encode() with %+v got = %s\nwant %s", opts, got.Bytes(), want)
+		}
+	}
+}
@@ -691,3 +691,178 @@ func TestJSONWriteActivityVocabularyTypes(t *testing.T) {
 		})
 	}
 }
+
+func TestMarshalJSONWithOptions(t *testing.T) {
+	note := &Object{
+		ID:      "https://example.com/1",
+		Type:    NoteType,
+		Content: DefaultNaturalLanguage("test"),
+		To:      ItemCollection{PublicNS},
+	}
+	tests := []struct {
+		name    string
+		arg     LinkOrIRI
+		opts    EncodeOptions
+		want    string
+		wantErr bool
+		// lossy is set when the output doesn't load back as the same item
+		lossy bool
+	}{
+		{
+			name: "empty",
+			arg:  nil,
+			want: "null",
+		},
+		{
+			name: "no options",
+			arg:  note,
+			want: `{"id":"https://example.com/1","type":"Note","content":"test","to":["https://www.w3.org/ns/activitystreams#Public"]}`,
+		},
+		{
+			name: "indented keeps the property order",
+			arg: &Activity{
+				Type:   CreateType,
+				Actor:  IRI("https://example.com/~jdoe"),
+				Object: note,
+			},
+			opts: EncodeOptions{Indent: "  "},
+			want: `{
+  "type": "Create",
+  "actor": "https://example.com/~jdoe",
+  "object": {
+    "id": "https://example.com/1",
+    "type": "Note",
+    "content": "test",
+    "to": [
+      "https://www.w3.org/ns/activitystreams#Public"
+    ]
+  }
+}`,
+		},
+		{
+			name: "language maps",
+			arg:  note,
+			opts: EncodeOptions{LanguageMaps: true},
+			want: `{"id":"https://example.com/1","type":"Note","contentMap":{"en":"test"},"to":["https://www.w3.org/ns/activitystreams#Public"]}`,
+		},
+		{
+			name: "language maps with value without language",
+			arg: &Object{
+				Type:    NoteType,
+				Name:    NaturalLanguageValuesNew(LangRefValue{Ref: NilLangRef, Value: Content("test")}),
+				Summary: NaturalLanguageValuesNew(RefValue(MakeRef([]byte("fr")), "teste"), LangRefValue{Ref: NilLangRef, Value: Content("test")}),
+			},
+			opts: EncodeOptions{LanguageMaps: true},
+			want: `{"type":"Note","nameMap":{"und":"test"},"summaryMap":{"fr":"teste","und":"test"}}`,
+		},
+		{
+			name: "single item collections",
+			arg: &Activity{
+				Type:   LikeType,
+				Object: ItemCollection{IRI("https://example.com/1")},
+			},
+			want:  `{"type":"Like","object":"https://example.com/1"}`,
+			lossy: true,
+		},
+		{
+			name: "single item collections as arrays",
+			arg: &Activity{
+				Type:   LikeType,
+				Object: ItemCollection{IRI("https://example.com/1")},
+			},
+			opts: EncodeOptions{SingleItemArrays: true},
+			want: `{"type":"Like","object":["https://example.com/1"]}`,
+		},
+		{
+			name: "multi-valued properties as arrays",
+			arg: &Activity{
+				Type:   CreateType,
+				Actor:  IRI("https://example.com/~jdoe"),
+				Object: &Object{ID: "https://example.com/1", Type: NoteType, Tag: ItemCollection{&Mention{Type: MentionType}}},
+				To:     ItemCollection{PublicNS},
+				Result: IRI("https://example.com/2"),
+			},
+			opts:  EncodeOptions{MultiValueArrays: true},
+			want:  `{"type":"Create","to":["https://www.w3.org/ns/activitystreams#Public"],"actor":["https://example.com/~jdoe"],"result":["https://example.com/2"],"object":[{"id":"https://example.com/1","type":"Note","tag":[{"type":"Mention"}]}]}`,
+			lossy: true,
+		},
+		{
+			name: "nested objects as IRIs",
+			arg: &Activity{
+				ID:    "https://example.com/activities/1",
+				Type:  CreateType,
+				Actor: &Actor{ID: "https://example.com/~jdoe", Type: PersonType},
+				Object: &Object{
+					ID:         "https://example.com/1",
+					Type:       NoteType,
+					Tag:        ItemCollection{&Mention{Type: MentionType, Href: "https://example.com/~alice"}},
+					Attachment: &Object{Type: ImageType, URL: IRI("https://example.com/1.png")},
+				},
+				To: ItemCollection{PublicNS, &Actor{ID: "https://example.com/~alice", Type: PersonType}},
+			},
+			opts:  EncodeOptions{Nested: NestedAsIRIs},
+			want:  `{"id":"https://example.com/activities/1","type":"Create","to":["https://www.w3.org/ns/activitystreams#Public","https://example.com/~alice"],"actor":"https://example.com/~jdoe","object":"https://example.com/1"}`,
+			lossy: true,
+		},
+		{
+			name: "nested objects as IRIs keeps links and anonymous objects",
+			arg: &Object{
+				ID:         "https://example.com/1",
+				Type:       NoteType,
+				Tag:        ItemCollection{&Mention{Type: MentionType, Href: "https://example.com/~alice"}},
+				Attachment: &Object{Type: ImageType, URL: IRI("https://example.com/1.png")},
+			},
+			opts: EncodeOptions{Nested: NestedAsIRIs},
+			want: `{"id":"https://example.com/1","type":"Note","attachment":{"type":"Image","url":"https://example.com/1.png"},"tag":[{"type":"Mention","href":"https://example.com/~alice"}]}`,
+		},
+		{
+			name: "nested objects embedded",
+			arg: &Activity{
+				Type:   LikeType,
+				Actor:  IRI("https://example.com/~jdoe"),
+				Object: note,
+				To:     ItemCollection{PublicNS, IRI("https://example.com/~jdoe/followers")},
+			},
+			opts:  EncodeOptions{Nested: NestedEmbedded},
+			want:  `{"type":"Like","to":["https://www.w3.org/ns/activitystreams#Public",{"id":"https://example.com/~jdoe/followers"}],"actor":{"id":"https://example.com/~jdoe"},"object":{"id":"https://example.com/1","type":"Note","content":"test","to":["https://www.w3.org/ns/activitystreams#Public"]}}`,
+			lossy: true,
+		},
+		{
+			name:  "nested objects embedded keeps URLs",
+			arg:   &Object{Type: ImageType, URL: IRI("https://example.com/1.png")},
+			opts:  EncodeOptions{Nested: NestedEmbedded, MultiValueArrays: true},
+			want:  `{"type":"Image","url":["https://example.com/1.png"]}`,
+			lossy: true,
+		},
+		{
+			name: "all options",
+			arg: &Activity{
+				Type:   LikeType,
+				Object: ItemCollection{note},
+			},
+			opts: EncodeOptions{Prefix: "", Indent: "\t", LanguageMaps: true, SingleItemArrays: true},
+			want: "{\n\t\"type\": \"Like\",\n\t\"object\": [\n\t\t{\n\t\t\t\"id\": \"https://example.com/1\",\n\t\t\t\"type\": \"Note\",\n\t\t\t\"contentMap\": {\n\t\t\t\t\"en\": \"test\"\n\t\t\t},\n\t\t\t\"to\": [\n\t\t\t\t\"https://www.w3.org/ns/activitystreams#Public\"\n\t\t\t]\n\t\t}\n\t]\n}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalJSONWithOptions(tt.arg, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MarshalJSONWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("MarshalJSONWithOptions() got = %s\nwant %s", got, tt.want)
+			}
+			if tt.wantErr || tt.lossy || tt.arg == nil {
+				return
+			}
+			it, err := UnmarshalJSON(got)
+			if err != nil {
+				t.Fatalf("UnmarshalJSON() error = %s", err)
+			}
+			if !ItemsEqual(it, tt.arg.(Item)) {
+				t.Errorf("UnmarshalJSON() of the output is different than the encoded item: %#v", it)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package activitypub
+
+import (
+	"strings"
+
+	"github.com/go-ap/errors"
+)
+
+// Error is the error returned for a problem described by one of the Err* values of the package, like
+// ErrInvalidDateTime or ErrMaxDepth, which is its Reason.
+//
+// NOTE(marius): the go-ap/errors values match any other go-ap/errors value with errors.Is, so Error compares
+// the Err* values by identity. For the same reason it doesn't implement Unwrap, as errors.Is would then match
+// any of the Err* values against a go-ap/errors Cause, but the Cause can still be inspected with errors.As.
+type Error struct {
+	// Path is where the problem was found, when it has a location: a JSON Pointer (RFC 6901) to a value
+	// of a document, or the IRI of the key, or of the recipient, with the problem.
+	Path string
+	// Reason is one of the Err* values of the package, or an error annotating one of them.
+	Reason error
+	// Cause is the error which caused the problem, if any, eg: the error returned by a resolver.
+	Cause error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	s := strings.Builder{}
+	if e.Path != "" {
+		s.WriteString(e.Path)
+		s.WriteString(": ")
+	}
+	if e.Reason != nil {
+		s.WriteString(e.Reason.Error())
+	}
+	if e.Cause != nil {
+		if e.Reason != nil {
+			s.WriteString(": ")
+		}
+		s.WriteString(e.Cause.Error())
+	}
+	return s.String()
+}
+
+// Is checks if target is the Reason of the problem, or is found in its Cause.
+func (e *Error) Is(target error) bool {
+	if sameError(e.Reason, target) || sameError(e.Cause, target) {
+		return true
+	}
+	if _, ok := target.(*errors.Err); ok {
+		return false
+	}
+	return errors.Is(e.Cause, target)
+}
+
+// As finds the first error in the Cause which matches target, and sets target to it.
+func (e *Error) As(target any) bool {
+	return e.Cause != nil && errors.As(e.Cause, target)
+}
+
+// sameError walks the chain of err looking for target, which it compares by identity, except for the *Error
+// values, which check it with their own Is method.
+func sameError(err, target error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if err == target {
+			return true
+		}
+		if e, ok := err.(*Error); ok {
+			return e.Is(target)
+		}
+	}
+	return false
+}
+
+// pathErr returns the error for the problem found at path, like the IRI of a recipient, or a handle, with
+// reason, one of the Err* values, as its description, and cause the error which caused it, if any.
+func pathErr(path string, reason error, cause error) *Error {
+	return &Error{Path: path, Reason: reason, Cause: cause}
+}
+
+// Errors is the list of the problems found in one go, eg: all the invalid properties of a document,
+// or all the recipients of an activity which can't be resolved.
+type Errors []*Error
+
+// Error implements the error interface
+func (e Errors) Error() string {
+	s := strings.Builder{}
+	for i, err := range e {
+		if i > 0 {
+			s.WriteString("; ")
+		}
+		s.WriteString(err.Error())
+	}
+	return s.String()
+}
+
+// Unwrap returns the individual problems, so they can be inspected with errors.Is and errors.As
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// orNil returns nil for an empty list, so it doesn't get returned as a non nil error.
+func (e Errors) orNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
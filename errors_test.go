@@ -0,0 +1,71 @@
+package activitypub
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/go-ap/errors"
+)
+
+func TestError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *Error
+		want string
+	}{
+		{
+			name: "reason",
+			err:  &Error{Reason: ErrMaxDocumentSize},
+			want: "maximum document size exceeded",
+		},
+		{
+			name: "path",
+			err:  &Error{Path: "/object/published", Reason: ErrInvalidDateTime},
+			want: "/object/published: invalid xsd:dateTime",
+		},
+		{
+			name: "cause",
+			err:  &Error{Path: "/", Reason: ErrInvalidJSON, Cause: errors.Newf("unexpected end")},
+			want: "/: invalid JSON document: unexpected end",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestError_Is(t *testing.T) {
+	urlErr := &url.Error{Op: "Get", URL: "https://example.com", Err: errors.Newf("timeout")}
+	err := &Error{
+		Path:   "https://example.com/~jdoe",
+		Reason: errors.Annotatef(ErrInvalidType, "Note"),
+		Cause:  &Error{Reason: ErrInvalidIRI, Cause: urlErr},
+	}
+	for _, target := range []error{ErrInvalidType, ErrInvalidIRI} {
+		if !errors.Is(err, target) {
+			t.Errorf("Is(%q) = false, want true", target)
+		}
+	}
+	// NOTE(marius): the go-ap/errors Cause would match any of these, if they were compared with its Is method
+	for _, target := range []error{ErrInvalidDateTime, ErrMaxDepth, errors.Newf("timeout")} {
+		if errors.Is(err, target) {
+			t.Errorf("Is(%q) = true, want false", target)
+		}
+	}
+	var uErr *url.Error
+	if !errors.As(err, &uErr) || uErr != urlErr {
+		t.Errorf("As(*url.Error) = %v, want %v", uErr, urlErr)
+	}
+
+	errs := Errors{{Path: "/id", Reason: ErrInvalidIRI}, {Path: "/published", Reason: ErrInvalidDateTime}}
+	if !errors.Is(errs, ErrInvalidDateTime) || errors.Is(errs, ErrInvalidFloat) {
+		t.Errorf("Errors %q don't match their reasons", errs)
+	}
+	if want := "/id: invalid IRI; /published: invalid xsd:dateTime"; errs.Error() != want {
+		t.Errorf("Errors.Error() = %q, want %q", errs.Error(), want)
+	}
+}
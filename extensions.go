@@ -0,0 +1,217 @@
+package activitypub
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"slices"
+)
+
+// Extensions holds the properties of an ActivityPub document which are not part of the vocabulary
+// known to this package, keyed by the property name, with their values stored as raw JSON.
+//
+// They are filled when decoding from JSON and written back as they were when encoding, which allows
+// objects with properties from other vocabularies (eg, Misskey's "isCat" or PeerTube's "uuid")
+// to be relayed or stored without losing information.
+type Extensions map[string]json.RawMessage
+
+// objectProperties are the JSON properties loaded by JSONLoadObject
+var objectProperties = []string{
+	"@context", "id", "type", "name", "nameMap", "content", "contentMap", "summary", "summaryMap",
+	"attachment", "attributedTo", "audience", "context", "mediaType", "endTime", "generator", "icon", "image",
+	"inReplyTo", "location", "preview", "published", "replies", "startTime", "tag", "updated", "url",
+	"to", "bto", "cc", "bcc", "duration", "likes", "shares", "source", "sensitive", "focalPoint", "blurhash",
+}
+
+// intransitiveActivityProperties are the JSON properties loaded by JSONLoadIntransitiveActivity
+var intransitiveActivityProperties = []string{"actor", "target", "result", "origin", "instrument"}
+
+// activityProperties are the JSON properties loaded by JSONLoadActivity
+var activityProperties = []string{"object", "signature"}
+
+// questionProperties are the JSON properties loaded by JSONLoadQuestion
+var questionProperties = []string{"oneOf", "anyOf", "closed"}
+
+// actorProperties are the JSON properties loaded by JSONLoadActor
+var actorProperties = []string{
+	"inbox", "outbox", "following", "followers", "liked", "preferredUsername", "preferredUsernameMap",
+	"endpoints", "streams", "publicKey", "assertionMethod", "manuallyApprovesFollowers", "discoverable",
+	"indexable", "featured", "featuredTags", "movedTo", "alsoKnownAs", "postingRestrictedToMods", "moderators",
+}
+
+// collectionProperties are the JSON properties loaded by JSONLoadCollection
+var collectionProperties = []string{"current", "first", "last", "totalItems", "items"}
+
+// orderedCollectionProperties are the JSON properties loaded by JSONLoadOrderedCollection
+var orderedCollectionProperties = []string{"current", "first", "last", "totalItems", "orderedItems"}
+
+// collectionPageProperties are the JSON properties loaded by JSONLoadCollectionPage
+var collectionPageProperties = []string{"next", "prev", "partOf"}
+
+// orderedCollectionPageProperties are the JSON properties loaded by JSONLoadOrderedCollectionPage
+var orderedCollectionPageProperties = []string{"next", "prev", "partOf", "startIndex"}
+
+// placeProperties are the JSON properties loaded by JSONLoadPlace
+var placeProperties = []string{"accuracy", "altitude", "latitude", "longitude", "radius", "units"}
+
+// profileProperties are the JSON properties loaded by JSONLoadProfile
+var profileProperties = []string{"describes"}
+
+// relationshipProperties are the JSON properties loaded by JSONLoadRelationship
+var relationshipProperties = []string{"subject", "object", "relationship"}
+
+// tombstoneProperties are the JSON properties loaded by JSONLoadTombstone
+var tombstoneProperties = []string{"formerType", "deleted"}
+
+// repositoryProperties are the JSON properties loaded by JSONLoadRepository
+var repositoryProperties = []string{"team", "forks", "ticketsTrackedBy", "cloneUri"}
+
+// ticketProperties are the JSON properties loaded by JSONLoadTicket
+var ticketProperties = []string{"isResolved", "resolvedBy", "resolved", "assignedTo"}
+
+// linkProperties are the JSON properties loaded by JSONLoadLink
+var linkProperties = []string{
+	"@context", "id", "type", "name", "nameMap", "rel", "mediaType", "height", "width", "preview", "href", "hrefLang",
+}
+
+func isKnownProperty(n string, known ...[]string) bool {
+	for _, props := range known {
+		if slices.Contains(props, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// Keys returns the sorted names of the extension properties.
+func (e Extensions) Keys() []string {
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Get returns the raw JSON value of the extension property with name n.
+func (e Extensions) Get(n string) (json.RawMessage, bool) {
+	v, ok := e[n]
+	return v, ok
+}
+
+// Set stores the raw JSON value of the extension property with name n.
+//
+// If n is the name of a property of the vocabulary known to this package, the value of the
+// corresponding field is the one that gets encoded, and the extension is ignored.
+func (e *Extensions) Set(n string, v json.RawMessage) {
+	if *e == nil {
+		*e = make(Extensions)
+	}
+	(*e)[n] = v
+}
+
+// Equal verifies if our receiver Extensions is equal with the "with" Extensions
+func (e Extensions) Equal(with Extensions) bool {
+	if len(e) != len(with) {
+		return false
+	}
+	for k, v := range e {
+		w, ok := with[k]
+		if !ok || !bytes.Equal(v, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone returns a copy of the receiver that doesn't share memory with it.
+func (e Extensions) Clone() Extensions {
+	if e == nil {
+		return nil
+	}
+	c := make(Extensions, len(e))
+	for k, v := range e {
+		c[k] = slices.Clone(v)
+	}
+	return c
+}
+
+// without returns the receiver Extensions without the properties with names nn.
+func (e Extensions) without(nn ...string) Extensions {
+	for _, n := range nn {
+		delete(e, n)
+	}
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// UnmarshalJSON decodes an incoming JSON object into the receiver Extensions.
+func (e *Extensions) UnmarshalJSON(data []byte) error {
+	val, err := parseJSON(data)
+	if err != nil {
+		return err
+	}
+	*e = JSONGetExtensions(val)
+	return nil
+}
+
+// MarshalJSON encodes the receiver Extensions to a JSON object.
+func (e Extensions) MarshalJSON() ([]byte, error) {
+	if len(e) == 0 {
+		return nil, nil
+	}
+	b := bytes.Buffer{}
+	JSONWrite(&b, '{')
+	JSONWriteExtensions(&b, e, false)
+	JSONWrite(&b, '}')
+	return b.Bytes(), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (e *Extensions) UnmarshalBinary(data []byte) error {
+	return e.GobDecode(data)
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (e Extensions) MarshalBinary() ([]byte, error) {
+	return e.GobEncode()
+}
+
+// GobEncode
+func (e Extensions) GobEncode() ([]byte, error) {
+	if len(e) == 0 {
+		return []byte{}, nil
+	}
+	mm := make(map[string][]byte, len(e))
+	for k, v := range e {
+		mm[k] = v
+	}
+	bb := bytes.Buffer{}
+	g := gob.NewEncoder(&bb)
+	if err := g.Encode(mm); err != nil {
+		return nil, err
+	}
+	return bb.Bytes(), nil
+}
+
+// GobDecode
+func (e *Extensions) GobDecode(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	mm, err := gobDecodeObjectAsMap(data)
+	if err != nil {
+		return err
+	}
+	if len(mm) == 0 {
+		return nil
+	}
+	ext := make(Extensions, len(mm))
+	for k, v := range mm {
+		ext[k] = v
+	}
+	*e = ext
+	return nil
+}
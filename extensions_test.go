@@ -0,0 +1,265 @@
+package activitypub
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExtensions_JSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Item
+	}{
+		{
+			name: "note with directMessage",
+			data: `{"id":"https://example.com/1","type":"Note","directMessage":true}`,
+			want: &Object{
+				ID:         "https://example.com/1",
+				Type:       NoteType,
+				Extensions: Extensions{"directMessage": json.RawMessage(`true`)},
+			},
+		},
+		{
+			name: "person with isCat and birthday",
+			data: `{"id":"https://example.com/~jdoe","type":"Person","inbox":"https://example.com/~jdoe/inbox","isCat":false,"vcard:bday":"2000-01-01"}`,
+			want: &Actor{
+				ID:    "https://example.com/~jdoe",
+				Type:  PersonType,
+				Inbox: IRI("https://example.com/~jdoe/inbox"),
+				Extensions: Extensions{
+					"isCat":      json.RawMessage(`false`),
+					"vcard:bday": json.RawMessage(`"2000-01-01"`),
+				},
+			},
+		},
+		{
+			name: "activity with nested object extension",
+			data: `{"id":"https://example.com/2","type":"Create","actor":"https://example.com/~jdoe","object":{"id":"https://example.com/1","type":"Note","forge:team":{"id":"https://example.com/team"}}}`,
+			want: &Activity{
+				ID:    "https://example.com/2",
+				Type:  CreateType,
+				Actor: IRI("https://example.com/~jdoe"),
+				Object: &Object{
+					ID:         "https://example.com/1",
+					Type:       NoteType,
+					Extensions: Extensions{"forge:team": json.RawMessage(`{"id":"https://example.com/team"}`)},
+				},
+			},
+		},
+		{
+			name: "mention with extension",
+			data: `{"type":"Mention","href":"https://example.com/~jdoe","custom":[1,2]}`,
+			want: &Link{
+				Type:       MentionType,
+				Href:       "https://example.com/~jdoe",
+				Extensions: Extensions{"custom": json.RawMessage(`[1,2]`)},
+			},
+		},
+		{
+			name: "context is not an extension",
+			data: `{"@context":"https://www.w3.org/ns/activitystreams","id":"https://example.com/1","type":"Note"}`,
+			want: &Object{ID: "https://example.com/1", Type: NoteType},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalJSON([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("UnmarshalJSON() error = %s", err)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Fatalf("UnmarshalJSON() got = %s", cmp.Diff(tt.want, got))
+			}
+			raw, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("MarshalJSON() error = %s", err)
+			}
+			again, err := UnmarshalJSON(raw)
+			if err != nil {
+				t.Fatalf("UnmarshalJSON() error = %s", err)
+			}
+			if !cmp.Equal(again, tt.want) {
+				t.Errorf("Re-encoded value is different got = %s", cmp.Diff(tt.want, again))
+			}
+		})
+	}
+}
+
+func TestJSONWriteExtensions(t *testing.T) {
+	tests := []struct {
+		name         string
+		ext          Extensions
+		needsComma   bool
+		want         string
+		wantNotEmpty bool
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:         "sorted by name",
+			ext:          Extensions{"directMessage": json.RawMessage(`true`), "_misskey_content": json.RawMessage(`"test"`)},
+			want:         `"_misskey_content":"test","directMessage":true`,
+			wantNotEmpty: true,
+		},
+		{
+			name:         "with comma",
+			ext:          Extensions{"directMessage": json.RawMessage(`true`)},
+			needsComma:   true,
+			want:         `,"directMessage":true`,
+			wantNotEmpty: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bytes.Buffer{}
+			gotNotEmpty := JSONWriteExtensions(&b, tt.ext, tt.needsComma)
+			if gotNotEmpty != tt.wantNotEmpty {
+				t.Errorf("JSONWriteExtensions() = %v, want %v", gotNotEmpty, tt.wantNotEmpty)
+			}
+			if b.String() != tt.want {
+				t.Errorf("JSONWriteExtensions() wrote = %s, want %s", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestExtensions_Gob(t *testing.T) {
+	tests := []struct {
+		name string
+		it   Item
+	}{
+		{
+			name: "object",
+			it: &Object{
+				ID:         "https://example.com/1",
+				Type:       NoteType,
+				Extensions: Extensions{"directMessage": json.RawMessage(`true`)},
+			},
+		},
+		{
+			name: "actor",
+			it: &Actor{
+				ID:         "https://example.com/~jdoe",
+				Type:       PersonType,
+				Extensions: Extensions{"isCat": json.RawMessage(`true`)},
+			},
+		},
+		{
+			name: "activity",
+			it: &Activity{
+				ID:         "https://example.com/2",
+				Type:       LikeType,
+				Extensions: Extensions{"_misskey_reaction": json.RawMessage(`":star:"`)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := GobEncode(tt.it)
+			if err != nil {
+				t.Fatalf("GobEncode() error = %s", err)
+			}
+			got, err := GobDecode(raw)
+			if err != nil {
+				t.Fatalf("GobDecode() error = %s", err)
+			}
+			if !cmp.Equal(got, tt.it) {
+				t.Errorf("GobDecode() got = %s", cmp.Diff(tt.it, got))
+			}
+		})
+	}
+}
+
+func TestExtensions_CloneAndCopy(t *testing.T) {
+	ob := &Object{ID: "https://example.com/1", Extensions: Extensions{"directMessage": json.RawMessage(`true`)}}
+
+	cl := Clone(ob)
+	ob.Extensions.Set("directMessage", json.RawMessage(`false`))
+	_ = OnObject(cl, func(o *Object) error {
+		if v, _ := o.Extensions.Get("directMessage"); string(v) != `true` {
+			t.Errorf("Clone() extensions share memory with the source, got %s", v)
+		}
+		return nil
+	})
+
+	from := &Object{ID: "https://example.com/1", Extensions: Extensions{"_misskey_content": json.RawMessage(`"test"`)}}
+	if _, err := CopyItemProperties(ob, from); err != nil {
+		t.Fatalf("CopyItemProperties() error = %s", err)
+	}
+	want := Extensions{"directMessage": json.RawMessage(`false`), "_misskey_content": json.RawMessage(`"test"`)}
+	if !ob.Extensions.Equal(want) {
+		t.Errorf("CopyItemProperties() extensions = %s", cmp.Diff(want, ob.Extensions))
+	}
+}
+
+func TestExtensions_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		it   Item
+		want string
+	}{
+		{
+			name: "extensions are written after the activity properties",
+			it: &Activity{
+				Type:       CreateType,
+				Actor:      IRI("https://example.com/~jdoe"),
+				Extensions: Extensions{"foo": json.RawMessage(`1`)},
+			},
+			want: `{"type":"Create","actor":"https://example.com/~jdoe","foo":1}`,
+		},
+		{
+			name: "extensions are written after the actor properties",
+			it: &Actor{
+				Type:       PersonType,
+				Inbox:      IRI("https://example.com/~jdoe/inbox"),
+				Extensions: Extensions{"isCat": json.RawMessage(`true`)},
+			},
+			want: `{"type":"Person","inbox":"https://example.com/~jdoe/inbox","isCat":true}`,
+		},
+		{
+			name: "object with only extensions",
+			it:   &Object{Extensions: Extensions{"directMessage": json.RawMessage(`true`)}},
+			want: `{"directMessage":true}`,
+		},
+		{
+			name: "known property wins over extension",
+			it: &Object{
+				ID:         "https://example.com/1",
+				Extensions: Extensions{"id": json.RawMessage(`"dup"`), "directMessage": json.RawMessage(`true`)},
+			},
+			want: `{"id":"https://example.com/1","directMessage":true}`,
+		},
+		{
+			name: "known property of the outer type wins over extension",
+			it: &Actor{
+				Type:       PersonType,
+				Extensions: Extensions{"inbox": json.RawMessage(`"dup"`)},
+			},
+			want: `{"type":"Person"}`,
+		},
+		{
+			name: "known link property wins over extension",
+			it: &Link{
+				Href:       "https://example.com",
+				Extensions: Extensions{"href": json.RawMessage(`"dup"`)},
+			},
+			want: `{"href":"https://example.com"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalJSON(tt.it)
+			if err != nil {
+				t.Fatalf("MarshalJSON() error = %s", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("MarshalJSON() got = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,751 @@
+package activitypub
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"time"
+	"unsafe"
+)
+
+// ForgeFed types
+// The document reference can be found at: https://forgefed.org/spec/
+//
+// Only the Repository and the Ticket have their own structs. The other types don't add properties this package
+// knows about, so they are only type names: a Patch, Commit or Branch loads into an Object, a Push into an Activity,
+// and a TicketDependency into a Relationship, which are then handled with the OnObject, OnActivity and
+// OnRelationship helpers.
+const (
+	RepositoryType       ActivityVocabularyType = "Repository"
+	TicketType           ActivityVocabularyType = "Ticket"
+	PatchType            ActivityVocabularyType = "Patch"
+	CommitType           ActivityVocabularyType = "Commit"
+	BranchType           ActivityVocabularyType = "Branch"
+	PushType             ActivityVocabularyType = "Push"
+	TicketDependencyType ActivityVocabularyType = "TicketDependency"
+)
+
+// ForgeFedContextURI is the IRI of the JSON-LD context of the ForgeFed vocabulary
+const ForgeFedContextURI IRI = "https://forgefed.org/ns"
+
+// ForgeFedContext is the context for the ForgeFed types and properties.
+var ForgeFedContext = ContextDefinition{
+	IRI: ForgeFedContextURI,
+	Properties: []string{
+		"isResolved", "resolvedBy", "resolved", "assignedTo", "forks", "ticketsTrackedBy", "team", "cloneUri",
+	},
+	Types: ActivityVocabularyTypes{
+		RepositoryType, TicketType, PatchType, CommitType, BranchType, PushType, TicketDependencyType,
+	},
+}
+
+// Repository represents a version control system repository. It is an actor, which publishes its Push
+// activities, and can receive Tickets and Patches.
+type Repository struct {
+	// ID provides the globally unique identifier for anActivity Pub Object or Link.
+	ID ID `jsonld:"id,omitempty"`
+	// Type identifies the Activity Pub Object or Link type. Multiple values may be specified.
+	Type Typer `jsonld:"type,omitempty"`
+	// Name a simple, human-readable, plain-text name for the object.
+	// HTML markup MUST NOT be included. The name MAY be expressed using multiple language-tagged values.
+	Name NaturalLanguageValues `jsonld:"name,omitempty,collapsible"`
+	// Attachment identifies a resource attached or related to an object that potentially requires special handling.
+	// The intent is to provide a model that is at least semantically similar to attachments in email.
+	Attachment Item `jsonld:"attachment,omitempty"`
+	// AttributedTo identifies one or more entities to which this object is attributed. The attributed entities might not be Actors.
+	// For instance, an object might be attributed to the completion of another activity.
+	AttributedTo Item `jsonld:"attributedTo,omitempty"`
+	// Audience identifies one or more entities that represent the total population of entities
+	// for which the object can considered to be relevant.
+	Audience ItemCollection `jsonld:"audience,omitempty"`
+	// Content or textual representation of the Activity Pub Object encoded as a JSON string.
+	// By default, the value of content is HTML.
+	// The mediaType property can be used in the object to indicate a different content type.
+	// (The content MAY be expressed using multiple language-tagged values.)
+	Content NaturalLanguageValues `jsonld:"content,omitempty,collapsible"`
+	// Context identifies the context within which the object exists or an activity was performed.
+	// The notion of "context" used is intentionally vague.
+	// The intended function is to serve as a means of grouping objects and activities that share a
+	// common originating context or purpose. An example could be all activities relating to a common project or event.
+	Context Item `jsonld:"context,omitempty"`
+	// MediaType when used on an Object, identifies the MIME media type of the value of the content property.
+	// If not specified, the content property is assumed to contain text/html content.
+	MediaType MimeType `jsonld:"mediaType,omitempty"`
+	// EndTime the date and time describing the actual or expected ending time of the object.
+	// When used with an Activity object, for instance, the endTime property specifies the moment
+	// the activity concluded or is expected to conclude.
+	EndTime time.Time `jsonld:"endTime,omitempty"`
+	// Generator identifies the entity (e.g. an application) that generated the object.
+	Generator Item `jsonld:"generator,omitempty"`
+	// Icon indicates an entity that describes an icon for this object.
+	// The image should have an aspect ratio of one (horizontal) to one (vertical)
+	// and should be suitable for presentation at a small size.
+	Icon Item `jsonld:"icon,omitempty"`
+	// Image indicates an entity that describes an image for this object.
+	// Unlike the icon property, there are no aspect ratio or display size limitations assumed.
+	Image Item `jsonld:"image,omitempty"`
+	// InReplyTo indicates one or more entities for which this object is considered a response.
+	InReplyTo Item `jsonld:"inReplyTo,omitempty"`
+	// Location indicates one or more physical or logical locations associated with the object.
+	Location Item `jsonld:"location,omitempty"`
+	// Preview identifies an entity that provides a preview of this object.
+	Preview Item `jsonld:"preview,omitempty"`
+	// Published the date and time at which the object was published
+	Published time.Time `jsonld:"published,omitempty"`
+	// Replies identifies a Collection containing objects considered to be responses to this object.
+	Replies Item `jsonld:"replies,omitempty"`
+	// StartTime the date and time describing the actual or expected starting time of the object.
+	// When used with an Activity object, for instance, the startTime property specifies
+	// the moment the activity began or is scheduled to begin.
+	StartTime time.Time `jsonld:"startTime,omitempty"`
+	// Summary a natural language summarization of the object encoded as HTML.
+	// *Multiple language tagged summaries may be provided.)
+	Summary NaturalLanguageValues `jsonld:"summary,omitempty,collapsible"`
+	// Tag one or more "tags" that have been associated with an objects. A tag can be any kind of Activity Pub Object.
+	// The key difference between attachment and tag is that the former implies association by inclusion,
+	// while the latter implies associated by reference.
+	Tag ItemCollection `jsonld:"tag,omitempty"`
+	// Updated the date and time at which the object was updated
+	Updated time.Time `jsonld:"updated,omitempty"`
+	// URL identifies one or more links to representations of the object
+	URL Item `jsonld:"url,omitempty"`
+	// To identifies an entity considered to be part of the public primary audience of an Activity Pub Object
+	To ItemCollection `jsonld:"to,omitempty"`
+	// Bto identifies anActivity Pub Object that is part of the private primary audience of this Activity Pub Object.
+	Bto ItemCollection `jsonld:"bto,omitempty"`
+	// CC identifies anActivity Pub Object that is part of the public secondary audience of this Activity Pub Object.
+	CC ItemCollection `jsonld:"cc,omitempty"`
+	// BCC identifies one or more Objects that are part of the private secondary audience of this Activity Pub Object.
+	BCC ItemCollection `jsonld:"bcc,omitempty"`
+	// Duration when the object describes a time-bound resource, such as an audio or video, a meeting, etc,
+	// the duration property indicates the object's approximate duration.
+	// The value must be expressed as an xsd:duration as defined by [ xmlschema11-2],
+	// section 3.3.6 (e.g. a period of 5 seconds is represented as "PT5S").
+	Duration time.Duration `jsonld:"duration,omitempty"`
+	// This is a list of all Like activities with this object as the object property, added as a side effect.
+	// The likes collection MUST be either an OrderedCollection or a Collection and MAY be filtered on privileges
+	// of an authenticated user or as appropriate when no authentication is given.
+	Likes Item `jsonld:"likes,omitempty"`
+	// This is a list of all Announce activities with this object as the object property, added as a side effect.
+	// The shares collection MUST be either an OrderedCollection or a Collection and MAY be filtered on privileges
+	// of an authenticated user or as appropriate when no authentication is given.
+	Shares Item `jsonld:"shares,omitempty"`
+	// Source property is intended to convey some sort of source from which the content markup was derived,
+	// as a form of provenance, or to support future editing by clients.
+	// In general, clients do the conversion from source to content, not the other way around.
+	Source Source `jsonld:"source,omitempty"`
+	// Sensitive marks the content of the object as not safe for all audiences, so it should be hidden
+	// behind its Summary. Like FocalPoint and Blurhash, it is a Mastodon extension to the vocabulary.
+	Sensitive bool `jsonld:"sensitive,omitempty"`
+	// FocalPoint is the point of an image which must stay visible when it is cropped, as x and y
+	// coordinates between -1.0 and 1.0.
+	FocalPoint []float64 `jsonld:"focalPoint,omitempty"`
+	// Blurhash is a compact representation of a blurred placeholder for an image.
+	Blurhash string `jsonld:"blurhash,omitempty"`
+	// Proof holds the Data Integrity proof of the document, like the eddsa-jcs-2022 ones of FEP-8b32.
+	// The proof sets, which hold more than one proof, are kept unchanged in the Extensions.
+	Proof *DataIntegrityProof `jsonld:"proof,omitempty"`
+	// Extensions holds the properties of the document that are not part of the vocabulary known to this package,
+	// like the ones coming from ActivityStreams extensions or custom namespaces.
+	// They are kept as raw JSON values so they can be written back unchanged.
+	Extensions Extensions `jsonld:"-"`
+	// A reference to an [ActivityStreams] OrderedCollection comprised of all the messages received by the actor;
+	// see 5.2 Inbox.
+	Inbox Item `jsonld:"inbox,omitempty"`
+	// An [ActivityStreams] OrderedCollection comprised of all the messages produced by the actor;
+	// see 5.1 outbox.
+	Outbox Item `jsonld:"outbox,omitempty"`
+	// A link to an [ActivityStreams] collection of the actors that this actor is following;
+	// see 5.4 Following Collection
+	Following Item `jsonld:"following,omitempty"`
+	// A link to an [ActivityStreams] collection of the actors that follow this actor;
+	// see 5.3 Followers Collection.
+	Followers Item `jsonld:"followers,omitempty"`
+	// A link to an [ActivityStreams] collection of objects this actor has liked;
+	// see 5.5 Liked Collection.
+	Liked Item `jsonld:"liked,omitempty"`
+	// A short username which may be used to refer to the actor, with no uniqueness guarantees.
+	PreferredUsername NaturalLanguageValues `jsonld:"preferredUsername,omitempty,collapsible"`
+	// A json object which maps additional (typically server/domain-wide) endpoints which may be useful either
+	// for this actor or someone referencing this actor.
+	// This mapping may be nested inside the actor document as the value or may be a link
+	// to a JSON-LD document with these properties.
+	Endpoints *Endpoints `jsonld:"endpoints,omitempty"`
+	// A list of supplementary Collections which may be of interest.
+	Streams   ItemCollection `jsonld:"streams,omitempty"`
+	PublicKey PublicKey      `jsonld:"publicKey,omitempty"`
+	// AssertionMethod holds the keys which the actor uses for signing, like the integrity proofs of
+	// its activities. Unlike the PublicKey, it can contain multiple keys, eg: during a key rotation.
+	// See FEP-521a.
+	AssertionMethod []Multikey `jsonld:"assertionMethod,omitempty"`
+	// ManuallyApprovesFollowers shows that the actor reviews the Follow activities it receives, before accepting
+	// them. This, and the properties below, are Mastodon extensions to the vocabulary.
+	ManuallyApprovesFollowers bool `jsonld:"manuallyApprovesFollowers,omitempty"`
+	// Discoverable shows that the actor agrees to be suggested to other users, and listed in directories.
+	Discoverable bool `jsonld:"discoverable,omitempty"`
+	// Indexable shows that the actor agrees to have its public objects indexed by search engines.
+	Indexable bool `jsonld:"indexable,omitempty"`
+	// Featured is the collection of the objects pinned by the actor on its profile.
+	Featured Item `jsonld:"featured,omitempty"`
+	// FeaturedTags is the collection of the Hashtags featured by the actor on its profile.
+	FeaturedTags Item `jsonld:"featuredTags,omitempty"`
+	// MovedTo is the actor this one has moved to. It's set when the account was migrated.
+	MovedTo Item `jsonld:"movedTo,omitempty"`
+	// AlsoKnownAs holds the other actors which belong to the same person, like the ones it has moved from.
+	AlsoKnownAs ItemCollection `jsonld:"alsoKnownAs,omitempty"`
+	// PostingRestrictedToMods shows that only the Moderators of a Group can create new posts in it, while the
+	// other members can still reply to them. This, and the Moderators property, are Lemmy extensions to the
+	// vocabulary, used by the groups of FEP-1b12.
+	PostingRestrictedToMods bool `jsonld:"postingRestrictedToMods,omitempty"`
+	// Moderators is the collection of the actors which moderate the Group.
+	Moderators Item `jsonld:"moderators,omitempty"`
+	// Team is a Collection of the actors which have access to the repository.
+	Team Item `jsonld:"team,omitempty"`
+	// Forks is an OrderedCollection of the repositories which were forked from this one.
+	Forks Item `jsonld:"forks,omitempty"`
+	// TicketsTrackedBy identifies the actor which tracks the tickets of the repository, like a TicketTracker,
+	// or the repository itself.
+	TicketsTrackedBy Item `jsonld:"ticketsTrackedBy,omitempty"`
+	// CloneURI is the location, or locations, from which the repository can be cloned.
+	CloneURI Item `jsonld:"cloneUri,omitempty"`
+}
+
+// Ticket represents an item which requires work or attention, like an issue or a bug report.
+type Ticket struct {
+	// ID provides the globally unique identifier for anActivity Pub Object or Link.
+	ID ID `jsonld:"id,omitempty"`
+	// Type identifies the Activity Pub Object or Link type. Multiple values may be specified.
+	Type Typer `jsonld:"type,omitempty"`
+	// Name a simple, human-readable, plain-text name for the object.
+	// HTML markup MUST NOT be included. The name MAY be expressed using multiple language-tagged values.
+	Name NaturalLanguageValues `jsonld:"name,omitempty,collapsible"`
+	// Attachment identifies a resource attached or related to an object that potentially requires special handling.
+	// The intent is to provide a model that is at least semantically similar to attachments in email.
+	Attachment Item `jsonld:"attachment,omitempty"`
+	// AttributedTo identifies one or more entities to which this object is attributed. The attributed entities might not be Actors.
+	// For instance, an object might be attributed to the completion of another activity.
+	AttributedTo Item `jsonld:"attributedTo,omitempty"`
+	// Audience identifies one or more entities that represent the total population of entities
+	// for which the object can considered to be relevant.
+	Audience ItemCollection `jsonld:"audience,omitempty"`
+	// Content or textual representation of the Activity Pub Object encoded as a JSON string.
+	// By default, the value of content is HTML.
+	// The mediaType property can be used in the object to indicate a different content type.
+	// (The content MAY be expressed using multiple language-tagged values.)
+	Content NaturalLanguageValues `jsonld:"content,omitempty,collapsible"`
+	// Context identifies the context within which the object exists or an activity was performed.
+	// The notion of "context" used is intentionally vague.
+	// The intended function is to serve as a means of grouping objects and activities that share a
+	// common originating context or purpose. An example could be all activities relating to a common project or event.
+	Context Item `jsonld:"context,omitempty"`
+	// MediaType when used on an Object, identifies the MIME media type of the value of the content property.
+	// If not specified, the content property is assumed to contain text/html content.
+	MediaType MimeType `jsonld:"mediaType,omitempty"`
+	// EndTime the date and time describing the actual or expected ending time of the object.
+	// When used with an Activity object, for instance, the endTime property specifies the moment
+	// the activity concluded or is expected to conclude.
+	EndTime time.Time `jsonld:"endTime,omitempty"`
+	// Generator identifies the entity (e.g. an application) that generated the object.
+	Generator Item `jsonld:"generator,omitempty"`
+	// Icon indicates an entity that describes an icon for this object.
+	// The image should have an aspect ratio of one (horizontal) to one (vertical)
+	// and should be suitable for presentation at a small size.
+	Icon Item `jsonld:"icon,omitempty"`
+	// Image indicates an entity that describes an image for this object.
+	// Unlike the icon property, there are no aspect ratio or display size limitations assumed.
+	Image Item `jsonld:"image,omitempty"`
+	// InReplyTo indicates one or more entities for which this object is considered a response.
+	InReplyTo Item `jsonld:"inReplyTo,omitempty"`
+	// Location indicates one or more physical or logical locations associated with the object.
+	Location Item `jsonld:"location,omitempty"`
+	// Preview identifies an entity that provides a preview of this object.
+	Preview Item `jsonld:"preview,omitempty"`
+	// Published the date and time at which the object was published
+	Published time.Time `jsonld:"published,omitempty"`
+	// Replies identifies a Collection containing objects considered to be responses to this object.
+	Replies Item `jsonld:"replies,omitempty"`
+	// StartTime the date and time describing the actual or expected starting time of the object.
+	// When used with an Activity object, for instance, the startTime property specifies
+	// the moment the activity began or is scheduled to begin.
+	StartTime time.Time `jsonld:"startTime,omitempty"`
+	// Summary a natural language summarization of the object encoded as HTML.
+	// *Multiple language tagged summaries may be provided.)
+	Summary NaturalLanguageValues `jsonld:"summary,omitempty,collapsible"`
+	// Tag one or more "tags" that have been associated with an objects. A tag can be any kind of Activity Pub Object.
+	// The key difference between attachment and tag is that the former implies association by inclusion,
+	// while the latter implies associated by reference.
+	Tag ItemCollection `jsonld:"tag,omitempty"`
+	// Updated the date and time at which the object was updated
+	Updated time.Time `jsonld:"updated,omitempty"`
+	// URL identifies one or more links to representations of the object
+	URL Item `jsonld:"url,omitempty"`
+	// To identifies an entity considered to be part of the public primary audience of an Activity Pub Object
+	To ItemCollection `jsonld:"to,omitempty"`
+	// Bto identifies anActivity Pub Object that is part of the private primary audience of this Activity Pub Object.
+	Bto ItemCollection `jsonld:"bto,omitempty"`
+	// CC identifies anActivity Pub Object that is part of the public secondary audience of this Activity Pub Object.
+	CC ItemCollection `jsonld:"cc,omitempty"`
+	// BCC identifies one or more Objects that are part of the private secondary audience of this Activity Pub Object.
+	BCC ItemCollection `jsonld:"bcc,omitempty"`
+	// Duration when the object describes a time-bound resource, such as an audio or video, a meeting, etc,
+	// the duration property indicates the object's approximate duration.
+	// The value must be expressed as an xsd:duration as defined by [ xmlschema11-2],
+	// section 3.3.6 (e.g. a period of 5 seconds is represented as "PT5S").
+	Duration time.Duration `jsonld:"duration,omitempty"`
+	// This is a list of all Like activities with this object as the object property, added as a side effect.
+	// The likes collection MUST be either an OrderedCollection or a Collection and MAY be filtered on privileges
+	// of an authenticated user or as appropriate when no authentication is given.
+	Likes Item `jsonld:"likes,omitempty"`
+	// This is a list of all Announce activities with this object as the object property, added as a side effect.
+	// The shares collection MUST be either an OrderedCollection or a Collection and MAY be filtered on privileges
+	// of an authenticated user or as appropriate when no authentication is given.
+	Shares Item `jsonld:"shares,omitempty"`
+	// Source property is intended to convey some sort of source from which the content markup was derived,
+	// as a form of provenance, or to support future editing by clients.
+	// In general, clients do the conversion from source to content, not the other way around.
+	Source Source `jsonld:"source,omitempty"`
+	// Sensitive marks the content of the object as not safe for all audiences, so it should be hidden
+	// behind its Summary. Like FocalPoint and Blurhash, it is a Mastodon extension to the vocabulary.
+	Sensitive bool `jsonld:"sensitive,omitempty"`
+	// FocalPoint is the point of an image which must stay visible when it is cropped, as x and y
+	// coordinates between -1.0 and 1.0.
+	FocalPoint []float64 `jsonld:"focalPoint,omitempty"`
+	// Blurhash is a compact representation of a blurred placeholder for an image.
+	Blurhash string `jsonld:"blurhash,omitempty"`
+	// Proof holds the Data Integrity proof of the document, like the eddsa-jcs-2022 ones of FEP-8b32.
+	// The proof sets, which hold more than one proof, are kept unchanged in the Extensions.
+	Proof *DataIntegrityProof `jsonld:"proof,omitempty"`
+	// Extensions holds the properties of the document that are not part of the vocabulary known to this package,
+	// like the ones coming from ActivityStreams extensions or custom namespaces.
+	// They are kept as raw JSON values so they can be written back unchanged.
+	Extensions Extensions `jsonld:"-"`
+	// IsResolved shows that the work the Ticket requires is done, and the Ticket is closed.
+	IsResolved bool `jsonld:"isResolved,omitempty"`
+	// ResolvedBy identifies the actor which resolved the Ticket.
+	ResolvedBy Item `jsonld:"resolvedBy,omitempty"`
+	// Resolved is the time at which the Ticket was resolved.
+	Resolved time.Time `jsonld:"resolved,omitempty"`
+	// AssignedTo identifies the actors which are assigned to work on the Ticket.
+	AssignedTo Item `jsonld:"assignedTo,omitempty"`
+}
+
+// GetLink returns the IRI corresponding to the current Repository object
+func (r Repository) GetLink() IRI {
+	return IRI(r.ID)
+}
+
+// GetType returns the type of the current Repository
+func (r Repository) GetType() Typer {
+	return r.Type
+}
+
+// GetID returns the ID corresponding to the current Repository
+func (r Repository) GetID() ID {
+	return r.ID
+}
+
+// Match returns whether the receiver matches the ActivityVocabularyType arguments.
+func (r Repository) Match(tt ...ActivityVocabularyType) bool {
+	return ActivityVocabularyTypes(tt).Match(r.Type)
+}
+
+// UnmarshalJSON decodes an incoming JSON document into the receiver object.
+func (r *Repository) UnmarshalJSON(data []byte) error {
+	val, err := parseJSON(data)
+	if err != nil {
+		return err
+	}
+	return JSONLoadRepository(val, r)
+}
+
+// MarshalJSON encodes the receiver object to a JSON document.
+func (r Repository) MarshalJSON() ([]byte, error) {
+	return jsonMarshal(r)
+}
+
+func (r Repository) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
+	notEmpty := false
+	start := b.Len()
+	JSONWrite(b, '{')
+
+	_ = OnActor(r, func(a *Actor) error {
+		notEmpty = jsonWriteActorValue(b, *a, opts)
+		return nil
+	})
+	if r.Team != nil {
+		notEmpty = jsonWriteItemProp(b, "team", r.Team, notEmpty, opts) || notEmpty
+	}
+	if r.Forks != nil {
+		notEmpty = jsonWriteItemProp(b, "forks", r.Forks, notEmpty, opts) || notEmpty
+	}
+	if r.TicketsTrackedBy != nil {
+		notEmpty = jsonWriteItemProp(b, "ticketsTrackedBy", r.TicketsTrackedBy, notEmpty, opts) || notEmpty
+	}
+	if r.CloneURI != nil {
+		notEmpty = jsonWriteItemProp(b, "cloneUri", r.CloneURI, notEmpty, opts) || notEmpty
+	}
+
+	if len(r.Extensions) > 0 {
+		notEmpty = JSONWriteExtensions(b, r.Extensions, notEmpty, objectProperties, actorProperties, repositoryProperties) || notEmpty
+	}
+	if !notEmpty {
+		b.Truncate(start)
+		return false
+	}
+	JSONWrite(b, '}')
+	return true
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (r *Repository) UnmarshalBinary(data []byte) error {
+	return r.GobDecode(data)
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (r Repository) MarshalBinary() ([]byte, error) {
+	return r.GobEncode()
+}
+
+// GobEncode
+func (r Repository) GobEncode() ([]byte, error) {
+	mm := make(map[string][]byte)
+	hasData, err := mapRepositoryProperties(mm, r)
+	if err != nil {
+		return nil, err
+	}
+	if !hasData {
+		return []byte{}, nil
+	}
+	bb := bytes.Buffer{}
+	g := gob.NewEncoder(&bb)
+	if err := g.Encode(mm); err != nil {
+		return nil, err
+	}
+	return bb.Bytes(), nil
+}
+
+// GobDecode
+func (r *Repository) GobDecode(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	mm, err := gobDecodeObjectAsMap(data)
+	if err != nil {
+		return err
+	}
+	return unmapRepositoryProperties(mm, r)
+}
+
+// Recipients performs recipient de-duplication on the Repository object's To, Bto, CC and BCC properties
+func (r *Repository) Recipients() ItemCollection {
+	aud := r.Audience
+	return ItemCollectionDeduplication(&r.To, &r.CC, &r.Bto, &r.BCC, &aud)
+}
+
+// Clean removes Bto and BCC properties
+func (r *Repository) Clean() Item {
+	rr := *r
+	rr.BCC = nil
+	rr.Bto = nil
+	CleanRecipients(rr.Audience)
+	CleanRecipients(rr.Attachment)
+	CleanRecipients(rr.Icon)
+	CleanRecipients(rr.Image)
+	CleanRecipients(rr.Context)
+	CleanRecipients(rr.Generator)
+	CleanRecipients(rr.AttributedTo)
+	CleanRecipients(rr.Preview)
+	CleanRecipients(rr.Tag)
+	return &rr
+}
+
+func (r Repository) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 's', 'v':
+		_, _ = fmt.Fprintf(s, "%T[%s] { }", r, r.GetType())
+	}
+}
+
+// Equals verifies if our receiver Repository is equals with the "with" Item
+func (r Repository) Equals(with Item) bool {
+	withRepository, err := ToRepository(with)
+	if err != nil {
+		return false
+	}
+	return r.equal(*withRepository)
+}
+
+// equal verifies if our receiver Repository is equals with the "with" Repository
+func (r Repository) equal(with Repository) bool {
+	result := true
+	_ = OnActor(r, func(a *Actor) error {
+		result = a.Equals(with)
+		return nil
+	})
+	if !result {
+		return false
+	}
+	if !ItemsEqual(r.Team, with.Team) {
+		return false
+	}
+	if !ItemsEqual(r.Forks, with.Forks) {
+		return false
+	}
+	if !ItemsEqual(r.TicketsTrackedBy, with.TicketsTrackedBy) {
+		return false
+	}
+	if !ItemsEqual(r.CloneURI, with.CloneURI) {
+		return false
+	}
+	return true
+}
+
+// ToRepository tries to convert the "it" Item to a Repository object
+func ToRepository(it LinkOrIRI) (*Repository, error) {
+	switch i := it.(type) {
+	case *Repository:
+		return i, nil
+	case Repository:
+		return &i, nil
+	case *Actor:
+		// NOTE(marius): the Repository shares the memory layout of the Actor, so an Actor can be converted,
+		// as long as we don't access the Repository specific properties, which the Actor doesn't have.
+		r := Repository{}
+		*(*Actor)(unsafe.Pointer(&r)) = *i
+		return &r, nil
+	case Actor:
+		r := Repository{}
+		*(*Actor)(unsafe.Pointer(&r)) = i
+		return &r, nil
+	default:
+		return reflectItemToType[Repository](it)
+	}
+}
+
+// OnRepository calls function fn on it Item if it can be asserted to type *Repository
+//
+// This function should be called if trying to access the Repository specific properties
+// like "team", "forks" or "cloneUri".
+// For the other properties OnActor should be used instead.
+func OnRepository(it LinkOrIRI, fn func(*Repository) error) error {
+	if IsNil(it) {
+		return nil
+	}
+	if IsItemCollection(it) {
+		return callOnItemCollection(it, OnRepository, fn)
+	}
+	r, err := ToRepository(it)
+	if err != nil {
+		return err
+	}
+	return fn(r)
+}
+
+// GetLink returns the IRI corresponding to the current Ticket object
+func (t Ticket) GetLink() IRI {
+	return IRI(t.ID)
+}
+
+// GetType returns the type of the current Ticket
+func (t Ticket) GetType() Typer {
+	return t.Type
+}
+
+// GetID returns the ID corresponding to the current Ticket
+func (t Ticket) GetID() ID {
+	return t.ID
+}
+
+// Match returns whether the receiver matches the ActivityVocabularyType arguments.
+func (t Ticket) Match(tt ...ActivityVocabularyType) bool {
+	return ActivityVocabularyTypes(tt).Match(t.Type)
+}
+
+// UnmarshalJSON decodes an incoming JSON document into the receiver object.
+func (t *Ticket) UnmarshalJSON(data []byte) error {
+	val, err := parseJSON(data)
+	if err != nil {
+		return err
+	}
+	return JSONLoadTicket(val, t)
+}
+
+// MarshalJSON encodes the receiver object to a JSON document.
+func (t Ticket) MarshalJSON() ([]byte, error) {
+	return jsonMarshal(t)
+}
+
+func (t Ticket) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
+	notEmpty := false
+	start := b.Len()
+	JSONWrite(b, '{')
+
+	_ = OnObject(t, func(o *Object) error {
+		notEmpty = jsonWriteObjectValue(b, *o, opts)
+		return nil
+	})
+	if t.IsResolved {
+		notEmpty = JSONWriteProp(b, "isResolved", []byte("true"), notEmpty) || notEmpty
+	}
+	if t.ResolvedBy != nil {
+		notEmpty = jsonWriteItemProp(b, "resolvedBy", t.ResolvedBy, notEmpty, opts) || notEmpty
+	}
+	if !t.Resolved.IsZero() {
+		notEmpty = JSONWriteTimeProp(b, "resolved", t.Resolved, notEmpty) || notEmpty
+	}
+	if t.AssignedTo != nil {
+		notEmpty = jsonWriteItemProp(b, "assignedTo", t.AssignedTo, notEmpty, opts) || notEmpty
+	}
+
+	if len(t.Extensions) > 0 {
+		notEmpty = JSONWriteExtensions(b, t.Extensions, notEmpty, objectProperties, ticketProperties) || notEmpty
+	}
+	if !notEmpty {
+		b.Truncate(start)
+		return false
+	}
+	JSONWrite(b, '}')
+	return true
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (t *Ticket) UnmarshalBinary(data []byte) error {
+	return t.GobDecode(data)
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (t Ticket) MarshalBinary() ([]byte, error) {
+	return t.GobEncode()
+}
+
+// GobEncode
+func (t Ticket) GobEncode() ([]byte, error) {
+	mm := make(map[string][]byte)
+	hasData, err := mapTicketProperties(mm, t)
+	if err != nil {
+		return nil, err
+	}
+	if !hasData {
+		return []byte{}, nil
+	}
+	bb := bytes.Buffer{}
+	g := gob.NewEncoder(&bb)
+	if err := g.Encode(mm); err != nil {
+		return nil, err
+	}
+	return bb.Bytes(), nil
+}
+
+// GobDecode
+func (t *Ticket) GobDecode(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	mm, err := gobDecodeObjectAsMap(data)
+	if err != nil {
+		return err
+	}
+	return unmapTicketProperties(mm, t)
+}
+
+// Recipients performs recipient de-duplication on the Ticket object's To, Bto, CC and BCC properties
+func (t *Ticket) Recipients() ItemCollection {
+	aud := t.Audience
+	return ItemCollectionDeduplication(&t.To, &t.CC, &t.Bto, &t.BCC, &aud)
+}
+
+// Clean removes Bto and BCC properties
+func (t *Ticket) Clean() Item {
+	tt := *t
+	tt.BCC = nil
+	tt.Bto = nil
+	CleanRecipients(tt.Audience)
+	CleanRecipients(tt.Attachment)
+	CleanRecipients(tt.Icon)
+	CleanRecipients(tt.Image)
+	CleanRecipients(tt.Context)
+	CleanRecipients(tt.Generator)
+	CleanRecipients(tt.AttributedTo)
+	CleanRecipients(tt.Preview)
+	CleanRecipients(tt.Tag)
+	return &tt
+}
+
+func (t Ticket) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 's', 'v':
+		_, _ = fmt.Fprintf(s, "%T[%s] { }", t, t.GetType())
+	}
+}
+
+// Equals verifies if our receiver Ticket is equals with the "with" Item
+func (t Ticket) Equals(with Item) bool {
+	withTicket, err := ToTicket(with)
+	if err != nil {
+		return false
+	}
+	return t.equal(*withTicket)
+}
+
+// equal verifies if our receiver Ticket is equals with the "with" Ticket
+func (t Ticket) equal(with Ticket) bool {
+	result := true
+	_ = OnObject(t, func(o *Object) error {
+		result = o.Equals(with)
+		return nil
+	})
+	if !result {
+		return false
+	}
+	if t.IsResolved != with.IsResolved {
+		return false
+	}
+	if !ItemsEqual(t.ResolvedBy, with.ResolvedBy) {
+		return false
+	}
+	if !t.Resolved.Equal(with.Resolved) {
+		return false
+	}
+	return ItemsEqual(t.AssignedTo, with.AssignedTo)
+}
+
+// ToTicket tries to convert the "it" Item to a Ticket object
+func ToTicket(it LinkOrIRI) (*Ticket, error) {
+	switch i := it.(type) {
+	case *Ticket:
+		return i, nil
+	case Ticket:
+		return &i, nil
+	case *Object:
+		// NOTE(marius): the Ticket shares the memory layout of the Object, so an Object can be converted,
+		// as long as we don't access the Ticket specific properties, which the Object doesn't have.
+		t := Ticket{}
+		*(*Object)(unsafe.Pointer(&t)) = *i
+		return &t, nil
+	case Object:
+		t := Ticket{}
+		*(*Object)(unsafe.Pointer(&t)) = i
+		return &t, nil
+	default:
+		return reflectItemToType[Ticket](it)
+	}
+}
+
+// OnTicket calls function fn on it Item if it can be asserted to type *Ticket
+//
+// This function should be called if trying to access the Ticket specific properties
+// like "isResolved", "resolvedBy", "resolved" or "assignedTo".
+// For the other properties OnObject should be used instead.
+func OnTicket(it LinkOrIRI, fn func(*Ticket) error) error {
+	if IsNil(it) {
+		return nil
+	}
+	if IsItemCollection(it) {
+		return callOnItemCollection(it, OnTicket, fn)
+	}
+	t, err := ToTicket(it)
+	if err != nil {
+		return err
+	}
+	return fn(t)
+}
@@ -0,0 +1,214 @@
+package activitypub
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRepository_ForgeFed(t *testing.T) {
+	data := []byte(`{"@context":["https://www.w3.org/ns/activitystreams","https://forgefed.org/ns"],` +
+		`"id":"https://forge.example/alice/repo","type":"Repository","name":"repo",` +
+		`"inbox":"https://forge.example/alice/repo/inbox","outbox":"https://forge.example/alice/repo/outbox",` +
+		`"team":"https://forge.example/alice/repo/team","forks":"https://forge.example/alice/repo/forks",` +
+		`"ticketsTrackedBy":"https://forge.example/alice/repo","cloneUri":"https://forge.example/alice/repo.git"}`)
+	want := &Repository{
+		ID:               "https://forge.example/alice/repo",
+		Type:             RepositoryType,
+		Name:             DefaultNaturalLanguage("repo"),
+		Inbox:            IRI("https://forge.example/alice/repo/inbox"),
+		Outbox:           IRI("https://forge.example/alice/repo/outbox"),
+		Team:             IRI("https://forge.example/alice/repo/team"),
+		Forks:            IRI("https://forge.example/alice/repo/forks"),
+		TicketsTrackedBy: IRI("https://forge.example/alice/repo"),
+		CloneURI:         IRI("https://forge.example/alice/repo.git"),
+	}
+
+	it, err := UnmarshalJSON(data)
+	if err != nil {
+		t.Fatalf("UnmarshalJSON() error = %s", err)
+	}
+	if !cmp.Equal(it, want) {
+		t.Fatalf("UnmarshalJSON() got = %s", cmp.Diff(want, it))
+	}
+
+	raw, err := MarshalJSONLD(it)
+	if err != nil {
+		t.Fatalf("MarshalJSONLD() error = %s", err)
+	}
+	if !bytes.Equal(raw, data) {
+		t.Errorf("MarshalJSONLD() got = %s, want %s", raw, data)
+	}
+
+	g, err := GobEncode(it)
+	if err != nil {
+		t.Fatalf("GobEncode() error = %s", err)
+	}
+	fromGob, err := GobDecode(g)
+	if err != nil {
+		t.Fatalf("GobDecode() error = %s", err)
+	}
+	if !cmp.Equal(fromGob, want) {
+		t.Errorf("GobDecode() got = %s", cmp.Diff(want, fromGob))
+	}
+
+	if !NotEmpty(want) || !ActorTypes.Match(want.GetType()) {
+		t.Errorf("Repository is not handled as an Actor")
+	}
+	forked := *want
+	forked.CloneURI = IRI("https://forge.example/bob/repo.git")
+	if want.Equals(&forked) {
+		t.Errorf("Equals() is true for repositories with different cloneUri")
+	}
+	renamed := *want
+	renamed.Inbox = IRI("https://forge.example/alice/other/inbox")
+	if want.Equals(&renamed) {
+		t.Errorf("Equals() is true for repositories with different inboxes")
+	}
+}
+
+func TestTicket_ForgeFed(t *testing.T) {
+	data := []byte(`{"id":"https://forge.example/alice/repo/issues/1","type":"Ticket","summary":"it doesn't build",` +
+		`"attributedTo":"https://forge.example/alice","context":"https://forge.example/alice/repo","isResolved":true,` +
+		`"resolvedBy":"https://forge.example/bob","resolved":"2024-03-01T10:00:00Z","assignedTo":"https://forge.example/bob"}`)
+	want := &Ticket{
+		ID:           "https://forge.example/alice/repo/issues/1",
+		Type:         TicketType,
+		AttributedTo: IRI("https://forge.example/alice"),
+		Context:      IRI("https://forge.example/alice/repo"),
+		Summary:      DefaultNaturalLanguage("it doesn't build"),
+		IsResolved:   true,
+		ResolvedBy:   IRI("https://forge.example/bob"),
+		Resolved:     time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		AssignedTo:   IRI("https://forge.example/bob"),
+	}
+
+	it, err := UnmarshalJSONStrict(data)
+	if err != nil {
+		t.Fatalf("UnmarshalJSONStrict() error = %s", err)
+	}
+	if !cmp.Equal(it, want) {
+		t.Fatalf("UnmarshalJSONStrict() got = %s", cmp.Diff(want, it))
+	}
+
+	raw, err := MarshalJSON(it)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %s", err)
+	}
+	if !bytes.Equal(raw, data) {
+		t.Errorf("MarshalJSON() got = %s, want %s", raw, data)
+	}
+
+	g, err := GobEncode(it)
+	if err != nil {
+		t.Fatalf("GobEncode() error = %s", err)
+	}
+	fromGob, err := GobDecode(g)
+	if err != nil {
+		t.Fatalf("GobDecode() error = %s", err)
+	}
+	if !cmp.Equal(fromGob, want) {
+		t.Errorf("GobDecode() got = %s", cmp.Diff(want, fromGob))
+	}
+
+	open := *want
+	open.IsResolved = false
+	if want.Equals(&open) {
+		t.Errorf("Equals() is true for tickets with different isResolved")
+	}
+
+	unassigned := *want
+	unassigned.AssignedTo = nil
+	if want.Equals(&unassigned) {
+		t.Errorf("Equals() is true for tickets with different assignedTo")
+	}
+
+	if _, err = UnmarshalJSONStrict([]byte(`{"type":"Ticket","isResolved":"yes"}`)); err == nil {
+		t.Errorf("UnmarshalJSONStrict() accepted a non boolean isResolved")
+	}
+	if _, err = UnmarshalJSONStrict([]byte(`{"type":"Ticket","resolved":"yesterday"}`)); err == nil {
+		t.Errorf("UnmarshalJSONStrict() accepted an invalid resolved time")
+	}
+}
+
+func TestGetItemByType_ForgeFed(t *testing.T) {
+	tests := []struct {
+		typ  ActivityVocabularyType
+		want Item
+	}{
+		{typ: RepositoryType, want: &Repository{Type: RepositoryType}},
+		{typ: TicketType, want: &Ticket{Type: TicketType}},
+		{typ: PatchType, want: &Object{Type: PatchType}},
+		{typ: CommitType, want: &Object{Type: CommitType}},
+		{typ: BranchType, want: &Object{Type: BranchType}},
+		{typ: PushType, want: &Activity{Type: PushType}},
+		{typ: TicketDependencyType, want: &Relationship{Type: TicketDependencyType}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.typ), func(t *testing.T) {
+			got, err := GetItemByType(tt.typ)
+			if err != nil {
+				t.Fatalf("GetItemByType() error = %s", err)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("GetItemByType() got = %s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestToRepository(t *testing.T) {
+	a := &Actor{ID: "https://forge.example/alice/repo", Type: RepositoryType, Inbox: IRI("https://forge.example/alice/repo/inbox")}
+
+	r, err := ToRepository(a)
+	if err != nil {
+		t.Fatalf("ToRepository() error = %s", err)
+	}
+	if r.ID != a.ID || r.Inbox != a.Inbox || r.Team != nil {
+		t.Errorf("ToRepository() got = %+v, want the properties of %+v", r, a)
+	}
+
+	r.Team = IRI("https://forge.example/alice/repo/team")
+	act, err := ToActor(r)
+	if err != nil {
+		t.Fatalf("ToActor() error = %s", err)
+	}
+	if act.ID != r.ID || act.Inbox != r.Inbox {
+		t.Errorf("ToActor() got = %+v, want the properties of %+v", act, r)
+	}
+	ob, err := ToObject(r)
+	if err != nil {
+		t.Fatalf("ToObject() error = %s", err)
+	}
+	if ob.ID != r.ID {
+		t.Errorf("ToObject() got = %+v, want the properties of %+v", ob, r)
+	}
+
+	if _, err = ToRepository(&Activity{Type: PushType}); err == nil {
+		t.Errorf("ToRepository() converted an Activity")
+	}
+}
+
+func TestOnTicket(t *testing.T) {
+	col := ItemCollection{
+		&Ticket{ID: "https://forge.example/alice/repo/issues/1", Type: TicketType},
+		&Object{ID: "https://forge.example/alice/repo/issues/2", Type: TicketType},
+	}
+	resolved := 0
+	err := OnTicket(col, func(t *Ticket) error {
+		t.IsResolved = true
+		resolved++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("OnTicket() error = %s", err)
+	}
+	if resolved != 2 {
+		t.Errorf("OnTicket() called fn %d times, want 2", resolved)
+	}
+	if !col[0].(*Ticket).IsResolved {
+		t.Errorf("OnTicket() didn't change the Ticket in the collection")
+	}
+}
@@ -0,0 +1,131 @@
+package activitypub
+
+import (
+	"github.com/go-ap/errors"
+)
+
+// GroupAnnouncedActivityTypes are the types of the activities that a Group announces to its followers,
+// when they are addressed to it.
+// The document reference can be found at: https://codeberg.org/fediverse/fep/src/branch/main/fep/1b12/fep-1b12.md
+var GroupAnnouncedActivityTypes = ActivityVocabularyTypes{
+	CreateType,
+	UpdateType,
+	DeleteType,
+	LikeType,
+	DislikeType,
+	UndoType,
+}
+
+// MaxAnnounceDepth is the number of nested Announce activities that UnwrapAnnounce goes through
+// before giving up on finding the original activity.
+var MaxAnnounceDepth = 8
+
+var (
+	// ErrNotAGroup is returned when announcing an activity on behalf of an actor which is not a Group.
+	ErrNotAGroup = errors.Newf("actor is not a Group")
+	// ErrNotAnnounceable is returned when announcing an activity which a Group doesn't forward to its followers.
+	ErrNotAnnounceable = errors.Newf("activity can't be announced by a Group")
+	// ErrAnnounceDepth is returned when unwrapping more than MaxAnnounceDepth nested Announce activities.
+	ErrAnnounceDepth = errors.Newf("maximum Announce depth exceeded")
+)
+
+// GroupAnnounceNew initializes the Announce activity with which the group forwards the act activity, that was
+// addressed to it, to its followers.
+//
+// The activity is embedded in the Announce, so the followers don't need to dereference it. The Announce is
+// addressed to the followers of the group and, if act was public, to the Public collection.
+func GroupAnnounceNew(id ID, group Item, act Item) (*Announce, error) {
+	g, err := ToActor(group)
+	if err != nil {
+		return nil, errors.Annotatef(ErrNotAGroup, "%s", err)
+	}
+	if !GroupType.Match(g.GetType()) {
+		return nil, errors.Annotatef(ErrNotAGroup, "%s", g.GetType())
+	}
+	if !ActivityTypes.Match(act.GetType()) || !GroupAnnouncedActivityTypes.Match(act.GetType()) {
+		return nil, errors.Annotatef(ErrNotAnnounceable, "%s", act.GetType())
+	}
+
+	a := AnnounceNew(id, act)
+	a.Actor = g.GetLink()
+	a.To = ItemCollection{Followers.IRI(g)}
+	if addressedToPublic(act) {
+		a.CC = ItemCollection{PublicNS}
+	}
+	return a, nil
+}
+
+// IsViaGroup returns whether the "it" activity is an Announce with which a Group forwarded an activity addressed
+// to it, like in FEP-1b12.
+//
+// As the actors of the Announce activities are usually IRIs, we can't always check that they are Groups, so,
+// when the groups argument is not empty, the actor must be one of them.
+func IsViaGroup(it Item, groups ...Item) bool {
+	if IsNil(it) || !AnnounceType.Match(it.GetType()) {
+		return false
+	}
+	via := false
+	_ = OnActivity(it, func(a *Activity) error {
+		if IsNil(a.Object) || IsIRI(a.Object) || !GroupAnnouncedActivityTypes.Match(a.Object.GetType()) {
+			return nil
+		}
+		if IsObject(a.Actor) && !GroupType.Match(a.Actor.GetType()) {
+			return nil
+		}
+		if len(groups) == 0 {
+			via = true
+			return nil
+		}
+		for _, g := range groups {
+			if !IsNil(g) && a.Actor != nil && g.GetLink().Equal(a.Actor.GetLink()) {
+				via = true
+				break
+			}
+		}
+		return nil
+	})
+	return via
+}
+
+// addressedToPublic returns whether the Public collection is in the To, CC or Audience recipients of "it".
+// The Bto and BCC recipients are removed before the activity is delivered, so they don't make it public.
+func addressedToPublic(it Item) bool {
+	public := false
+	_ = OnObject(it, func(ob *Object) error {
+		for _, recipients := range []ItemCollection{ob.To, ob.CC, ob.Audience} {
+			for _, rec := range recipients {
+				if !IsNil(rec) && isPublicNS(rec.GetLink()) {
+					public = true
+				}
+			}
+		}
+		return nil
+	})
+	return public
+}
+
+// UnwrapAnnounce goes through the embedded objects of the "it" Announce activity, and of the Announce
+// activities nested in it, and returns the first object which is not an Announce.
+//
+// If "it" is not an Announce it's returned unchanged. If one of the Announce activities in the chain
+// doesn't embed its object, the IRI of the object is returned.
+func UnwrapAnnounce(it Item) (Item, error) {
+	for range MaxAnnounceDepth {
+		if IsNil(it) || IsIRI(it) || !AnnounceType.Match(it.GetType()) {
+			return it, nil
+		}
+		var ob Item
+		err := OnActivity(it, func(a *Activity) error {
+			ob = a.Object
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		it = ob
+	}
+	if IsNil(it) || IsIRI(it) || !AnnounceType.Match(it.GetType()) {
+		return it, nil
+	}
+	return nil, errors.Annotatef(ErrAnnounceDepth, "%d", MaxAnnounceDepth)
+}
@@ -0,0 +1,265 @@
+package activitypub
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var (
+	mockGroup = &Actor{
+		ID:        "https://lemmy.example/c/tenforward",
+		Type:      GroupType,
+		Followers: IRI("https://lemmy.example/c/tenforward/followers"),
+	}
+	mockGroupNote = &Object{
+		ID:           "https://mastodon.example/users/alice/statuses/1",
+		Type:         NoteType,
+		AttributedTo: IRI("https://mastodon.example/users/alice"),
+	}
+	mockGroupCreate = &Activity{
+		ID:     "https://mastodon.example/users/alice/statuses/1/activity",
+		Type:   CreateType,
+		Actor:  IRI("https://mastodon.example/users/alice"),
+		To:     ItemCollection{mockGroup.GetLink(), PublicNS},
+		Object: mockGroupNote,
+	}
+)
+
+func TestGroupAnnounceNew(t *testing.T) {
+	followersOnly := *mockGroupCreate
+	followersOnly.To = ItemCollection{mockGroup.GetLink()}
+	blindPublic := followersOnly
+	blindPublic.Bto = ItemCollection{PublicNS}
+	blindPublic.BCC = ItemCollection{PublicNS}
+
+	tests := []struct {
+		name    string
+		group   Item
+		act     Item
+		want    *Announce
+		wantErr error
+	}{
+		{
+			name:  "public create",
+			group: mockGroup,
+			act:   mockGroupCreate,
+			want: &Announce{
+				ID:      "https://lemmy.example/activities/announce/1",
+				Type:    AnnounceType,
+				Name:    NaturalLanguageValuesNew(),
+				Content: NaturalLanguageValuesNew(),
+				Actor:   mockGroup.GetLink(),
+				To:      ItemCollection{IRI("https://lemmy.example/c/tenforward/followers")},
+				CC:      ItemCollection{PublicNS},
+				Object:  mockGroupCreate,
+			},
+		},
+		{
+			name:  "create for the followers",
+			group: mockGroup,
+			act:   &followersOnly,
+			want: &Announce{
+				ID:      "https://lemmy.example/activities/announce/1",
+				Type:    AnnounceType,
+				Name:    NaturalLanguageValuesNew(),
+				Content: NaturalLanguageValuesNew(),
+				Actor:   mockGroup.GetLink(),
+				To:      ItemCollection{IRI("https://lemmy.example/c/tenforward/followers")},
+				Object:  &followersOnly,
+			},
+		},
+		{
+			name:  "create with Public in bto and bcc",
+			group: mockGroup,
+			act:   &blindPublic,
+			want: &Announce{
+				ID:      "https://lemmy.example/activities/announce/1",
+				Type:    AnnounceType,
+				Name:    NaturalLanguageValuesNew(),
+				Content: NaturalLanguageValuesNew(),
+				Actor:   mockGroup.GetLink(),
+				To:      ItemCollection{IRI("https://lemmy.example/c/tenforward/followers")},
+				Object:  &blindPublic,
+			},
+		},
+		{
+			name:  "group without followers",
+			group: &Actor{ID: "https://lemmy.example/c/tenforward", Type: GroupType},
+			act:   LikeNew("https://mastodon.example/likes/1", mockGroupNote),
+			want: &Announce{
+				ID:      "https://lemmy.example/activities/announce/1",
+				Type:    AnnounceType,
+				Name:    NaturalLanguageValuesNew(),
+				Content: NaturalLanguageValuesNew(),
+				Actor:   mockGroup.GetLink(),
+				To:      ItemCollection{IRI("https://lemmy.example/c/tenforward/followers")},
+				Object:  LikeNew("https://mastodon.example/likes/1", mockGroupNote),
+			},
+		},
+		{
+			name:    "person",
+			group:   PersonNew("https://mastodon.example/users/alice"),
+			act:     mockGroupCreate,
+			wantErr: ErrNotAGroup,
+		},
+		{
+			name:    "group IRI",
+			group:   mockGroup.GetLink(),
+			act:     mockGroupCreate,
+			wantErr: ErrNotAGroup,
+		},
+		{
+			name:    "follow",
+			group:   mockGroup,
+			act:     FollowNew("https://mastodon.example/follows/1", mockGroup.GetLink()),
+			wantErr: ErrNotAnnounceable,
+		},
+		{
+			name:    "note",
+			group:   mockGroup,
+			act:     mockGroupNote,
+			wantErr: ErrNotAnnounceable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GroupAnnounceNew("https://lemmy.example/activities/announce/1", tt.group, tt.act)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("GroupAnnounceNew() error = %v, want %v", err, tt.wantErr)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("GroupAnnounceNew() got = %s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestIsViaGroup(t *testing.T) {
+	announce, err := GroupAnnounceNew("https://lemmy.example/activities/announce/1", mockGroup, mockGroupCreate)
+	if err != nil {
+		t.Fatalf("GroupAnnounceNew() error = %s", err)
+	}
+	byPerson := *announce
+	byPerson.Actor = PersonNew("https://mastodon.example/users/bob")
+	byGroup := *announce
+	byGroup.Actor = mockGroup
+
+	tests := []struct {
+		name   string
+		it     Item
+		groups ItemCollection
+		want   bool
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name: "create",
+			it:   mockGroupCreate,
+		},
+		{
+			name: "announce by a group",
+			it:   announce,
+			want: true,
+		},
+		{
+			name: "announce with an embedded group",
+			it:   &byGroup,
+			want: true,
+		},
+		{
+			name:   "announce by one of the groups",
+			it:     announce,
+			groups: ItemCollection{IRI("https://lemmy.example/c/other"), mockGroup},
+			want:   true,
+		},
+		{
+			name:   "announce by another group",
+			it:     announce,
+			groups: ItemCollection{IRI("https://lemmy.example/c/other")},
+		},
+		{
+			name: "announce by a person",
+			it:   &byPerson,
+		},
+		{
+			name: "boost of a note",
+			it:   AnnounceNew("https://mastodon.example/users/bob/statuses/2/activity", mockGroupNote),
+		},
+		{
+			name: "announce of an activity IRI",
+			it:   AnnounceNew("https://lemmy.example/activities/announce/2", mockGroupCreate.GetLink()),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsViaGroup(tt.it, tt.groups...); got != tt.want {
+				t.Errorf("IsViaGroup() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnwrapAnnounce(t *testing.T) {
+	nested := func(depth int, ob Item) Item {
+		it := ob
+		for range depth {
+			it = AnnounceNew("", it)
+		}
+		return it
+	}
+
+	tests := []struct {
+		name    string
+		it      Item
+		want    Item
+		wantErr error
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name: "create",
+			it:   mockGroupCreate,
+			want: mockGroupCreate,
+		},
+		{
+			name: "announce",
+			it:   nested(1, mockGroupCreate),
+			want: mockGroupCreate,
+		},
+		{
+			name: "nested announces",
+			it:   nested(3, mockGroupCreate),
+			want: mockGroupCreate,
+		},
+		{
+			name: "announce of an IRI",
+			it:   nested(2, mockGroupCreate.GetLink()),
+			want: mockGroupCreate.GetLink(),
+		},
+		{
+			name: "max depth",
+			it:   nested(MaxAnnounceDepth, mockGroupNote),
+			want: mockGroupNote,
+		},
+		{
+			name:    "too deep",
+			it:      nested(MaxAnnounceDepth+1, mockGroupNote),
+			wantErr: ErrAnnounceDepth,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnwrapAnnounce(tt.it)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("UnwrapAnnounce() error = %v, want %v", err, tt.wantErr)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("UnwrapAnnounce() got = %s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
@@ -206,7 +206,11 @@ func notEmptyObject(o *Object) bool {
 		o.Tag != nil ||
 		o.To != nil ||
 		!o.Updated.IsZero() ||
-		o.URL != nil
+		o.URL != nil ||
+		o.Sensitive ||
+		len(o.FocalPoint) > 0 ||
+		len(o.Blurhash) > 0 ||
+		o.Proof != nil
 }
 
 func notEmptyInstransitiveActivity(i *IntransitiveActivity) bool {
@@ -231,7 +235,7 @@ func notEmptyActivity(a *Activity) bool {
 		notEmpty = notEmptyInstransitiveActivity(i)
 		return nil
 	})
-	return notEmpty || a.Object != nil
+	return notEmpty || a.Object != nil || a.Signature != nil
 }
 
 func notEmptyActor(a *Actor) bool {
@@ -249,7 +253,17 @@ func notEmptyActor(a *Actor) bool {
 		a.PreferredUsername != nil ||
 		a.Endpoints != nil ||
 		a.Streams != nil ||
-		len(a.PublicKey.ID)+len(a.PublicKey.Owner)+len(a.PublicKey.PublicKeyPem) > 0
+		len(a.PublicKey.ID)+len(a.PublicKey.Owner)+len(a.PublicKey.PublicKeyPem) > 0 ||
+		len(a.AssertionMethod) > 0 ||
+		a.ManuallyApprovesFollowers ||
+		a.Discoverable ||
+		a.Indexable ||
+		a.Featured != nil ||
+		a.FeaturedTags != nil ||
+		a.MovedTo != nil ||
+		len(a.AlsoKnownAs) > 0 ||
+		a.PostingRestrictedToMods ||
+		a.Moderators != nil
 }
 
 // NotEmpty tells us if an Item interface value has a non nil value for various types
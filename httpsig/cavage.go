@@ -0,0 +1,312 @@
+package httpsig
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	vocab "github.com/go-ap/activitypub"
+	"github.com/go-ap/errors"
+)
+
+const (
+	algorithmHS2019    = "hs2019"
+	algorithmRSASHA256 = "rsa-sha256"
+	algorithmEd25519   = "ed25519"
+	algorithmECDSA256  = "ecdsa-sha256"
+)
+
+// DefaultHeaders are the headers covered by the draft-cavage signatures of a Signer without explicit Headers.
+// The "digest" is signed only for requests with a body.
+var DefaultHeaders = []string{"(request-target)", "host", "date", "digest"}
+
+// signCavage adds the Date, the Digest and the draft-cavage Signature headers to r.
+func (s *Signer) signCavage(r *http.Request, body []byte) error {
+	alg, err := signatureAlgorithm(s.Key)
+	if err != nil {
+		return err
+	}
+	if r.Header.Get("Date") == "" {
+		r.Header.Set("Date", s.time().UTC().Format(http.TimeFormat))
+	}
+	headers := s.Headers
+	if len(headers) == 0 {
+		headers = DefaultHeaders
+	}
+	if len(body) > 0 {
+		r.Header.Set("Digest", Digest(body))
+	} else {
+		// NOTE(marius): requests without a body, like the GET requests for fetching objects, don't have a digest
+		headers = slices.DeleteFunc(slices.Clone(headers), func(h string) bool { return strings.EqualFold(h, "digest") })
+	}
+	params := signatureParams{keyID: s.KeyID.String(), algorithm: alg, headers: headers}
+	data, err := params.signingString(r)
+	if err != nil {
+		return err
+	}
+	sig, err := sign(s.Key, data)
+	if err != nil {
+		return err
+	}
+	params.signature = sig
+	r.Header.Set("Signature", params.String())
+	return nil
+}
+
+// verifyCavage checks the draft-cavage signature of r.
+func (v *Verifier) verifyCavage(r *http.Request, body []byte) (vocab.PublicKey, error) {
+	var key vocab.PublicKey
+	params, err := parseSignature(r)
+	if err != nil {
+		return key, err
+	}
+	if err = checkRequired(params.headers, v.RequiredHeaders, DefaultHeaders, "digest", len(body) > 0); err != nil {
+		return key, err
+	}
+	if err = v.checkCavageTime(r, params); err != nil {
+		return key, err
+	}
+	if d := r.Header.Get("Digest"); d != "" {
+		if err = checkDigest(d, body); err != nil {
+			return key, err
+		}
+	}
+	data, err := params.signingString(r)
+	if err != nil {
+		return key, err
+	}
+	key, pub, err := v.loadKey(r, params.keyID)
+	if err != nil {
+		return key, err
+	}
+	if err = verify(pub, params.algorithm, data, params.signature); err != nil {
+		return key, err
+	}
+	return key, nil
+}
+
+func (v *Verifier) checkCavageTime(r *http.Request, params signatureParams) error {
+	now, skew := v.time(), v.skew()
+	if slices.Contains(params.headers, "date") {
+		date, err := http.ParseTime(r.Header.Get("Date"))
+		if err != nil {
+			return verifyErr(ErrMissingHeader, errors.Annotatef(err, "invalid date"))
+		}
+		if d := now.Sub(date); d > skew || d < -skew {
+			return verifyErr(ErrClockSkew, errors.Newf("date %s", date.Format(time.RFC3339)))
+		}
+	}
+	return checkCreated(now, skew, params.created, params.expires)
+}
+
+// signatureParams are the parameters of the Signature header
+type signatureParams struct {
+	keyID     string
+	algorithm string
+	headers   []string
+	created   time.Time
+	expires   time.Time
+	signature []byte
+}
+
+// parseSignature loads the parameters from the Signature header, or from the Authorization header
+// with the "Signature" scheme.
+func parseSignature(r *http.Request) (signatureParams, error) {
+	params := signatureParams{}
+	raw := ""
+	if r.Header.Get("Signature-Input") == "" {
+		// NOTE(marius): when there's a Signature-Input header, the Signature header belongs to RFC 9421
+		raw = r.Header.Get("Signature")
+	}
+	if raw == "" {
+		auth := r.Header.Get("Authorization")
+		if scheme, rest, ok := strings.Cut(auth, " "); ok && strings.EqualFold(scheme, "Signature") {
+			raw = rest
+		}
+	}
+	if raw == "" {
+		return params, verifyErr(ErrMissingSignature, nil)
+	}
+	for raw = strings.TrimLeft(raw, " ,"); len(raw) > 0; raw = strings.TrimLeft(raw, " ,") {
+		var name, val string
+		var ok bool
+		name, raw, ok = strings.Cut(raw, "=")
+		if !ok {
+			return params, verifyErr(ErrInvalidSignatureHeader, errors.Newf("parameter %q without value", name))
+		}
+		if strings.HasPrefix(raw, `"`) {
+			end := strings.IndexByte(raw[1:], '"')
+			if end < 0 {
+				return params, verifyErr(ErrInvalidSignatureHeader, errors.Newf("unterminated value for %q", name))
+			}
+			val, raw = raw[1:end+1], raw[end+2:]
+		} else {
+			val, raw, _ = strings.Cut(raw, ",")
+		}
+		var err error
+		switch strings.TrimSpace(name) {
+		case "keyId":
+			params.keyID = val
+		case "algorithm":
+			params.algorithm = strings.ToLower(val)
+		case "headers":
+			params.headers = strings.Fields(strings.ToLower(val))
+		case "created":
+			params.created, err = parseUnixTime(val)
+		case "expires":
+			params.expires, err = parseUnixTime(val)
+		case "signature":
+			params.signature, err = base64.StdEncoding.DecodeString(val)
+		}
+		if err != nil {
+			return params, verifyErr(ErrInvalidSignatureHeader, err)
+		}
+	}
+	if params.keyID == "" || len(params.signature) == 0 {
+		return params, verifyErr(ErrInvalidSignatureHeader, errors.Newf("missing keyId or signature"))
+	}
+	if len(params.headers) == 0 {
+		params.headers = []string{"date"}
+	}
+	return params, nil
+}
+
+func parseUnixTime(s string) (time.Time, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)), nil
+}
+
+// String returns the value of the Signature header
+func (p signatureParams) String() string {
+	s := strings.Builder{}
+	s.WriteString(`keyId="` + p.keyID + `"`)
+	s.WriteString(`,algorithm="` + p.algorithm + `"`)
+	s.WriteString(`,headers="` + strings.Join(p.headers, " ") + `"`)
+	s.WriteString(`,signature="` + base64.StdEncoding.EncodeToString(p.signature) + `"`)
+	return s.String()
+}
+
+// signingString builds the string which is signed, from the values of the signed headers of r.
+func (p signatureParams) signingString(r *http.Request) ([]byte, error) {
+	s := strings.Builder{}
+	for i, h := range p.headers {
+		h = strings.ToLower(h)
+		var val string
+		switch h {
+		case "(request-target)":
+			val = strings.ToLower(r.Method) + " " + r.URL.RequestURI()
+		case "(created)":
+			if p.created.IsZero() {
+				return nil, verifyErr(ErrMissingHeader, errors.Newf("%q", h))
+			}
+			val = strconv.FormatInt(p.created.Unix(), 10)
+		case "(expires)":
+			if p.expires.IsZero() {
+				return nil, verifyErr(ErrMissingHeader, errors.Newf("%q", h))
+			}
+			val = strconv.FormatInt(p.expires.Unix(), 10)
+		case "host":
+			val = r.Host
+			if val == "" {
+				val = r.URL.Host
+			}
+		default:
+			values := r.Header.Values(h)
+			if len(values) == 0 {
+				return nil, verifyErr(ErrMissingHeader, errors.Newf("%q", h))
+			}
+			trimmed := make([]string, len(values))
+			for j, hv := range values {
+				trimmed[j] = strings.TrimSpace(hv)
+			}
+			val = strings.Join(trimmed, ", ")
+		}
+		if i > 0 {
+			s.WriteByte('\n')
+		}
+		s.WriteString(h + ": " + val)
+	}
+	return []byte(s.String()), nil
+}
+
+func signatureAlgorithm(key crypto.Signer) (string, error) {
+	if key == nil {
+		return "", errors.Newf("missing private key")
+	}
+	switch k := key.Public().(type) {
+	case *rsa.PublicKey:
+		return algorithmRSASHA256, nil
+	case ed25519.PublicKey:
+		return algorithmHS2019, nil
+	case *ecdsa.PublicKey:
+		if k.Curve.Params().Name == "P-256" {
+			return algorithmHS2019, nil
+		}
+	}
+	return "", errors.Newf("unsupported private key %T", key)
+}
+
+func sign(key crypto.Signer, data []byte) ([]byte, error) {
+	switch key.Public().(type) {
+	case ed25519.PublicKey:
+		return key.Sign(rand.Reader, data, crypto.Hash(0))
+	default:
+		sum := sha256.Sum256(data)
+		return key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	}
+}
+
+// verify checks the signature of data with the key, according to the algorithm parameter.
+// For "hs2019" the algorithm is derived from the key, like the specification requires.
+func verify(key crypto.PublicKey, alg string, data, sig []byte) error {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if alg != "" && alg != algorithmHS2019 && alg != algorithmRSASHA256 {
+			return verifyErr(ErrUnsupportedAlgorithm, errors.Newf("%q with an RSA key", alg))
+		}
+		sum := sha256.Sum256(data)
+		err := rsa.VerifyPKCS1v15(k, crypto.SHA256, sum[:], sig)
+		if err != nil && alg == algorithmHS2019 {
+			// NOTE(marius): the specification expects RSASSA-PSS with SHA-512 for "hs2019", but most
+			// servers use the same PKCS #1 v1.5 signatures as for "rsa-sha256", so we try both
+			sum := sha512.Sum512(data)
+			err = rsa.VerifyPSS(k, crypto.SHA512, sum[:], sig, nil)
+		}
+		if err != nil {
+			return verifyErr(ErrInvalidSignature, err)
+		}
+	case ed25519.PublicKey:
+		if alg != "" && alg != algorithmHS2019 && alg != algorithmEd25519 {
+			return verifyErr(ErrUnsupportedAlgorithm, errors.Newf("%q with an Ed25519 key", alg))
+		}
+		if !ed25519.Verify(k, data, sig) {
+			return verifyErr(ErrInvalidSignature, nil)
+		}
+	case *ecdsa.PublicKey:
+		if alg != "" && alg != algorithmHS2019 && alg != algorithmECDSA256 {
+			return verifyErr(ErrUnsupportedAlgorithm, errors.Newf("%q with an ECDSA key", alg))
+		}
+		sum := sha256.Sum256(data)
+		if !ecdsa.VerifyASN1(k, sum[:], sig) {
+			return verifyErr(ErrInvalidSignature, nil)
+		}
+	default:
+		return verifyErr(ErrUnsupportedAlgorithm, errors.Newf("key %T", key))
+	}
+	return nil
+}
@@ -0,0 +1,338 @@
+package httpsig
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	vocab "github.com/go-ap/activitypub"
+)
+
+type mockActor struct {
+	actor *vocab.Actor
+	key   crypto.Signer
+}
+
+func mockActors(t *testing.T) map[string]mockActor {
+	t.Helper()
+	rk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate RSA key: %s", err)
+	}
+	_, ek, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate Ed25519 key: %s", err)
+	}
+	ck, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate ECDSA key: %s", err)
+	}
+
+	actors := make(map[string]mockActor)
+	for name, key := range map[string]crypto.Signer{"rsa": rk, "ed25519": ek, "ecdsa": ck} {
+		a := &vocab.Actor{ID: vocab.ID("https://example.com/~" + name), Type: vocab.PersonType}
+		if a.PublicKey, err = vocab.NewPublicKey(key.Public(), a); err != nil {
+			t.Fatalf("unable to build the public key: %s", err)
+		}
+		actors[name] = mockActor{actor: a, key: key}
+	}
+	return actors
+}
+
+func mockKeyLoader(actors map[string]mockActor) KeyLoaderFn {
+	return func(_ context.Context, keyID vocab.IRI) (vocab.PublicKey, error) {
+		for _, a := range actors {
+			if a.actor.PublicKey.ID.String() == keyID.String() {
+				return a.actor.PublicKey, nil
+			}
+		}
+		return vocab.PublicKey{}, fmt.Errorf("key %s not found", keyID)
+	}
+}
+
+func mockActivity(actor *vocab.Actor) *vocab.Activity {
+	return &vocab.Activity{
+		ID:     "https://example.com/activities/1",
+		Type:   vocab.CreateType,
+		Actor:  actor.GetLink(),
+		To:     vocab.ItemCollection{vocab.PublicNS},
+		Object: &vocab.Object{ID: "https://example.com/objects/1", Type: vocab.NoteType},
+	}
+}
+
+func TestSigner_Sign_httptest(t *testing.T) {
+	actors := mockActors(t)
+	verifier := NewVerifier(mockKeyLoader(actors))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, err := verifier.Verify(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		it, err := vocab.UnmarshalJSON(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		act, err := vocab.ToActivity(it)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err = key.CheckOwner(act.Actor); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	for name, a := range actors {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewActivityRequest(context.Background(), vocab.IRI(srv.URL+"/inbox"), mockActivity(a.actor))
+			if err != nil {
+				t.Fatalf("NewActivityRequest() error = %s", err)
+			}
+			if err = NewSigner(vocab.IRI(a.actor.PublicKey.ID), a.key).Sign(r); err != nil {
+				t.Fatalf("Sign() error = %s", err)
+			}
+			res, err := srv.Client().Do(r)
+			if err != nil {
+				t.Fatalf("unable to send the request: %s", err)
+			}
+			msg, _ := io.ReadAll(res.Body)
+			_ = res.Body.Close()
+			if res.StatusCode != http.StatusAccepted {
+				t.Errorf("the server responded with %s: %s", res.Status, msg)
+			}
+		})
+	}
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	actors := mockActors(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		method  string
+		actor   string
+		headers []string
+		// mod changes the request after it was signed
+		mod     func(r *http.Request)
+		signAt  time.Time
+		wantErr error
+	}{
+		{
+			name:   "valid",
+			method: http.MethodPost,
+			actor:  "rsa",
+		},
+		{
+			name:   "valid ed25519",
+			method: http.MethodPost,
+			actor:  "ed25519",
+		},
+		{
+			name:   "valid ecdsa",
+			method: http.MethodPost,
+			actor:  "ecdsa",
+		},
+		{
+			name:   "valid GET without digest",
+			method: http.MethodGet,
+			actor:  "rsa",
+		},
+		{
+			name:   "in Authorization header",
+			method: http.MethodPost,
+			actor:  "rsa",
+			mod: func(r *http.Request) {
+				r.Header.Set("Authorization", "Signature "+r.Header.Get("Signature"))
+				r.Header.Del("Signature")
+			},
+		},
+		{
+			name:   "within clock skew",
+			method: http.MethodPost,
+			actor:  "rsa",
+			signAt: now.Add(-4 * time.Minute),
+		},
+		{
+			name:    "missing signature",
+			method:  http.MethodPost,
+			actor:   "rsa",
+			mod:     func(r *http.Request) { r.Header.Del("Signature") },
+			wantErr: ErrMissingSignature,
+		},
+		{
+			name:    "invalid signature header",
+			method:  http.MethodPost,
+			actor:   "rsa",
+			mod:     func(r *http.Request) { r.Header.Set("Signature", `keyId="https://example.com/~rsa#main-key`) },
+			wantErr: ErrInvalidSignatureHeader,
+		},
+		{
+			name:    "too old",
+			method:  http.MethodPost,
+			actor:   "rsa",
+			signAt:  now.Add(-time.Hour),
+			wantErr: ErrClockSkew,
+		},
+		{
+			name:    "in the future",
+			method:  http.MethodPost,
+			actor:   "rsa",
+			signAt:  now.Add(10 * time.Minute),
+			wantErr: ErrClockSkew,
+		},
+		{
+			name:    "unsigned host",
+			method:  http.MethodPost,
+			actor:   "rsa",
+			headers: []string{"(request-target)", "date", "digest"},
+			wantErr: ErrUnsignedHeader,
+		},
+		{
+			name:    "unsigned digest",
+			method:  http.MethodPost,
+			actor:   "rsa",
+			headers: []string{"(request-target)", "host", "date"},
+			wantErr: ErrUnsignedHeader,
+		},
+		{
+			name:    "missing signed header",
+			method:  http.MethodPost,
+			actor:   "rsa",
+			headers: []string{"(request-target)", "host", "date", "digest", "content-type"},
+			mod:     func(r *http.Request) { r.Header.Del("Content-Type") },
+			wantErr: ErrMissingHeader,
+		},
+		{
+			name:   "tampered body",
+			method: http.MethodPost,
+			actor:  "rsa",
+			mod: func(r *http.Request) {
+				r.Body = io.NopCloser(strings.NewReader(`{"type":"Delete"}`))
+			},
+			wantErr: ErrDigestMismatch,
+		},
+		{
+			name:    "tampered header",
+			method:  http.MethodPost,
+			actor:   "rsa",
+			mod:     func(r *http.Request) { r.Host = "example.net" },
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:   "key with another algorithm",
+			method: http.MethodPost,
+			actor:  "rsa",
+			mod: func(r *http.Request) {
+				sig := r.Header.Get("Signature")
+				r.Header.Set("Signature", strings.Replace(sig, "~rsa", "~ed25519", 1))
+			},
+			wantErr: ErrUnsupportedAlgorithm,
+		},
+		{
+			name:   "unknown key",
+			method: http.MethodPost,
+			actor:  "rsa",
+			mod: func(r *http.Request) {
+				sig := r.Header.Get("Signature")
+				r.Header.Set("Signature", strings.Replace(sig, "~rsa", "~jdoe", 1))
+			},
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name:   "algorithm doesn't match the key",
+			method: http.MethodPost,
+			actor:  "rsa",
+			mod: func(r *http.Request) {
+				sig := r.Header.Get("Signature")
+				r.Header.Set("Signature", strings.Replace(sig, `algorithm="rsa-sha256"`, `algorithm="ed25519"`, 1))
+			},
+			wantErr: ErrUnsupportedAlgorithm,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := actors[tt.actor]
+			var body io.Reader
+			if tt.method == http.MethodPost {
+				raw, err := vocab.MarshalJSON(mockActivity(a.actor))
+				if err != nil {
+					t.Fatalf("MarshalJSON() error = %s", err)
+				}
+				body = strings.NewReader(string(raw))
+			}
+			r := httptest.NewRequest(tt.method, "https://example.com/inbox?page=1", body)
+			r.Header.Set("Content-Type", "application/activity+json")
+
+			signAt := tt.signAt
+			if signAt.IsZero() {
+				signAt = now
+			}
+			s := NewSigner(vocab.IRI(a.actor.PublicKey.ID), a.key)
+			if tt.headers != nil {
+				s.Headers = tt.headers
+			}
+			s.now = func() time.Time { return signAt }
+			if err := s.Sign(r); err != nil {
+				t.Fatalf("Sign() error = %s", err)
+			}
+			if tt.mod != nil {
+				tt.mod(r)
+			}
+
+			v := NewVerifier(mockKeyLoader(actors))
+			v.now = func() time.Time { return now }
+			key, err := v.Verify(r)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Verify() error = %s", err)
+				}
+				if key.ID != a.actor.PublicKey.ID {
+					t.Errorf("Verify() returned key %s, want %s", key.ID, a.actor.PublicKey.ID)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %s", err, tt.wantErr)
+			}
+			var vErr *vocab.Error
+			if !errors.As(err, &vErr) {
+				t.Errorf("Verify() error is %T, want *vocab.Error", err)
+			}
+		})
+	}
+}
+
+func TestDigest(t *testing.T) {
+	// NOTE(marius): the value from the examples of draft-cavage-http-signatures-12
+	body := []byte("{\"hello\": \"world\"}")
+	want := "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE="
+	if got := Digest(body); got != want {
+		t.Errorf("Digest() = %s, want %s", got, want)
+	}
+	if err := checkDigest(want, body); err != nil {
+		t.Errorf("checkDigest() error = %s", err)
+	}
+	if err := checkDigest("MD5=Sd/dVLAcvNLSq16eXua5uQ==", body); !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("checkDigest() error = %v, want %s", err, ErrDigestMismatch)
+	}
+}
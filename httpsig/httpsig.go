@@ -0,0 +1,303 @@
+// Package httpsig signs and verifies the HTTP requests exchanged by ActivityPub servers.
+//
+// It implements the HTTP Signatures of the draft-cavage-http-signatures-12, which is what most of the
+// fediverse uses, together with the Digest header of RFC 3230 for the request bodies, and the
+// HTTP Message Signatures of RFC 9421, with the Content-Digest header of RFC 9530.
+package httpsig
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	vocab "github.com/go-ap/activitypub"
+	"github.com/go-ap/errors"
+)
+
+// KeyLoader resolves the keyId of a signature to the public key which verifies it,
+// usually by loading the actor that owns the key.
+type KeyLoader interface {
+	LoadKey(ctx context.Context, keyID vocab.IRI) (vocab.PublicKey, error)
+}
+
+// KeyLoaderFn is a function which can be used as a KeyLoader.
+type KeyLoaderFn func(ctx context.Context, keyID vocab.IRI) (vocab.PublicKey, error)
+
+// LoadKey implements the KeyLoader interface
+func (fn KeyLoaderFn) LoadKey(ctx context.Context, keyID vocab.IRI) (vocab.PublicKey, error) {
+	return fn(ctx, keyID)
+}
+
+// Format is the specification of the HTTP signatures created by a Signer.
+type Format int8
+
+const (
+	// Cavage signatures follow draft-cavage-http-signatures-12
+	Cavage Format = iota
+	// RFC9421 signatures follow RFC 9421 HTTP Message Signatures
+	RFC9421
+)
+
+// DefaultClockSkew is the maximum difference allowed between the date of a signature and the local clock.
+const DefaultClockSkew = 5 * time.Minute
+
+// Signer signs the requests sent on behalf of an actor.
+type Signer struct {
+	// KeyID is the ID of the PublicKey of the actor, which the receiving server will use to verify the signature
+	KeyID vocab.IRI
+	// Key is a *rsa.PrivateKey, an ed25519.PrivateKey or an *ecdsa.PrivateKey on the P-256 curve
+	Key crypto.Signer
+	// Format is the specification of the signatures, draft-cavage by default
+	Format Format
+	// Headers are the headers covered by the draft-cavage signatures, in lower case
+	Headers []string
+	// Components are the components covered by the RFC 9421 signatures, in lower case
+	Components []string
+
+	now func() time.Time
+}
+
+// NewSigner returns a Signer using key for creating draft-cavage signatures of the DefaultHeaders.
+func NewSigner(keyID vocab.IRI, key crypto.Signer) *Signer {
+	return &Signer{KeyID: keyID, Key: key, Headers: DefaultHeaders, Components: DefaultComponents}
+}
+
+// Sign adds the signature headers to r, together with the digest of its body.
+// The body of the request is read for computing its digest, and replaced with a copy.
+func (s *Signer) Sign(r *http.Request) error {
+	if s.Key == nil {
+		return errors.Newf("missing private key")
+	}
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	if s.Format == RFC9421 {
+		return s.signRFC9421(r, body)
+	}
+	return s.signCavage(r, body)
+}
+
+func (s *Signer) time() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+// Verifier checks the HTTP signatures of the requests received from other servers.
+type Verifier struct {
+	// Keys resolves the key IDs of the signatures
+	Keys KeyLoader
+	// ClockSkew is the maximum difference allowed between the date of the signature and the local clock
+	ClockSkew time.Duration
+	// RequiredHeaders are the headers which must be covered by draft-cavage signatures, in lower case.
+	// The "digest" is required only for requests with a body.
+	RequiredHeaders []string
+	// RequiredComponents are the components which must be covered by RFC 9421 signatures, in lower case.
+	// The "content-digest" is required only for requests with a body.
+	RequiredComponents []string
+
+	now func() time.Time
+}
+
+// NewVerifier returns a Verifier which loads the keys with keys, allows for the DefaultClockSkew, and requires
+// the DefaultHeaders or the DefaultComponents to be signed.
+func NewVerifier(keys KeyLoader) *Verifier {
+	return &Verifier{
+		Keys:               keys,
+		ClockSkew:          DefaultClockSkew,
+		RequiredHeaders:    DefaultHeaders,
+		RequiredComponents: DefaultComponents,
+	}
+}
+
+// Verify checks the signature of r, and returns the PublicKey which verified it.
+// The caller still needs to check that the Owner of the key is allowed to act on behalf of the actor of the activity.
+//
+// Requests with a Signature-Input header are verified as RFC 9421 signatures. If that fails, and the request
+// has also a draft-cavage signature in the Authorization header, we fall back to verifying that one, so senders
+// can include both during the transition between the two. When both fail, the RFC 9421 error is returned.
+//
+// The body of the request is read for checking its digest, and replaced with a copy.
+// Any error returned is a *vocab.Error, with one of the Err* values of the package as its Reason.
+func (v *Verifier) Verify(r *http.Request) (vocab.PublicKey, error) {
+	body, err := readBody(r)
+	if err != nil {
+		return vocab.PublicKey{}, verifyErr(ErrDigestMismatch, err)
+	}
+	if r.Header.Get("Signature-Input") == "" {
+		return v.verifyCavage(r, body)
+	}
+	key, err := v.verifyRFC9421(r, body)
+	if err != nil && strings.HasPrefix(strings.ToLower(r.Header.Get("Authorization")), "signature ") {
+		if cKey, cErr := v.verifyCavage(r, body); cErr == nil {
+			return cKey, nil
+		}
+	}
+	return key, err
+}
+
+func (v *Verifier) time() time.Time {
+	if v.now != nil {
+		return v.now()
+	}
+	return time.Now()
+}
+
+func (v *Verifier) skew() time.Duration {
+	if v.ClockSkew <= 0 {
+		return DefaultClockSkew
+	}
+	return v.ClockSkew
+}
+
+// loadKey resolves the keyID with the KeyLoader, and decodes it.
+func (v *Verifier) loadKey(r *http.Request, keyID string) (vocab.PublicKey, crypto.PublicKey, error) {
+	key, err := v.Keys.LoadKey(r.Context(), vocab.IRI(keyID))
+	if err != nil {
+		return key, nil, verifyErr(ErrKeyNotFound, err)
+	}
+	if key.ID.String() != keyID {
+		return key, nil, verifyErr(ErrKeyNotFound, errors.Newf("loaded key %s instead of %s", key.ID, keyID))
+	}
+	pub, err := key.CryptoKey()
+	if err != nil {
+		return key, nil, verifyErr(ErrUnsupportedAlgorithm, err)
+	}
+	return key, pub, nil
+}
+
+// checkRequired verifies that all the required headers, or the defaults, are in the signed list.
+// The digest header is required only for requests with a body.
+func checkRequired(signed, required, defaults []string, digest string, hasBody bool) error {
+	if required == nil {
+		required = defaults
+	}
+	for _, h := range required {
+		h = strings.ToLower(h)
+		if h == digest && !hasBody {
+			continue
+		}
+		if !slices.Contains(signed, h) {
+			return verifyErr(ErrUnsignedHeader, errors.Newf("%q", h))
+		}
+	}
+	return nil
+}
+
+// checkCreated verifies that a signature wasn't created in the future, and that it didn't expire.
+func checkCreated(now time.Time, skew time.Duration, created, expires time.Time) error {
+	if !created.IsZero() && created.Sub(now) > skew {
+		return verifyErr(ErrClockSkew, errors.Newf("created %s", created.Format(time.RFC3339)))
+	}
+	if !expires.IsZero() && now.Sub(expires) > skew {
+		return verifyErr(ErrClockSkew, errors.Newf("expired %s", expires.Format(time.RFC3339)))
+	}
+	return nil
+}
+
+var (
+	// ErrMissingSignature is the cause for requests which don't have a signature
+	ErrMissingSignature = errors.Newf("missing HTTP signature")
+	// ErrInvalidSignatureHeader is the cause for signatures which can't be parsed
+	ErrInvalidSignatureHeader = errors.Newf("invalid HTTP signature header")
+	// ErrUnsignedHeader is the cause for signatures which don't cover one of the required headers
+	ErrUnsignedHeader = errors.Newf("required header is not signed")
+	// ErrMissingHeader is the cause for requests which don't contain one of the signed headers
+	ErrMissingHeader = errors.Newf("signed header is missing")
+	// ErrClockSkew is the cause for signatures created too far in the past or the future, or already expired
+	ErrClockSkew = errors.Newf("signature date is outside the allowed clock skew")
+	// ErrDigestMismatch is the cause for request bodies which don't match their digest
+	ErrDigestMismatch = errors.Newf("body digest mismatch")
+	// ErrUnsupportedAlgorithm is the cause for signatures made with an algorithm which doesn't match the key
+	ErrUnsupportedAlgorithm = errors.Newf("unsupported signature algorithm")
+	// ErrKeyNotFound is the cause for signatures whose key can't be loaded
+	ErrKeyNotFound = errors.Newf("unable to load the signature key")
+	// ErrInvalidSignature is the cause for signatures which don't verify
+	ErrInvalidSignature = errors.Newf("invalid HTTP signature")
+)
+
+// verifyErr returns the error for requests whose signature can't be verified, with err, one of the Err* values
+// of the package, as the reason for the failure, and cause the error which caused it, if any.
+func verifyErr(err error, cause error) *vocab.Error {
+	return &vocab.Error{Reason: err, Cause: cause}
+}
+
+// readBody returns the body of r, and replaces it with a new reader, so it can be read again.
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+// Digest returns the value of the RFC 3230 Digest header for body, using SHA-256.
+func Digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// checkDigest verifies the body against the SHA-256 or SHA-512 values of the header, ignoring other algorithms.
+func checkDigest(header string, body []byte) error {
+	for _, d := range strings.Split(header, ",") {
+		alg, val, ok := strings.Cut(strings.TrimSpace(d), "=")
+		if !ok {
+			continue
+		}
+		var sum []byte
+		switch strings.ToUpper(alg) {
+		case "SHA-256":
+			s := sha256.Sum256(body)
+			sum = s[:]
+		case "SHA-512":
+			s := sha512.Sum512(body)
+			sum = s[:]
+		default:
+			continue
+		}
+		want, err := base64.StdEncoding.DecodeString(val)
+		if err != nil {
+			return verifyErr(ErrDigestMismatch, err)
+		}
+		if subtle.ConstantTimeCompare(sum, want) != 1 {
+			return verifyErr(ErrDigestMismatch, nil)
+		}
+		return nil
+	}
+	return verifyErr(ErrDigestMismatch, errors.Newf("no supported algorithm in %q", header))
+}
+
+// NewActivityRequest builds a POST request to the inbox IRI, with the JSON-LD encoding of "it", with its @context, as the body.
+// It still needs to be signed before sending.
+func NewActivityRequest(ctx context.Context, inbox vocab.IRI, it vocab.Item) (*http.Request, error) {
+	body, err := vocab.MarshalJSONLD(it)
+	if err != nil {
+		return nil, err
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Content-Type", `application/ld+json; profile="https://www.w3.org/ns/activitystreams"`)
+	r.Header.Set("Accept", `application/ld+json; profile="https://www.w3.org/ns/activitystreams"`)
+	return r, nil
+}
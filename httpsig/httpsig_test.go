@@ -0,0 +1,35 @@
+package httpsig
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	vocab "github.com/go-ap/activitypub"
+)
+
+func TestNewActivityRequest(t *testing.T) {
+	a := &vocab.Actor{ID: "https://example.com/~jdoe", Type: vocab.PersonType}
+	r, err := NewActivityRequest(context.Background(), "https://other.example/inbox", mockActivity(a))
+	if err != nil {
+		t.Fatalf("NewActivityRequest() error = %s", err)
+	}
+	if r.URL.String() != "https://other.example/inbox" {
+		t.Errorf("NewActivityRequest() URL = %s, want %s", r.URL, "https://other.example/inbox")
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatalf("unable to read the body: %s", err)
+	}
+	doc := make(map[string]any)
+	if err = json.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("invalid JSON body %s: %s", body, err)
+	}
+	if ctx, ok := doc["@context"]; !ok || ctx != vocab.ActivityBaseURI.String() {
+		t.Errorf("NewActivityRequest() @context = %v, want %s", ctx, vocab.ActivityBaseURI)
+	}
+	if doc["type"] != string(vocab.CreateType) {
+		t.Errorf("NewActivityRequest() type = %v, want %s", doc["type"], vocab.CreateType)
+	}
+}
@@ -0,0 +1,571 @@
+package httpsig
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/asn1"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	vocab "github.com/go-ap/activitypub"
+	"github.com/go-ap/errors"
+)
+
+const (
+	algorithmRSAv15SHA256 = "rsa-v1_5-sha256"
+	algorithmRSAPSSSHA512 = "rsa-pss-sha512"
+	algorithmECDSAP256    = "ecdsa-p256-sha256"
+
+	// signatureLabel is the label of the RFC 9421 signatures created by a Signer
+	signatureLabel = "sig1"
+)
+
+// DefaultComponents are the components covered by the RFC 9421 signatures of a Signer without explicit Components.
+// The "content-digest" is signed only for requests with a body.
+var DefaultComponents = []string{"@method", "@target-uri", "content-digest"}
+
+// ContentDigest returns the value of the RFC 9530 Content-Digest header for body, using SHA-256.
+func ContentDigest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+}
+
+// signRFC9421 adds the Content-Digest, the Signature-Input and the Signature headers of RFC 9421 to r.
+func (s *Signer) signRFC9421(r *http.Request, body []byte) error {
+	components := s.Components
+	if len(components) == 0 {
+		components = DefaultComponents
+	}
+	if len(body) > 0 {
+		r.Header.Set("Content-Digest", ContentDigest(body))
+	} else {
+		components = slices.DeleteFunc(slices.Clone(components), func(c string) bool { return strings.EqualFold(c, "content-digest") })
+	}
+	in := signatureInput{
+		label:      signatureLabel,
+		components: components,
+		created:    s.time(),
+		keyID:      s.KeyID.String(),
+	}
+	in.params = in.serialize()
+	base, err := in.signatureBase(r)
+	if err != nil {
+		return err
+	}
+	sig, err := signRFC9421(s.Key, base)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Signature-Input", in.label+"="+in.params)
+	r.Header.Set("Signature", in.label+"=:"+base64.StdEncoding.EncodeToString(sig)+":")
+	return nil
+}
+
+// verifyRFC9421 checks the first RFC 9421 signature of r.
+func (v *Verifier) verifyRFC9421(r *http.Request, body []byte) (vocab.PublicKey, error) {
+	var key vocab.PublicKey
+	inputs, err := parseSignatureInput(r.Header.Get("Signature-Input"))
+	if err != nil {
+		return key, err
+	}
+	signatures, err := parseByteSequences(r.Header.Values("Signature"))
+	if err != nil {
+		return key, err
+	}
+	var in signatureInput
+	var sig []byte
+	for _, i := range inputs {
+		if s, ok := signatures[i.label]; ok {
+			in, sig = i, s
+			break
+		}
+	}
+	if len(sig) == 0 {
+		return key, verifyErr(ErrMissingSignature, nil)
+	}
+	if in.keyID == "" {
+		return key, verifyErr(ErrInvalidSignatureHeader, errors.Newf("missing keyid parameter"))
+	}
+	if err = checkRequired(in.components, v.RequiredComponents, DefaultComponents, "content-digest", len(body) > 0); err != nil {
+		return key, err
+	}
+	if err = v.checkRFC9421Time(in); err != nil {
+		return key, err
+	}
+	if d := r.Header.Values("Content-Digest"); len(d) > 0 {
+		if err = checkContentDigest(d, body); err != nil {
+			return key, err
+		}
+	}
+	base, err := in.signatureBase(r)
+	if err != nil {
+		return key, err
+	}
+	key, pub, err := v.loadKey(r, in.keyID)
+	if err != nil {
+		return key, err
+	}
+	if err = verifyRFC9421(pub, in.alg, base, sig); err != nil {
+		return key, err
+	}
+	return key, nil
+}
+
+func (v *Verifier) checkRFC9421Time(in signatureInput) error {
+	if in.created.IsZero() {
+		return verifyErr(ErrInvalidSignatureHeader, errors.Newf("missing created parameter"))
+	}
+	now, skew := v.time(), v.skew()
+	if now.Sub(in.created) > skew {
+		return verifyErr(ErrClockSkew, errors.Newf("created %s", in.created.Format(time.RFC3339)))
+	}
+	return checkCreated(now, skew, in.created, in.expires)
+}
+
+// signatureInput is a member of the Signature-Input header
+type signatureInput struct {
+	label      string
+	components []string
+	// params is the serialization of the inner list of components, and of the parameters,
+	// which is used as the value of the "@signature-params" component
+	params  string
+	created time.Time
+	expires time.Time
+	keyID   string
+	alg     string
+}
+
+// serialize returns the value of the "@signature-params" component for a signature we create.
+func (in signatureInput) serialize() string {
+	s := strings.Builder{}
+	s.WriteByte('(')
+	for i, c := range in.components {
+		if i > 0 {
+			s.WriteByte(' ')
+		}
+		s.WriteString(sfString(strings.ToLower(c)))
+	}
+	s.WriteByte(')')
+	s.WriteString(";created=" + strconv.FormatInt(in.created.Unix(), 10))
+	s.WriteString(";keyid=" + sfString(in.keyID))
+	return s.String()
+}
+
+// signatureBase builds the data which is signed, from the values of the covered components of r.
+func (in signatureInput) signatureBase(r *http.Request) ([]byte, error) {
+	s := strings.Builder{}
+	for _, c := range in.components {
+		val, err := componentValue(r, c)
+		if err != nil {
+			return nil, err
+		}
+		s.WriteString(sfString(c) + ": " + val + "\n")
+	}
+	s.WriteString(`"@signature-params": ` + in.params)
+	return []byte(s.String()), nil
+}
+
+// componentValue returns the value of the c component of r. The derived components start with "@",
+// the rest are header fields.
+func componentValue(r *http.Request, c string) (string, error) {
+	switch c {
+	case "@method":
+		return r.Method, nil
+	case "@target-uri":
+		return requestScheme(r) + "://" + requestAuthority(r) + r.URL.RequestURI(), nil
+	case "@authority":
+		return requestAuthority(r), nil
+	case "@scheme":
+		return requestScheme(r), nil
+	case "@request-target":
+		return r.URL.RequestURI(), nil
+	case "@path":
+		if p := r.URL.EscapedPath(); p != "" {
+			return p, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + r.URL.RawQuery, nil
+	}
+	if strings.HasPrefix(c, "@") {
+		return "", verifyErr(ErrInvalidSignatureHeader, errors.Newf("unsupported component %q", c))
+	}
+	values := r.Header.Values(c)
+	if len(values) == 0 {
+		return "", verifyErr(ErrMissingHeader, errors.Newf("%q", c))
+	}
+	trimmed := make([]string, len(values))
+	for i, hv := range values {
+		trimmed[i] = strings.TrimSpace(hv)
+	}
+	return strings.Join(trimmed, ", "), nil
+}
+
+func requestAuthority(r *http.Request) string {
+	if r.Host != "" {
+		return strings.ToLower(r.Host)
+	}
+	return strings.ToLower(r.URL.Host)
+}
+
+// requestScheme returns the scheme of the request URL, which for the requests received by a server is not set,
+// so we use the TLS connection, or the X-Forwarded-Proto header set by reverse proxies.
+func requestScheme(r *http.Request) string {
+	switch {
+	case r.URL.Scheme != "":
+		return strings.ToLower(r.URL.Scheme)
+	case r.TLS != nil:
+		return "https"
+	case r.Header.Get("X-Forwarded-Proto") != "":
+		return strings.ToLower(r.Header.Get("X-Forwarded-Proto"))
+	}
+	return "http"
+}
+
+// checkContentDigest verifies the body against the sha-256 or sha-512 values of the Content-Digest header.
+func checkContentDigest(header []string, body []byte) error {
+	digests, err := parseByteSequences(header)
+	if err != nil {
+		return verifyErr(ErrDigestMismatch, err)
+	}
+	var sum []byte
+	var want []byte
+	if d, ok := digests["sha-512"]; ok {
+		s := sha512.Sum512(body)
+		sum, want = s[:], d
+	} else if d, ok = digests["sha-256"]; ok {
+		s := sha256.Sum256(body)
+		sum, want = s[:], d
+	} else {
+		return verifyErr(ErrDigestMismatch, errors.Newf("no supported algorithm in %q", header))
+	}
+	if subtle.ConstantTimeCompare(sum, want) != 1 {
+		return verifyErr(ErrDigestMismatch, nil)
+	}
+	return nil
+}
+
+func signRFC9421(key crypto.Signer, data []byte) ([]byte, error) {
+	switch k := key.Public().(type) {
+	case ed25519.PublicKey:
+		return key.Sign(rand.Reader, data, crypto.Hash(0))
+	case *rsa.PublicKey:
+		sum := sha256.Sum256(data)
+		return key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	case *ecdsa.PublicKey:
+		if k.Curve.Params().Name != "P-256" {
+			break
+		}
+		sum := sha256.Sum256(data)
+		der, err := key.Sign(rand.Reader, sum[:], crypto.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		// NOTE(marius): RFC 9421 uses the concatenation of the r and s values, instead of their ASN.1 encoding
+		sig := struct{ R, S *big.Int }{}
+		if _, err = asn1.Unmarshal(der, &sig); err != nil {
+			return nil, err
+		}
+		raw := make([]byte, 64)
+		sig.R.FillBytes(raw[:32])
+		sig.S.FillBytes(raw[32:])
+		return raw, nil
+	}
+	return nil, errors.Newf("unsupported private key %T", key)
+}
+
+// verifyRFC9421 checks the signature of data with the key, according to the alg parameter, or to the key type.
+func verifyRFC9421(key crypto.PublicKey, alg string, data, sig []byte) error {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		var err error
+		switch alg {
+		case "", algorithmRSAv15SHA256:
+			sum := sha256.Sum256(data)
+			err = rsa.VerifyPKCS1v15(k, crypto.SHA256, sum[:], sig)
+		case algorithmRSAPSSSHA512:
+			sum := sha512.Sum512(data)
+			err = rsa.VerifyPSS(k, crypto.SHA512, sum[:], sig, nil)
+		default:
+			return verifyErr(ErrUnsupportedAlgorithm, errors.Newf("%q with an RSA key", alg))
+		}
+		if err != nil {
+			return verifyErr(ErrInvalidSignature, err)
+		}
+	case ed25519.PublicKey:
+		if alg != "" && alg != algorithmEd25519 {
+			return verifyErr(ErrUnsupportedAlgorithm, errors.Newf("%q with an Ed25519 key", alg))
+		}
+		if !ed25519.Verify(k, data, sig) {
+			return verifyErr(ErrInvalidSignature, nil)
+		}
+	case *ecdsa.PublicKey:
+		if alg != "" && alg != algorithmECDSAP256 {
+			return verifyErr(ErrUnsupportedAlgorithm, errors.Newf("%q with an ECDSA key", alg))
+		}
+		if len(sig) != 64 {
+			return verifyErr(ErrInvalidSignature, errors.Newf("invalid signature length %d", len(sig)))
+		}
+		der, err := asn1.Marshal(struct{ R, S *big.Int }{
+			R: new(big.Int).SetBytes(sig[:32]),
+			S: new(big.Int).SetBytes(sig[32:]),
+		})
+		if err != nil {
+			return verifyErr(ErrInvalidSignature, err)
+		}
+		sum := sha256.Sum256(data)
+		if !ecdsa.VerifyASN1(k, sum[:], der) {
+			return verifyErr(ErrInvalidSignature, nil)
+		}
+	default:
+		return verifyErr(ErrUnsupportedAlgorithm, errors.Newf("key %T", key))
+	}
+	return nil
+}
+
+// parseSignatureInput parses the members of the Signature-Input structured field dictionary (RFC 8941), which
+// are inner lists of strings with parameters, eg: sig1=("@method" "@target-uri");created=1618884473;keyid="test".
+func parseSignatureInput(header string) ([]signatureInput, error) {
+	var inputs []signatureInput
+	p := sfParser{s: header}
+	for p.skipSpaces(); !p.done(); p.skipSpaces() {
+		in := signatureInput{}
+		in.label = p.key()
+		if in.label == "" || !p.consume('=') || !p.consume('(') {
+			return nil, p.errorf("invalid member")
+		}
+		start := p.i - 1
+		for {
+			p.skipSpaces()
+			if p.consume(')') {
+				break
+			}
+			c, ok := p.str()
+			if !ok || p.peek() == ';' {
+				return nil, p.errorf("invalid component")
+			}
+			in.components = append(in.components, c)
+		}
+		for p.consume(';') {
+			name := p.key()
+			if !p.consume('=') {
+				return nil, p.errorf("parameter %q without value", name)
+			}
+			ok := true
+			switch name {
+			case "created":
+				var n int64
+				n, ok = p.integer()
+				in.created = time.Unix(n, 0)
+			case "expires":
+				var n int64
+				n, ok = p.integer()
+				in.expires = time.Unix(n, 0)
+			case "keyid":
+				in.keyID, ok = p.str()
+			case "alg":
+				in.alg, ok = p.str()
+			default:
+				ok = p.skipValue() == nil
+			}
+			if !ok {
+				return nil, p.errorf("invalid %s parameter", name)
+			}
+		}
+		in.params = p.s[start:p.i]
+		inputs = append(inputs, in)
+		if !p.nextMember() {
+			return nil, p.errorf("invalid separator")
+		}
+	}
+	if len(inputs) == 0 {
+		return nil, verifyErr(ErrMissingSignature, nil)
+	}
+	return inputs, nil
+}
+
+// parseByteSequences parses the members of a structured field dictionary (RFC 8941) which are byte sequences,
+// like the ones of the Signature and Content-Digest headers, eg: sig1=:base64:. Members of other types are ignored.
+func parseByteSequences(headers []string) (map[string][]byte, error) {
+	values := make(map[string][]byte)
+	p := sfParser{s: strings.Join(headers, ", ")}
+	for p.skipSpaces(); !p.done(); p.skipSpaces() {
+		name := p.key()
+		if name == "" || !p.consume('=') {
+			return nil, p.errorf("invalid member")
+		}
+		if p.peek() == ':' {
+			val, err := p.byteSequence()
+			if err != nil {
+				return nil, err
+			}
+			values[name] = val
+		} else if err := p.skipValue(); err != nil {
+			return nil, err
+		}
+		for p.consume(';') {
+			p.key()
+			if p.consume('=') {
+				if err := p.skipValue(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if !p.nextMember() {
+			return nil, p.errorf("invalid separator")
+		}
+	}
+	return values, nil
+}
+
+// sfString returns the serialization of s as a structured field string.
+func sfString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// sfParser is a minimal parser for the RFC 8941 structured fields used by RFC 9421.
+type sfParser struct {
+	s string
+	i int
+}
+
+func (p *sfParser) done() bool {
+	return p.i >= len(p.s)
+}
+
+func (p *sfParser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.s[p.i]
+}
+
+func (p *sfParser) consume(c byte) bool {
+	if p.peek() != c || p.done() {
+		return false
+	}
+	p.i++
+	return true
+}
+
+func (p *sfParser) skipSpaces() {
+	for !p.done() && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+// nextMember moves past the comma separating the members of a dictionary, and reports if the input is valid.
+func (p *sfParser) nextMember() bool {
+	p.skipSpaces()
+	if p.done() {
+		return true
+	}
+	if !p.consume(',') {
+		return false
+	}
+	p.skipSpaces()
+	return !p.done()
+}
+
+func (p *sfParser) key() string {
+	start := p.i
+	for !p.done() {
+		c := p.s[p.i]
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_' || c == '-' || c == '.' || c == '*' {
+			p.i++
+			continue
+		}
+		break
+	}
+	return p.s[start:p.i]
+}
+
+func (p *sfParser) str() (string, bool) {
+	if !p.consume('"') {
+		return "", false
+	}
+	s := strings.Builder{}
+	for !p.done() {
+		c := p.s[p.i]
+		p.i++
+		switch c {
+		case '\\':
+			if p.done() {
+				return "", false
+			}
+			s.WriteByte(p.s[p.i])
+			p.i++
+		case '"':
+			return s.String(), true
+		default:
+			s.WriteByte(c)
+		}
+	}
+	return "", false
+}
+
+func (p *sfParser) integer() (int64, bool) {
+	start := p.i
+	if p.peek() == '-' {
+		p.i++
+	}
+	for !p.done() && p.s[p.i] >= '0' && p.s[p.i] <= '9' {
+		p.i++
+	}
+	n, err := strconv.ParseInt(p.s[start:p.i], 10, 64)
+	return n, err == nil
+}
+
+func (p *sfParser) byteSequence() ([]byte, error) {
+	if !p.consume(':') {
+		return nil, p.errorf("invalid byte sequence")
+	}
+	end := strings.IndexByte(p.s[p.i:], ':')
+	if end < 0 {
+		return nil, p.errorf("unterminated byte sequence")
+	}
+	val, err := base64.StdEncoding.DecodeString(p.s[p.i : p.i+end])
+	if err != nil {
+		return nil, verifyErr(ErrInvalidSignatureHeader, err)
+	}
+	p.i += end + 1
+	return val, nil
+}
+
+// skipValue moves past a bare item: a string, a byte sequence, a number, a token or a boolean.
+func (p *sfParser) skipValue() error {
+	switch p.peek() {
+	case '"':
+		if _, ok := p.str(); !ok {
+			return p.errorf("invalid string")
+		}
+	case ':':
+		if _, err := p.byteSequence(); err != nil {
+			return err
+		}
+	default:
+		start := p.i
+		for !p.done() && !strings.ContainsRune(" \t,;()", rune(p.s[p.i])) {
+			p.i++
+		}
+		if start == p.i {
+			return p.errorf("missing value")
+		}
+	}
+	return nil
+}
+
+func (p *sfParser) errorf(format string, args ...any) error {
+	return verifyErr(ErrInvalidSignatureHeader, errors.Newf(format+" at %d", append(args, p.i)...))
+}
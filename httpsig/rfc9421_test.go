@@ -0,0 +1,306 @@
+package httpsig
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	vocab "github.com/go-ap/activitypub"
+)
+
+func TestSigner_Sign_RFC9421_httptest(t *testing.T) {
+	actors := mockActors(t)
+	verifier := NewVerifier(mockKeyLoader(actors))
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Signature-Input") == "" {
+			http.Error(w, "expected an RFC 9421 signature", http.StatusBadRequest)
+			return
+		}
+		if _, err := verifier.Verify(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	for name, a := range actors {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewActivityRequest(context.Background(), vocab.IRI(srv.URL+"/inbox"), mockActivity(a.actor))
+			if err != nil {
+				t.Fatalf("NewActivityRequest() error = %s", err)
+			}
+			s := NewSigner(vocab.IRI(a.actor.PublicKey.ID), a.key)
+			s.Format = RFC9421
+			if err = s.Sign(r); err != nil {
+				t.Fatalf("Sign() error = %s", err)
+			}
+			res, err := srv.Client().Do(r)
+			if err != nil {
+				t.Fatalf("unable to send the request: %s", err)
+			}
+			msg, _ := io.ReadAll(res.Body)
+			_ = res.Body.Close()
+			if res.StatusCode != http.StatusAccepted {
+				t.Errorf("the server responded with %s: %s", res.Status, msg)
+			}
+		})
+	}
+}
+
+func TestVerifier_Verify_RFC9421(t *testing.T) {
+	actors := mockActors(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		method     string
+		actor      string
+		components []string
+		// cavage adds a draft-cavage signature in the Authorization header
+		cavage  bool
+		mod     func(r *http.Request)
+		signAt  time.Time
+		wantErr error
+	}{
+		{
+			name:   "valid",
+			method: http.MethodPost,
+			actor:  "rsa",
+		},
+		{
+			name:   "valid ed25519",
+			method: http.MethodPost,
+			actor:  "ed25519",
+		},
+		{
+			name:   "valid ecdsa",
+			method: http.MethodPost,
+			actor:  "ecdsa",
+		},
+		{
+			name:   "valid GET without content digest",
+			method: http.MethodGet,
+			actor:  "rsa",
+		},
+		{
+			name:   "with multiple signatures",
+			method: http.MethodPost,
+			actor:  "ed25519",
+			mod: func(r *http.Request) {
+				r.Header.Set("Signature-Input", `proxy=("@method");created=1;keyid="test", `+r.Header.Get("Signature-Input"))
+			},
+		},
+		{
+			name:    "too old",
+			method:  http.MethodPost,
+			actor:   "rsa",
+			signAt:  now.Add(-time.Hour),
+			wantErr: ErrClockSkew,
+		},
+		{
+			name:    "in the future",
+			method:  http.MethodPost,
+			actor:   "rsa",
+			signAt:  now.Add(time.Hour),
+			wantErr: ErrClockSkew,
+		},
+		{
+			name:   "missing created",
+			method: http.MethodPost,
+			actor:  "rsa",
+			mod: func(r *http.Request) {
+				in := r.Header.Get("Signature-Input")
+				r.Header.Set("Signature-Input", in[:strings.Index(in, ";")]+`;keyid="https://example.com/~rsa#main-key"`)
+			},
+			wantErr: ErrInvalidSignatureHeader,
+		},
+		{
+			name:    "invalid signature input",
+			method:  http.MethodPost,
+			actor:   "rsa",
+			mod:     func(r *http.Request) { r.Header.Set("Signature-Input", `sig1=("@method" "@target-uri";created=1`) },
+			wantErr: ErrInvalidSignatureHeader,
+		},
+		{
+			name:   "signature with another label",
+			method: http.MethodPost,
+			actor:  "rsa",
+			mod: func(r *http.Request) {
+				r.Header.Set("Signature", strings.Replace(r.Header.Get("Signature"), "sig1", "sig2", 1))
+			},
+			wantErr: ErrMissingSignature,
+		},
+		{
+			name:       "unsigned content digest",
+			method:     http.MethodPost,
+			actor:      "rsa",
+			components: []string{"@method", "@target-uri"},
+			wantErr:    ErrUnsignedHeader,
+		},
+		{
+			name:   "tampered body",
+			method: http.MethodPost,
+			actor:  "ecdsa",
+			mod: func(r *http.Request) {
+				r.Body = io.NopCloser(strings.NewReader(`{"type":"Delete"}`))
+			},
+			wantErr: ErrDigestMismatch,
+		},
+		{
+			name:    "tampered target",
+			method:  http.MethodPost,
+			actor:   "ed25519",
+			mod:     func(r *http.Request) { r.URL.RawQuery = "page=2" },
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:   "algorithm doesn't match the key",
+			method: http.MethodPost,
+			actor:  "rsa",
+			mod: func(r *http.Request) {
+				r.Header.Set("Signature-Input", r.Header.Get("Signature-Input")+`;alg="ed25519"`)
+			},
+			wantErr: ErrUnsupportedAlgorithm,
+		},
+		{
+			name:   "fall back to cavage",
+			method: http.MethodPost,
+			actor:  "rsa",
+			cavage: true,
+			mod: func(r *http.Request) {
+				r.Header.Set("Signature", strings.Replace(r.Header.Get("Signature"), "sig1", "sig2", 1))
+			},
+		},
+		{
+			name:   "fall back to invalid cavage",
+			method: http.MethodPost,
+			actor:  "rsa",
+			cavage: true,
+			mod: func(r *http.Request) {
+				r.Header.Set("Signature", strings.Replace(r.Header.Get("Signature"), "sig1", "sig2", 1))
+				r.Header.Set("Authorization", strings.Replace(r.Header.Get("Authorization"), "~rsa", "~jdoe", 1))
+			},
+			wantErr: ErrMissingSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := actors[tt.actor]
+			var body io.Reader
+			if tt.method == http.MethodPost {
+				raw, err := vocab.MarshalJSON(mockActivity(a.actor))
+				if err != nil {
+					t.Fatalf("MarshalJSON() error = %s", err)
+				}
+				body = strings.NewReader(string(raw))
+			}
+			r := httptest.NewRequest(tt.method, "https://example.com/inbox?page=1", body)
+
+			signAt := tt.signAt
+			if signAt.IsZero() {
+				signAt = now
+			}
+			if tt.cavage {
+				s := NewSigner(vocab.IRI(a.actor.PublicKey.ID), a.key)
+				s.now = func() time.Time { return signAt }
+				if err := s.Sign(r); err != nil {
+					t.Fatalf("Sign() error = %s", err)
+				}
+				r.Header.Set("Authorization", "Signature "+r.Header.Get("Signature"))
+				r.Header.Del("Signature")
+			}
+			s := NewSigner(vocab.IRI(a.actor.PublicKey.ID), a.key)
+			s.Format = RFC9421
+			if tt.components != nil {
+				s.Components = tt.components
+			}
+			s.now = func() time.Time { return signAt }
+			if err := s.Sign(r); err != nil {
+				t.Fatalf("Sign() error = %s", err)
+			}
+			if tt.mod != nil {
+				tt.mod(r)
+			}
+
+			v := NewVerifier(mockKeyLoader(actors))
+			v.now = func() time.Time { return now }
+			key, err := v.Verify(r)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Verify() error = %s", err)
+				}
+				if key.ID != a.actor.PublicKey.ID {
+					t.Errorf("Verify() returned key %s, want %s", key.ID, a.actor.PublicKey.ID)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestVerifier_Verify_RFC9421_vector checks the example of the Ed25519 signature from section B.2.6 of RFC 9421,
+// with the key loaded from its Multikey form.
+func TestVerifier_Verify_RFC9421_vector(t *testing.T) {
+	pem := "-----BEGIN PUBLIC KEY-----\nMCowBQYDK2VwAyEAJrQLj5P/89iXES9+vFgrIy29clF9CC/oPPsw3c5D0bs=\n-----END PUBLIC KEY-----\n"
+	pub, err := vocab.PublicKey{PublicKeyPem: pem}.CryptoKey()
+	if err != nil {
+		t.Fatalf("CryptoKey() error = %s", err)
+	}
+	multibase, err := vocab.EncodeMultibaseKey(pub.(ed25519.PublicKey))
+	if err != nil {
+		t.Fatalf("EncodeMultibaseKey() error = %s", err)
+	}
+	keys := KeyLoaderFn(func(_ context.Context, keyID vocab.IRI) (vocab.PublicKey, error) {
+		if keyID != "test-key-ed25519" {
+			return vocab.PublicKey{}, fmt.Errorf("key %s not found", keyID)
+		}
+		return vocab.PublicKeyFromMultibase(vocab.ID(keyID), "https://example.com/~jdoe", multibase)
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "https://example.com/foo?param=Value&Pet=dog", strings.NewReader(`{"hello": "world"}`))
+	r.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Content-Digest", "sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:")
+	r.Header.Set("Content-Length", "18")
+	r.Header.Set("Signature-Input", `sig-b26=("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`)
+	r.Header.Set("Signature", "sig-b26=:wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw==:")
+
+	v := NewVerifier(keys)
+	v.RequiredComponents = []string{"@method", "@authority", "@path"}
+	v.now = func() time.Time { return time.Unix(1618884473, 0) }
+	key, err := v.Verify(r)
+	if err != nil {
+		t.Fatalf("Verify() error = %s", err)
+	}
+	if key.ID != "test-key-ed25519" {
+		t.Errorf("Verify() returned key %s", key.ID)
+	}
+
+	r.Header.Set("Content-Type", "text/plain")
+	if _, err = v.Verify(r); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() error = %v, want %s", err, ErrInvalidSignature)
+	}
+}
+
+func TestContentDigest(t *testing.T) {
+	body := []byte(`{"hello": "world"}`)
+	want := "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:"
+	if got := ContentDigest(body); got != want {
+		t.Errorf("ContentDigest() = %s, want %s", got, want)
+	}
+	if err := checkContentDigest([]string{"md5=:Sd/dVLAcvNLSq16eXua5uQ==:"}, body); !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("checkContentDigest() error = %v, want %s", err, ErrDigestMismatch)
+	}
+}
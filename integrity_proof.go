@@ -0,0 +1,477 @@
+package activitypub
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/json"
+	"time"
+
+	"github.com/go-ap/errors"
+	"github.com/valyala/fastjson"
+)
+
+const (
+	// DataIntegrityContextURI is the URI of the JSON-LD context for the Data Integrity proofs
+	DataIntegrityContextURI = IRI("https://w3id.org/security/data-integrity/v1")
+
+	// DataIntegrityProofType is the type of the proofs of the W3C Verifiable Credential Data Integrity specification
+	DataIntegrityProofType ActivityVocabularyType = "DataIntegrityProof"
+
+	// CryptosuiteEdDSAJCS2022 is the cryptosuite which signs documents canonicalized with the JSON Canonicalization
+	// Scheme using Ed25519 keys, as used by FEP-8b32
+	CryptosuiteEdDSAJCS2022 = "eddsa-jcs-2022"
+
+	// ProofPurposeAssertionMethod is the purpose of the proofs made with one of the assertionMethod keys of an actor
+	ProofPurposeAssertionMethod = "assertionMethod"
+)
+
+// DataIntegrityContext is the context for the "proof" property of the documents, and for the Multikey
+// "assertionMethod" keys of the actors.
+var DataIntegrityContext = ContextDefinition{
+	IRI:        DataIntegrityContextURI,
+	Properties: []string{"proof", "assertionMethod"},
+	Types:      ActivityVocabularyTypes{MultikeyType},
+}
+
+var (
+	// ErrProofMissing is the cause for documents which don't have a proof
+	ErrProofMissing = errors.Newf("missing integrity proof")
+	// ErrProofInvalid is the cause for documents, or proofs, which can't be decoded
+	ErrProofInvalid = errors.Newf("invalid integrity proof")
+	// ErrProofUnsupported is the cause for proofs of another type, cryptosuite, or purpose than the ones supported
+	ErrProofUnsupported = errors.Newf("unsupported integrity proof")
+	// ErrProofKeyNotFound is the cause for proofs whose verification method can't be loaded
+	ErrProofKeyNotFound = errors.Newf("unable to load the integrity proof verification method")
+	// ErrProofMismatch is the cause for proofs which don't verify
+	ErrProofMismatch = errors.Newf("integrity proof doesn't match the document")
+)
+
+// proofErr returns the error for documents whose integrity proof, or Linked Data Signature, can't be created
+// or verified, with err, one of the ErrProof* values, as the reason for the failure, and cause the error
+// which caused it, if any, eg: the error returned by the VerificationMethodLoader.
+func proofErr(err error, cause error) *Error {
+	return &Error{Reason: err, Cause: cause}
+}
+
+// DataIntegrityProof holds the "proof" property of a document, as described by the W3C Verifiable Credential
+// Data Integrity specification.
+// The document reference can be found at:
+// https://www.w3.org/TR/vc-data-integrity/#proofs
+type DataIntegrityProof struct {
+	// Context is the raw JSON value of the "@context" of the proof, which is the "@context" of the
+	// document it was created for
+	Context            json.RawMessage        `jsonld:"@context,omitempty"`
+	Type               ActivityVocabularyType `jsonld:"type,omitempty"`
+	Cryptosuite        string                 `jsonld:"cryptosuite,omitempty"`
+	VerificationMethod IRI                    `jsonld:"verificationMethod,omitempty"`
+	ProofPurpose       string                 `jsonld:"proofPurpose,omitempty"`
+	Created            time.Time              `jsonld:"created,omitempty"`
+	ProofValue         string                 `jsonld:"proofValue,omitempty"`
+}
+
+func (p *DataIntegrityProof) UnmarshalJSON(data []byte) error {
+	val, err := parseJSON(data)
+	if err != nil {
+		return err
+	}
+	return JSONLoadDataIntegrityProof(val, p)
+}
+
+func (p DataIntegrityProof) MarshalJSON() ([]byte, error) {
+	return jsonMarshal(p)
+}
+
+func (p DataIntegrityProof) writeJSON(b *bytes.Buffer, _ EncodeOptions) bool {
+	notEmpty := false
+	start := b.Len()
+	JSONWrite(b, '{')
+	if len(p.Context) > 0 {
+		notEmpty = JSONWriteProp(b, "@context", p.Context, notEmpty) || notEmpty
+	}
+	if len(p.Type) > 0 {
+		notEmpty = JSONWriteProp(b, "type", []byte(`"`+p.Type+`"`), notEmpty) || notEmpty
+	}
+	for _, prop := range [][2]string{
+		{"cryptosuite", p.Cryptosuite},
+		{"verificationMethod", p.VerificationMethod.String()},
+		{"proofPurpose", p.ProofPurpose},
+	} {
+		if len(prop[1]) == 0 {
+			continue
+		}
+		if v, err := json.Marshal(prop[1]); err == nil {
+			notEmpty = JSONWriteProp(b, prop[0], v, notEmpty) || notEmpty
+		}
+	}
+	if !p.Created.IsZero() {
+		notEmpty = JSONWriteTimeProp(b, "created", p.Created, notEmpty) || notEmpty
+	}
+	if len(p.ProofValue) > 0 {
+		if v, err := json.Marshal(p.ProofValue); err == nil {
+			notEmpty = JSONWriteProp(b, "proofValue", v, notEmpty) || notEmpty
+		}
+	}
+
+	if !notEmpty {
+		b.Truncate(start)
+		return false
+	}
+	JSONWrite(b, '}')
+	return true
+}
+
+func JSONLoadDataIntegrityProof(val *fastjson.Value, p *DataIntegrityProof) error {
+	if val.Type() != fastjson.TypeObject {
+		return proofErr(ErrProofInvalid, errors.Newf("expected a JSON object, got %s", val.Type()))
+	}
+	if ctx := val.Get("@context"); ctx != nil {
+		p.Context = ctx.MarshalTo(nil)
+	}
+	p.Type = ActivityVocabularyType(val.GetStringBytes("type"))
+	p.Cryptosuite = string(val.GetStringBytes("cryptosuite"))
+	p.VerificationMethod = JSONGetIRI(val, "verificationMethod")
+	p.ProofPurpose = string(val.GetStringBytes("proofPurpose"))
+	p.Created = JSONGetTime(val, "created")
+	p.ProofValue = string(val.GetStringBytes("proofValue"))
+	return nil
+}
+
+// JSONGetDataIntegrityProof returns the DataIntegrityProof in the prop property of the val JSON object.
+// It returns nil when the property is missing, or when it isn't a single proof, like the proof sets.
+func JSONGetDataIntegrityProof(val *fastjson.Value, prop string) *DataIntegrityProof {
+	v := val.Get(prop)
+	if v == nil || v.Type() != fastjson.TypeObject {
+		return nil
+	}
+	p := new(DataIntegrityProof)
+	if err := JSONLoadDataIntegrityProof(v, p); err != nil {
+		return nil
+	}
+	return p
+}
+
+// Equal verifies if our receiver DataIntegrityProof is equal with the "with" DataIntegrityProof
+func (p *DataIntegrityProof) Equal(with *DataIntegrityProof) bool {
+	if p == nil || with == nil {
+		return p == with
+	}
+	return bytes.Equal(p.Context, with.Context) &&
+		p.Type == with.Type &&
+		p.Cryptosuite == with.Cryptosuite &&
+		p.VerificationMethod == with.VerificationMethod &&
+		p.ProofPurpose == with.ProofPurpose &&
+		p.Created.Equal(with.Created) &&
+		p.ProofValue == with.ProofValue
+}
+
+// Clone returns a copy of the receiver DataIntegrityProof, which doesn't share its Context with it.
+func (p *DataIntegrityProof) Clone() *DataIntegrityProof {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	c.Context = bytes.Clone(p.Context)
+	return &c
+}
+
+// GobEncode
+func (p DataIntegrityProof) GobEncode() ([]byte, error) {
+	var (
+		mm      = make(map[string][]byte)
+		err     error
+		hasData bool
+	)
+	if len(p.Context) > 0 {
+		mm["@context"] = p.Context
+		hasData = true
+	}
+	if len(p.Type) > 0 {
+		if mm["type"], err = p.Type.GobEncode(); err != nil {
+			return nil, err
+		}
+		hasData = true
+	}
+	if len(p.VerificationMethod) > 0 {
+		if mm["verificationMethod"], err = p.VerificationMethod.GobEncode(); err != nil {
+			return nil, err
+		}
+		hasData = true
+	}
+	if !p.Created.IsZero() {
+		if mm["created"], err = p.Created.GobEncode(); err != nil {
+			return nil, err
+		}
+		hasData = true
+	}
+	for n, v := range map[string]string{"cryptosuite": p.Cryptosuite, "proofPurpose": p.ProofPurpose, "proofValue": p.ProofValue} {
+		if len(v) > 0 {
+			mm[n] = []byte(v)
+			hasData = true
+		}
+	}
+	if !hasData {
+		return []byte{}, nil
+	}
+	bb := bytes.Buffer{}
+	g := gob.NewEncoder(&bb)
+	if err := g.Encode(mm); err != nil {
+		return nil, err
+	}
+	return bb.Bytes(), nil
+}
+
+// GobDecode
+func (p *DataIntegrityProof) GobDecode(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	mm, err := gobDecodeObjectAsMap(data)
+	if err != nil {
+		return err
+	}
+	if raw, ok := mm["@context"]; ok {
+		p.Context = raw
+	}
+	if raw, ok := mm["type"]; ok {
+		if err = p.Type.GobDecode(raw); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["verificationMethod"]; ok {
+		if err = p.VerificationMethod.GobDecode(raw); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["created"]; ok {
+		if err = p.Created.GobDecode(raw); err != nil {
+			return err
+		}
+	}
+	p.Cryptosuite = string(mm["cryptosuite"])
+	p.ProofPurpose = string(mm["proofPurpose"])
+	p.ProofValue = string(mm["proofValue"])
+	return nil
+}
+
+// ItemProof returns the DataIntegrityProof of the "it" Item, from its Proof property.
+func ItemProof(it Item) (DataIntegrityProof, bool) {
+	var p *DataIntegrityProof
+	_ = OnObject(it, func(o *Object) error {
+		p = o.Proof
+		return nil
+	})
+	if p == nil {
+		return DataIntegrityProof{}, false
+	}
+	return *p, true
+}
+
+// VerificationMethodLoader resolves the verificationMethod of a proof to the PublicKey which verifies it, usually
+// by loading the actor that owns the key and looking it up in its assertionMethod keys.
+type VerificationMethodLoader func(id IRI) (PublicKey, error)
+
+// AddItemProof encodes the "it" Item with MarshalJSONLD, with the Data Integrity context, and adds
+// an eddsa-jcs-2022 integrity proof to it, like AddProof does.
+func AddItemProof(it Item, key ed25519.PrivateKey, verificationMethod IRI, created time.Time) ([]byte, error) {
+	// NOTE(marius): the document doesn't have a "proof" yet, so we need to add the context explicitly
+	doc, err := MarshalJSONLD(it, ContextDefinition{IRI: DataIntegrityContextURI})
+	if err != nil {
+		return nil, proofErr(ErrProofInvalid, err)
+	}
+	return AddProof(doc, key, verificationMethod, created)
+}
+
+// AddProof signs the doc JSON document with the Ed25519 key, using the eddsa-jcs-2022 cryptosuite as described
+// by FEP-8b32, and returns it with the proof added as its "proof" property.
+//
+// The verificationMethod is the ID of the public key, which must be one of the assertionMethod keys of the actor.
+// The proof gets the "@context" of the document, which keeps it verifiable by the implementations which
+// don't add it themselves to the proof configuration.
+func AddProof(doc []byte, key ed25519.PrivateKey, verificationMethod IRI, created time.Time) ([]byte, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, proofErr(ErrProofUnsupported, errors.Newf("invalid Ed25519 private key length %d", len(key)))
+	}
+	p := fastjson.Parser{}
+	val, err := p.ParseBytes(doc)
+	if err != nil {
+		return nil, proofErr(ErrProofInvalid, err)
+	}
+	ob, err := val.Object()
+	if err != nil {
+		return nil, proofErr(ErrProofInvalid, err)
+	}
+	if ob.Get("proof") != nil {
+		return nil, proofErr(ErrProofInvalid, errors.Newf("the document already has a proof"))
+	}
+
+	proof := DataIntegrityProof{
+		Type:               DataIntegrityProofType,
+		Cryptosuite:        CryptosuiteEdDSAJCS2022,
+		VerificationMethod: verificationMethod,
+		ProofPurpose:       ProofPurposeAssertionMethod,
+		Created:            created,
+	}
+	if ctx := ob.Get("@context"); ctx != nil {
+		proof.Context = ctx.MarshalTo(nil)
+	}
+	config, err := proof.MarshalJSON()
+	if err != nil {
+		return nil, proofErr(ErrProofInvalid, err)
+	}
+	hash, err := eddsaJCS2022Hash(config, val.MarshalTo(nil))
+	if err != nil {
+		return nil, proofErr(ErrProofInvalid, err)
+	}
+	proof.ProofValue = "z" + base58Encode(ed25519.Sign(key, hash))
+
+	raw, err := proof.MarshalJSON()
+	if err != nil {
+		return nil, proofErr(ErrProofInvalid, err)
+	}
+	pp := fastjson.Parser{}
+	pv, err := pp.ParseBytes(raw)
+	if err != nil {
+		return nil, proofErr(ErrProofInvalid, err)
+	}
+	ob.Set("proof", pv)
+	return val.MarshalTo(nil), nil
+}
+
+// VerifyProof checks the eddsa-jcs-2022 integrity proof of the doc JSON document, as described by FEP-8b32,
+// and returns the PublicKey which verified it.
+// The caller still needs to check that the Owner of the key is the actor of the activity, or the author of the object.
+//
+// The key is loaded with the keys VerificationMethodLoader, and it must be an Ed25519 key.
+// Any error returned is a *Error, with one of the ErrProof* values as its Reason.
+func VerifyProof(doc []byte, keys VerificationMethodLoader) (PublicKey, error) {
+	p := fastjson.Parser{}
+	val, err := p.ParseBytes(doc)
+	if err != nil {
+		return PublicKey{}, proofErr(ErrProofInvalid, err)
+	}
+	ob, err := val.Object()
+	if err != nil {
+		return PublicKey{}, proofErr(ErrProofInvalid, err)
+	}
+	pv := ob.Get("proof")
+	if pv == nil {
+		return PublicKey{}, proofErr(ErrProofMissing, nil)
+	}
+	if pv.Type() == fastjson.TypeArray {
+		return PublicKey{}, proofErr(ErrProofUnsupported, errors.Newf("proof sets are not supported"))
+	}
+	proof := DataIntegrityProof{}
+	if err = JSONLoadDataIntegrityProof(pv, &proof); err != nil {
+		return PublicKey{}, err
+	}
+	if err = checkProof(proof); err != nil {
+		return PublicKey{}, err
+	}
+	if len(proof.ProofValue) < 2 || proof.ProofValue[0] != 'z' {
+		return PublicKey{}, proofErr(ErrProofInvalid, errors.Newf("unsupported proofValue multibase encoding"))
+	}
+	sig, err := base58Decode(proof.ProofValue[1:])
+	if err != nil {
+		return PublicKey{}, proofErr(ErrProofInvalid, err)
+	}
+
+	key, err := keys(proof.VerificationMethod)
+	if err != nil {
+		return key, proofErr(ErrProofKeyNotFound, err)
+	}
+	if key.ID.String() != proof.VerificationMethod.String() {
+		return key, proofErr(ErrProofKeyNotFound, errors.Newf("loaded key %s instead of %s", key.ID, proof.VerificationMethod))
+	}
+	pub, err := key.CryptoKey()
+	if err != nil {
+		return key, proofErr(ErrProofUnsupported, err)
+	}
+	edKey, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return key, proofErr(ErrProofUnsupported, errors.Newf("%s requires an Ed25519 key, got %T", CryptosuiteEdDSAJCS2022, pub))
+	}
+
+	// NOTE(marius): the document is hashed without its proof, and the proof without its value. If the proof has
+	// a "@context", it must be the start of the document's one, and it replaces it, otherwise the proof
+	// configuration gets the "@context" of the document.
+	pv.GetObject().Del("proofValue")
+	ob.Del("proof")
+	if ctx := pv.Get("@context"); ctx != nil {
+		if !contextStartsWith(ob.Get("@context"), ctx) {
+			return key, proofErr(ErrProofMismatch, errors.Newf("the proof @context doesn't match the document"))
+		}
+		ob.Set("@context", ctx)
+	} else if ctx = ob.Get("@context"); ctx != nil {
+		pv.GetObject().Set("@context", ctx)
+	}
+	hash, err := eddsaJCS2022Hash(pv.MarshalTo(nil), val.MarshalTo(nil))
+	if err != nil {
+		return key, proofErr(ErrProofInvalid, err)
+	}
+	if !ed25519.Verify(edKey, hash, sig) {
+		return key, proofErr(ErrProofMismatch, nil)
+	}
+	return key, nil
+}
+
+// checkProof verifies that the proof is an eddsa-jcs-2022 DataIntegrityProof made with an assertionMethod key.
+func checkProof(p DataIntegrityProof) error {
+	if p.Type != DataIntegrityProofType {
+		return proofErr(ErrProofUnsupported, errors.Newf("type %q", p.Type))
+	}
+	if p.Cryptosuite != CryptosuiteEdDSAJCS2022 {
+		return proofErr(ErrProofUnsupported, errors.Newf("cryptosuite %q", p.Cryptosuite))
+	}
+	if p.ProofPurpose != ProofPurposeAssertionMethod {
+		return proofErr(ErrProofUnsupported, errors.Newf("proofPurpose %q", p.ProofPurpose))
+	}
+	if len(p.VerificationMethod) == 0 {
+		return proofErr(ErrProofInvalid, errors.Newf("missing verificationMethod"))
+	}
+	return nil
+}
+
+// eddsaJCS2022Hash returns the data which gets signed for the eddsa-jcs-2022 cryptosuite: the SHA-256 hash of
+// the canonical proof configuration, followed by the SHA-256 hash of the canonical document.
+func eddsaJCS2022Hash(config, doc []byte) ([]byte, error) {
+	config, err := JSONCanonicalize(config)
+	if err != nil {
+		return nil, err
+	}
+	doc, err = JSONCanonicalize(doc)
+	if err != nil {
+		return nil, err
+	}
+	ch := sha256.Sum256(config)
+	dh := sha256.Sum256(doc)
+	return append(ch[:], dh[:]...), nil
+}
+
+// contextStartsWith checks if the values of the prefix "@context" are the first values of the ctx "@context".
+func contextStartsWith(ctx, prefix *fastjson.Value) bool {
+	values := func(v *fastjson.Value) []string {
+		if v == nil {
+			return nil
+		}
+		if v.Type() != fastjson.TypeArray {
+			return []string{string(v.MarshalTo(nil))}
+		}
+		vv := make([]string, 0)
+		for _, e := range v.GetArray() {
+			vv = append(vv, string(e.MarshalTo(nil)))
+		}
+		return vv
+	}
+	c, p := values(ctx), values(prefix)
+	if len(p) > len(c) {
+		return false
+	}
+	for i := range p {
+		if p[i] != c[i] {
+			return false
+		}
+	}
+	return true
+}
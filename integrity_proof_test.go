@@ -0,0 +1,368 @@
+package activitypub
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The key pair and the document of the FEP-8b32 examples.
+// As Ed25519 signatures are deterministic, the proofValue of the document signed with them never changes.
+const (
+	fep8b32PublicKey = "z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2"
+	fep8b32SecretKey = "z3u2en7t5LR2WtQH5PfFqMqwVHBeXouLzo6haApm8XHqvjxq"
+	fep8b32KeyID     = "https://server.example/users/alice#ed25519-key"
+	fep8b32Document  = `{
+    "@context": [
+        "https://www.w3.org/ns/activitystreams",
+        "https://w3id.org/security/data-integrity/v1"
+    ],
+    "id": "https://server.example/activities/1",
+    "type": "Create",
+    "actor": "https://server.example/users/alice",
+    "object": {
+        "id": "https://server.example/objects/1",
+        "type": "Note",
+        "attributedTo": "https://server.example/users/alice",
+        "content": "Hello world",
+        "location": {
+            "type": "Place",
+            "longitude": -71.184902,
+            "latitude": 25.273962
+        }
+    }
+}`
+	fep8b32ProofValue = "zLaewdp4H9kqtwyrLatK4cjY5oRHwVcw4gibPSUDYDMhi4M49v8pcYk3ZB6D69dNpAPbUmY8ocuJ3m9KhKJEEg7z"
+)
+
+var fep8b32Created = time.Date(2023, 2, 24, 23, 36, 38, 0, time.UTC)
+
+func fep8b32Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	raw, err := base58Decode(fep8b32SecretKey[1:])
+	if err != nil {
+		t.Fatalf("unable to decode the secret key: %s", err)
+	}
+	// NOTE(marius): the secret key is the seed, prefixed with the ed25519-priv multicodec header
+	if len(raw) != 2+ed25519.SeedSize || raw[0] != 0x80 || raw[1] != 0x26 {
+		t.Fatalf("invalid secret key %x", raw)
+	}
+	return ed25519.NewKeyFromSeed(raw[2:])
+}
+
+func fep8b32Keys(_ IRI) (PublicKey, error) {
+	return PublicKeyFromMultibase(fep8b32KeyID, "https://server.example/users/alice", fep8b32PublicKey)
+}
+
+func TestAddProof(t *testing.T) {
+	key := fep8b32Key(t)
+	if pub, _ := EncodeMultibaseKey(key.Public()); pub != fep8b32PublicKey {
+		t.Fatalf("the secret key doesn't match the public key %s", pub)
+	}
+
+	signed, err := AddProof([]byte(fep8b32Document), key, fep8b32KeyID, fep8b32Created)
+	if err != nil {
+		t.Fatalf("AddProof() error = %s", err)
+	}
+	it, err := UnmarshalJSON(signed)
+	if err != nil {
+		t.Fatalf("UnmarshalJSON() error = %s", err)
+	}
+	proof, ok := ItemProof(it)
+	if !ok {
+		t.Fatalf("ItemProof() didn't find the proof in %s", signed)
+	}
+	want := DataIntegrityProof{
+		Context:            []byte(`["https://www.w3.org/ns/activitystreams","https://w3id.org/security/data-integrity/v1"]`),
+		Type:               DataIntegrityProofType,
+		Cryptosuite:        CryptosuiteEdDSAJCS2022,
+		VerificationMethod: fep8b32KeyID,
+		ProofPurpose:       ProofPurposeAssertionMethod,
+		Created:            fep8b32Created,
+		ProofValue:         fep8b32ProofValue,
+	}
+	if !assertDeepEquals(t.Errorf, proof, want) {
+		t.Errorf("ItemProof() = %#v, want %#v", proof, want)
+	}
+
+	if _, err = AddProof(signed, key, fep8b32KeyID, fep8b32Created); !errors.Is(err, ErrProofInvalid) {
+		t.Errorf("AddProof() for a signed document error = %v, want %s", err, ErrProofInvalid)
+	}
+}
+
+func TestAddItemProof(t *testing.T) {
+	key := fep8b32Key(t)
+	act := &Activity{
+		ID:     "https://server.example/activities/1",
+		Type:   CreateType,
+		Actor:  IRI("https://server.example/users/alice"),
+		Object: &Object{ID: "https://server.example/objects/1", Type: NoteType, Content: DefaultNaturalLanguage("Hello world")},
+	}
+	signed, err := AddItemProof(act, key, fep8b32KeyID, fep8b32Created)
+	if err != nil {
+		t.Fatalf("AddItemProof() error = %s", err)
+	}
+	if !strings.Contains(string(signed), `"@context":["https://www.w3.org/ns/activitystreams","https://w3id.org/security/data-integrity/v1"]`) {
+		t.Errorf("AddItemProof() = %s, missing the data integrity context", signed)
+	}
+	if _, err = VerifyProof(signed, fep8b32Keys); err != nil {
+		t.Fatalf("VerifyProof() error = %s", err)
+	}
+
+	// NOTE(marius): the proof is loaded in the Proof property, and encoding the item again produces the same document
+	it, err := UnmarshalJSON(signed)
+	if err != nil {
+		t.Fatalf("UnmarshalJSON() error = %s", err)
+	}
+	_ = OnObject(it, func(o *Object) error {
+		if o.Proof == nil || o.Proof.ProofValue == "" {
+			t.Errorf("UnmarshalJSON() didn't load the proof of %s", signed)
+		}
+		if _, ok := o.Extensions.Get("proof"); ok {
+			t.Errorf("UnmarshalJSON() kept the proof in the Extensions")
+		}
+		return nil
+	})
+	again, err := MarshalJSONLD(it)
+	if err != nil {
+		t.Fatalf("MarshalJSONLD() error = %s", err)
+	}
+	if _, err = VerifyProof(again, fep8b32Keys); err != nil {
+		t.Errorf("VerifyProof() of the re-encoded document error = %s", err)
+	}
+}
+
+func TestVerifyProof(t *testing.T) {
+	rk, _, _ := mockKeys(t)
+	rsaKey, err := NewPublicKey(rk, IRI("https://server.example/users/alice"))
+	if err != nil {
+		t.Fatalf("NewPublicKey() error = %s", err)
+	}
+	rsaKey.ID = fep8b32KeyID
+
+	withProof := func(proof string) string {
+		return strings.TrimSuffix(fep8b32Document, "}") + `,"proof":` + proof + `}`
+	}
+	proof := func(mod ...[2]string) string {
+		props := [][2]string{
+			{"@context", `["https://www.w3.org/ns/activitystreams","https://w3id.org/security/data-integrity/v1"]`},
+			{"type", `"DataIntegrityProof"`},
+			{"cryptosuite", `"eddsa-jcs-2022"`},
+			{"verificationMethod", `"` + fep8b32KeyID + `"`},
+			{"proofPurpose", `"assertionMethod"`},
+			{"proofValue", `"` + fep8b32ProofValue + `"`},
+			{"created", `"2023-02-24T23:36:38Z"`},
+		}
+		for _, m := range mod {
+			for i, p := range props {
+				if p[0] == m[0] {
+					props[i][1] = m[1]
+				}
+			}
+		}
+		s := make([]string, 0, len(props))
+		for _, p := range props {
+			if p[1] != "" {
+				s = append(s, fmt.Sprintf("%q:%s", p[0], p[1]))
+			}
+		}
+		return "{" + strings.Join(s, ",") + "}"
+	}
+
+	tests := []struct {
+		name    string
+		doc     string
+		keys    VerificationMethodLoader
+		wantErr error
+	}{
+		{
+			name: "FEP-8b32 example document",
+			doc:  withProof(proof()),
+		},
+		{
+			name: "proof without @context",
+			doc:  withProof(proof([2]string{"@context", ""})),
+		},
+		{
+			name: "different formatting",
+			doc:  strings.ReplaceAll(withProof(proof()), "\n", "\r\n"),
+		},
+		{
+			name:    "missing proof",
+			doc:     fep8b32Document,
+			wantErr: ErrProofMissing,
+		},
+		{
+			name:    "not an object",
+			doc:     `["https://server.example/activities/1"]`,
+			wantErr: ErrProofInvalid,
+		},
+		{
+			name:    "tampered document",
+			doc:     strings.Replace(withProof(proof()), "Hello world", "Goodbye world", 1),
+			wantErr: ErrProofMismatch,
+		},
+		{
+			name:    "tampered created",
+			doc:     withProof(proof([2]string{"created", `"2023-02-24T23:36:39Z"`})),
+			wantErr: ErrProofMismatch,
+		},
+		{
+			name:    "proof @context is not the document's",
+			doc:     withProof(proof([2]string{"@context", `"https://w3id.org/security/data-integrity/v1"`})),
+			wantErr: ErrProofMismatch,
+		},
+		{
+			name:    "invalid proofValue",
+			doc:     withProof(proof([2]string{"proofValue", `"uAAAA"`})),
+			wantErr: ErrProofInvalid,
+		},
+		{
+			name:    "another cryptosuite",
+			doc:     withProof(proof([2]string{"cryptosuite", `"eddsa-rdfc-2022"`})),
+			wantErr: ErrProofUnsupported,
+		},
+		{
+			name:    "another purpose",
+			doc:     withProof(proof([2]string{"proofPurpose", `"authentication"`})),
+			wantErr: ErrProofUnsupported,
+		},
+		{
+			name:    "proof set",
+			doc:     withProof("[" + proof() + "]"),
+			wantErr: ErrProofUnsupported,
+		},
+		{
+			name: "unknown key",
+			doc:  withProof(proof()),
+			keys: func(id IRI) (PublicKey, error) {
+				return PublicKey{}, fmt.Errorf("key %s not found", id)
+			},
+			wantErr: ErrProofKeyNotFound,
+		},
+		{
+			name: "loaded another key",
+			doc:  withProof(proof()),
+			keys: func(_ IRI) (PublicKey, error) {
+				return PublicKeyFromMultibase("https://server.example/users/alice#main-key", "https://server.example/users/alice", fep8b32PublicKey)
+			},
+			wantErr: ErrProofKeyNotFound,
+		},
+		{
+			name: "not an Ed25519 key",
+			doc:  withProof(proof()),
+			keys: func(_ IRI) (PublicKey, error) {
+				return rsaKey, nil
+			},
+			wantErr: ErrProofUnsupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := tt.keys
+			if keys == nil {
+				keys = fep8b32Keys
+			}
+			key, err := VerifyProof([]byte(tt.doc), keys)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("VerifyProof() error = %s", err)
+				}
+				if key.ID != fep8b32KeyID {
+					t.Errorf("VerifyProof() returned key %s, want %s", key.ID, fep8b32KeyID)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyProof() error = %v, want %s", err, tt.wantErr)
+			}
+			var pErr *Error
+			if !errors.As(err, &pErr) {
+				t.Errorf("VerifyProof() error is %T, want *Error", err)
+			}
+		})
+	}
+}
+
+func TestDataIntegrityProof_property(t *testing.T) {
+	proof := &DataIntegrityProof{
+		Context:            []byte(`["https://www.w3.org/ns/activitystreams","https://w3id.org/security/data-integrity/v1"]`),
+		Type:               DataIntegrityProofType,
+		Cryptosuite:        CryptosuiteEdDSAJCS2022,
+		VerificationMethod: fep8b32KeyID,
+		ProofPurpose:       ProofPurposeAssertionMethod,
+		Created:            fep8b32Created,
+		ProofValue:         fep8b32ProofValue,
+	}
+	note := &Object{ID: "https://server.example/objects/1", Type: NoteType, Proof: proof}
+
+	t.Run("JSON", func(t *testing.T) {
+		raw, err := note.MarshalJSON()
+		if err != nil {
+			t.Fatalf("MarshalJSON() error = %s", err)
+		}
+		got := new(Object)
+		if err = got.UnmarshalJSON(raw); err != nil {
+			t.Fatalf("UnmarshalJSON() error = %s", err)
+		}
+		if !got.Equals(note) {
+			t.Errorf("UnmarshalJSON() = %#v, want %#v", got.Proof, proof)
+		}
+	})
+	t.Run("gob", func(t *testing.T) {
+		raw, err := note.GobEncode()
+		if err != nil {
+			t.Fatalf("GobEncode() error = %s", err)
+		}
+		got := new(Object)
+		if err = got.GobDecode(raw); err != nil {
+			t.Fatalf("GobDecode() error = %s", err)
+		}
+		if !got.Equals(note) {
+			t.Errorf("GobDecode() = %#v, want %#v", got.Proof, proof)
+		}
+	})
+	t.Run("equals", func(t *testing.T) {
+		other := *note
+		other.Proof = &DataIntegrityProof{Type: DataIntegrityProofType, ProofValue: "z1"}
+		if note.Equals(other) {
+			t.Errorf("Equals() = true for objects with different proofs")
+		}
+		other.Proof = nil
+		if note.Equals(other) {
+			t.Errorf("Equals() = true for an object without proof")
+		}
+	})
+	t.Run("clone", func(t *testing.T) {
+		c, ok := Clone(note).(*Object)
+		if !ok || !c.Equals(note) {
+			t.Fatalf("Clone() = %#v, want %#v", c, note)
+		}
+		if c.Proof == note.Proof {
+			t.Errorf("Clone() shares the proof with the original")
+		}
+		c.Proof.Context[2] = 'x'
+		if c.Proof.Equal(note.Proof) {
+			t.Errorf("Clone() shares the proof @context with the original")
+		}
+	})
+	t.Run("proof set", func(t *testing.T) {
+		raw := []byte(`{"type":"Note","proof":[{"type":"DataIntegrityProof"},{"type":"DataIntegrityProof"}]}`)
+		got := new(Object)
+		if err := got.UnmarshalJSON(raw); err != nil {
+			t.Fatalf("UnmarshalJSON() error = %s", err)
+		}
+		if got.Proof != nil {
+			t.Errorf("UnmarshalJSON() loaded a proof set as a single proof: %#v", got.Proof)
+		}
+		if set, ok := got.Extensions.Get("proof"); !ok || string(set) != `[{"type":"DataIntegrityProof"},{"type":"DataIntegrityProof"}]` {
+			t.Errorf("UnmarshalJSON() didn't keep the proof set in the Extensions: %s", set)
+		}
+		if again, _ := got.MarshalJSON(); string(again) != string(raw) {
+			t.Errorf("MarshalJSON() = %s, want %s", again, raw)
+		}
+	})
+}
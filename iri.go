@@ -47,6 +47,12 @@ const (
 	PublicNS = ActivityBaseURI + "#Public"
 )
 
+// isPublicNS checks if iri is the Public collection, in any of the three representations listed in the
+// note of PublicNS.
+func isPublicNS(iri IRI) bool {
+	return iri == PublicNS || iri == "as:Public" || iri == "Public"
+}
+
 // JsonLDContext is a slice of IRIs that form the default context for the objects in the
 // GoActivitypub vocabulary.
 // It does not represent just the default ActivityStreams public namespace, but it also
@@ -96,14 +102,17 @@ func (i *IRI) UnmarshalJSON(s []byte) error {
 
 // MarshalJSON encodes the receiver object to a JSON document.
 func (i IRI) MarshalJSON() ([]byte, error) {
+	return jsonMarshal(i)
+}
+
+func (i IRI) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
 	if i == "" {
-		return nil, nil
+		return false
 	}
-	b := bytes.Buffer{}
-	JSONWrite(&b, '"')
-	JSONWriteS(&b, i.String())
-	JSONWrite(&b, '"')
-	return b.Bytes(), nil
+	JSONWrite(b, '"')
+	JSONWriteS(b, i.String())
+	JSONWrite(b, '"')
+	return true
 }
 
 // UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
@@ -218,8 +227,7 @@ func (i *IRIs) UnmarshalJSON(data []byte) error {
 	if i == nil {
 		return nil
 	}
-	p := fastjson.Parser{}
-	val, err := p.ParseBytes(data)
+	val, err := parseJSON(data)
 	if err != nil {
 		return err
 	}
@@ -220,6 +220,14 @@ func IsObject(it LinkOrIRI) bool {
 		return true
 	case *Relationship:
 		return ob != nil
+	case Ticket:
+		return true
+	case *Ticket:
+		return ob != nil
+	case Repository:
+		return true
+	case *Repository:
+		return ob != nil
 	case Tombstone:
 		return true
 	case *Tombstone:
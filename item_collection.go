@@ -46,15 +46,18 @@ func (i ItemCollection) IRIs() IRIs {
 
 // MarshalJSON encodes the receiver object to a JSON document.
 func (i ItemCollection) MarshalJSON() ([]byte, error) {
+	return jsonMarshal(i)
+}
+
+func (i ItemCollection) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
 	if i == nil {
-		return nil, nil
+		return false
 	}
 	if len(i) == 0 {
-		return []byte{'[', ']'}, nil
+		JSONWrite(b, '[', ']')
+		return true
 	}
-	b := bytes.Buffer{}
-	JSONWriteItemCollectionValue(&b, i, true)
-	return b.Bytes(), nil
+	return jsonWriteItemCollectionValue(b, i, true, opts)
 }
 
 // Append facilitates adding elements to Item arrays
@@ -252,7 +255,6 @@ func ToIRIs(it LinkOrIRI) (*IRIs, error) {
 	default:
 		return reflectItemToType[IRIs](it)
 	}
-	return nil, ErrorInvalidType[IRIs](it)
 }
 
 // ItemsMatch
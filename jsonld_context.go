@@ -0,0 +1,255 @@
+package activitypub
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/go-ap/errors"
+	"github.com/valyala/fastjson"
+)
+
+// ContextDefinition describes a JSON-LD context which gets added to the "@context" of the documents
+// encoded by MarshalJSONLD, when any of its Properties or Types are present in the document.
+//
+// A definition without Properties and Types is always added.
+type ContextDefinition struct {
+	// IRI is the location of a remote context document, eg: "https://w3id.org/security/v1".
+	IRI IRI
+	// Terms are inline term definitions, which get merged in a single JSON object at the end of the "@context"
+	// array, eg: {"toot": "http://joinmastodon.org/ns#", "sensitive": "as:sensitive"}.
+	Terms map[string]any
+	// Properties are the JSON property names which require the context.
+	Properties []string
+	// Types are the ActivityVocabularyTypes which require the context.
+	Types ActivityVocabularyTypes
+}
+
+// SecurityContext is the context for the Actor's PublicKey related properties.
+var SecurityContext = ContextDefinition{
+	IRI:        SecurityContextURI,
+	Properties: []string{"publicKey", "publicKeyPem", "signature"},
+}
+
+// TootContext holds the terms of the Mastodon extensions to the vocabulary, as Mastodon defines them inline
+// in the "@context" of its documents.
+var TootContext = ContextDefinition{
+	Terms: map[string]any{
+		"toot":                      "http://joinmastodon.org/ns#",
+		"sensitive":                 "as:sensitive",
+		"manuallyApprovesFollowers": "as:manuallyApprovesFollowers",
+		"movedTo":                   map[string]any{"@id": "as:movedTo", "@type": "@id"},
+		"alsoKnownAs":               map[string]any{"@id": "as:alsoKnownAs", "@type": "@id"},
+		"Hashtag":                   "as:Hashtag",
+		"discoverable":              "toot:discoverable",
+		"indexable":                 "toot:indexable",
+		"featured":                  map[string]any{"@id": "toot:featured", "@type": "@id"},
+		"featuredTags":              map[string]any{"@id": "toot:featuredTags", "@type": "@id"},
+		"Emoji":                     "toot:Emoji",
+		"focalPoint":                map[string]any{"@container": "@list", "@id": "toot:focalPoint"},
+		"blurhash":                  "toot:blurhash",
+	},
+	Properties: []string{
+		"sensitive", "manuallyApprovesFollowers", "movedTo", "alsoKnownAs", "discoverable", "indexable",
+		"featured", "featuredTags", "focalPoint", "blurhash",
+	},
+	Types: ActivityVocabularyTypes{HashtagType, EmojiType},
+}
+
+// LemmyContext holds the terms of the Lemmy extensions for the Group actors of FEP-1b12, as Lemmy defines them
+// inline in the "@context" of its documents.
+var LemmyContext = ContextDefinition{
+	Terms: map[string]any{
+		"lemmy":                   "https://join-lemmy.org/ns#",
+		"postingRestrictedToMods": "lemmy:postingRestrictedToMods",
+		"moderators":              map[string]any{"@id": "lemmy:moderators", "@type": "@id"},
+	},
+	Properties: []string{"postingRestrictedToMods", "moderators"},
+}
+
+// ErrConflictingTerm is returned when two contexts used by a document have different definitions for the same term.
+var ErrConflictingTerm = errors.Newf("conflicting JSON-LD term definitions")
+
+var contexts = struct {
+	sync.RWMutex
+	defs []ContextDefinition
+}{
+	defs: []ContextDefinition{SecurityContext, DataIntegrityContext, TootContext, LemmyContext, ForgeFedContext},
+}
+
+// RegisterContext adds the ctx ContextDefinition to the ones that MarshalJSONLD considers for every document.
+// If a definition with the same IRI, or for definitions without an IRI, with the same term names, was already
+// registered, it gets replaced.
+func RegisterContext(ctx ContextDefinition) {
+	contexts.Lock()
+	defer contexts.Unlock()
+
+	for i, def := range contexts.defs {
+		if def.sameAs(ctx) {
+			contexts.defs[i] = ctx
+			return
+		}
+	}
+	contexts.defs = append(contexts.defs, ctx)
+}
+
+func (c ContextDefinition) sameAs(other ContextDefinition) bool {
+	if len(c.IRI) > 0 || len(other.IRI) > 0 {
+		return c.IRI == other.IRI
+	}
+	if len(c.Terms) != len(other.Terms) {
+		return false
+	}
+	for t := range c.Terms {
+		if _, ok := other.Terms[t]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func registeredContexts() []ContextDefinition {
+	contexts.RLock()
+	defer contexts.RUnlock()
+	return slices.Clone(contexts.defs)
+}
+
+func (c ContextDefinition) usedBy(props map[string]struct{}, types map[ActivityVocabularyType]struct{}) bool {
+	if len(c.Properties) == 0 && len(c.Types) == 0 {
+		return true
+	}
+	for _, p := range c.Properties {
+		if _, ok := props[p]; ok {
+			return true
+		}
+	}
+	for _, t := range c.Types {
+		if _, ok := types[t]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// collectUsedTerms walks the val JSON document and stores the property names and the types found in it.
+//
+// It only looks at the keys of the document and of the objects that are values of its Object or Link
+// properties, as the keys of language maps, or of the values of extension properties, are not terms.
+func collectUsedTerms(val *fastjson.Value, props map[string]struct{}, types map[ActivityVocabularyType]struct{}) {
+	switch val.Type() {
+	case fastjson.TypeArray:
+		for _, v := range val.GetArray() {
+			collectUsedTerms(v, props, types)
+		}
+	case fastjson.TypeObject:
+		ob, _ := val.Object()
+		ob.Visit(func(key []byte, v *fastjson.Value) {
+			k := string(key)
+			if k == "@context" {
+				return
+			}
+			props[k] = struct{}{}
+			if k == "type" {
+				for _, t := range JSONGetTypes(val).AsTypes() {
+					types[t] = struct{}{}
+				}
+			}
+			if k == "publicKey" || slices.Contains(strictItemProperties, k) {
+				collectUsedTerms(v, props, types)
+			}
+		})
+	}
+}
+
+// JSONLDContextFor returns the minimal "@context" value for the raw JSON document: the ActivityStreams
+// namespace, followed by the registered and the extra contexts whose properties or types are used in it.
+func JSONLDContextFor(raw []byte, extra ...ContextDefinition) ([]byte, error) {
+	p := fastjson.Parser{}
+	val, err := p.ParseBytes(raw)
+	if err != nil {
+		return nil, err
+	}
+	props := make(map[string]struct{})
+	types := make(map[ActivityVocabularyType]struct{})
+	collectUsedTerms(val, props, types)
+
+	iris := IRIs{ActivityBaseURI}
+	terms := make(map[string]any)
+	for _, ctx := range append(registeredContexts(), extra...) {
+		if !ctx.usedBy(props, types) {
+			continue
+		}
+		if len(ctx.IRI) > 0 && !slices.Contains(iris, ctx.IRI) {
+			iris = append(iris, ctx.IRI)
+		}
+		for t, def := range ctx.Terms {
+			if prev, ok := terms[t]; ok && !reflect.DeepEqual(prev, def) {
+				return nil, errors.Annotatef(ErrConflictingTerm, "%s", t)
+			}
+			terms[t] = def
+		}
+	}
+
+	if len(iris) == 1 && len(terms) == 0 {
+		b := bytes.Buffer{}
+		JSONWriteStringValue(&b, iris[0].String())
+		return b.Bytes(), nil
+	}
+	b := bytes.Buffer{}
+	JSONWrite(&b, '[')
+	for i, iri := range iris {
+		if i > 0 {
+			JSONWriteComma(&b)
+		}
+		JSONWriteStringValue(&b, iri.String())
+	}
+	if len(terms) > 0 {
+		// NOTE(marius): encoding/json sorts the map keys, so the output is stable
+		t, err := json.Marshal(terms)
+		if err != nil {
+			return nil, err
+		}
+		JSONWriteComma(&b)
+		JSONWrite(&b, t...)
+	}
+	JSONWrite(&b, ']')
+	return b.Bytes(), nil
+}
+
+// MarshalJSONLD encodes the "it" Item to a JSON-LD document which has, as its first property, the minimal
+// "@context" required by the properties and types present in the document.
+//
+// Besides the ActivityStreams namespace, the context contains the security namespace if a public key is
+// present, the contexts registered with RegisterContext and the extra ones, when the document uses them.
+func MarshalJSONLD(it LinkOrIRI, extra ...ContextDefinition) ([]byte, error) {
+	if IsNil(it) {
+		return []byte("null"), nil
+	}
+	m, ok := it.(json.Marshaler)
+	if !ok {
+		return MarshalJSON(it)
+	}
+	raw, err := m.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < 2 || raw[0] != '{' {
+		// NOTE(marius): IRIs, collections of items, or empty objects can't hold a context
+		return raw, nil
+	}
+	ctx, err := JSONLDContextFor(raw, extra...)
+	if err != nil {
+		return nil, err
+	}
+	b := bytes.Buffer{}
+	b.Grow(len(raw) + len(ctx) + 13)
+	JSONWrite(&b, '{')
+	JSONWriteProp(&b, "@context", ctx, false)
+	if len(raw) > 2 {
+		JSONWriteComma(&b)
+	}
+	JSONWrite(&b, raw[1:]...)
+	return b.Bytes(), nil
+}
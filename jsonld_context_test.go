@@ -0,0 +1,174 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+var mockLitepubContext = ContextDefinition{
+	Terms: map[string]any{
+		"litepub":       "http://litepub.social/ns#",
+		"directMessage": "litepub:directMessage",
+		"EmojiReact":    "litepub:EmojiReact",
+	},
+	Properties: []string{"directMessage"},
+	Types:      ActivityVocabularyTypes{"EmojiReact"},
+}
+
+func TestMarshalJSONLD(t *testing.T) {
+	tests := []struct {
+		name  string
+		it    LinkOrIRI
+		extra []ContextDefinition
+		want  string
+	}{
+		{
+			name: "nil",
+			want: `null`,
+		},
+		{
+			name: "IRI",
+			it:   IRI("https://example.com"),
+			want: `"https://example.com"`,
+		},
+		{
+			name: "note",
+			it:   &Object{ID: "https://example.com/1", Type: NoteType},
+			want: `{"@context":"https://www.w3.org/ns/activitystreams","id":"https://example.com/1","type":"Note"}`,
+		},
+		{
+			name: "actor with public key",
+			it: &Actor{
+				ID:        "https://example.com/~jdoe",
+				Type:      PersonType,
+				PublicKey: PublicKey{ID: "https://example.com/~jdoe#main-key", Owner: "https://example.com/~jdoe"},
+			},
+			want: `{"@context":["https://www.w3.org/ns/activitystreams","https://w3id.org/security/v1"],"id":"https://example.com/~jdoe","type":"Person","publicKey":{"id":"https://example.com/~jdoe#main-key","owner":"https://example.com/~jdoe"}}`,
+		},
+		{
+			name:  "unused extra context is skipped",
+			it:    &Object{ID: "https://example.com/1", Type: NoteType},
+			extra: []ContextDefinition{mockLitepubContext},
+			want:  `{"@context":"https://www.w3.org/ns/activitystreams","id":"https://example.com/1","type":"Note"}`,
+		},
+		{
+			name: "extra context for nested extension property",
+			it: &Activity{
+				Type: CreateType,
+				Object: &Object{
+					ID:         "https://example.com/1",
+					Type:       NoteType,
+					Extensions: Extensions{"directMessage": json.RawMessage(`true`)},
+				},
+			},
+			extra: []ContextDefinition{mockLitepubContext},
+			want:  `{"@context":["https://www.w3.org/ns/activitystreams",{"EmojiReact":"litepub:EmojiReact","directMessage":"litepub:directMessage","litepub":"http://litepub.social/ns#"}],"type":"Create","object":{"id":"https://example.com/1","type":"Note","directMessage":true}}`,
+		},
+		{
+			name:  "extra context for type",
+			it:    &Object{ID: "https://example.com/react/1", Type: ActivityVocabularyType("EmojiReact")},
+			extra: []ContextDefinition{{IRI: "https://example.com/ns"}, mockLitepubContext},
+			want:  `{"@context":["https://www.w3.org/ns/activitystreams","https://example.com/ns",{"EmojiReact":"litepub:EmojiReact","directMessage":"litepub:directMessage","litepub":"http://litepub.social/ns#"}],"id":"https://example.com/react/1","type":"EmojiReact"}`,
+		},
+		{
+			name: "extension values are opaque",
+			it: &Object{
+				Type:       NoteType,
+				Extensions: Extensions{"custom": json.RawMessage(`{"directMessage":true}`)},
+			},
+			extra: []ContextDefinition{mockLitepubContext},
+			want:  `{"@context":"https://www.w3.org/ns/activitystreams","type":"Note","custom":{"directMessage":true}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalJSONLD(tt.it, tt.extra...)
+			if err != nil {
+				t.Fatalf("MarshalJSONLD() error = %s", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("MarshalJSONLD() got = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterContext(t *testing.T) {
+	old := registeredContexts()
+	t.Cleanup(func() {
+		contexts.Lock()
+		contexts.defs = old
+		contexts.Unlock()
+	})
+
+	RegisterContext(mockLitepubContext)
+	RegisterContext(mockLitepubContext)
+	RegisterContext(ContextDefinition{IRI: SecurityContextURI, Properties: []string{"assertionMethod"}})
+
+	defs := registeredContexts()
+	if len(defs) != len(old)+1 {
+		t.Fatalf("RegisterContext() expected %d definitions, got %d", len(old)+1, len(defs))
+	}
+	i := slices.IndexFunc(defs, func(d ContextDefinition) bool { return d.IRI == SecurityContextURI })
+	if !slices.Equal(defs[i].Properties, []string{"assertionMethod"}) {
+		t.Errorf("RegisterContext() didn't replace the definition with the same IRI: %v", defs[i].Properties)
+	}
+
+	ob := &Object{ID: "https://example.com/1", Type: NoteType, Extensions: Extensions{"directMessage": json.RawMessage(`true`)}}
+	got, err := MarshalJSONLD(ob)
+	if err != nil {
+		t.Fatalf("MarshalJSONLD() error = %s", err)
+	}
+	want := `{"@context":["https://www.w3.org/ns/activitystreams",{"EmojiReact":"litepub:EmojiReact","directMessage":"litepub:directMessage","litepub":"http://litepub.social/ns#"}],"id":"https://example.com/1","type":"Note","directMessage":true}`
+	if string(got) != want {
+		t.Errorf("MarshalJSONLD() got = %s, want %s", got, want)
+	}
+}
+
+func TestJSONLDContextFor_conflictingTerms(t *testing.T) {
+	other := ContextDefinition{
+		Terms:      map[string]any{"directMessage": "https://example.com/ns#directMessage"},
+		Properties: []string{"directMessage"},
+	}
+	_, err := JSONLDContextFor([]byte(`{"type":"Note","directMessage":true}`), mockLitepubContext, other)
+	if !errors.Is(err, ErrConflictingTerm) {
+		t.Errorf("JSONLDContextFor() error = %v, want %s", err, ErrConflictingTerm)
+	}
+}
+
+func TestJSONLDContextFor(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{
+			name: "language map keys are not terms",
+			raw:  `{"type":"Note","contentMap":{"en":"test","directMessage":"test"}}`,
+			want: `"https://www.w3.org/ns/activitystreams"`,
+		},
+		{
+			name: "existing context is ignored",
+			raw:  `{"@context":{"directMessage":"litepub:directMessage"},"type":"Note"}`,
+			want: `"https://www.w3.org/ns/activitystreams"`,
+		},
+		{
+			name: "terms of nested objects",
+			raw:  `{"type":"Create","object":[{"type":"Note","directMessage":true}]}`,
+			want: `["https://www.w3.org/ns/activitystreams",{"EmojiReact":"litepub:EmojiReact","directMessage":"litepub:directMessage","litepub":"http://litepub.social/ns#"}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONLDContextFor([]byte(tt.raw), mockLitepubContext)
+			if err != nil {
+				t.Fatalf("JSONLDContextFor() error = %s", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("JSONLDContextFor() got = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
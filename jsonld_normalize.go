@@ -0,0 +1,766 @@
+package activitypub
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-ap/errors"
+	"github.com/valyala/fastjson"
+)
+
+const (
+	rdfNS         = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	rdfType       = rdfNS + "type"
+	rdfFirst      = rdfNS + "first"
+	rdfRest       = rdfNS + "rest"
+	rdfNil        = rdfNS + "nil"
+	rdfLangString = rdfNS + "langString"
+
+	xsdNS      = "http://www.w3.org/2001/XMLSchema#"
+	xsdString  = xsdNS + "string"
+	xsdBoolean = xsdNS + "boolean"
+	xsdInteger = xsdNS + "integer"
+	xsdDouble  = xsdNS + "double"
+)
+
+var (
+	// ErrJSONLDRelativeIRI is returned for the relative IRIs of the JSON-LD documents which don't have a "@base"
+	// to resolve them against.
+	ErrJSONLDRelativeIRI = errors.Newf("relative IRI without a base IRI")
+	// ErrJSONLDUnsupported is returned for the JSON-LD keywords which NormalizeJSONLD doesn't support.
+	ErrJSONLDUnsupported = errors.Newf("unsupported JSON-LD keyword")
+)
+
+// NormalizeJSONLD converts the data JSON-LD document to RDF, and returns its canonical N-Quads form,
+// as produced by the URDNA2015 algorithm of the RDF Dataset Canonicalization specification.
+// This is the normalization used by the Linked Data Signatures, like RsaSignature2017.
+//
+// The conversion supports the subset of JSON-LD used by the ActivityPub documents: aliases of keywords,
+// compact IRIs, "@vocab", "@base", type coercion, language or list containers, and named graphs.
+// Like a JSON-LD processor does, it drops the properties whose terms are not defined by the "@context".
+// The relative IRIs which can't be resolved, because the document doesn't have a "@base", return
+// an ErrJSONLDRelativeIRI error, and the "@reverse", "@nest" and "@included" keywords return
+// an ErrJSONLDUnsupported error, instead of being dropped.
+// The context documents referenced by IRI are loaded with the loader.
+func NormalizeJSONLD(data []byte, loader ContextLoader) ([]byte, error) {
+	val, err := fastjson.ParseBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	quads, err := jsonldToRDF(val, loader)
+	if err != nil {
+		return nil, err
+	}
+	return urdna2015(quads), nil
+}
+
+type rdfTermKind uint8
+
+const (
+	rdfIRI rdfTermKind = iota
+	rdfBlank
+	rdfLiteral
+)
+
+type rdfTerm struct {
+	kind     rdfTermKind
+	value    string
+	datatype string
+	language string
+}
+
+// rdfQuad is a triple of the graph of the dataset, which is the default graph when the graph term is empty.
+type rdfQuad struct {
+	subject, predicate, object, graph rdfTerm
+}
+
+func (t rdfTerm) isBlank() bool {
+	return t.kind == rdfBlank
+}
+
+func (t rdfTerm) isEmpty() bool {
+	return len(t.value) == 0
+}
+
+// nquad writes the term in its N-Quads form, with its blank node label replaced by label.
+func (t rdfTerm) nquad(b *strings.Builder, label string) {
+	switch t.kind {
+	case rdfIRI:
+		b.WriteByte('<')
+		b.WriteString(t.value)
+		b.WriteByte('>')
+	case rdfBlank:
+		b.WriteString("_:")
+		b.WriteString(label)
+	case rdfLiteral:
+		b.WriteByte('"')
+		for _, r := range t.value {
+			switch r {
+			case '\\':
+				b.WriteString(`\\`)
+			case '\t':
+				b.WriteString(`\t`)
+			case '\n':
+				b.WriteString(`\n`)
+			case '\r':
+				b.WriteString(`\r`)
+			case '"':
+				b.WriteString(`\"`)
+			default:
+				b.WriteRune(r)
+			}
+		}
+		b.WriteByte('"')
+		if len(t.language) > 0 {
+			b.WriteByte('@')
+			b.WriteString(t.language)
+		} else if len(t.datatype) > 0 && t.datatype != xsdString {
+			b.WriteString("^^<")
+			b.WriteString(t.datatype)
+			b.WriteByte('>')
+		}
+	}
+}
+
+// jsonldRDFConverter produces the RDF quads of a JSON-LD document, following the "Deserialize JSON-LD to RDF"
+// algorithm, without building the expanded form of the document first.
+type jsonldRDFConverter struct {
+	p      jsonldContextProcessor
+	quads  []rdfQuad
+	seen   map[rdfQuad]struct{}
+	blanks map[string]string
+	count  int
+	// graph is the name of the graph the quads are added to, empty for the default graph
+	graph rdfTerm
+}
+
+func jsonldToRDF(val *fastjson.Value, loader ContextLoader) ([]rdfQuad, error) {
+	if loader == nil {
+		loader = DefaultContextLoader
+	}
+	c := jsonldRDFConverter{
+		p:      jsonldContextProcessor{loader: loader, loaded: make(map[IRI]*fastjson.Value)},
+		seen:   make(map[rdfQuad]struct{}),
+		blanks: make(map[string]string),
+	}
+	if err := c.nodes(jsonldContext{terms: make(map[string]jsonldTerm)}, val, true); err != nil {
+		return nil, err
+	}
+	return c.quads, nil
+}
+
+// nodes converts the node objects of val, which can be a single one, or an array of them. The top level
+// objects which only have a "@graph", and a "@context", hold the nodes of the default graph.
+func (c *jsonldRDFConverter) nodes(ctx jsonldContext, val *fastjson.Value, top bool) error {
+	values := []*fastjson.Value{val}
+	if val.Type() == fastjson.TypeArray {
+		values = val.GetArray()
+	}
+	for _, v := range values {
+		if v.Type() != fastjson.TypeObject {
+			continue
+		}
+		if top {
+			lctx, graph, err := c.graphContainer(ctx, v)
+			if err != nil {
+				return err
+			}
+			if graph != nil {
+				if err = c.nodes(lctx, graph, false); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if _, err := c.node(ctx, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// graphContainer returns the "@graph" value of the val object, with its active context, if it has no other
+// properties than "@graph" and "@context".
+func (c *jsonldRDFConverter) graphContainer(ctx jsonldContext, val *fastjson.Value) (jsonldContext, *fastjson.Value, error) {
+	var err error
+	if local := val.Get("@context"); local != nil {
+		if ctx, err = c.p.process(ctx, local, 0); err != nil {
+			return ctx, nil, err
+		}
+	}
+	ob, _ := val.Object()
+	var graph *fastjson.Value
+	others := false
+	ob.Visit(func(key []byte, v *fastjson.Value) {
+		switch ctx.expandIRI(string(key), true) {
+		case "@graph":
+			graph = v
+		case "@context":
+		default:
+			others = true
+		}
+	})
+	if others {
+		return ctx, nil, nil
+	}
+	return ctx, graph, nil
+}
+
+// blank returns a new blank node, or the one corresponding to the label from the document.
+func (c *jsonldRDFConverter) blank(label string) rdfTerm {
+	if len(label) > 0 {
+		if id, ok := c.blanks[label]; ok {
+			return rdfTerm{kind: rdfBlank, value: id}
+		}
+	}
+	id := "b" + strconv.Itoa(c.count)
+	c.count++
+	if len(label) > 0 {
+		c.blanks[label] = id
+	}
+	return rdfTerm{kind: rdfBlank, value: id}
+}
+
+// iri returns the term for the expanded IRI s, and false for relative IRIs.
+func (c *jsonldRDFConverter) iri(s string) (rdfTerm, bool) {
+	if strings.HasPrefix(s, "_:") {
+		return c.blank(s), true
+	}
+	if strings.HasPrefix(s, "@") || !isAbsoluteIRI(s) {
+		return rdfTerm{}, false
+	}
+	return rdfTerm{kind: rdfIRI, value: s}, true
+}
+
+// ref returns the term for the s value of an "@id", or of an "@type" when vocab is true, resolving it
+// against the "@base" of the context if it's a relative IRI. The keywords don't have a term.
+func (c *jsonldRDFConverter) ref(ctx jsonldContext, s string, vocab bool) (rdfTerm, bool, error) {
+	s = ctx.expandIRI(s, vocab)
+	if strings.HasPrefix(s, "@") {
+		return rdfTerm{}, false, nil
+	}
+	iri, ok := ctx.resolveIRI(s)
+	if !ok {
+		return rdfTerm{}, false, errors.Annotatef(ErrJSONLDRelativeIRI, "%q", s)
+	}
+	t, ok := c.iri(iri)
+	return t, ok, nil
+}
+
+func (c *jsonldRDFConverter) add(s, p, o rdfTerm) {
+	q := rdfQuad{subject: s, predicate: p, object: o, graph: c.graph}
+	// NOTE(marius): the RDF dataset is a set, so we skip the duplicate quads
+	if _, ok := c.seen[q]; !ok {
+		c.seen[q] = struct{}{}
+		c.quads = append(c.quads, q)
+	}
+}
+
+// node converts the val node object, and returns its subject. When it has a "@graph", its nodes are
+// converted in the graph named by the subject.
+func (c *jsonldRDFConverter) node(ctx jsonldContext, val *fastjson.Value) (rdfTerm, error) {
+	var err error
+	if local := val.Get("@context"); local != nil {
+		if ctx, err = c.p.process(ctx, local, 0); err != nil {
+			return rdfTerm{}, err
+		}
+	}
+	ob, _ := val.Object()
+
+	var subject rdfTerm
+	var graph *fastjson.Value
+	ob.Visit(func(key []byte, v *fastjson.Value) {
+		if err != nil {
+			return
+		}
+		switch ctx.expandIRI(string(key), true) {
+		case "@id":
+			if v.Type() == fastjson.TypeString {
+				subject, _, err = c.ref(ctx, string(v.GetStringBytes()), false)
+			}
+		case "@graph":
+			graph = v
+		case "@reverse", "@nest", "@included":
+			err = errors.Annotatef(ErrJSONLDUnsupported, "%s", key)
+		}
+	})
+	if err != nil {
+		return subject, err
+	}
+	if subject.isEmpty() {
+		subject = c.blank("")
+	}
+	if err = c.properties(ctx, ob, subject); err != nil {
+		return subject, err
+	}
+	if graph != nil {
+		outer := c.graph
+		c.graph = subject
+		err = c.nodes(ctx, graph, false)
+		c.graph = outer
+	}
+	return subject, err
+}
+
+func (c *jsonldRDFConverter) properties(ctx jsonldContext, ob *fastjson.Object, subject rdfTerm) error {
+	var err error
+	ob.Visit(func(key []byte, v *fastjson.Value) {
+		if err != nil {
+			return
+		}
+		k := string(key)
+		prop := ctx.expandIRI(k, true)
+		switch prop {
+		case "@type":
+			values := []*fastjson.Value{v}
+			if v.Type() == fastjson.TypeArray {
+				values = v.GetArray()
+			}
+			for _, t := range values {
+				var o rdfTerm
+				var ok bool
+				if o, ok, err = c.ref(ctx, string(t.GetStringBytes()), true); err != nil {
+					return
+				}
+				if ok {
+					c.add(subject, rdfTerm{kind: rdfIRI, value: rdfType}, o)
+				}
+			}
+			return
+		case "@id", "@context", "@graph":
+			return
+		}
+		p, ok := c.iri(prop)
+		if !ok || p.isBlank() {
+			// NOTE(marius): properties which don't expand to absolute IRIs are dropped, like the ones whose
+			// terms are not defined, and so are the keywords which don't produce quads, like "@index"
+			return
+		}
+		var objects []rdfTerm
+		if objects, err = c.objects(ctx, ctx.terms[k], v); err != nil {
+			return
+		}
+		for _, o := range objects {
+			c.add(subject, p, o)
+		}
+	})
+	return err
+}
+
+// objects returns the RDF terms for the v value of a property with the def term definition.
+func (c *jsonldRDFConverter) objects(ctx jsonldContext, def jsonldTerm, v *fastjson.Value) ([]rdfTerm, error) {
+	switch v.Type() {
+	case fastjson.TypeNull:
+		return nil, nil
+	case fastjson.TypeArray:
+		if def.container == "@list" {
+			o, err := c.list(ctx, def, v.GetArray())
+			return []rdfTerm{o}, err
+		}
+		objects := make([]rdfTerm, 0, len(v.GetArray()))
+		for _, e := range v.GetArray() {
+			oo, err := c.objects(ctx, def, e)
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, oo...)
+		}
+		return objects, nil
+	case fastjson.TypeObject:
+		if def.container == "@language" {
+			return c.languageMap(v), nil
+		}
+		ob, _ := v.Object()
+		keywords := make(map[string]*fastjson.Value)
+		ob.Visit(func(key []byte, e *fastjson.Value) {
+			if k := ctx.expandIRI(string(key), true); strings.HasPrefix(k, "@") {
+				keywords[k] = e
+			}
+		})
+		if val, ok := keywords["@value"]; ok {
+			lit := jsonldTerm{}
+			if t := keywords["@type"]; t != nil {
+				lit.typ = ctx.expandIRI(string(t.GetStringBytes()), true)
+			}
+			o, ok := c.literal(lit, val)
+			if l := keywords["@language"]; ok && l != nil && o.datatype == xsdString {
+				o.language = string(l.GetStringBytes())
+				o.datatype = rdfLangString
+			}
+			if !ok {
+				return nil, nil
+			}
+			return []rdfTerm{o}, nil
+		}
+		if list, ok := keywords["@list"]; ok {
+			items := []*fastjson.Value{list}
+			if list.Type() == fastjson.TypeArray {
+				items = list.GetArray()
+			}
+			o, err := c.list(ctx, def, items)
+			return []rdfTerm{o}, err
+		}
+		if set, ok := keywords["@set"]; ok {
+			return c.objects(ctx, def, set)
+		}
+		o, err := c.node(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		return []rdfTerm{o}, nil
+	case fastjson.TypeString:
+		s := string(v.GetStringBytes())
+		if def.typ == "@id" || def.typ == "@vocab" {
+			o, ok, err := c.ref(ctx, s, def.typ == "@vocab")
+			if err != nil || !ok {
+				return nil, err
+			}
+			return []rdfTerm{o}, nil
+		}
+	}
+	if o, ok := c.literal(def, v); ok {
+		return []rdfTerm{o}, nil
+	}
+	return nil, nil
+}
+
+// literal converts the scalar v to a literal, with the datatype of the def term definition, if it has one.
+func (c *jsonldRDFConverter) literal(def jsonldTerm, v *fastjson.Value) (rdfTerm, bool) {
+	datatype := def.typ
+	if strings.HasPrefix(datatype, "@") {
+		datatype = ""
+	}
+	lit := rdfTerm{kind: rdfLiteral, datatype: datatype}
+	switch v.Type() {
+	case fastjson.TypeString:
+		lit.value = string(v.GetStringBytes())
+		if len(lit.datatype) == 0 {
+			lit.datatype = xsdString
+		}
+	case fastjson.TypeTrue, fastjson.TypeFalse:
+		lit.value = v.String()
+		if len(lit.datatype) == 0 {
+			lit.datatype = xsdBoolean
+		}
+	case fastjson.TypeNumber:
+		f := v.GetFloat64()
+		if f != math.Trunc(f) || math.Abs(f) >= 1e21 || datatype == xsdDouble {
+			lit.value = jsonldCanonicalDouble(f)
+			if len(lit.datatype) == 0 {
+				lit.datatype = xsdDouble
+			}
+		} else {
+			lit.value = strconv.FormatFloat(f, 'f', 0, 64)
+			if len(lit.datatype) == 0 {
+				lit.datatype = xsdInteger
+			}
+		}
+	default:
+		return lit, false
+	}
+	return lit, true
+}
+
+// jsonldCanonicalDouble formats f like the JSON-LD to RDF conversion does for xsd:double values, eg: 5.3E0.
+func jsonldCanonicalDouble(f float64) string {
+	s := strconv.FormatFloat(f, 'e', 15, 64)
+	mantissa, exp, _ := strings.Cut(s, "e")
+	if strings.Contains(mantissa, ".") {
+		mantissa = strings.TrimRight(mantissa, "0")
+		if strings.HasSuffix(mantissa, ".") {
+			mantissa += "0"
+		}
+	}
+	e, _ := strconv.Atoi(exp)
+	return mantissa + "E" + strconv.Itoa(e)
+}
+
+func (c *jsonldRDFConverter) languageMap(v *fastjson.Value) []rdfTerm {
+	var objects []rdfTerm
+	ob, _ := v.Object()
+	ob.Visit(func(key []byte, e *fastjson.Value) {
+		values := []*fastjson.Value{e}
+		if e.Type() == fastjson.TypeArray {
+			values = e.GetArray()
+		}
+		for _, s := range values {
+			if s.Type() != fastjson.TypeString {
+				continue
+			}
+			lit := rdfTerm{kind: rdfLiteral, value: string(s.GetStringBytes()), datatype: xsdString}
+			if lang := string(key); lang != "@none" {
+				lit.language = lang
+				lit.datatype = rdfLangString
+			}
+			objects = append(objects, lit)
+		}
+	})
+	return objects
+}
+
+// list builds the rdf:first/rdf:rest chain for the items, and returns its head.
+func (c *jsonldRDFConverter) list(ctx jsonldContext, def jsonldTerm, items []*fastjson.Value) (rdfTerm, error) {
+	def.container = ""
+	objects := make([]rdfTerm, 0, len(items))
+	for _, it := range items {
+		oo, err := c.objects(ctx, def, it)
+		if err != nil {
+			return rdfTerm{}, err
+		}
+		objects = append(objects, oo...)
+	}
+	head := rdfTerm{kind: rdfIRI, value: rdfNil}
+	for i := len(objects) - 1; i >= 0; i-- {
+		node := c.blank("")
+		c.add(node, rdfTerm{kind: rdfIRI, value: rdfFirst}, objects[i])
+		c.add(node, rdfTerm{kind: rdfIRI, value: rdfRest}, head)
+		head = node
+	}
+	return head, nil
+}
+
+// canonicalIssuer issues the identifiers of the blank nodes, in the order they are requested.
+type canonicalIssuer struct {
+	prefix string
+	issued map[string]string
+	order  []string
+}
+
+func newCanonicalIssuer(prefix string) *canonicalIssuer {
+	return &canonicalIssuer{prefix: prefix, issued: make(map[string]string)}
+}
+
+func (i *canonicalIssuer) issue(id string) string {
+	if c, ok := i.issued[id]; ok {
+		return c
+	}
+	c := i.prefix + strconv.Itoa(len(i.order))
+	i.issued[id] = c
+	i.order = append(i.order, id)
+	return c
+}
+
+func (i *canonicalIssuer) clone() *canonicalIssuer {
+	n := &canonicalIssuer{prefix: i.prefix, issued: make(map[string]string, len(i.issued)), order: slices.Clone(i.order)}
+	for k, v := range i.issued {
+		n.issued[k] = v
+	}
+	return n
+}
+
+// urdna2015 implements the URDNA2015 canonicalization algorithm, which is the same as RDFC-1.0 with SHA-256.
+func urdna2015(quads []rdfQuad) []byte {
+	c := canonicalizer{
+		blankQuads: make(map[string][]rdfQuad),
+		canonical:  newCanonicalIssuer("c14n"),
+		firstHash:  make(map[string]string),
+	}
+	for _, q := range quads {
+		for _, t := range []rdfTerm{q.subject, q.object, q.graph} {
+			if t.isBlank() && !slices.Contains(c.blankQuads[t.value], q) {
+				c.blankQuads[t.value] = append(c.blankQuads[t.value], q)
+			}
+		}
+	}
+
+	byHash := make(map[string][]string)
+	for id := range c.blankQuads {
+		h := c.hashFirstDegree(id)
+		byHash[h] = append(byHash[h], id)
+	}
+	hashes := make([]string, 0, len(byHash))
+	for h := range byHash {
+		hashes = append(hashes, h)
+	}
+	slices.Sort(hashes)
+	for _, h := range hashes {
+		if len(byHash[h]) == 1 {
+			c.canonical.issue(byHash[h][0])
+		}
+	}
+	for _, h := range hashes {
+		ids := byHash[h]
+		if len(ids) == 1 {
+			continue
+		}
+		slices.Sort(ids)
+		type result struct {
+			hash   string
+			issuer *canonicalIssuer
+		}
+		results := make([]result, 0, len(ids))
+		for _, id := range ids {
+			if _, ok := c.canonical.issued[id]; ok {
+				continue
+			}
+			tmp := newCanonicalIssuer("b")
+			tmp.issue(id)
+			h, issuer := c.hashNDegree(id, tmp)
+			results = append(results, result{hash: h, issuer: issuer})
+		}
+		slices.SortStableFunc(results, func(a, b result) int { return strings.Compare(a.hash, b.hash) })
+		for _, r := range results {
+			for _, id := range r.issuer.order {
+				c.canonical.issue(id)
+			}
+		}
+	}
+
+	lines := make([]string, 0, len(quads))
+	for _, q := range quads {
+		lines = append(lines, c.nquad(q, func(t rdfTerm) string { return c.canonical.issued[t.value] }))
+	}
+	slices.Sort(lines)
+	return []byte(strings.Join(lines, ""))
+}
+
+type canonicalizer struct {
+	blankQuads map[string][]rdfQuad
+	canonical  *canonicalIssuer
+	firstHash  map[string]string
+}
+
+func (c *canonicalizer) nquad(q rdfQuad, label func(rdfTerm) string) string {
+	b := strings.Builder{}
+	q.subject.nquad(&b, label(q.subject))
+	b.WriteByte(' ')
+	q.predicate.nquad(&b, "")
+	b.WriteByte(' ')
+	q.object.nquad(&b, label(q.object))
+	if !q.graph.isEmpty() {
+		b.WriteByte(' ')
+		q.graph.nquad(&b, label(q.graph))
+	}
+	b.WriteString(" .\n")
+	return b.String()
+}
+
+func sha256Hex(s string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(s)))
+}
+
+// hashFirstDegree hashes the quads which reference the id blank node, in which it is labeled "a" and the
+// other blank nodes "z".
+func (c *canonicalizer) hashFirstDegree(id string) string {
+	if h, ok := c.firstHash[id]; ok {
+		return h
+	}
+	lines := make([]string, 0, len(c.blankQuads[id]))
+	for _, q := range c.blankQuads[id] {
+		lines = append(lines, c.nquad(q, func(t rdfTerm) string {
+			if t.value == id {
+				return "a"
+			}
+			return "z"
+		}))
+	}
+	slices.Sort(lines)
+	h := sha256Hex(strings.Join(lines, ""))
+	c.firstHash[id] = h
+	return h
+}
+
+func (c *canonicalizer) hashRelated(related string, q rdfQuad, issuer *canonicalIssuer, position string) string {
+	var id string
+	if cid, ok := c.canonical.issued[related]; ok {
+		id = "_:" + cid
+	} else if tid, ok := issuer.issued[related]; ok {
+		id = "_:" + tid
+	} else {
+		id = c.hashFirstDegree(related)
+	}
+	input := position
+	if position != "g" {
+		input += "<" + q.predicate.value + ">"
+	}
+	return sha256Hex(input + id)
+}
+
+func (c *canonicalizer) hashNDegree(id string, issuer *canonicalIssuer) (string, *canonicalIssuer) {
+	related := make(map[string][]string)
+	for _, q := range c.blankQuads[id] {
+		for _, comp := range []struct {
+			t   rdfTerm
+			pos string
+		}{{q.subject, "s"}, {q.object, "o"}, {q.graph, "g"}} {
+			if !comp.t.isBlank() || comp.t.value == id {
+				continue
+			}
+			h := c.hashRelated(comp.t.value, q, issuer, comp.pos)
+			related[h] = append(related[h], comp.t.value)
+		}
+	}
+	hashes := make([]string, 0, len(related))
+	for h := range related {
+		hashes = append(hashes, h)
+	}
+	slices.Sort(hashes)
+
+	data := bytes.Buffer{}
+	for _, h := range hashes {
+		data.WriteString(h)
+		chosenPath := ""
+		var chosenIssuer *canonicalIssuer
+		permute(related[h], func(perm []string) {
+			issuerCopy := issuer.clone()
+			path := ""
+			var recursion []string
+			longer := func() bool {
+				return len(chosenPath) > 0 && len(path) >= len(chosenPath) && path > chosenPath
+			}
+			for _, r := range perm {
+				if cid, ok := c.canonical.issued[r]; ok {
+					path += "_:" + cid
+				} else {
+					if _, ok := issuerCopy.issued[r]; !ok {
+						recursion = append(recursion, r)
+					}
+					path += "_:" + issuerCopy.issue(r)
+				}
+				if longer() {
+					return
+				}
+			}
+			for _, r := range recursion {
+				rh, ri := c.hashNDegree(r, issuerCopy)
+				path += "_:" + issuerCopy.issue(r)
+				path += "<" + rh + ">"
+				issuerCopy = ri
+				if longer() {
+					return
+				}
+			}
+			if len(chosenPath) == 0 || path < chosenPath {
+				chosenPath = path
+				chosenIssuer = issuerCopy
+			}
+		})
+		data.WriteString(chosenPath)
+		issuer = chosenIssuer
+	}
+	return sha256Hex(data.String()), issuer
+}
+
+// permute calls fn with all the permutations of the ids.
+func permute(ids []string, fn func([]string)) {
+	perm := slices.Clone(ids)
+	slices.Sort(perm)
+	var generate func(k int)
+	generate = func(k int) {
+		if k == len(perm) {
+			fn(slices.Clone(perm))
+			return
+		}
+		for i := k; i < len(perm); i++ {
+			perm[k], perm[i] = perm[i], perm[k]
+			generate(k + 1)
+			perm[k], perm[i] = perm[i], perm[k]
+		}
+	}
+	generate(0)
+}
@@ -0,0 +1,198 @@
+package activitypub
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNormalizeJSONLD(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "empty",
+			doc:  `{}`,
+			want: ``,
+		},
+		{
+			name: "blank node",
+			doc:  `{"@context":{"name":"http://schema.org/name"},"name":"x"}`,
+			want: `_:c14n0 <http://schema.org/name> "x" .` + "\n",
+		},
+		{
+			name: "undefined terms are dropped",
+			doc:  `{"@context":{"name":"http://schema.org/name"},"id":"https://example.com/1","name":"x","other":"y"}`,
+			want: `_:c14n0 <http://schema.org/name> "x" .` + "\n",
+		},
+		{
+			name:    "relative subject",
+			doc:     `{"@context":{"name":"http://schema.org/name"},"@id":"/1","name":"x"}`,
+			wantErr: ErrJSONLDRelativeIRI,
+		},
+		{
+			name: "relative IRIs with a base",
+			doc: `{"@context":{"@base":"https://example.com/notes/","name":"http://schema.org/name","rel":{"@id":"http://example.com/rel","@type":"@id"}},` +
+				`"@id":"1","name":"x","rel":"../2"}`,
+			want: `<https://example.com/notes/1> <http://example.com/rel> <https://example.com/2> .` + "\n" +
+				`<https://example.com/notes/1> <http://schema.org/name> "x" .` + "\n",
+		},
+		{
+			name:    "reverse properties",
+			doc:     `{"@context":{"@vocab":"http://example.com/"},"@id":"http://example.com/1","@reverse":{"p":{"@id":"http://example.com/2"}}}`,
+			wantErr: ErrJSONLDUnsupported,
+		},
+		{
+			name: "default graph",
+			doc:  `{"@context":{"@vocab":"http://example.com/"},"@graph":[{"@id":"http://example.com/1","p":"a"},{"@id":"http://example.com/2","p":"b"}]}`,
+			want: `<http://example.com/1> <http://example.com/p> "a" .` + "\n" +
+				`<http://example.com/2> <http://example.com/p> "b" .` + "\n",
+		},
+		{
+			name: "named graph",
+			doc:  `{"@context":{"@vocab":"http://example.com/"},"@id":"http://example.com/g","p":"meta","@graph":{"@id":"http://example.com/1","p":"a"}}`,
+			want: `<http://example.com/1> <http://example.com/p> "a" <http://example.com/g> .` + "\n" +
+				`<http://example.com/g> <http://example.com/p> "meta" .` + "\n",
+		},
+		{
+			name: "blank node graph",
+			doc:  `{"@context":{"@vocab":"http://example.com/"},"@graph":{"@id":"http://example.com/1","p":"a"},"p":"meta"}`,
+			want: `<http://example.com/1> <http://example.com/p> "a" _:c14n0 .` + "\n" +
+				`_:c14n0 <http://example.com/p> "meta" .` + "\n",
+		},
+		{
+			name: "escaped literal",
+			doc:  `{"@context":{"@vocab":"http://example.com/"},"@id":"http://example.com/1","s":"a\"b\\c\nd\re\tf"}`,
+			want: `<http://example.com/1> <http://example.com/s> "a\"b\\c\nd\re\tf" .` + "\n",
+		},
+		{
+			name: "typed literals",
+			doc:  `{"@context":{"@vocab":"http://example.com/"},"@id":"http://example.com/1","i":10,"d":-71.184902,"b":false}`,
+			want: `<http://example.com/1> <http://example.com/b> "false"^^<http://www.w3.org/2001/XMLSchema#boolean> .` + "\n" +
+				`<http://example.com/1> <http://example.com/d> "-7.118490199999999E1"^^<http://www.w3.org/2001/XMLSchema#double> .` + "\n" +
+				`<http://example.com/1> <http://example.com/i> "10"^^<http://www.w3.org/2001/XMLSchema#integer> .` + "\n",
+		},
+		{
+			name: "language map and value objects",
+			doc:  `{"@context":{"@vocab":"http://example.com/","m":{"@container":"@language"}},"@id":"http://example.com/1","m":{"en":"hi","ro":"salut"},"v":{"@value":"x","@type":"http://example.com/t"}}`,
+			want: `<http://example.com/1> <http://example.com/m> "hi"@en .` + "\n" +
+				`<http://example.com/1> <http://example.com/m> "salut"@ro .` + "\n" +
+				`<http://example.com/1> <http://example.com/v> "x"^^<http://example.com/t> .` + "\n",
+		},
+		{
+			name: "list",
+			doc:  `{"@context":{"@vocab":"http://example.com/","l":{"@container":"@list"}},"@id":"http://example.com/1","l":["a","b"]}`,
+			want: `<http://example.com/1> <http://example.com/l> _:c14n1 .` + "\n" +
+				`_:c14n0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "b" .` + "\n" +
+				`_:c14n0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .` + "\n" +
+				`_:c14n1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "a" .` + "\n" +
+				`_:c14n1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> _:c14n0 .` + "\n",
+		},
+		{
+			name: "activitystreams",
+			doc: `{"@context":"https://www.w3.org/ns/activitystreams","id":"https://example.com/1","type":"Note",` +
+				`"to":"https://www.w3.org/ns/activitystreams#Public","published":"2020-01-01T00:00:00Z"}`,
+			want: `<https://example.com/1> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <https://www.w3.org/ns/activitystreams#Note> .` + "\n" +
+				`<https://example.com/1> <https://www.w3.org/ns/activitystreams#published> "2020-01-01T00:00:00Z"^^<http://www.w3.org/2001/XMLSchema#dateTime> .` + "\n" +
+				`<https://example.com/1> <https://www.w3.org/ns/activitystreams#to> <https://www.w3.org/ns/activitystreams#Public> .` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeJSONLD([]byte(tt.doc), nil)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("NormalizeJSONLD() error = %v, want %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("NormalizeJSONLD() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeJSONLD_blankNodeLabels(t *testing.T) {
+	// NOTE(marius): the documents of each set describe the same graph, with blank nodes which can only
+	// be told apart by their relations, so their canonical forms must be the same.
+	sets := [][]string{
+		{
+			`{"@context":{"@vocab":"http://example.com/","k":{"@type":"@id"}},"@id":"_:a","k":{"@id":"_:b","k":"_:c","n":"x"},"n":"y"}`,
+			`{"@context":{"@vocab":"http://example.com/","k":{"@type":"@id"}},"n":"y","@id":"_:z","k":{"n":"x","k":"_:y","@id":"_:x"}}`,
+			`{"@context":{"@vocab":"http://example.com/","k":{"@type":"@id"}},"n":"y","k":{"n":"x","k":"_:b0"}}`,
+		},
+		{
+			`{"@context":{"@vocab":"http://example.com/","k":{"@type":"@id"}},"@id":"_:a","k":{"@id":"_:b","k":"_:a"}}`,
+			`{"@context":{"@vocab":"http://example.com/","k":{"@type":"@id"}},"@id":"_:q","k":{"@id":"_:p","k":"_:q"}}`,
+		},
+		{
+			`{"@context":{"@vocab":"http://example.com/","k":{"@type":"@id"}},"@id":"_:a","k":[{"@id":"_:b","k":"_:c"},{"@id":"_:c","k":"_:a"}]}`,
+			`{"@context":{"@vocab":"http://example.com/","k":{"@type":"@id"}},"@id":"_:c","k":[{"@id":"_:a","k":"_:b"},{"@id":"_:b","k":"_:c"}]}`,
+		},
+	}
+	for _, docs := range sets {
+		var want string
+		for i, doc := range docs {
+			got, err := NormalizeJSONLD([]byte(doc), nil)
+			if err != nil {
+				t.Fatalf("NormalizeJSONLD() error = %s", err)
+			}
+			if i == 0 {
+				want = string(got)
+				continue
+			}
+			if string(got) != want {
+				t.Errorf("NormalizeJSONLD(%s) =\n%s\nwant\n%s", doc, got, want)
+			}
+		}
+	}
+
+	got, _ := NormalizeJSONLD([]byte(sets[1][0]), nil)
+	want := `_:c14n0 <http://example.com/k> _:c14n1 .` + "\n" + `_:c14n1 <http://example.com/k> _:c14n0 .` + "\n"
+	if string(got) != want {
+		t.Errorf("NormalizeJSONLD() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func Test_canonicalizer_hashFirstDegree(t *testing.T) {
+	x := rdfTerm{kind: rdfBlank, value: "x"}
+	y := rdfTerm{kind: rdfBlank, value: "y"}
+	k := rdfTerm{kind: rdfIRI, value: "http://example.com/k"}
+	p := rdfTerm{kind: rdfIRI, value: "http://example.com/p"}
+	inGraph := rdfQuad{subject: rdfTerm{kind: rdfIRI, value: "http://example.com/1"}, predicate: p, object: rdfTerm{kind: rdfLiteral, value: "a", datatype: xsdString}, graph: x}
+	link := rdfQuad{subject: x, predicate: k, object: y}
+	c := canonicalizer{
+		blankQuads: map[string][]rdfQuad{"x": {link, inGraph}, "y": {link}},
+		canonical:  newCanonicalIssuer("c14n"),
+		firstHash:  make(map[string]string),
+	}
+
+	// NOTE(marius): the hashed data is the sorted N-Quads of the node, with it labeled "a", and the others "z",
+	// including when they name the graph of the quad
+	tests := map[string]string{
+		"x": `<http://example.com/1> <http://example.com/p> "a" _:a .` + "\n" + `_:a <http://example.com/k> _:z .` + "\n",
+		"y": `_:z <http://example.com/k> _:a .` + "\n",
+	}
+	for id, data := range tests {
+		want := fmt.Sprintf("%x", sha256.Sum256([]byte(data)))
+		if got := c.hashFirstDegree(id); got != want {
+			t.Errorf("hashFirstDegree(%s) = %s, want %s", id, got, want)
+		}
+	}
+}
+
+func Test_jsonldCanonicalDouble(t *testing.T) {
+	tests := map[float64]string{
+		5.3:       "5.3E0",
+		1:         "1.0E0",
+		-0.000015: "-1.5E-5",
+		1.5e300:   "1.5E300",
+	}
+	for f, want := range tests {
+		if got := jsonldCanonicalDouble(f); got != want {
+			t.Errorf("jsonldCanonicalDouble(%v) = %s, want %s", f, got, want)
+		}
+	}
+}
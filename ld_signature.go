@@ -0,0 +1,259 @@
+package activitypub
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-ap/errors"
+	"github.com/valyala/fastjson"
+)
+
+const (
+	// RsaSignature2017Type is the type of the Linked Data Signatures that Mastodon attaches to the
+	// public activities, so they can be relayed by other servers than the one of the actor.
+	RsaSignature2017Type ActivityVocabularyType = "RsaSignature2017"
+
+	// IdentityContextURI is the URI of the JSON-LD context used for normalizing the options of the
+	// Linked Data Signatures
+	IdentityContextURI = IRI("https://w3id.org/identity/v1")
+)
+
+// LinkedDataSignature holds the "signature" property of an activity, as created by the
+// Linked Data Signatures 1.0 draft.
+// The document reference can be found at:
+// https://web.archive.org/web/20170717200644/https://w3c-dvcg.github.io/ld-signatures/
+type LinkedDataSignature struct {
+	Type           ActivityVocabularyType `jsonld:"type,omitempty"`
+	Creator        IRI                    `jsonld:"creator,omitempty"`
+	Created        time.Time              `jsonld:"created,omitempty"`
+	SignatureValue string                 `jsonld:"signatureValue,omitempty"`
+}
+
+func (s *LinkedDataSignature) UnmarshalJSON(data []byte) error {
+	val, err := parseJSON(data)
+	if err != nil {
+		return err
+	}
+	JSONLoadLinkedDataSignature(val, s)
+	return nil
+}
+
+func (s LinkedDataSignature) MarshalJSON() ([]byte, error) {
+	return jsonMarshal(s)
+}
+
+func (s LinkedDataSignature) writeJSON(b *bytes.Buffer, _ EncodeOptions) bool {
+	notEmpty := false
+	start := b.Len()
+	JSONWrite(b, '{')
+	if len(s.Type) > 0 {
+		if t, err := json.Marshal(s.Type); err == nil {
+			notEmpty = JSONWriteProp(b, "type", t, notEmpty) || notEmpty
+		}
+	}
+	if len(s.Creator) > 0 {
+		notEmpty = JSONWriteIRIProp(b, "creator", s.Creator, notEmpty) || notEmpty
+	}
+	if !s.Created.IsZero() {
+		notEmpty = JSONWriteTimeProp(b, "created", s.Created, notEmpty) || notEmpty
+	}
+	if len(s.SignatureValue) > 0 {
+		if v, err := json.Marshal(s.SignatureValue); err == nil {
+			notEmpty = JSONWriteProp(b, "signatureValue", v, notEmpty) || notEmpty
+		}
+	}
+	if !notEmpty {
+		b.Truncate(start)
+		return false
+	}
+	JSONWrite(b, '}')
+	return true
+}
+
+// GobEncode
+func (s LinkedDataSignature) GobEncode() ([]byte, error) {
+	var (
+		mm      = make(map[string][]byte)
+		err     error
+		hasData bool
+	)
+	if len(s.Type) > 0 {
+		if mm["type"], err = s.Type.GobEncode(); err != nil {
+			return nil, err
+		}
+		hasData = true
+	}
+	if len(s.Creator) > 0 {
+		if mm["creator"], err = s.Creator.GobEncode(); err != nil {
+			return nil, err
+		}
+		hasData = true
+	}
+	if !s.Created.IsZero() {
+		if mm["created"], err = s.Created.GobEncode(); err != nil {
+			return nil, err
+		}
+		hasData = true
+	}
+	if len(s.SignatureValue) > 0 {
+		mm["signatureValue"] = []byte(s.SignatureValue)
+		hasData = true
+	}
+	if !hasData {
+		return []byte{}, nil
+	}
+	bb := bytes.Buffer{}
+	g := gob.NewEncoder(&bb)
+	if err := g.Encode(mm); err != nil {
+		return nil, err
+	}
+	return bb.Bytes(), nil
+}
+
+// GobDecode
+func (s *LinkedDataSignature) GobDecode(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	mm, err := gobDecodeObjectAsMap(data)
+	if err != nil {
+		return err
+	}
+	if raw, ok := mm["type"]; ok {
+		if err = s.Type.GobDecode(raw); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["creator"]; ok {
+		if err = s.Creator.GobDecode(raw); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["created"]; ok {
+		if err = s.Created.GobDecode(raw); err != nil {
+			return err
+		}
+	}
+	if raw, ok := mm["signatureValue"]; ok {
+		s.SignatureValue = string(raw)
+	}
+	return nil
+}
+
+func JSONLoadLinkedDataSignature(val *fastjson.Value, s *LinkedDataSignature) {
+	s.Type = ActivityVocabularyType(val.GetStringBytes("type"))
+	s.Creator = JSONGetIRI(val, "creator")
+	s.Created = JSONGetTime(val, "created")
+	s.SignatureValue = string(val.GetStringBytes("signatureValue"))
+}
+
+// JSONGetLinkedDataSignature loads the prop property of val as a LinkedDataSignature, if it is a JSON object.
+func JSONGetLinkedDataSignature(val *fastjson.Value, prop string) *LinkedDataSignature {
+	v := val.Get(prop)
+	if v == nil || v.Type() != fastjson.TypeObject {
+		return nil
+	}
+	s := new(LinkedDataSignature)
+	JSONLoadLinkedDataSignature(v, s)
+	return s
+}
+
+// VerifyRsaSignature2017 checks the RsaSignature2017 Linked Data Signature of the doc JSON-LD document, like
+// the ones Mastodon attaches to the public activities, and returns the IRI of the key which created it.
+// The caller still needs to check that the owner of the key is the actor of the activity.
+//
+// The document, and the signature options, are normalized with the URDNA2015 algorithm, loading the contexts
+// they reference with the contexts ContextLoader. When it's nil, the DefaultContextLoader is used, which
+// doesn't access the network.
+// The key is loaded with the keys VerificationMethodLoader, and it must be an RSA key.
+// Any error returned is a *Error, with one of the ErrProof* values as its Reason.
+func VerifyRsaSignature2017(doc []byte, keys VerificationMethodLoader, contexts ContextLoader) (IRI, error) {
+	p := fastjson.Parser{}
+	val, err := p.ParseBytes(doc)
+	if err != nil {
+		return "", proofErr(ErrProofInvalid, err)
+	}
+	ob, err := val.Object()
+	if err != nil {
+		return "", proofErr(ErrProofInvalid, err)
+	}
+	sv := ob.Get("signature")
+	if sv == nil {
+		return "", proofErr(ErrProofMissing, errors.Newf("the document doesn't have a signature"))
+	}
+	if sv.Type() != fastjson.TypeObject {
+		return "", proofErr(ErrProofInvalid, errors.Newf("expected a JSON object, got %s", sv.Type()))
+	}
+	sig := LinkedDataSignature{}
+	JSONLoadLinkedDataSignature(sv, &sig)
+	if sig.Type != RsaSignature2017Type {
+		return "", proofErr(ErrProofUnsupported, errors.Newf("type %q", sig.Type))
+	}
+	if len(sig.Creator) == 0 {
+		return "", proofErr(ErrProofInvalid, errors.Newf("missing creator"))
+	}
+	raw, err := base64.StdEncoding.DecodeString(sig.SignatureValue)
+	if err != nil || len(raw) == 0 {
+		return sig.Creator, proofErr(ErrProofInvalid, errors.Newf("invalid signatureValue"))
+	}
+
+	key, err := keys(sig.Creator)
+	if err != nil {
+		return sig.Creator, proofErr(ErrProofKeyNotFound, err)
+	}
+	if key.ID.String() != sig.Creator.String() {
+		return sig.Creator, proofErr(ErrProofKeyNotFound, errors.Newf("loaded key %s instead of %s", key.ID, sig.Creator))
+	}
+	pub, err := key.CryptoKey()
+	if err != nil {
+		return sig.Creator, proofErr(ErrProofUnsupported, err)
+	}
+	rsaKey, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return sig.Creator, proofErr(ErrProofUnsupported, errors.Newf("%s requires an RSA key, got %T", RsaSignature2017Type, pub))
+	}
+
+	// NOTE(marius): the options are the properties of the signature, except its type, id and value, normalized
+	// with the identity context, and the document is normalized without its signature.
+	options := sv.GetObject()
+	options.Del("type")
+	options.Del("id")
+	options.Del("signatureValue")
+	options.Set("@context", fastjson.MustParse(`"`+IdentityContextURI.String()+`"`))
+	ob.Del("signature")
+
+	hash, err := rsaSignature2017Hash(sv.MarshalTo(nil), val.MarshalTo(nil), contexts)
+	if err != nil {
+		return sig.Creator, proofErr(ErrProofInvalid, err)
+	}
+	if err = rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, hash, raw); err != nil {
+		return sig.Creator, proofErr(ErrProofMismatch, err)
+	}
+	return sig.Creator, nil
+}
+
+// rsaSignature2017Hash returns the SHA-256 hash of the data which gets signed for an RsaSignature2017: the
+// hexadecimal SHA-256 hash of the normalized options, followed by the one of the normalized document.
+func rsaSignature2017Hash(options, doc []byte, contexts ContextLoader) ([]byte, error) {
+	if contexts == nil {
+		contexts = DefaultContextLoader
+	}
+	normalized, err := NormalizeJSONLD(options, contexts)
+	if err != nil {
+		return nil, err
+	}
+	toBeSigned := fmt.Sprintf("%x", sha256.Sum256(normalized))
+	if normalized, err = NormalizeJSONLD(doc, contexts); err != nil {
+		return nil, err
+	}
+	toBeSigned += fmt.Sprintf("%x", sha256.Sum256(normalized))
+	hash := sha256.Sum256([]byte(toBeSigned))
+	return hash[:], nil
+}
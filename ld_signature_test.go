@@ -0,0 +1,346 @@
+package activitypub
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	mastodonKeyID    = "https://mastodon.example/users/alice#main-key"
+	mastodonDocument = `{
+  "@context": [
+    "https://www.w3.org/ns/activitystreams",
+    "https://w3id.org/security/v1",
+    {
+      "toot": "http://joinmastodon.org/ns#",
+      "sensitive": "as:sensitive",
+      "Hashtag": "as:Hashtag",
+      "blurhash": "toot:blurhash"
+    }
+  ],
+  "id": "https://mastodon.example/users/alice/statuses/1/activity",
+  "type": "Create",
+  "actor": "https://mastodon.example/users/alice",
+  "published": "2024-03-01T10:00:00Z",
+  "to": ["https://www.w3.org/ns/activitystreams#Public"],
+  "cc": ["https://mastodon.example/users/alice/followers"],
+  "object": {
+    "id": "https://mastodon.example/users/alice/statuses/1",
+    "type": "Note",
+    "sensitive": false,
+    "content": "<p>Hello <a href=\"https://mastodon.example/tags/world\">#world</a></p>",
+    "contentMap": {"en": "<p>Hello <a href=\"https://mastodon.example/tags/world\">#world</a></p>"},
+    "attributedTo": "https://mastodon.example/users/alice",
+    "tag": [{"type": "Hashtag", "href": "https://mastodon.example/tags/world", "name": "#world"}],
+    "attachment": [{"type": "Document", "mediaType": "image/png", "url": "https://mastodon.example/1.png", "blurhash": "UBL_:rOpGG-o"}]
+  }
+}`
+)
+
+// The canonical N-Quads of the signature options and of mastodonDocument, derived by hand from the
+// ActivityStreams, identity and local contexts. The labels of the two blank nodes of the document follow
+// the order of the SHA-256 hashes of their first degree quads, 76f9becf... for the attachment, and
+// 80550f9f... for the tag, computed outside this package.
+const (
+	mastodonCreated       = "2024-03-01T10:00:01Z"
+	mastodonOptionsNQuads = `_:c14n0 <http://purl.org/dc/terms/created> "2024-03-01T10:00:01Z"^^<http://www.w3.org/2001/XMLSchema#dateTime> .
+_:c14n0 <http://purl.org/dc/terms/creator> <https://mastodon.example/users/alice#main-key> .
+`
+	mastodonDocumentNQuads = `<https://mastodon.example/users/alice/statuses/1/activity> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <https://www.w3.org/ns/activitystreams#Create> .
+<https://mastodon.example/users/alice/statuses/1/activity> <https://www.w3.org/ns/activitystreams#actor> <https://mastodon.example/users/alice> .
+<https://mastodon.example/users/alice/statuses/1/activity> <https://www.w3.org/ns/activitystreams#cc> <https://mastodon.example/users/alice/followers> .
+<https://mastodon.example/users/alice/statuses/1/activity> <https://www.w3.org/ns/activitystreams#object> <https://mastodon.example/users/alice/statuses/1> .
+<https://mastodon.example/users/alice/statuses/1/activity> <https://www.w3.org/ns/activitystreams#published> "2024-03-01T10:00:00Z"^^<http://www.w3.org/2001/XMLSchema#dateTime> .
+<https://mastodon.example/users/alice/statuses/1/activity> <https://www.w3.org/ns/activitystreams#to> <https://www.w3.org/ns/activitystreams#Public> .
+<https://mastodon.example/users/alice/statuses/1> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <https://www.w3.org/ns/activitystreams#Note> .
+<https://mastodon.example/users/alice/statuses/1> <https://www.w3.org/ns/activitystreams#attachment> _:c14n0 .
+<https://mastodon.example/users/alice/statuses/1> <https://www.w3.org/ns/activitystreams#attributedTo> <https://mastodon.example/users/alice> .
+<https://mastodon.example/users/alice/statuses/1> <https://www.w3.org/ns/activitystreams#content> "<p>Hello <a href=\"https://mastodon.example/tags/world\">#world</a></p>" .
+<https://mastodon.example/users/alice/statuses/1> <https://www.w3.org/ns/activitystreams#content> "<p>Hello <a href=\"https://mastodon.example/tags/world\">#world</a></p>"@en .
+<https://mastodon.example/users/alice/statuses/1> <https://www.w3.org/ns/activitystreams#sensitive> "false"^^<http://www.w3.org/2001/XMLSchema#boolean> .
+<https://mastodon.example/users/alice/statuses/1> <https://www.w3.org/ns/activitystreams#tag> _:c14n1 .
+_:c14n0 <http://joinmastodon.org/ns#blurhash> "UBL_:rOpGG-o" .
+_:c14n0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <https://www.w3.org/ns/activitystreams#Document> .
+_:c14n0 <https://www.w3.org/ns/activitystreams#mediaType> "image/png" .
+_:c14n0 <https://www.w3.org/ns/activitystreams#url> <https://mastodon.example/1.png> .
+_:c14n1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <https://www.w3.org/ns/activitystreams#Hashtag> .
+_:c14n1 <https://www.w3.org/ns/activitystreams#href> <https://mastodon.example/tags/world> .
+_:c14n1 <https://www.w3.org/ns/activitystreams#name> "#world" .
+`
+)
+
+// signRsaSignature2017 adds to mastodonDocument an RsaSignature2017 created with the key, like Mastodon does.
+//
+// NOTE(marius): the signed data is built from the N-Quads above, and not with rsaSignature2017Hash, so the
+// verification doesn't check the normalization against itself.
+func signRsaSignature2017(t *testing.T, key *rsa.PrivateKey) string {
+	t.Helper()
+	toBeSigned := fmt.Sprintf("%x%x", sha256.Sum256([]byte(mastodonOptionsNQuads)), sha256.Sum256([]byte(mastodonDocumentNQuads)))
+	hash := sha256.Sum256([]byte(toBeSigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatalf("unable to sign: %s", err)
+	}
+	signature := fmt.Sprintf(`{"type":"RsaSignature2017","creator":%q,"created":%q,"signatureValue":%q}`,
+		mastodonKeyID, mastodonCreated, base64.StdEncoding.EncodeToString(sig))
+	return strings.TrimSuffix(strings.TrimSpace(mastodonDocument), "}") + `,"signature":` + signature + "}"
+}
+
+func TestNormalizeJSONLD_mastodon(t *testing.T) {
+	got, err := NormalizeJSONLD([]byte(mastodonDocument), nil)
+	if err != nil {
+		t.Fatalf("NormalizeJSONLD() error = %s", err)
+	}
+	if string(got) != mastodonDocumentNQuads {
+		t.Errorf("NormalizeJSONLD() =\n%s\nwant\n%s", got, mastodonDocumentNQuads)
+	}
+	options := fmt.Sprintf(`{"@context":%q,"creator":%q,"created":%q}`, string(IdentityContextURI), mastodonKeyID, mastodonCreated)
+	if got, err = NormalizeJSONLD([]byte(options), nil); err != nil {
+		t.Fatalf("NormalizeJSONLD() error = %s", err)
+	}
+	if string(got) != mastodonOptionsNQuads {
+		t.Errorf("NormalizeJSONLD() =\n%s\nwant\n%s", got, mastodonOptionsNQuads)
+	}
+}
+
+func TestVerifyRsaSignature2017(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate RSA key: %s", err)
+	}
+	owner := &Actor{ID: "https://mastodon.example/users/alice"}
+	pub, err := NewPublicKey(&key.PublicKey, owner)
+	if err != nil {
+		t.Fatalf("NewPublicKey() error = %s", err)
+	}
+	keys := func(id IRI) (PublicKey, error) {
+		if id != mastodonKeyID {
+			return PublicKey{}, fmt.Errorf("key %s not found", id)
+		}
+		return pub, nil
+	}
+	_, ek, _ := mockKeys(t)
+	edKey, err := NewPublicKey(ek, owner)
+	if err != nil {
+		t.Fatalf("NewPublicKey() error = %s", err)
+	}
+
+	signed := signRsaSignature2017(t, key)
+
+	tests := []struct {
+		name     string
+		doc      string
+		keys     VerificationMethodLoader
+		contexts ContextLoader
+		wantErr  error
+	}{
+		{
+			name: "mastodon activity",
+			doc:  signed,
+		},
+		{
+			name: "reordered properties",
+			doc:  strings.Replace(strings.Replace(signed, `"type": "Note",`, "", 1), `"sensitive": false,`, `"sensitive": false, "type": "Note",`, 1),
+		},
+		{
+			name: "contexts from the loader",
+			doc:  signed,
+			contexts: ContextLoaderFn(func(iri IRI) ([]byte, error) {
+				return DefaultContextLoader.LoadContext(iri)
+			}),
+		},
+		{
+			name: "identity context missing from the loader",
+			doc:  signed,
+			contexts: ContextLoaderFn(func(iri IRI) ([]byte, error) {
+				if iri == IdentityContextURI {
+					return nil, ErrContextNotFound
+				}
+				return DefaultContextLoader.LoadContext(iri)
+			}),
+			wantErr: ErrProofInvalid,
+		},
+		{
+			name: "changes to undefined terms",
+			doc:  strings.Replace(signed, `"mediaType"`, `"undefined": 1, "mediaType"`, 1),
+		},
+		{
+			name:    "tampered content",
+			doc:     strings.Replace(signed, "Hello", "Goodbye", 1),
+			wantErr: ErrProofMismatch,
+		},
+		{
+			name:    "tampered created",
+			doc:     strings.Replace(signed, `"created":"2024-03-01T10:00:01Z"`, `"created":"2024-03-01T10:00:02Z"`, 1),
+			wantErr: ErrProofMismatch,
+		},
+		{
+			name:    "missing signature",
+			doc:     mastodonDocument,
+			wantErr: ErrProofMissing,
+		},
+		{
+			name:    "not an object",
+			doc:     `"https://mastodon.example/users/alice/statuses/1/activity"`,
+			wantErr: ErrProofInvalid,
+		},
+		{
+			name:    "another type",
+			doc:     strings.Replace(signed, `"type":"RsaSignature2017"`, `"type":"Ed25519Signature2018"`, 1),
+			wantErr: ErrProofUnsupported,
+		},
+		{
+			name:    "invalid signatureValue",
+			doc:     strings.Replace(signed, `"signatureValue":"`, `"signatureValue":"!`, 1),
+			wantErr: ErrProofInvalid,
+		},
+		{
+			name: "unknown key",
+			doc:  signed,
+			keys: func(id IRI) (PublicKey, error) {
+				return PublicKey{}, fmt.Errorf("key %s not found", id)
+			},
+			wantErr: ErrProofKeyNotFound,
+		},
+		{
+			name: "loaded another key",
+			doc:  signed,
+			keys: func(_ IRI) (PublicKey, error) {
+				k := pub
+				k.ID = "https://mastodon.example/users/alice#other-key"
+				return k, nil
+			},
+			wantErr: ErrProofKeyNotFound,
+		},
+		{
+			name: "not an RSA key",
+			doc:  signed,
+			keys: func(_ IRI) (PublicKey, error) {
+				k := edKey
+				k.ID = mastodonKeyID
+				return k, nil
+			},
+			wantErr: ErrProofUnsupported,
+		},
+		{
+			name: "context which can't be loaded",
+			doc:  signed,
+			contexts: ContextLoaderFn(func(iri IRI) ([]byte, error) {
+				return nil, fmt.Errorf("unable to load %s", iri)
+			}),
+			wantErr: ErrProofInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := tt.keys
+			if k == nil {
+				k = keys
+			}
+			got, err := VerifyRsaSignature2017([]byte(tt.doc), k, tt.contexts)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("VerifyRsaSignature2017() error = %s", err)
+				}
+				if got != mastodonKeyID {
+					t.Errorf("VerifyRsaSignature2017() = %s, want %s", got, mastodonKeyID)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyRsaSignature2017() error = %v, want %s", err, tt.wantErr)
+			}
+			var pErr *Error
+			if !errors.As(err, &pErr) {
+				t.Errorf("VerifyRsaSignature2017() error is %T, want *Error", err)
+			}
+		})
+	}
+}
+
+func Test_rsaSignature2017Hash(t *testing.T) {
+	options := `{"@context":"https://w3id.org/identity/v1","creator":"https://example.com/~jdoe#main-key","created":"2024-03-01T10:00:01Z"}`
+	doc := `{"@context":"https://www.w3.org/ns/activitystreams","id":"https://example.com/1","type":"Note"}`
+
+	// NOTE(marius): the normalized forms of the options and of the document can be derived by hand
+	normalizedOptions := `_:c14n0 <http://purl.org/dc/terms/created> "2024-03-01T10:00:01Z"^^<http://www.w3.org/2001/XMLSchema#dateTime> .` + "\n" +
+		`_:c14n0 <http://purl.org/dc/terms/creator> <https://example.com/~jdoe#main-key> .` + "\n"
+	normalizedDoc := `<https://example.com/1> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <https://www.w3.org/ns/activitystreams#Note> .` + "\n"
+	toBeSigned := fmt.Sprintf("%x%x", sha256.Sum256([]byte(normalizedOptions)), sha256.Sum256([]byte(normalizedDoc)))
+	want := sha256.Sum256([]byte(toBeSigned))
+
+	got, err := rsaSignature2017Hash([]byte(options), []byte(doc), nil)
+	if err != nil {
+		t.Fatalf("rsaSignature2017Hash() error = %s", err)
+	}
+	if !bytes.Equal(got, want[:]) {
+		t.Errorf("rsaSignature2017Hash() = %x, want %x", got, want)
+	}
+}
+
+func TestActivity_Signature(t *testing.T) {
+	data := `{"id":"https://mastodon.example/users/alice/statuses/1/activity","type":"Create",` +
+		`"signature":{"type":"RsaSignature2017","creator":"https://mastodon.example/users/alice#main-key",` +
+		`"created":"2024-03-01T10:00:01Z","signatureValue":"dGVzdA=="}}`
+	want := &Activity{
+		ID:   "https://mastodon.example/users/alice/statuses/1/activity",
+		Type: CreateType,
+		Signature: &LinkedDataSignature{
+			Type:           RsaSignature2017Type,
+			Creator:        mastodonKeyID,
+			Created:        time.Date(2024, 3, 1, 10, 0, 1, 0, time.UTC),
+			SignatureValue: "dGVzdA==",
+		},
+	}
+
+	a := new(Activity)
+	if err := a.UnmarshalJSON([]byte(data)); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %s", err)
+	}
+	if !assertDeepEquals(t.Errorf, a, want) {
+		t.Errorf("UnmarshalJSON() = %#v, want %#v", a, want)
+	}
+	if len(a.Extensions) > 0 {
+		t.Errorf("UnmarshalJSON() kept the signature in the extensions %v", a.Extensions)
+	}
+
+	got, err := a.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %s", err)
+	}
+	if string(got) != data {
+		t.Errorf("MarshalJSON() = %s, want %s", got, data)
+	}
+
+	buf := bytes.Buffer{}
+	if err = gob.NewEncoder(&buf).Encode(a); err != nil {
+		t.Fatalf("gob Encode() error = %s", err)
+	}
+	dec := new(Activity)
+	if err = gob.NewDecoder(&buf).Decode(dec); err != nil {
+		t.Fatalf("gob Decode() error = %s", err)
+	}
+	if !dec.Equals(want) {
+		t.Errorf("gob round trip = %#v, want %#v", dec, want)
+	}
+
+	other := *want
+	other.Signature = &LinkedDataSignature{Type: RsaSignature2017Type, Creator: mastodonKeyID}
+	if want.Equals(&other) {
+		t.Errorf("Equals() with another signature = true")
+	}
+	other.Signature = nil
+	if want.Equals(&other) {
+		t.Errorf("Equals() without a signature = true")
+	}
+}
@@ -4,14 +4,13 @@ import (
 	"bytes"
 	"encoding/gob"
 	"fmt"
-
-	"github.com/valyala/fastjson"
 )
 
 // LinkTypes represent the valid values for a Link object
 var LinkTypes = ActivityVocabularyTypes{
 	LinkType,
 	MentionType,
+	HashtagType,
 }
 
 type Links interface {
@@ -61,11 +60,17 @@ type Link struct {
 	// Hints as to the language used by the target resource.
 	// Value must be a [BCP47](https://tools.ietf.org/html/bcp47) Language-Tag.
 	HrefLang LangRef `jsonld:"hrefLang,omitempty"`
+	// Extensions holds the properties of the document that are not part of the vocabulary known to this package.
+	// They are kept as raw JSON values so they can be written back unchanged.
+	Extensions Extensions `jsonld:"-"`
 }
 
 // Mention is a specialized Link that represents a @mention.
 type Mention = Link
 
+// Hashtag is a specialized Link that represents a #hashtag, as used by Mastodon in the "tag" of objects.
+type Hashtag = Link
+
 // LinkNew initializes a new Link
 func LinkNew(id ID, typ ActivityVocabularyType) *Link {
 	if !LinkTypes.Match(typ) {
@@ -79,6 +84,11 @@ func MentionNew(id ID) *Mention {
 	return &Mention{ID: id, Type: MentionType}
 }
 
+// HashtagNew initializes a new Hashtag, with the href pointing to the list of objects using it
+func HashtagNew(href IRI, name string) *Hashtag {
+	return &Hashtag{Type: HashtagType, Href: href, Name: DefaultNaturalLanguage(name)}
+}
+
 // GetID returns the ID corresponding to the Link object
 func (l Link) GetID() ID {
 	return l.ID
@@ -101,20 +111,24 @@ func (l Link) Match(tt ...ActivityVocabularyType) bool {
 
 // MarshalJSON encodes the receiver object to a JSON document.
 func (l Link) MarshalJSON() ([]byte, error) {
-	b := bytes.Buffer{}
-	JSONWrite(&b, '{')
+	return jsonMarshal(l)
+}
+
+func (l Link) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
+	start := b.Len()
+	JSONWrite(b, '{')
 
-	if JSONWriteLinkValue(&b, l) {
-		JSONWrite(&b, '}')
-		return b.Bytes(), nil
+	if jsonWriteLinkValue(b, l, opts) {
+		JSONWrite(b, '}')
+		return true
 	}
-	return nil, nil
+	b.Truncate(start)
+	return false
 }
 
 // UnmarshalJSON decodes an incoming JSON document into the receiver object.
 func (l *Link) UnmarshalJSON(data []byte) error {
-	p := fastjson.Parser{}
-	val, err := p.ParseBytes(data)
+	val, err := parseJSON(data)
 	if err != nil {
 		return err
 	}
@@ -157,6 +171,9 @@ func (l Link) equal(with Link) bool {
 	if l.Width != with.Width {
 		return false
 	}
+	if !l.Extensions.Equal(with.Extensions) {
+		return false
+	}
 	return ItemsEqual(l.Preview, with.Preview)
 }
 
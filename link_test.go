@@ -141,3 +141,13 @@ func TestLink_GobDecode(t *testing.T) {
 		})
 	}
 }
+
+func TestHashtagNew(t *testing.T) {
+	h := HashtagNew("https://example.com/tags/go", "#go")
+	if h.Type != HashtagType {
+		t.Errorf("Hashtag type %s, expected %s", h.Type, HashtagType)
+	}
+	if h.Href != "https://example.com/tags/go" || h.Name.First().String() != "#go" {
+		t.Errorf("Hashtag %#v doesn't have the expected href and name", h)
+	}
+}
@@ -0,0 +1,268 @@
+package activitypub
+
+import (
+	"html"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-ap/errors"
+)
+
+// MentionResolver turns the "user@host" handles mentioned in the content of an object into the IRIs
+// of their actors, usually with a WebFinger request.
+type MentionResolver interface {
+	ResolveMention(handle string) (IRI, error)
+}
+
+// MentionResolverFn is a function which implements the MentionResolver interface.
+type MentionResolverFn func(handle string) (IRI, error)
+
+// ResolveMention calls fn.
+func (fn MentionResolverFn) ResolveMention(handle string) (IRI, error) {
+	return fn(handle)
+}
+
+var (
+	// ErrMentionUnresolved is the cause for the handles which couldn't be turned into actor IRIs.
+	ErrMentionUnresolved = errors.Newf("unable to resolve mention")
+)
+
+// TagOptions configure how ExtractTags builds the tags found in the content.
+type TagOptions struct {
+	// Resolver turns the "@user@host" handles of the text into actor IRIs.
+	// The mention anchors aren't resolved, as they already have the IRI in their href.
+	Resolver MentionResolver
+	// HashtagBase is the IRI of the collections of objects using a hashtag, to which the lowercase name
+	// of the hashtag is added as a path, like "https://example.com/tags".
+	// Without it, the hashtags found outside anchors don't have a Href.
+	HashtagBase IRI
+}
+
+// ContentTags are the tags and recipients that ExtractTags found in the content.
+type ContentTags struct {
+	// Tag holds the Mention and Hashtag links, in the order they appear in the content.
+	Tag ItemCollection
+	// CC holds the IRIs of the mentioned actors.
+	CC ItemCollection
+}
+
+var (
+	anchorRegexp    = regexp.MustCompile(`(?is)<a(?:\s([^>]*))?>(.*?)</a\s*>`)
+	attributeRegexp = regexp.MustCompile(`(?is)([a-z-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	htmlTagRegexp   = regexp.MustCompile(`(?s)<[^>]*>`)
+	handleRegexp    = regexp.MustCompile(`(?:^|[^\w@/:.])@(\w(?:[\w.-]*\w)?)@((?:[\p{L}\p{N}-]+\.)+[\p{L}\p{N}-]+(?::\d+)?)`)
+	hashtagRegexp   = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_/#])#([\p{L}\p{N}_]*[\p{L}_][\p{L}\p{N}_]*)`)
+)
+
+// ExtractTags scans the HTML of the content, in all of its languages, for "@user@host" handles, "#hashtags",
+// and the mention and hashtag anchors, like the ones of Mastodon:
+//
+//	<a href="https://example.com/@alice" class="u-url mention">@<span>alice</span></a>
+//	<a href="https://example.com/tags/fediverse" class="mention hashtag" rel="tag">#<span>fediverse</span></a>
+//
+// It returns a Mention, with the actor IRI as Href and the handle as Name, for each mentioned actor, which
+// also gets added to the CC recipients, and a Hashtag for each hashtag, with "#" and the name as Name.
+// Mentions and hashtags are only returned once, the latter compared case-insensitively.
+//
+// The mention anchors use their href as the actor IRI, and the other handles are turned into actor IRIs with
+// the opts.Resolver. The handles which can't be resolved are skipped, and returned as Errors, with the handle
+// as Path, next to the tags which were found.
+// The text of the other anchors is ignored, so links to pages with fragments don't become hashtags.
+func ExtractTags(content NaturalLanguageValues, opts TagOptions) (ContentTags, error) {
+	t := tagExtractor{opts: opts, hashtags: make(map[string]struct{})}
+	refs := slices.SortedFunc(maps.Keys(content), func(a, b LangRef) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	for _, ref := range refs {
+		t.scanHTML(content[ref].String())
+	}
+	return t.tags, t.errs.orNil()
+}
+
+// TagObject extracts the tags of the Content of the "it" object, with ExtractTags, and adds to its Tag and
+// CC the ones it doesn't have already. The mentioned actors which are already recipients of the object, in
+// any of its addressing properties, aren't added to the CC.
+// It returns the tags and recipients which were added.
+func TagObject(it Item, opts TagOptions) (ContentTags, error) {
+	added := ContentTags{}
+	if IsNil(it) || !IsObject(it) {
+		return added, nil
+	}
+	var errs error
+	err := OnObject(it, func(ob *Object) error {
+		found, err := ExtractTags(ob.Content, opts)
+		errs = err
+		for _, tag := range found.Tag {
+			if hasTag(ob.Tag, tag) {
+				continue
+			}
+			ob.Tag = append(ob.Tag, tag)
+			added.Tag = append(added.Tag, tag)
+		}
+		recipients := slices.Concat(ob.To, ob.Bto, ob.CC, ob.BCC, ob.Audience)
+		for _, rec := range found.CC {
+			if hasRecipient(recipients, rec) {
+				continue
+			}
+			ob.CC = append(ob.CC, rec)
+			added.CC = append(added.CC, rec)
+		}
+		return nil
+	})
+	if err != nil {
+		return added, err
+	}
+	return added, errs
+}
+
+type tagExtractor struct {
+	opts     TagOptions
+	tags     ContentTags
+	hashtags map[string]struct{}
+	errs     Errors
+}
+
+func (t *tagExtractor) scanHTML(s string) {
+	start := 0
+	for _, m := range anchorRegexp.FindAllStringSubmatchIndex(s, -1) {
+		t.scanText(s[start:m[0]])
+		attrs := ""
+		if m[2] >= 0 {
+			attrs = s[m[2]:m[3]]
+		}
+		t.scanAnchor(attrs, s[m[4]:m[5]])
+		start = m[1]
+	}
+	t.scanText(s[start:])
+}
+
+func (t *tagExtractor) scanText(s string) {
+	// NOTE(marius): the tags are replaced with spaces, so the words in different elements don't get joined
+	text := html.UnescapeString(htmlTagRegexp.ReplaceAllString(s, " "))
+
+	handles := handleRegexp.FindAllStringSubmatchIndex(text, -1)
+	hashtags := hashtagRegexp.FindAllStringSubmatchIndex(text, -1)
+	for len(handles) > 0 || len(hashtags) > 0 {
+		if len(hashtags) == 0 || (len(handles) > 0 && handles[0][0] < hashtags[0][0]) {
+			m := handles[0]
+			handles = handles[1:]
+			t.addMention(text[m[2]:m[3]]+"@"+text[m[4]:m[5]], "")
+			continue
+		}
+		m := hashtags[0]
+		hashtags = hashtags[1:]
+		t.addHashtag(text[m[2]:m[3]], "")
+	}
+}
+
+func (t *tagExtractor) scanAnchor(attrs, inner string) {
+	var href IRI
+	var classes, rel []string
+	for _, m := range attributeRegexp.FindAllStringSubmatch(attrs, -1) {
+		val := html.UnescapeString(m[2] + m[3])
+		switch strings.ToLower(m[1]) {
+		case "href":
+			href = IRI(strings.TrimSpace(val))
+		case "class":
+			classes = strings.Fields(val)
+		case "rel":
+			rel = strings.Fields(val)
+		}
+	}
+	text := strings.TrimSpace(html.UnescapeString(htmlTagRegexp.ReplaceAllString(inner, "")))
+
+	isHashtag := slices.Contains(classes, "hashtag") || slices.Contains(rel, "tag")
+	if !isHashtag && !slices.Contains(classes, "mention") {
+		return
+	}
+	if isHashtag || strings.HasPrefix(text, "#") {
+		if name := strings.TrimPrefix(text, "#"); len(name) > 0 {
+			t.addHashtag(name, href)
+		}
+		return
+	}
+	handle := strings.TrimPrefix(text, "@")
+	if len(handle) == 0 {
+		return
+	}
+	if !strings.Contains(handle, "@") {
+		// NOTE(marius): the anchors have only the username in their text, so the host is taken from the href
+		u, err := href.URL()
+		if err != nil || len(u.Host) == 0 {
+			return
+		}
+		handle = handle + "@" + u.Host
+	}
+	t.addMention(handle, href)
+}
+
+func (t *tagExtractor) addMention(handle string, href IRI) {
+	if len(href) == 0 {
+		if t.opts.Resolver == nil {
+			t.errs = append(t.errs, pathErr(handle, ErrMentionUnresolved, nil))
+			return
+		}
+		iri, err := t.opts.Resolver.ResolveMention(handle)
+		if err != nil {
+			t.errs = append(t.errs, pathErr(handle, ErrMentionUnresolved, err))
+			return
+		}
+		href = iri
+	}
+	if hasRecipient(t.tags.CC, href) {
+		return
+	}
+	m := MentionNew("")
+	m.Href = href
+	m.Name = DefaultNaturalLanguage("@" + handle)
+	t.tags.Tag = append(t.tags.Tag, m)
+	t.tags.CC = append(t.tags.CC, href)
+}
+
+func (t *tagExtractor) addHashtag(name string, href IRI) {
+	key := strings.ToLower(name)
+	if _, ok := t.hashtags[key]; ok {
+		return
+	}
+	t.hashtags[key] = struct{}{}
+	if len(href) == 0 && len(t.opts.HashtagBase) > 0 {
+		href = t.opts.HashtagBase.AddPath(key)
+	}
+	t.tags.Tag = append(t.tags.Tag, HashtagNew(href, "#"+name))
+}
+
+// hasTag returns whether the tag is already in col, comparing the Mentions by Href, and the Hashtags by
+// their case-insensitive Name.
+func hasTag(col ItemCollection, tag Item) bool {
+	for _, it := range col {
+		if IsNil(it) || it.GetType() != tag.GetType() {
+			continue
+		}
+		if HashtagType.Match(tag.GetType()) {
+			if strings.EqualFold(NameOf(it), NameOf(tag)) {
+				return true
+			}
+			continue
+		}
+		if linkHref(it) == linkHref(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func linkHref(it Item) IRI {
+	var href IRI
+	if IsLink(it) {
+		_ = OnLink(it, func(l *Link) error {
+			href = l.Href
+			return nil
+		})
+	}
+	if len(href) == 0 {
+		href = it.GetLink()
+	}
+	return href
+}
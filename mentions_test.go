@@ -0,0 +1,188 @@
+package activitypub
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func mockMention(href IRI, name string) *Mention {
+	m := MentionNew("")
+	m.Href = href
+	m.Name = DefaultNaturalLanguage(name)
+	return m
+}
+
+func TestExtractTags(t *testing.T) {
+	alice := IRI("https://mastodon.example/users/alice")
+	bob := IRI("https://other.example/users/bob")
+
+	res := MentionResolverFn(func(handle string) (IRI, error) {
+		switch handle {
+		case "alice@mastodon.example":
+			return alice, nil
+		case "bob@other.example":
+			return bob, nil
+		}
+		return "", fmt.Errorf("not found %s", handle)
+	})
+	tags := IRI("https://mastodon.example/tags")
+
+	tests := []struct {
+		name    string
+		content NaturalLanguageValues
+		opts    TagOptions
+		want    ContentTags
+		wantErr error
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:    "text",
+			content: DefaultNaturalLanguage("<p>Hello @alice@mastodon.example, have you seen #Fediverse and #go_ap? #1 is not a tag</p>"),
+			opts:    TagOptions{Resolver: res, HashtagBase: tags},
+			want: ContentTags{
+				Tag: ItemCollection{
+					mockMention(alice, "@alice@mastodon.example"),
+					HashtagNew("https://mastodon.example/tags/fediverse", "#Fediverse"),
+					HashtagNew("https://mastodon.example/tags/go_ap", "#go_ap"),
+				},
+				CC: ItemCollection{alice},
+			},
+		},
+		{
+			name: "anchors",
+			content: DefaultNaturalLanguage(`<p><span class="h-card"><a href="https://other.example/@bob" class="u-url mention">@<span>bob</span></a></span> ` +
+				`<a href="https://other.example/tags/cats" class="mention hashtag" rel="tag">#<span>Cats</span></a> ` +
+				`see <a href="https://example.com/page#section">https://example.com/page#section</a></p>`),
+			opts: TagOptions{Resolver: res},
+			want: ContentTags{
+				Tag: ItemCollection{
+					mockMention("https://other.example/@bob", "@bob@other.example"),
+					HashtagNew("https://other.example/tags/cats", "#Cats"),
+				},
+				CC: ItemCollection{IRI("https://other.example/@bob")},
+			},
+		},
+		{
+			name:    "anchors the resolver fails for",
+			content: DefaultNaturalLanguage(`<a href="https://gone.example/users/eve" class="u-url mention">@<span>eve</span></a>`),
+			opts:    TagOptions{Resolver: res},
+			want: ContentTags{
+				Tag: ItemCollection{mockMention("https://gone.example/users/eve", "@eve@gone.example")},
+				CC:  ItemCollection{IRI("https://gone.example/users/eve")},
+			},
+		},
+		{
+			name:    "anchors without resolver",
+			content: DefaultNaturalLanguage(`<a href="https://other.example/users/bob" class="mention">@bob</a> and @alice@mastodon.example #cats`),
+			want: ContentTags{
+				Tag: ItemCollection{
+					mockMention(bob, "@bob@other.example"),
+					HashtagNew("", "#cats"),
+				},
+				CC: ItemCollection{bob},
+			},
+			wantErr: ErrMentionUnresolved,
+		},
+		{
+			name:    "unresolved",
+			content: DefaultNaturalLanguage("@eve@gone.example, @bob@other.example"),
+			opts:    TagOptions{Resolver: res},
+			want: ContentTags{
+				Tag: ItemCollection{mockMention(bob, "@bob@other.example")},
+				CC:  ItemCollection{bob},
+			},
+			wantErr: ErrMentionUnresolved,
+		},
+		{
+			name:    "not mentions",
+			content: DefaultNaturalLanguage("mail alice@mastodon.example, or visit https://mastodon.example/@alice@mastodon.example/#top &amp; #"),
+			opts:    TagOptions{Resolver: res},
+		},
+		{
+			name: "languages",
+			content: NaturalLanguageValues{
+				MakeRef([]byte("en")): Content("Thanks @bob@other.example &#35;cats"),
+				MakeRef([]byte("fr")): Content("Merci @bob@other.example #Cats #chats"),
+			},
+			opts: TagOptions{Resolver: res},
+			want: ContentTags{
+				Tag: ItemCollection{
+					mockMention(bob, "@bob@other.example"),
+					HashtagNew("", "#cats"),
+					HashtagNew("", "#chats"),
+				},
+				CC: ItemCollection{bob},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractTags(tt.content, tt.opts)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("ExtractTags() error = %v, want %v", err, tt.wantErr)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("ExtractTags() got = %s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestTagObject(t *testing.T) {
+	alice := IRI("https://mastodon.example/users/alice")
+	bob := IRI("https://other.example/users/bob")
+	res := MentionResolverFn(func(handle string) (IRI, error) {
+		return map[string]IRI{"alice@mastodon.example": alice, "bob@other.example": bob}[handle], nil
+	})
+
+	ob := &Object{
+		Type:    NoteType,
+		To:      ItemCollection{bob},
+		Tag:     ItemCollection{HashtagNew("https://mastodon.example/tags/cats", "#Cats")},
+		Content: DefaultNaturalLanguage("@alice@mastodon.example @bob@other.example #cats #dogs"),
+	}
+	want := &Object{
+		Type: NoteType,
+		To:   ItemCollection{bob},
+		CC:   ItemCollection{alice},
+		Tag: ItemCollection{
+			HashtagNew("https://mastodon.example/tags/cats", "#Cats"),
+			mockMention(alice, "@alice@mastodon.example"),
+			mockMention(bob, "@bob@other.example"),
+			HashtagNew("", "#dogs"),
+		},
+		Content: DefaultNaturalLanguage("@alice@mastodon.example @bob@other.example #cats #dogs"),
+	}
+	wantAdded := ContentTags{
+		Tag: ItemCollection{
+			mockMention(alice, "@alice@mastodon.example"),
+			mockMention(bob, "@bob@other.example"),
+			HashtagNew("", "#dogs"),
+		},
+		CC: ItemCollection{alice},
+	}
+
+	added, err := TagObject(ob, TagOptions{Resolver: res})
+	if err != nil {
+		t.Fatalf("TagObject() error = %s", err)
+	}
+	if !cmp.Equal(ob, want) {
+		t.Errorf("TagObject() got = %s", cmp.Diff(want, ob))
+	}
+	if !cmp.Equal(added, wantAdded) {
+		t.Errorf("TagObject() added = %s", cmp.Diff(wantAdded, added))
+	}
+
+	added, err = TagObject(ob, TagOptions{Resolver: res})
+	if err != nil {
+		t.Fatalf("TagObject() error = %s", err)
+	}
+	if !cmp.Equal(added, ContentTags{}) {
+		t.Errorf("TagObject() added again = %s", cmp.Diff(ContentTags{}, added))
+	}
+}
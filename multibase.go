@@ -0,0 +1,177 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"strings"
+
+	"github.com/go-ap/errors"
+)
+
+// ErrKeyInvalidMultibase is the cause for keys whose multibase value can't be decoded
+var ErrKeyInvalidMultibase = errors.Newf("invalid public key multibase")
+
+// The multicodec headers, as unsigned varints, of the public keys which can be encoded in multibase,
+// from https://github.com/multiformats/multicodec/blob/master/table.csv
+const (
+	multicodecEd25519 = "\xed\x01"
+	multicodecP256    = "\x80\x24"
+	multicodecRSA     = "\x85\x24"
+)
+
+// EncodeMultibaseKey returns the publicKeyMultibase value of the key: the base58-btc encoding of the key, prefixed with
+// its multicodec header. Ed25519 keys are encoded raw, ECDSA P-256 keys compressed, and RSA keys in PKCS #1 DER form.
+func EncodeMultibaseKey(key crypto.PublicKey) (string, error) {
+	if err := checkKeyAlgorithm(key); err != nil {
+		return "", &Error{Reason: err}
+	}
+	var raw []byte
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		raw = append(append(raw, multicodecEd25519...), k...)
+	case *ecdsa.PublicKey:
+		raw = append(append(raw, multicodecP256...), elliptic.MarshalCompressed(k.Curve, k.X, k.Y)...)
+	case *rsa.PublicKey:
+		raw = append(append(raw, multicodecRSA...), x509.MarshalPKCS1PublicKey(k)...)
+	}
+	return "z" + base58Encode(raw), nil
+}
+
+// DecodeMultibaseKey returns the key from a publicKeyMultibase value, the reverse of EncodeMultibaseKey.
+// Only the base58-btc encoding, with the "z" prefix, is supported.
+func DecodeMultibaseKey(s string) (crypto.PublicKey, error) {
+	if len(s) < 2 || s[0] != 'z' {
+		return nil, &Error{Reason: ErrKeyInvalidMultibase, Cause: errors.Newf("unsupported multibase encoding")}
+	}
+	raw, err := base58Decode(s[1:])
+	if err != nil {
+		return nil, &Error{Reason: ErrKeyInvalidMultibase, Cause: err}
+	}
+	if len(raw) < 2 {
+		return nil, &Error{Reason: ErrKeyInvalidMultibase, Cause: errors.Newf("missing multicodec header")}
+	}
+	header, data := string(raw[:2]), raw[2:]
+	switch header {
+	case multicodecEd25519:
+		if len(data) != ed25519.PublicKeySize {
+			return nil, &Error{Reason: ErrKeyInvalidMultibase, Cause: errors.Newf("invalid Ed25519 key length %d", len(data))}
+		}
+		return ed25519.PublicKey(data), nil
+	case multicodecP256:
+		x, y := elliptic.UnmarshalCompressed(elliptic.P256(), data)
+		if x == nil {
+			return nil, &Error{Reason: ErrKeyInvalidMultibase, Cause: errors.Newf("invalid P-256 point")}
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	case multicodecRSA:
+		k, err := x509.ParsePKCS1PublicKey(data)
+		if err != nil {
+			return nil, &Error{Reason: ErrKeyInvalidMultibase, Cause: err}
+		}
+		return k, nil
+	}
+	return nil, &Error{Reason: ErrKeyUnsupported, Cause: errors.Newf("multicodec %x", raw[:2])}
+}
+
+// PublicKeyFromMultibase builds a PublicKey with the id, owned by the owner actor, for the key in the multibase form.
+// It allows the keys of the Multikey type to be used wherever a PublicKey is expected.
+func PublicKeyFromMultibase(id ID, owner IRI, multibase string) (PublicKey, error) {
+	key, err := DecodeMultibaseKey(multibase)
+	if err != nil {
+		if kErr, ok := err.(*Error); ok {
+			kErr.Path = string(id)
+		}
+		return PublicKey{}, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return PublicKey{}, &Error{Path: string(id), Reason: ErrKeyUnsupported, Cause: err}
+	}
+	return PublicKey{
+		ID:           id,
+		Owner:        owner,
+		PublicKeyPem: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}, nil
+}
+
+// NewMultikey builds the Multikey with the id, controlled by the controller actor, for a *rsa.PublicKey,
+// an ed25519.PublicKey or an *ecdsa.PublicKey on the P-256 curve.
+func NewMultikey(id ID, key crypto.PublicKey, controller Item) (Multikey, error) {
+	if IsNil(controller) || controller.GetLink() == "" {
+		return Multikey{}, &Error{Path: string(id), Reason: ErrKeyMissingOwner}
+	}
+	mb, err := EncodeMultibaseKey(key)
+	if err != nil {
+		if kErr, ok := err.(*Error); ok {
+			kErr.Path = string(id)
+		}
+		return Multikey{}, err
+	}
+	return Multikey{ID: id, Type: MultikeyType, Controller: controller.GetLink(), PublicKeyMultibase: mb}, nil
+}
+
+// CryptoKey decodes the PublicKeyMultibase of the Multikey.
+// Any error returned is a *Error.
+func (m Multikey) CryptoKey() (crypto.PublicKey, error) {
+	key, err := DecodeMultibaseKey(m.PublicKeyMultibase)
+	if err != nil {
+		if kErr, ok := err.(*Error); ok {
+			kErr.Path = string(m.ID)
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// PublicKey returns the Multikey as a PublicKey, owned by its Controller.
+func (m Multikey) PublicKey() (PublicKey, error) {
+	return PublicKeyFromMultibase(m.ID, m.Controller, m.PublicKeyMultibase)
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var big58 = big.NewInt(58)
+
+func base58Encode(raw []byte) string {
+	n := new(big.Int).SetBytes(raw)
+	mod := new(big.Int)
+	out := make([]byte, 0, len(raw)*138/100+1)
+	for n.Sign() > 0 {
+		n.DivMod(n, big58, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	// NOTE(marius): every leading zero byte is encoded as a leading "1"
+	for _, b := range raw {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func base58Decode(s string) ([]byte, error) {
+	n := new(big.Int)
+	for i := 0; i < len(s); i++ {
+		idx := strings.IndexByte(base58Alphabet, s[i])
+		if idx < 0 {
+			return nil, errors.Newf("invalid base58 character %q", s[i])
+		}
+		n.Mul(n, big58)
+		n.Add(n, big.NewInt(int64(idx)))
+	}
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
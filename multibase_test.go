@@ -0,0 +1,166 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+)
+
+func Test_base58(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{raw: "", want: ""},
+		{raw: "Hello World!", want: "2NEpo7TZRRrLZSi2U"},
+		{raw: "\x00\x00\x28\x7f\xb4\xcd", want: "11233QC4"},
+	}
+	for _, tt := range tests {
+		if got := base58Encode([]byte(tt.raw)); got != tt.want {
+			t.Errorf("base58Encode(%q) = %s, want %s", tt.raw, got, tt.want)
+		}
+		got, err := base58Decode(tt.want)
+		if err != nil {
+			t.Fatalf("base58Decode(%s) error = %s", tt.want, err)
+		}
+		if string(got) != tt.raw {
+			t.Errorf("base58Decode(%s) = %q, want %q", tt.want, got, tt.raw)
+		}
+	}
+	if _, err := base58Decode("0OIl"); err == nil {
+		t.Errorf("base58Decode() expected error for characters outside the alphabet")
+	}
+}
+
+func TestEncodeMultibaseKey(t *testing.T) {
+	rk, ek, ck := mockKeys(t)
+	for _, key := range []crypto.PublicKey{rk, ek, ck} {
+		s, err := EncodeMultibaseKey(key)
+		if err != nil {
+			t.Fatalf("EncodeMultibaseKey(%T) error = %s", key, err)
+		}
+		got, err := DecodeMultibaseKey(s)
+		if err != nil {
+			t.Fatalf("DecodeMultibaseKey(%s) error = %s", s, err)
+		}
+		if k, ok := got.(interface{ Equal(crypto.PublicKey) bool }); !ok || !k.Equal(key) {
+			t.Errorf("DecodeMultibaseKey() = %v, want %v", got, key)
+		}
+	}
+	if s, _ := EncodeMultibaseKey(ek); s[:4] != "z6Mk" {
+		t.Errorf("EncodeMultibaseKey() = %s, Ed25519 keys should start with z6Mk", s)
+	}
+	if s, _ := EncodeMultibaseKey(ck); s[:4] != "zDna" {
+		t.Errorf("EncodeMultibaseKey() = %s, P-256 keys should start with zDna", s)
+	}
+}
+
+func TestDecodeMultibaseKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    any
+		wantErr error
+	}{
+		{
+			// NOTE(marius): the key from the examples of the Data Integrity EdDSA Cryptosuites
+			name: "ed25519",
+			s:    "z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2",
+			want: ed25519.PublicKey{},
+		},
+		{
+			// NOTE(marius): the key from the examples of the Data Integrity ECDSA Cryptosuites
+			name: "p-256",
+			s:    "zDnaepBuvsQ8cpsWrVKw8fbpGpvPeNSjVPTWoq6cRqaYzBKVP",
+			want: &ecdsa.PublicKey{},
+		},
+		{name: "empty", s: "", wantErr: ErrKeyInvalidMultibase},
+		{name: "base64", s: "mAQID", wantErr: ErrKeyInvalidMultibase},
+		{name: "invalid base58", s: "z0OIl", wantErr: ErrKeyInvalidMultibase},
+		{name: "short ed25519", s: "z" + base58Encode([]byte(multicodecEd25519+"test")), wantErr: ErrKeyInvalidMultibase},
+		{name: "unknown codec", s: "z" + base58Encode([]byte("\x12\x34test")), wantErr: ErrKeyUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeMultibaseKey(tt.s)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("DecodeMultibaseKey() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeMultibaseKey() error = %s", err)
+			}
+			switch tt.want.(type) {
+			case ed25519.PublicKey:
+				if _, ok := got.(ed25519.PublicKey); !ok {
+					t.Fatalf("DecodeMultibaseKey() = %T, want %T", got, tt.want)
+				}
+			case *ecdsa.PublicKey:
+				if _, ok := got.(*ecdsa.PublicKey); !ok {
+					t.Fatalf("DecodeMultibaseKey() = %T, want %T", got, tt.want)
+				}
+			}
+			if s, _ := EncodeMultibaseKey(got); s != tt.s {
+				t.Errorf("EncodeMultibaseKey() = %s, want %s", s, tt.s)
+			}
+		})
+	}
+}
+
+func TestPublicKeyFromMultibase(t *testing.T) {
+	owner := &Actor{ID: "https://example.com/~jdoe"}
+	p, err := PublicKeyFromMultibase("https://example.com/~jdoe#ed25519-key", owner.ID, "z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2")
+	if err != nil {
+		t.Fatalf("PublicKeyFromMultibase() error = %s", err)
+	}
+	key, err := p.OwnedCryptoKey(owner)
+	if err != nil {
+		t.Fatalf("OwnedCryptoKey() error = %s", err)
+	}
+	if _, ok := key.(ed25519.PublicKey); !ok {
+		t.Errorf("OwnedCryptoKey() = %T, want ed25519.PublicKey", key)
+	}
+
+	_, err = PublicKeyFromMultibase("https://example.com/~jdoe#key", owner.ID, "test")
+	var kErr *Error
+	if !errors.As(err, &kErr) || kErr.Path != "https://example.com/~jdoe#key" || !errors.Is(err, ErrKeyInvalidMultibase) {
+		t.Errorf("PublicKeyFromMultibase() error = %#v, want a *Error for the key", err)
+	}
+}
+
+func TestNewMultikey(t *testing.T) {
+	_, ek, ck := mockKeys(t)
+	owner := &Actor{ID: "https://example.com/~jdoe"}
+	for name, key := range map[string]crypto.PublicKey{"ed25519": ek, "ecdsa": ck} {
+		t.Run(name, func(t *testing.T) {
+			id := ID("https://example.com/~jdoe#" + name + "-key")
+			mk, err := NewMultikey(id, key, owner)
+			if err != nil {
+				t.Fatalf("NewMultikey() error = %s", err)
+			}
+			if mk.Type != MultikeyType || mk.Controller != owner.GetLink() || mk.ID != id {
+				t.Errorf("NewMultikey() = %#v", mk)
+			}
+			got, err := mk.CryptoKey()
+			if err != nil {
+				t.Fatalf("CryptoKey() error = %s", err)
+			}
+			if !got.(interface{ Equal(crypto.PublicKey) bool }).Equal(key) {
+				t.Errorf("CryptoKey() returned another key")
+			}
+		})
+	}
+
+	if _, err := NewMultikey("https://example.com/~jdoe#key", ek, nil); !errors.Is(err, ErrKeyMissingOwner) {
+		t.Errorf("NewMultikey() error = %v, want %s", err, ErrKeyMissingOwner)
+	}
+	_, err := Multikey{ID: "https://example.com/~jdoe#key", PublicKeyMultibase: "test"}.CryptoKey()
+	var kErr *Error
+	if !errors.As(err, &kErr) || kErr.Path != "https://example.com/~jdoe#key" || !errors.Is(err, ErrKeyInvalidMultibase) {
+		t.Errorf("CryptoKey() error = %#v, want a *Error for the key", err)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/gob"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
 	"strings"
 	"unicode/utf8"
 
@@ -366,41 +368,54 @@ func stringBytes(e *bytes.Buffer, s []byte, escapeHTML bool) {
 
 // MarshalJSON encodes the receiver object to a JSON document.
 func (n NaturalLanguageValues) MarshalJSON() ([]byte, error) {
+	return jsonMarshal(n)
+}
+
+// writeJSON writes a single value as a string, unless opts.LanguageMaps is set, and multiple values as a map,
+// in the order of their language references, so the output is stable.
+func (n NaturalLanguageValues) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
 	l := len(n)
 	if l <= 0 {
-		return nil, nil
+		return false
 	}
 
-	b := bytes.Buffer{}
-	if l == 1 {
+	if l == 1 && !opts.LanguageMaps {
 		val := n.First()
 		if len(val) > 0 {
-			val = unescape(val)
-			stringBytes(&b, val, false)
-			return b.Bytes(), nil
+			if bytes.IndexByte(val, '\\') >= 0 {
+				val = unescape(val)
+			}
+			stringBytes(b, val, false)
+			return true
 		}
 	}
+	start := b.Len()
 	b.Write([]byte{'{'})
 	empty := true
-	for ref, val := range n {
+	refs := slices.SortedFunc(maps.Keys(n), func(a, b LangRef) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	for _, ref := range refs {
+		val := n[ref]
 		if len(val) == 0 {
 			continue
 		}
 		if !empty {
 			b.Write([]byte{','})
 		}
-		if ref.Valid() {
-			stringBytes(&b, []byte(ref.String()), false)
-			b.Write([]byte{':'})
-		}
-		stringBytes(&b, val, false)
-		empty = len(val) == 0
+		// NOTE(marius): the values without a language are written with the "und" key, which we load back
+		// as NilLangRef, so the map is always valid JSON
+		stringBytes(b, []byte(ref.String()), false)
+		b.Write([]byte{':'})
+		stringBytes(b, val, false)
+		empty = false
+	}
+	if empty {
+		b.Truncate(start)
+		return false
 	}
 	b.Write([]byte{'}'})
-	if !empty {
-		return b.Bytes(), nil
-	}
-	return nil, nil
+	return true
 }
 
 // First returns the first element in the map
@@ -503,8 +518,7 @@ func (l LangRefValue) Format(s fmt.State, verb rune) {
 
 // UnmarshalJSON decodes an incoming JSON document into the receiver object.
 func (l *LangRefValue) UnmarshalJSON(data []byte) error {
-	p := fastjson.Parser{}
-	val, err := p.ParseBytes(data)
+	val, err := parseJSON(data)
 	if err != nil {
 		l.Ref = NilLangRef
 		l.Value = unescape(data)
@@ -682,8 +696,7 @@ func (n *NaturalLanguageValues) UnmarshalJSON(data []byte) error {
 	if len(data) == 0 {
 		return nil
 	}
-	p := fastjson.Parser{}
-	val, err := p.ParseBytes(data)
+	val, err := parseJSON(data)
 	if err != nil {
 		// try our luck if data contains an unquoted string
 		cont := Content{}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"slices"
 	"strings"
 	"time"
 	"unsafe"
@@ -42,6 +43,14 @@ const (
 	// MentionType is a link type for @mentions
 	MentionType ActivityVocabularyType = "Mention"
 	NilType     ActivityVocabularyType = ""
+
+	// Mastodon extension types
+	// https://docs.joinmastodon.org/spec/activitypub/
+
+	// HashtagType is a link type for #hashtags
+	HashtagType ActivityVocabularyType = "Hashtag"
+	// EmojiType is an object type for the :custom_emoji: shortcodes used in the content of objects
+	EmojiType ActivityVocabularyType = "Emoji"
 )
 
 var GenericTypes = ActivityVocabularyTypes{
@@ -64,6 +73,12 @@ var ObjectTypes = ActivityVocabularyTypes{
 	RelationshipType,
 	TombstoneType,
 	VideoType,
+	EmojiType,
+	TicketType,
+	PatchType,
+	CommitType,
+	BranchType,
+	TicketDependencyType,
 }
 
 type (
@@ -108,7 +123,7 @@ type (
 )
 
 type Objects interface {
-	Object | Tombstone | Place | Profile | Relationship |
+	Object | Tombstone | Place | Profile | Relationship | Ticket | Repository |
 		Actors |
 		Activities |
 		IntransitiveActivities |
@@ -212,6 +227,21 @@ type Object struct {
 	// as a form of provenance, or to support future editing by clients.
 	// In general, clients do the conversion from source to content, not the other way around.
 	Source Source `jsonld:"source,omitempty"`
+	// Sensitive marks the content of the object as not safe for all audiences, so it should be hidden
+	// behind its Summary. Like FocalPoint and Blurhash, it is a Mastodon extension to the vocabulary.
+	Sensitive bool `jsonld:"sensitive,omitempty"`
+	// FocalPoint is the point of an image which must stay visible when it is cropped, as x and y
+	// coordinates between -1.0 and 1.0.
+	FocalPoint []float64 `jsonld:"focalPoint,omitempty"`
+	// Blurhash is a compact representation of a blurred placeholder for an image.
+	Blurhash string `jsonld:"blurhash,omitempty"`
+	// Proof holds the Data Integrity proof of the document, like the eddsa-jcs-2022 ones of FEP-8b32.
+	// The proof sets, which hold more than one proof, are kept unchanged in the Extensions.
+	Proof *DataIntegrityProof `jsonld:"proof,omitempty"`
+	// Extensions holds the properties of the document that are not part of the vocabulary known to this package,
+	// like the ones coming from ActivityStreams extensions or custom namespaces.
+	// They are kept as raw JSON values so they can be written back unchanged.
+	Extensions Extensions `jsonld:"-"`
 }
 
 func types(m Typer) ActivityVocabularyTypes {
@@ -258,8 +288,7 @@ func (o Object) Match(tt ...ActivityVocabularyType) bool {
 
 // UnmarshalJSON decodes an incoming JSON document into the receiver object.
 func (o *Object) UnmarshalJSON(data []byte) error {
-	p := fastjson.Parser{}
-	val, err := p.ParseBytes(data)
+	val, err := parseJSON(data)
 	if err != nil {
 		return err
 	}
@@ -268,14 +297,21 @@ func (o *Object) UnmarshalJSON(data []byte) error {
 
 // MarshalJSON encodes the receiver object to a JSON document.
 func (o Object) MarshalJSON() ([]byte, error) {
-	b := bytes.Buffer{}
-	JSONWrite(&b, '{')
+	return jsonMarshal(o)
+}
 
-	if JSONWriteObjectValue(&b, o) {
-		JSONWrite(&b, '}')
-		return b.Bytes(), nil
+func (o Object) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
+	start := b.Len()
+	JSONWrite(b, '{')
+
+	notEmpty := jsonWriteObjectValue(b, o, opts)
+	notEmpty = JSONWriteExtensions(b, o.Extensions, notEmpty, objectProperties) || notEmpty
+	if notEmpty {
+		JSONWrite(b, '}')
+		return true
 	}
-	return nil, nil
+	b.Truncate(start)
+	return false
 }
 
 // UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
@@ -613,6 +649,10 @@ func ToObject(it LinkOrIRI) (*Object, error) {
 		return (*Object)(unsafe.Pointer(i)), nil
 	case Relationship:
 		return (*Object)(unsafe.Pointer(&i)), nil
+	case *Ticket:
+		return (*Object)(unsafe.Pointer(i)), nil
+	case Ticket:
+		return (*Object)(unsafe.Pointer(&i)), nil
 	case *Tombstone:
 		return (*Object)(unsafe.Pointer(i)), nil
 	case Tombstone:
@@ -621,6 +661,10 @@ func ToObject(it LinkOrIRI) (*Object, error) {
 		return (*Object)(unsafe.Pointer(i)), nil
 	case Actor:
 		return (*Object)(unsafe.Pointer(&i)), nil
+	case *Repository:
+		return (*Object)(unsafe.Pointer(i)), nil
+	case Repository:
+		return (*Object)(unsafe.Pointer(&i)), nil
 	case *Activity:
 		return (*Object)(unsafe.Pointer(i)), nil
 	case Activity:
@@ -706,8 +750,7 @@ func GetAPSource(val *fastjson.Value) Source {
 
 // UnmarshalJSON decodes an incoming JSON document into the receiver object.
 func (s *Source) UnmarshalJSON(data []byte) error {
-	p := fastjson.Parser{}
-	val, err := p.ParseBytes(data)
+	val, err := parseJSON(data)
 	if err != nil {
 		return err
 	}
@@ -717,22 +760,27 @@ func (s *Source) UnmarshalJSON(data []byte) error {
 
 // MarshalJSON encodes the receiver object to a JSON document.
 func (s Source) MarshalJSON() ([]byte, error) {
-	b := bytes.Buffer{}
+	return jsonMarshal(s)
+}
+
+func (s Source) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
 	notEmpty := false
-	JSONWrite(&b, '{')
+	start := b.Len()
+	JSONWrite(b, '{')
 	if len(s.MediaType) > 0 {
 		if v, err := s.MediaType.MarshalJSON(); err == nil && len(v) > 0 {
-			notEmpty = JSONWriteProp(&b, "mediaType", v, false)
+			notEmpty = JSONWriteProp(b, "mediaType", v, false)
 		}
 	}
 	if len(s.Content) > 0 {
-		notEmpty = JSONWriteNaturalLanguageProp(&b, "content", s.Content, notEmpty)
+		notEmpty = jsonWriteNaturalLanguageProp(b, "content", s.Content, notEmpty, opts)
 	}
 	if !notEmpty {
-		return nil, nil
+		b.Truncate(start)
+		return false
 	}
-	JSONWrite(&b, '}')
-	return b.Bytes(), nil
+	JSONWrite(b, '}')
+	return true
 }
 
 // UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
@@ -905,6 +953,21 @@ func (o Object) equal(with Object) bool {
 	if !ItemsEqual(o.Shares, with.Shares) {
 		return false
 	}
+	if o.Sensitive != with.Sensitive {
+		return false
+	}
+	if !slices.Equal(o.FocalPoint, with.FocalPoint) {
+		return false
+	}
+	if o.Blurhash != with.Blurhash {
+		return false
+	}
+	if !o.Proof.Equal(with.Proof) {
+		return false
+	}
+	if !o.Extensions.Equal(with.Extensions) {
+		return false
+	}
 	return result
 }
 
@@ -1214,3 +1214,64 @@ func Test_reflectedItemByType_Object(t *testing.T) {
 		})
 	}
 }
+
+func TestObject_Mastodon(t *testing.T) {
+	data := []byte(`{"id":"https://mastodon.example/media/1","type":"Image","url":"https://mastodon.example/1.png",` +
+		`"sensitive":true,"focalPoint":[-0.5,0.25],"blurhash":"UBL_:rOpGG-o"}`)
+	want := &Object{
+		ID:         "https://mastodon.example/media/1",
+		Type:       ImageType,
+		URL:        IRI("https://mastodon.example/1.png"),
+		Sensitive:  true,
+		FocalPoint: []float64{-0.5, 0.25},
+		Blurhash:   "UBL_:rOpGG-o",
+	}
+
+	it, err := UnmarshalJSON(data)
+	if err != nil {
+		t.Fatalf("UnmarshalJSON() error = %s", err)
+	}
+	if !cmp.Equal(it, want) {
+		t.Fatalf("UnmarshalJSON() got = %s", cmp.Diff(want, it))
+	}
+	raw, err := MarshalJSON(it)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %s", err)
+	}
+	if !bytes.Equal(raw, data) {
+		t.Errorf("MarshalJSON() got = %s, want %s", raw, data)
+	}
+
+	g, err := GobEncode(it)
+	if err != nil {
+		t.Fatalf("GobEncode() error = %s", err)
+	}
+	fromGob, err := GobDecode(g)
+	if err != nil {
+		t.Fatalf("GobDecode() error = %s", err)
+	}
+	if !cmp.Equal(fromGob, want) {
+		t.Errorf("GobDecode() got = %s", cmp.Diff(want, fromGob))
+	}
+
+	cropped := *want
+	cropped.FocalPoint = []float64{0, 0}
+	if want.Equals(&cropped) {
+		t.Errorf("Equals() is true for objects with different focalPoint")
+	}
+	cl := Clone(want).(*Object)
+	cl.FocalPoint[0] = 1
+	if want.FocalPoint[0] != -0.5 {
+		t.Errorf("Clone() focalPoint shares memory with the source")
+	}
+
+	for _, typ := range []ActivityVocabularyType{HashtagType, EmojiType} {
+		it, err := GetItemByType(typ)
+		if err != nil {
+			t.Fatalf("GetItemByType(%s) error = %s", typ, err)
+		}
+		if it.GetType() != typ {
+			t.Errorf("GetItemByType(%s) returned an item of type %s", typ, it.GetType())
+		}
+	}
+}
@@ -6,8 +6,6 @@ import (
 	"fmt"
 	"time"
 	"unsafe"
-
-	"github.com/valyala/fastjson"
 )
 
 // OrderedCollectionPage type extends from both CollectionPage and OrderedCollection.
@@ -107,6 +105,21 @@ type OrderedCollectionPage struct {
 	// as a form of provenance, or to support future editing by clients.
 	// In general, clients do the conversion from source to content, not the other way around.
 	Source Source `jsonld:"source,omitempty"`
+	// Sensitive marks the content of the object as not safe for all audiences, so it should be hidden
+	// behind its Summary. Like FocalPoint and Blurhash, it is a Mastodon extension to the vocabulary.
+	Sensitive bool `jsonld:"sensitive,omitempty"`
+	// FocalPoint is the point of an image which must stay visible when it is cropped, as x and y
+	// coordinates between -1.0 and 1.0.
+	FocalPoint []float64 `jsonld:"focalPoint,omitempty"`
+	// Blurhash is a compact representation of a blurred placeholder for an image.
+	Blurhash string `jsonld:"blurhash,omitempty"`
+	// Proof holds the Data Integrity proof of the document, like the eddsa-jcs-2022 ones of FEP-8b32.
+	// The proof sets, which hold more than one proof, are kept unchanged in the Extensions.
+	Proof *DataIntegrityProof `jsonld:"proof,omitempty"`
+	// Extensions holds the properties of the document that are not part of the vocabulary known to this package,
+	// like the ones coming from ActivityStreams extensions or custom namespaces.
+	// They are kept as raw JSON values so they can be written back unchanged.
+	Extensions Extensions `jsonld:"-"`
 	// In a paged Collection, indicates the page that contains the most recently updated member items.
 	Current ObjectOrLink `jsonld:"current,omitempty"`
 	// In a paged Collection, indicates the furthest preceding page of items in the collection.
@@ -197,8 +210,7 @@ func (o OrderedCollectionPage) Contains(r Item) bool {
 
 // UnmarshalJSON decodes an incoming JSON document into the receiver object.
 func (o *OrderedCollectionPage) UnmarshalJSON(data []byte) error {
-	p := fastjson.Parser{}
-	val, err := p.ParseBytes(data)
+	val, err := parseJSON(data)
 	if err != nil {
 		return err
 	}
@@ -207,41 +219,49 @@ func (o *OrderedCollectionPage) UnmarshalJSON(data []byte) error {
 
 // MarshalJSON encodes the receiver object to a JSON document.
 func (o OrderedCollectionPage) MarshalJSON() ([]byte, error) {
-	b := bytes.Buffer{}
+	return jsonMarshal(o)
+}
+
+func (o OrderedCollectionPage) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
 	notEmpty := false
-	JSONWrite(&b, '{')
+	start := b.Len()
+	JSONWrite(b, '{')
 
 	_ = OnObject(o, func(o *Object) error {
-		notEmpty = JSONWriteObjectValue(&b, *o)
+		notEmpty = jsonWriteObjectValue(b, *o, opts)
 		return nil
 	})
 	if o.PartOf != nil {
-		notEmpty = JSONWriteItemProp(&b, "partOf", o.PartOf, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "partOf", o.PartOf, notEmpty, opts) || notEmpty
 	}
 	if o.Current != nil {
-		notEmpty = JSONWriteItemProp(&b, "current", o.Current, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "current", o.Current, notEmpty, opts) || notEmpty
 	}
 	if o.First != nil {
-		notEmpty = JSONWriteItemProp(&b, "first", o.First, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "first", o.First, notEmpty, opts) || notEmpty
 	}
 	if o.Last != nil {
-		notEmpty = JSONWriteItemProp(&b, "last", o.Last, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "last", o.Last, notEmpty, opts) || notEmpty
 	}
 	if o.Next != nil {
-		notEmpty = JSONWriteItemProp(&b, "next", o.Next, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "next", o.Next, notEmpty, opts) || notEmpty
 	}
 	if o.Prev != nil {
-		notEmpty = JSONWriteItemProp(&b, "prev", o.Prev, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "prev", o.Prev, notEmpty, opts) || notEmpty
 	}
-	notEmpty = JSONWriteIntProp(&b, "totalItems", int64(o.TotalItems), notEmpty) || notEmpty
+	notEmpty = JSONWriteIntProp(b, "totalItems", int64(o.TotalItems), notEmpty) || notEmpty
 	if o.OrderedItems != nil {
-		notEmpty = JSONWriteItemCollectionProp(&b, "orderedItems", o.OrderedItems, false, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemCollectionProp(b, "orderedItems", o.OrderedItems, false, notEmpty, opts) || notEmpty
+	}
+	if len(o.Extensions) > 0 {
+		notEmpty = JSONWriteExtensions(b, o.Extensions, notEmpty, objectProperties, orderedCollectionProperties, orderedCollectionPageProperties) || notEmpty
 	}
 	if !notEmpty {
-		return nil, nil
+		b.Truncate(start)
+		return false
 	}
-	JSONWrite(&b, '}')
-	return b.Bytes(), nil
+	JSONWrite(b, '}')
+	return true
 }
 
 // UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
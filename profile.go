@@ -5,8 +5,6 @@ import (
 	"encoding/gob"
 	"fmt"
 	"time"
-
-	"github.com/valyala/fastjson"
 )
 
 // Profile a Profile is a content object that describes another Object,
@@ -105,6 +103,21 @@ type Profile struct {
 	// as a form of provenance, or to support future editing by clients.
 	// In general, clients do the conversion from source to content, not the other way around.
 	Source Source `jsonld:"source,omitempty"`
+	// Sensitive marks the content of the object as not safe for all audiences, so it should be hidden
+	// behind its Summary. Like FocalPoint and Blurhash, it is a Mastodon extension to the vocabulary.
+	Sensitive bool `jsonld:"sensitive,omitempty"`
+	// FocalPoint is the point of an image which must stay visible when it is cropped, as x and y
+	// coordinates between -1.0 and 1.0.
+	FocalPoint []float64 `jsonld:"focalPoint,omitempty"`
+	// Blurhash is a compact representation of a blurred placeholder for an image.
+	Blurhash string `jsonld:"blurhash,omitempty"`
+	// Proof holds the Data Integrity proof of the document, like the eddsa-jcs-2022 ones of FEP-8b32.
+	// The proof sets, which hold more than one proof, are kept unchanged in the Extensions.
+	Proof *DataIntegrityProof `jsonld:"proof,omitempty"`
+	// Extensions holds the properties of the document that are not part of the vocabulary known to this package,
+	// like the ones coming from ActivityStreams extensions or custom namespaces.
+	// They are kept as raw JSON values so they can be written back unchanged.
+	Extensions Extensions `jsonld:"-"`
 	// Describes On a Profile object, the describes property identifies the object described by the Profile.
 	Describes Item `jsonld:"describes,omitempty"`
 }
@@ -131,8 +144,7 @@ func (p Profile) Match(tt ...ActivityVocabularyType) bool {
 
 // UnmarshalJSON decodes an incoming JSON document into the receiver object.
 func (p *Profile) UnmarshalJSON(data []byte) error {
-	par := fastjson.Parser{}
-	val, err := par.ParseBytes(data)
+	val, err := parseJSON(data)
 	if err != nil {
 		return err
 	}
@@ -141,24 +153,32 @@ func (p *Profile) UnmarshalJSON(data []byte) error {
 
 // MarshalJSON encodes the receiver object to a JSON document.
 func (p Profile) MarshalJSON() ([]byte, error) {
-	b := bytes.Buffer{}
+	return jsonMarshal(p)
+}
+
+func (p Profile) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
 	notEmpty := false
-	JSONWrite(&b, '{')
+	start := b.Len()
+	JSONWrite(b, '{')
 
 	_ = OnObject(p, func(o *Object) error {
-		notEmpty = JSONWriteObjectValue(&b, *o)
+		notEmpty = jsonWriteObjectValue(b, *o, opts)
 		return nil
 	})
 
 	if p.Describes != nil {
-		notEmpty = JSONWriteItemProp(&b, "describes", p.Describes, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "describes", p.Describes, notEmpty, opts) || notEmpty
 	}
 
+	if len(p.Extensions) > 0 {
+		notEmpty = JSONWriteExtensions(b, p.Extensions, notEmpty, objectProperties, profileProperties) || notEmpty
+	}
 	if !notEmpty {
-		return nil, nil
+		b.Truncate(start)
+		return false
 	}
-	JSONWrite(&b, '}')
-	return b.Bytes(), nil
+	JSONWrite(b, '}')
+	return true
 }
 
 // UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
@@ -0,0 +1,156 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+
+	"github.com/go-ap/errors"
+)
+
+var (
+	// ErrKeyMissingPEM is the cause for public keys which have an empty PublicKeyPem
+	ErrKeyMissingPEM = errors.Newf("missing public key PEM")
+	// ErrKeyInvalidPEM is the cause for public keys whose PublicKeyPem can't be decoded
+	ErrKeyInvalidPEM = errors.Newf("invalid public key PEM")
+	// ErrKeyUnsupported is the cause for keys which are not RSA, Ed25519 or ECDSA with the P-256 curve
+	ErrKeyUnsupported = errors.Newf("unsupported public key algorithm")
+	// ErrKeyMissingOwner is the cause for public keys, or owners, which don't have an ID
+	ErrKeyMissingOwner = errors.Newf("missing public key owner")
+	// ErrKeyOwnerMismatch is the cause for public keys whose Owner is not the ID of the actor
+	ErrKeyOwnerMismatch = errors.Newf("public key owner doesn't match the actor")
+	// ErrKeyOriginMismatch is the cause for public keys whose ID is not on the same origin as the actor
+	ErrKeyOriginMismatch = errors.Newf("public key ID doesn't match the origin of the actor")
+	// ErrKeyNotFound is the cause for key IDs which don't match any of the keys of an actor
+	ErrKeyNotFound = errors.Newf("public key not found")
+)
+
+// NewPublicKey builds the PublicKey of the owner actor from a *rsa.PublicKey, an ed25519.PublicKey
+// or an *ecdsa.PublicKey on the P-256 curve. The ID of the key is the ID of the owner with a "#main-key" fragment.
+func NewPublicKey(key crypto.PublicKey, owner Item) (PublicKey, error) {
+	if IsNil(owner) || owner.GetLink() == "" {
+		return PublicKey{}, &Error{Reason: ErrKeyMissingOwner}
+	}
+	iri := owner.GetLink()
+	id := ID(iri + "#main-key")
+	if err := checkKeyAlgorithm(key); err != nil {
+		return PublicKey{}, &Error{Path: string(id), Reason: err}
+	}
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return PublicKey{}, &Error{Path: string(id), Reason: ErrKeyUnsupported, Cause: err}
+	}
+	return PublicKey{
+		ID:           id,
+		Owner:        iri,
+		PublicKeyPem: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}, nil
+}
+
+// CryptoKey decodes the PublicKeyPem of p, which can be either a PKIX "PUBLIC KEY" or a PKCS #1 "RSA PUBLIC KEY".
+// It returns a *rsa.PublicKey, an ed25519.PublicKey or an *ecdsa.PublicKey, or a *Error.
+func (p PublicKey) CryptoKey() (crypto.PublicKey, error) {
+	if strings.TrimSpace(p.PublicKeyPem) == "" {
+		return nil, &Error{Path: string(p.ID), Reason: ErrKeyMissingPEM}
+	}
+	block, _ := pem.Decode([]byte(p.PublicKeyPem))
+	if block == nil {
+		return nil, &Error{Path: string(p.ID), Reason: ErrKeyInvalidPEM}
+	}
+	var (
+		key crypto.PublicKey
+		err error
+	)
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, &Error{Path: string(p.ID), Reason: ErrKeyInvalidPEM, Cause: errors.Newf("unknown PEM block type %q", block.Type)}
+	}
+	if err != nil {
+		return nil, &Error{Path: string(p.ID), Reason: ErrKeyInvalidPEM, Cause: err}
+	}
+	if err = checkKeyAlgorithm(key); err != nil {
+		return nil, &Error{Path: string(p.ID), Reason: err}
+	}
+	return key, nil
+}
+
+// CheckOwner verifies that p belongs to the owner actor: the Owner of the key must be the ID of the actor,
+// and the ID of the key must have the same origin, meaning scheme, host and port, as the actor.
+func (p PublicKey) CheckOwner(owner Item) error {
+	if IsNil(owner) || owner.GetLink() == "" || p.Owner == "" {
+		return &Error{Path: string(p.ID), Reason: ErrKeyMissingOwner}
+	}
+	iri := owner.GetLink()
+	if !p.Owner.Equal(iri) {
+		return &Error{Path: string(p.ID), Reason: ErrKeyOwnerMismatch}
+	}
+	if !sameOrigin(p.ID, iri) {
+		return &Error{Path: string(p.ID), Reason: ErrKeyOriginMismatch}
+	}
+	return nil
+}
+
+// OwnedCryptoKey checks that p belongs to the owner actor, and returns the decoded key.
+func (p PublicKey) OwnedCryptoKey(owner Item) (crypto.PublicKey, error) {
+	if err := p.CheckOwner(owner); err != nil {
+		return nil, err
+	}
+	return p.CryptoKey()
+}
+
+func checkKeyAlgorithm(key crypto.PublicKey) error {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if k == nil {
+			return ErrKeyUnsupported
+		}
+	case ed25519.PublicKey:
+		if len(k) != ed25519.PublicKeySize {
+			return ErrKeyUnsupported
+		}
+	case *ecdsa.PublicKey:
+		if k == nil || k.Curve != elliptic.P256() {
+			return ErrKeyUnsupported
+		}
+	default:
+		return ErrKeyUnsupported
+	}
+	return nil
+}
+
+// sameOrigin checks if the a and b IRIs have the same scheme, host and port.
+func sameOrigin(a, b IRI) bool {
+	ua, err := a.URL()
+	if err != nil || ua.Host == "" {
+		return false
+	}
+	ub, err := b.URL()
+	if err != nil || ub.Host == "" {
+		return false
+	}
+	return strings.EqualFold(ua.Scheme, ub.Scheme) && strings.EqualFold(ua.Host, ub.Host)
+}
+
+// KeyByID returns the key of the actor with the id, looking at its PublicKey and at its AssertionMethod keys,
+// which get converted to a PublicKey owned by their controller.
+// The fragment of the id is significant, as actors usually have multiple keys in the same document.
+func (a Actor) KeyByID(id IRI) (PublicKey, error) {
+	if len(id) > 0 && a.PublicKey.ID.String() == id.String() {
+		return a.PublicKey, nil
+	}
+	for _, k := range a.AssertionMethod {
+		if len(id) > 0 && k.ID.String() == id.String() {
+			return k.PublicKey()
+		}
+	}
+	return PublicKey{}, &Error{Path: string(id), Reason: ErrKeyNotFound}
+}
@@ -0,0 +1,266 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+)
+
+func mockKeys(t *testing.T) (*rsa.PublicKey, ed25519.PublicKey, *ecdsa.PublicKey) {
+	t.Helper()
+	rk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate RSA key: %s", err)
+	}
+	ek, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate Ed25519 key: %s", err)
+	}
+	ck, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate ECDSA key: %s", err)
+	}
+	return &rk.PublicKey, ek, &ck.PublicKey
+}
+
+func TestNewPublicKey(t *testing.T) {
+	rk, ek, ck := mockKeys(t)
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate ECDSA key: %s", err)
+	}
+	owner := &Actor{ID: "https://example.com/~jdoe", Type: PersonType}
+
+	tests := []struct {
+		name    string
+		key     crypto.PublicKey
+		owner   Item
+		wantErr error
+	}{
+		{name: "rsa", key: rk, owner: owner},
+		{name: "ed25519", key: ek, owner: owner},
+		{name: "ecdsa P-256", key: ck, owner: owner},
+		{name: "ecdsa P-384", key: &p384.PublicKey, owner: owner, wantErr: ErrKeyUnsupported},
+		{name: "private key", key: ed25519.PrivateKey{}, owner: owner, wantErr: ErrKeyUnsupported},
+		{name: "nil owner", key: ek, owner: nil, wantErr: ErrKeyMissingOwner},
+		{name: "owner without ID", key: ek, owner: &Actor{}, wantErr: ErrKeyMissingOwner},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewPublicKey(tt.key, tt.owner)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("NewPublicKey() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewPublicKey() error = %s", err)
+			}
+			if got.ID != "https://example.com/~jdoe#main-key" || got.Owner != owner.ID {
+				t.Errorf("NewPublicKey() = %v, wrong ID or owner", got)
+			}
+			key, err := got.OwnedCryptoKey(owner)
+			if err != nil {
+				t.Fatalf("OwnedCryptoKey() error = %s", err)
+			}
+			if k, ok := key.(interface{ Equal(crypto.PublicKey) bool }); !ok || !k.Equal(tt.key) {
+				t.Errorf("OwnedCryptoKey() = %v, want %v", key, tt.key)
+			}
+		})
+	}
+}
+
+func TestPublicKey_CryptoKey(t *testing.T) {
+	rk, _, _ := mockKeys(t)
+	pkcs1 := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(rk)}))
+
+	tests := []struct {
+		name    string
+		pem     string
+		wantErr error
+	}{
+		{name: "pkcs1", pem: pkcs1},
+		{name: "empty", pem: "", wantErr: ErrKeyMissingPEM},
+		{name: "not PEM", pem: "test", wantErr: ErrKeyInvalidPEM},
+		{name: "unknown block", pem: "-----BEGIN CERTIFICATE-----\ndGVzdA==\n-----END CERTIFICATE-----\n", wantErr: ErrKeyInvalidPEM},
+		{name: "invalid key", pem: "-----BEGIN PUBLIC KEY-----\ndGVzdA==\n-----END PUBLIC KEY-----\n", wantErr: ErrKeyInvalidPEM},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := PublicKey{ID: "https://example.com/~jdoe#main-key", PublicKeyPem: tt.pem}
+			got, err := p.CryptoKey()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CryptoKey() error = %v, want %s", err, tt.wantErr)
+				}
+				var kErr *Error
+				if !errors.As(err, &kErr) || kErr.Path != string(p.ID) {
+					t.Errorf("CryptoKey() error = %#v, want a *Error for %s", err, p.ID)
+				}
+				if errors.Is(err, ErrKeyUnsupported) {
+					t.Errorf("CryptoKey() error = %v, also matches %s", err, ErrKeyUnsupported)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CryptoKey() error = %s", err)
+			}
+			if !rk.Equal(got) {
+				t.Errorf("CryptoKey() = %v, want %v", got, rk)
+			}
+		})
+	}
+}
+
+func TestPublicKey_CheckOwner(t *testing.T) {
+	owner := &Actor{ID: "https://example.com/~jdoe", Type: PersonType}
+	tests := []struct {
+		name    string
+		key     PublicKey
+		owner   Item
+		wantErr error
+	}{
+		{
+			name:  "valid",
+			key:   PublicKey{ID: "https://example.com/~jdoe#main-key", Owner: "https://example.com/~jdoe"},
+			owner: owner,
+		},
+		{
+			name:  "key on another path",
+			key:   PublicKey{ID: "https://example.com/keys/1", Owner: "https://example.com/~jdoe"},
+			owner: owner,
+		},
+		{
+			name:    "missing owner",
+			key:     PublicKey{ID: "https://example.com/~jdoe#main-key"},
+			owner:   owner,
+			wantErr: ErrKeyMissingOwner,
+		},
+		{
+			name:    "nil actor",
+			key:     PublicKey{ID: "https://example.com/~jdoe#main-key", Owner: "https://example.com/~jdoe"},
+			wantErr: ErrKeyMissingOwner,
+		},
+		{
+			name:    "other owner",
+			key:     PublicKey{ID: "https://example.com/~jdoe#main-key", Owner: "https://example.com/~alice"},
+			owner:   owner,
+			wantErr: ErrKeyOwnerMismatch,
+		},
+		{
+			name:    "other host",
+			key:     PublicKey{ID: "https://example.net/~jdoe#main-key", Owner: "https://example.com/~jdoe"},
+			owner:   owner,
+			wantErr: ErrKeyOriginMismatch,
+		},
+		{
+			name:    "other port",
+			key:     PublicKey{ID: "https://example.com:8443/~jdoe#main-key", Owner: "https://example.com/~jdoe"},
+			owner:   owner,
+			wantErr: ErrKeyOriginMismatch,
+		},
+		{
+			name:    "other scheme",
+			key:     PublicKey{ID: "http://example.com/~jdoe#main-key", Owner: "https://example.com/~jdoe"},
+			owner:   owner,
+			wantErr: ErrKeyOriginMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.key.CheckOwner(tt.owner)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("CheckOwner() error = %s", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckOwner() error = %v, want %s", err, tt.wantErr)
+			}
+			for _, other := range []error{ErrKeyMissingOwner, ErrKeyOwnerMismatch, ErrKeyOriginMismatch} {
+				if other != tt.wantErr && errors.Is(err, other) {
+					t.Errorf("CheckOwner() error = %v, also matches %s", err, other)
+				}
+			}
+		})
+	}
+}
+
+func TestActor_KeyByID(t *testing.T) {
+	rk, ek, _ := mockKeys(t)
+	a := &Actor{ID: "https://example.com/~jdoe", Type: PersonType}
+	var err error
+	if a.PublicKey, err = NewPublicKey(rk, a); err != nil {
+		t.Fatalf("NewPublicKey() error = %s", err)
+	}
+	mk, err := NewMultikey("https://example.com/~jdoe#ed25519-key", ek, a)
+	if err != nil {
+		t.Fatalf("NewMultikey() error = %s", err)
+	}
+	a.AssertionMethod = []Multikey{mk}
+
+	tests := []struct {
+		name    string
+		id      IRI
+		want    crypto.PublicKey
+		wantErr error
+	}{
+		{
+			name: "publicKey",
+			id:   "https://example.com/~jdoe#main-key",
+			want: rk,
+		},
+		{
+			name: "assertionMethod",
+			id:   "https://example.com/~jdoe#ed25519-key",
+			want: ek,
+		},
+		{
+			name:    "another fragment",
+			id:      "https://example.com/~jdoe#other-key",
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name:    "without fragment",
+			id:      "https://example.com/~jdoe",
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name:    "empty",
+			wantErr: ErrKeyNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := a.KeyByID(tt.id)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("KeyByID() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("KeyByID() error = %s", err)
+			}
+			if key.ID.String() != tt.id.String() {
+				t.Errorf("KeyByID() returned key %s, want %s", key.ID, tt.id)
+			}
+			pub, err := key.OwnedCryptoKey(a)
+			if err != nil {
+				t.Fatalf("OwnedCryptoKey() error = %s", err)
+			}
+			if !pub.(interface{ Equal(crypto.PublicKey) bool }).Equal(tt.want) {
+				t.Errorf("KeyByID() returned another key")
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package activitypub
+
+// PublishOptions change what PrepareForPublication removes from the items, besides their Bto and BCC recipients.
+type PublishOptions struct {
+	// DropPrivate removes the properties which are meant only for the owner of an item, and its clients.
+	// These are the Endpoints of the actors, except for the sharedInbox, which is needed for delivery:
+	// "uploadMedia", "oauthAuthorizationEndpoint", "oauthTokenEndpoint", "provideClientKey", "signClientKey"
+	// and "proxyUrl".
+	DropPrivate bool
+}
+
+// maxPublishDepth is the depth of the embedded items that PrepareForPublication goes through.
+// The items embedded deeper are replaced by their IRIs, which also stops the cycles between items.
+const maxPublishDepth = 32
+
+// PrepareForPublication returns a copy of the "it" Item, without the Bto and BCC recipients, which can be
+// stored, or sent to other servers, without disclosing them.
+//
+// Unlike the Clean methods, it goes through all the items embedded in "it", like the Object, Target, Result
+// or Instrument of the activities, the options of the questions, the items and pages of the collections,
+// the collections of the actors, or the team and forks of the repositories, and removes their blind recipients too. The Audience is kept.
+// The "it" Item, and the items embedded in it, are not modified.
+func PrepareForPublication(it Item, opts PublishOptions) Item {
+	return opts.prepare(it, 0)
+}
+
+func (opts PublishOptions) prepare(it Item, depth int) Item {
+	if IsNil(it) || IsIRI(it) || IsLink(it) {
+		return it
+	}
+	if IsItemCollection(it) {
+		col := DerefItem(it)
+		items := make(ItemCollection, 0, len(col))
+		for _, ob := range col {
+			items = append(items, opts.prepare(ob, depth))
+		}
+		return items
+	}
+	if depth >= maxPublishDepth {
+		return it.GetLink()
+	}
+	n := Clone(it)
+	if IsNil(n) || !IsObject(n) {
+		// NOTE(marius): the types of the vocabulary extensions which can't be cloned are kept as they are
+		return it
+	}
+	depth++
+
+	_ = OnObject(n, func(o *Object) error {
+		o.Bto = nil
+		o.BCC = nil
+		o.To = opts.prepareCollection(o.To, depth)
+		o.CC = opts.prepareCollection(o.CC, depth)
+		o.Audience = opts.prepareCollection(o.Audience, depth)
+		o.Attachment = opts.prepare(o.Attachment, depth)
+		o.AttributedTo = opts.prepare(o.AttributedTo, depth)
+		o.Context = opts.prepare(o.Context, depth)
+		o.Generator = opts.prepare(o.Generator, depth)
+		o.Icon = opts.prepare(o.Icon, depth)
+		o.Image = opts.prepare(o.Image, depth)
+		o.InReplyTo = opts.prepare(o.InReplyTo, depth)
+		o.Location = opts.prepare(o.Location, depth)
+		o.Preview = opts.prepare(o.Preview, depth)
+		o.Replies = opts.prepare(o.Replies, depth)
+		o.Likes = opts.prepare(o.Likes, depth)
+		o.Shares = opts.prepare(o.Shares, depth)
+		o.Tag = opts.prepareCollection(o.Tag, depth)
+		return nil
+	})
+
+	typ := n.GetType()
+	switch {
+	case ActivityTypes.Match(typ) || IntransitiveActivityTypes.Match(typ):
+		_ = OnIntransitiveActivity(n, func(act *IntransitiveActivity) error {
+			act.Actor = opts.prepare(act.Actor, depth)
+			act.Target = opts.prepare(act.Target, depth)
+			act.Result = opts.prepare(act.Result, depth)
+			act.Origin = opts.prepare(act.Origin, depth)
+			act.Instrument = opts.prepare(act.Instrument, depth)
+			return nil
+		})
+		if QuestionType.Match(typ) {
+			_ = OnQuestion(n, func(q *Question) error {
+				q.OneOf = opts.prepare(q.OneOf, depth)
+				q.AnyOf = opts.prepare(q.AnyOf, depth)
+				return nil
+			})
+		}
+		if ActivityTypes.Match(typ) {
+			_ = OnActivity(n, func(act *Activity) error {
+				act.Object = opts.prepare(act.Object, depth)
+				return nil
+			})
+		}
+	case ActorTypes.Match(typ):
+		_ = OnActor(n, func(a *Actor) error {
+			a.Inbox = opts.prepare(a.Inbox, depth)
+			a.Outbox = opts.prepare(a.Outbox, depth)
+			a.Following = opts.prepare(a.Following, depth)
+			a.Followers = opts.prepare(a.Followers, depth)
+			a.Liked = opts.prepare(a.Liked, depth)
+			a.Streams = opts.prepareCollection(a.Streams, depth)
+			a.Featured = opts.prepare(a.Featured, depth)
+			a.FeaturedTags = opts.prepare(a.FeaturedTags, depth)
+			a.MovedTo = opts.prepare(a.MovedTo, depth)
+			a.AlsoKnownAs = opts.prepareCollection(a.AlsoKnownAs, depth)
+			a.Moderators = opts.prepare(a.Moderators, depth)
+			if opts.DropPrivate && a.Endpoints != nil {
+				a.Endpoints = publicEndpoints(*a.Endpoints)
+			}
+			return nil
+		})
+		if RepositoryType.Match(typ) {
+			_ = OnRepository(n, func(r *Repository) error {
+				r.Team = opts.prepare(r.Team, depth)
+				r.Forks = opts.prepare(r.Forks, depth)
+				r.TicketsTrackedBy = opts.prepare(r.TicketsTrackedBy, depth)
+				return nil
+			})
+		}
+	case CollectionTypes.Match(typ):
+		_ = OnCollection(n, func(c *Collection) error {
+			c.Current = opts.prepare(c.Current, depth)
+			c.First = opts.prepare(c.First, depth)
+			c.Last = opts.prepare(c.Last, depth)
+			c.Items = opts.prepareCollection(c.Items, depth)
+			return nil
+		})
+		if (ActivityVocabularyTypes{CollectionPageType, OrderedCollectionPageType}).Match(typ) {
+			_ = OnCollectionPage(n, func(p *CollectionPage) error {
+				p.PartOf = opts.prepare(p.PartOf, depth)
+				p.Next = opts.prepare(p.Next, depth)
+				p.Prev = opts.prepare(p.Prev, depth)
+				return nil
+			})
+		}
+	case ActivityVocabularyTypes{RelationshipType, TicketDependencyType}.Match(typ):
+		_ = OnRelationship(n, func(r *Relationship) error {
+			r.Subject = opts.prepare(r.Subject, depth)
+			r.Object = opts.prepare(r.Object, depth)
+			r.Relationship = opts.prepare(r.Relationship, depth)
+			return nil
+		})
+	case TicketType.Match(typ):
+		_ = OnTicket(n, func(t *Ticket) error {
+			t.ResolvedBy = opts.prepare(t.ResolvedBy, depth)
+			t.AssignedTo = opts.prepare(t.AssignedTo, depth)
+			return nil
+		})
+	case ProfileType.Match(typ):
+		_ = OnProfile(n, func(p *Profile) error {
+			p.Describes = opts.prepare(p.Describes, depth)
+			return nil
+		})
+	}
+	return n
+}
+
+func (opts PublishOptions) prepareCollection(col ItemCollection, depth int) ItemCollection {
+	if col == nil {
+		return nil
+	}
+	items := make(ItemCollection, 0, len(col))
+	for _, it := range col {
+		items = append(items, opts.prepare(it, depth))
+	}
+	return items
+}
+
+func publicEndpoints(e Endpoints) *Endpoints {
+	if IsNil(e.SharedInbox) {
+		return nil
+	}
+	return &Endpoints{SharedInbox: e.SharedInbox}
+}
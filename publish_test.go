@@ -0,0 +1,244 @@
+package activitypub
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPrepareForPublication(t *testing.T) {
+	alice := IRI("https://mastodon.example/users/alice")
+	bob := IRI("https://other.example/users/bob")
+	carol := IRI("https://third.example/users/carol")
+	group := IRI("https://lemmy.example/c/tenforward")
+
+	blindNote := func(id IRI) *Object {
+		return &Object{ID: ID(id), Type: NoteType, To: ItemCollection{bob}, Bto: ItemCollection{carol}, BCC: ItemCollection{alice}, Audience: ItemCollection{group}}
+	}
+	note := func(id IRI) *Object {
+		return &Object{ID: ID(id), Type: NoteType, To: ItemCollection{bob}, Audience: ItemCollection{group}}
+	}
+	endpoints := &Endpoints{
+		SharedInbox:                IRI("https://mastodon.example/inbox"),
+		UploadMedia:                IRI("https://mastodon.example/upload"),
+		OauthAuthorizationEndpoint: IRI("https://mastodon.example/oauth/authorize"),
+		OauthTokenEndpoint:         IRI("https://mastodon.example/oauth/token"),
+		ProxyURL:                   "https://mastodon.example/proxy",
+	}
+
+	tests := []struct {
+		name string
+		it   Item
+		opts PublishOptions
+		want Item
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name: "IRI",
+			it:   alice,
+			want: alice,
+		},
+		{
+			name: "object",
+			it:   blindNote("https://mastodon.example/notes/1"),
+			want: note("https://mastodon.example/notes/1"),
+		},
+		{
+			name: "activity",
+			it: &Activity{
+				Type:       CreateType,
+				Bto:        ItemCollection{carol},
+				Actor:      &Actor{ID: ID(alice), Type: PersonType, BCC: ItemCollection{bob}},
+				Object:     blindNote("https://mastodon.example/notes/1"),
+				Target:     ItemCollection{blindNote("https://mastodon.example/notes/2"), IRI("https://mastodon.example/notes/3")},
+				Result:     blindNote("https://mastodon.example/notes/4"),
+				Instrument: &Object{Type: ApplicationType, Bto: ItemCollection{bob}, Icon: &Image{Type: ImageType, BCC: ItemCollection{carol}}},
+			},
+			want: &Activity{
+				Type:       CreateType,
+				Actor:      &Actor{ID: ID(alice), Type: PersonType},
+				Object:     note("https://mastodon.example/notes/1"),
+				Target:     ItemCollection{note("https://mastodon.example/notes/2"), IRI("https://mastodon.example/notes/3")},
+				Result:     note("https://mastodon.example/notes/4"),
+				Instrument: &Object{Type: ApplicationType, Icon: &Image{Type: ImageType}},
+			},
+		},
+		{
+			name: "actor",
+			it: &Actor{
+				ID:        ID(alice),
+				Type:      PersonType,
+				Bto:       ItemCollection{bob},
+				Endpoints: endpoints,
+				Outbox:    &OrderedCollection{Type: OrderedCollectionType, OrderedItems: ItemCollection{blindNote("https://mastodon.example/notes/1")}},
+				Featured:  &Collection{Type: CollectionType, Items: ItemCollection{blindNote("https://mastodon.example/notes/2")}},
+			},
+			want: &Actor{
+				ID:        ID(alice),
+				Type:      PersonType,
+				Endpoints: endpoints,
+				Outbox:    &OrderedCollection{Type: OrderedCollectionType, OrderedItems: ItemCollection{note("https://mastodon.example/notes/1")}},
+				Featured:  &Collection{Type: CollectionType, Items: ItemCollection{note("https://mastodon.example/notes/2")}},
+			},
+		},
+		{
+			name: "actor without private properties",
+			it:   &Actor{ID: ID(alice), Type: PersonType, BCC: ItemCollection{bob}, Endpoints: endpoints},
+			opts: PublishOptions{DropPrivate: true},
+			want: &Actor{ID: ID(alice), Type: PersonType, Endpoints: &Endpoints{SharedInbox: IRI("https://mastodon.example/inbox")}},
+		},
+		{
+			name: "actor without shared inbox",
+			it:   &Actor{ID: ID(alice), Type: PersonType, Endpoints: &Endpoints{OauthTokenEndpoint: IRI("https://mastodon.example/oauth/token")}},
+			opts: PublishOptions{DropPrivate: true},
+			want: &Actor{ID: ID(alice), Type: PersonType},
+		},
+		{
+			name: "question",
+			it: &Question{
+				Type:     QuestionType,
+				Actor:    alice,
+				BCC:      ItemCollection{bob},
+				Audience: ItemCollection{group},
+				OneOf: ItemCollection{
+					&Object{Type: NoteType, Name: DefaultNaturalLanguage("yes"), Bto: ItemCollection{carol}},
+					&Object{Type: NoteType, Name: DefaultNaturalLanguage("no"), Replies: &Collection{Type: CollectionType, Items: ItemCollection{blindNote("https://mastodon.example/notes/1")}}},
+				},
+			},
+			want: &Question{
+				Type:     QuestionType,
+				Actor:    alice,
+				Audience: ItemCollection{group},
+				OneOf: ItemCollection{
+					&Object{Type: NoteType, Name: DefaultNaturalLanguage("yes")},
+					&Object{Type: NoteType, Name: DefaultNaturalLanguage("no"), Replies: &Collection{Type: CollectionType, Items: ItemCollection{note("https://mastodon.example/notes/1")}}},
+				},
+			},
+		},
+		{
+			name: "place",
+			it: Place{
+				Type:       PlaceType,
+				Latitude:   45.5,
+				Longitude:  25.6,
+				Bto:        ItemCollection{bob},
+				Attachment: blindNote("https://mastodon.example/notes/1"),
+			},
+			want: &Place{
+				Type:       PlaceType,
+				Latitude:   45.5,
+				Longitude:  25.6,
+				Attachment: note("https://mastodon.example/notes/1"),
+			},
+		},
+		{
+			name: "collection page",
+			it: &CollectionPage{
+				Type:   CollectionPageType,
+				BCC:    ItemCollection{bob},
+				PartOf: &Collection{ID: "https://mastodon.example/notes", Type: CollectionType, Bto: ItemCollection{carol}},
+				Items:  ItemCollection{blindNote("https://mastodon.example/notes/1"), blindNote("https://mastodon.example/notes/2")},
+				Next:   &CollectionPage{Type: CollectionPageType, Items: ItemCollection{blindNote("https://mastodon.example/notes/3")}},
+			},
+			want: &CollectionPage{
+				Type:   CollectionPageType,
+				PartOf: &Collection{ID: "https://mastodon.example/notes", Type: CollectionType},
+				Items:  ItemCollection{note("https://mastodon.example/notes/1"), note("https://mastodon.example/notes/2")},
+				Next:   &CollectionPage{Type: CollectionPageType, Items: ItemCollection{note("https://mastodon.example/notes/3")}},
+			},
+		},
+		{
+			name: "ordered collection page",
+			it: &OrderedCollectionPage{
+				Type:         OrderedCollectionPageType,
+				Bto:          ItemCollection{bob},
+				OrderedItems: ItemCollection{&Activity{Type: CreateType, BCC: ItemCollection{carol}, Object: blindNote("https://mastodon.example/notes/1")}},
+				Prev:         IRI("https://mastodon.example/outbox?page=1"),
+			},
+			want: &OrderedCollectionPage{
+				Type:         OrderedCollectionPageType,
+				OrderedItems: ItemCollection{&Activity{Type: CreateType, Object: note("https://mastodon.example/notes/1")}},
+				Prev:         IRI("https://mastodon.example/outbox?page=1"),
+			},
+		},
+		{
+			name: "profile",
+			it:   &Profile{Type: ProfileType, Describes: &Actor{ID: ID(alice), Type: PersonType, Bto: ItemCollection{bob}}},
+			want: &Profile{Type: ProfileType, Describes: &Actor{ID: ID(alice), Type: PersonType}},
+		},
+		{
+			name: "repository",
+			it: &Repository{
+				ID:               "https://forge.example/alice/repo",
+				Type:             RepositoryType,
+				Bto:              ItemCollection{bob},
+				Outbox:           &OrderedCollection{Type: OrderedCollectionType, BCC: ItemCollection{carol}},
+				Team:             &Collection{Type: CollectionType, Items: ItemCollection{&Actor{ID: ID(alice), Type: PersonType, Bto: ItemCollection{bob}}}},
+				Forks:            &OrderedCollection{Type: OrderedCollectionType, OrderedItems: ItemCollection{&Repository{Type: RepositoryType, BCC: ItemCollection{carol}}}},
+				TicketsTrackedBy: &Repository{ID: "https://forge.example/alice/tracker", Type: RepositoryType, Bto: ItemCollection{bob}},
+			},
+			want: &Repository{
+				ID:               "https://forge.example/alice/repo",
+				Type:             RepositoryType,
+				Outbox:           &OrderedCollection{Type: OrderedCollectionType},
+				Team:             &Collection{Type: CollectionType, Items: ItemCollection{&Actor{ID: ID(alice), Type: PersonType}}},
+				Forks:            &OrderedCollection{Type: OrderedCollectionType, OrderedItems: ItemCollection{&Repository{Type: RepositoryType}}},
+				TicketsTrackedBy: &Repository{ID: "https://forge.example/alice/tracker", Type: RepositoryType},
+			},
+		},
+		{
+			name: "ticket",
+			it: &Ticket{
+				Type:       TicketType,
+				BCC:        ItemCollection{carol},
+				Context:    &Repository{ID: "https://forge.example/alice/repo", Type: RepositoryType, Bto: ItemCollection{bob}},
+				ResolvedBy: &Actor{ID: ID(alice), Type: PersonType, BCC: ItemCollection{carol}},
+				AssignedTo: ItemCollection{&Actor{ID: ID(bob), Type: PersonType, Bto: ItemCollection{carol}}, carol},
+			},
+			want: &Ticket{
+				Type:       TicketType,
+				Context:    &Repository{ID: "https://forge.example/alice/repo", Type: RepositoryType},
+				ResolvedBy: &Actor{ID: ID(alice), Type: PersonType},
+				AssignedTo: ItemCollection{&Actor{ID: ID(bob), Type: PersonType}, carol},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := Clone(tt.it)
+			got := PrepareForPublication(tt.it, tt.opts)
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("PrepareForPublication() got = %s", cmp.Diff(tt.want, got))
+			}
+			if !IsNil(tt.it) && !cmp.Equal(Clone(tt.it), before) {
+				t.Errorf("PrepareForPublication() modified the item = %s", cmp.Diff(before, tt.it))
+			}
+		})
+	}
+}
+
+func TestPrepareForPublication_cycle(t *testing.T) {
+	a := &Actor{ID: "https://mastodon.example/users/alice", Type: PersonType, Bto: ItemCollection{IRI("https://other.example/users/bob")}}
+	a.MovedTo = a
+
+	got := PrepareForPublication(a, PublishOptions{})
+	depth := 0
+	for it := got; IsObject(it); depth++ {
+		act, err := ToActor(it)
+		if err != nil {
+			t.Fatalf("ToActor() error = %s", err)
+		}
+		if act.Bto != nil {
+			t.Fatalf("PrepareForPublication() kept the bto at depth %d", depth)
+		}
+		it = act.MovedTo
+	}
+	if depth != maxPublishDepth {
+		t.Errorf("PrepareForPublication() embedded %d items, want %d", depth, maxPublishDepth)
+	}
+	if a.Bto == nil {
+		t.Errorf("PrepareForPublication() modified the item")
+	}
+}
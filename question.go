@@ -5,8 +5,6 @@ import (
 	"encoding/gob"
 	"fmt"
 	"time"
-
-	"github.com/valyala/fastjson"
 )
 
 // Question represents a question being asked. Question objects are an extension of IntransitiveActivity.
@@ -107,6 +105,21 @@ type Question struct {
 	// as a form of provenance, or to support future editing by clients.
 	// In general, clients do the conversion from source to content, not the other way around.
 	Source Source `jsonld:"source,omitempty"`
+	// Sensitive marks the content of the object as not safe for all audiences, so it should be hidden
+	// behind its Summary. Like FocalPoint and Blurhash, it is a Mastodon extension to the vocabulary.
+	Sensitive bool `jsonld:"sensitive,omitempty"`
+	// FocalPoint is the point of an image which must stay visible when it is cropped, as x and y
+	// coordinates between -1.0 and 1.0.
+	FocalPoint []float64 `jsonld:"focalPoint,omitempty"`
+	// Blurhash is a compact representation of a blurred placeholder for an image.
+	Blurhash string `jsonld:"blurhash,omitempty"`
+	// Proof holds the Data Integrity proof of the document, like the eddsa-jcs-2022 ones of FEP-8b32.
+	// The proof sets, which hold more than one proof, are kept unchanged in the Extensions.
+	Proof *DataIntegrityProof `jsonld:"proof,omitempty"`
+	// Extensions holds the properties of the document that are not part of the vocabulary known to this package,
+	// like the ones coming from ActivityStreams extensions or custom namespaces.
+	// They are kept as raw JSON values so they can be written back unchanged.
+	Extensions Extensions `jsonld:"-"`
 	// CanReceiveActivities describes one or more entities that either performed or are expected to perform the activity.
 	// Any single activity can have multiple actors. The actor may be specified using an indirect Link.
 	Actor CanReceiveActivities `jsonld:"actor,omitempty"`
@@ -157,8 +170,7 @@ func (q Question) Match(tt ...ActivityVocabularyType) bool {
 
 // UnmarshalJSON decodes an incoming JSON document into the receiver object.
 func (q *Question) UnmarshalJSON(data []byte) error {
-	p := fastjson.Parser{}
-	val, err := p.ParseBytes(data)
+	val, err := parseJSON(data)
 	if err != nil {
 		return err
 	}
@@ -167,14 +179,21 @@ func (q *Question) UnmarshalJSON(data []byte) error {
 
 // MarshalJSON encodes the receiver object to a JSON document.
 func (q Question) MarshalJSON() ([]byte, error) {
-	b := bytes.Buffer{}
-	JSONWrite(&b, '{')
+	return jsonMarshal(q)
+}
+
+func (q Question) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
+	start := b.Len()
+	JSONWrite(b, '{')
 
-	if !JSONWriteQuestionValue(&b, q) {
-		return nil, nil
+	notEmpty := jsonWriteQuestionValue(b, q, opts)
+	notEmpty = JSONWriteExtensions(b, q.Extensions, notEmpty, objectProperties, intransitiveActivityProperties, questionProperties) || notEmpty
+	if !notEmpty {
+		b.Truncate(start)
+		return false
 	}
-	JSONWrite(&b, '}')
-	return b.Bytes(), nil
+	JSONWrite(b, '}')
+	return true
 }
 
 // UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
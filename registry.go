@@ -0,0 +1,173 @@
+package activitypub
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/go-ap/errors"
+	"github.com/valyala/fastjson"
+)
+
+// TypeDefinition describes a type from a vocabulary extension, like Funkwhale's "Library" or PeerTube's
+// "CacheFile", so the decoders and encoders of the package know how to handle it.
+//
+// The simplest definitions only have a Base type, which is a type known to the package whose struct, and JSON
+// and gob functions, are used for the new type. Extension packages with their own structs need to set the
+// functions which can't be inferred from the Base.
+type TypeDefinition struct {
+	// Type is the value of the "type" property of the objects, eg: "Library", or, for documents which
+	// don't compact their types, a namespaced IRI, eg: "https://funkwhale.audio/ns#Library".
+	Type ActivityVocabularyType
+	// Base is the type known to the package that the Type extends, eg: ObjectType, PersonType or CreateType.
+	// The Type is added to the groups of types the Base belongs to, like ObjectTypes or ActorTypes.
+	Base ActivityVocabularyType
+	// New returns a new, empty, item of the Type.
+	// When nil, the item is the one that GetItemByType returns for the Base.
+	New func() Item
+	// LoadJSON loads the val JSON document into the it item, as returned by New.
+	// When nil, the document is loaded like the ones of the Base type.
+	LoadJSON func(val *fastjson.Value, it Item) error
+	// MarshalJSON encodes the it item to JSON.
+	// When nil, the json.Marshaler implementation of the item is used.
+	MarshalJSON func(it Item) ([]byte, error)
+	// GobEncode encodes the it item to gob.
+	// When nil, the item is encoded like the ones of the Base type.
+	GobEncode func(it Item) ([]byte, error)
+	// GobDecode decodes the data into the it item, as returned by New.
+	// When nil, the data is decoded like the ones of the Base type.
+	GobDecode func(data []byte, it Item) error
+}
+
+var (
+	// ErrTypeRegistered is returned when registering a type which the package, or another registration, already knows.
+	ErrTypeRegistered = errors.Newf("type is already registered")
+	// ErrInvalidTypeDefinition is returned when registering a TypeDefinition which can't be used for decoding.
+	ErrInvalidTypeDefinition = errors.Newf("invalid type definition")
+)
+
+var registry = struct {
+	sync.RWMutex
+	defs map[ActivityVocabularyType]TypeDefinition
+}{
+	defs: make(map[ActivityVocabularyType]TypeDefinition),
+}
+
+// RegisterType adds the def TypeDefinition to the registry, so its type gets loaded by UnmarshalJSON and
+// GobDecode, and is considered valid by the strict decoder.
+//
+// Types can't be registered twice, and the types of the ActivityStreams vocabulary can't be replaced, so the
+// extension packages can't clobber each other's types. As it changes the groups of types, like Types or
+// ActorTypes, it is meant to be called from the init functions of the packages.
+func RegisterType(def TypeDefinition) error {
+	if len(def.Type) == 0 {
+		return errors.Annotatef(ErrInvalidTypeDefinition, "missing type")
+	}
+	if len(def.Base) == 0 && (def.New == nil || def.LoadJSON == nil) {
+		return errors.Annotatef(ErrInvalidTypeDefinition, "type %s needs either a base type, or New and LoadJSON functions", def.Type)
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if len(def.Base) > 0 && !builtinType(def.Base) {
+		return errors.Annotatef(ErrInvalidTypeDefinition, "unknown base type %s for %s", def.Base, def.Type)
+	}
+	if _, ok := registry.defs[def.Type]; ok || builtinType(def.Type) {
+		return errors.Annotatef(ErrTypeRegistered, "%s", def.Type)
+	}
+	registry.defs[def.Type] = def
+	for _, group := range typeGroupsOf(def.Base) {
+		*group = append(*group, def.Type)
+	}
+	if !slices.Contains(Types, def.Type) {
+		Types = append(Types, def.Type)
+	}
+	return nil
+}
+
+// UnregisterType removes the typ type from the registry, and from the groups of types it was added to.
+func UnregisterType(typ ActivityVocabularyType) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	def, ok := registry.defs[typ]
+	if !ok {
+		return
+	}
+	delete(registry.defs, typ)
+	isType := func(t ActivityVocabularyType) bool { return t == typ }
+	for _, group := range typeGroupsOf(def.Base) {
+		*group = slices.DeleteFunc(*group, isType)
+	}
+	Types = slices.DeleteFunc(Types, isType)
+}
+
+// RegisteredType returns the TypeDefinition of the first of the types in typ which was registered.
+func RegisteredType(typ Typer) (TypeDefinition, bool) {
+	if typ == nil {
+		return TypeDefinition{}, false
+	}
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if len(registry.defs) == 0 {
+		return TypeDefinition{}, false
+	}
+	for _, t := range typ.AsTypes() {
+		if def, ok := registry.defs[t]; ok {
+			return def, true
+		}
+	}
+	return TypeDefinition{}, false
+}
+
+func builtinType(typ ActivityVocabularyType) bool {
+	if _, ok := registry.defs[typ]; ok {
+		return false
+	}
+	return slices.Contains(Types, typ) || slices.Contains(GenericTypes, typ)
+}
+
+// typeGroupsOf returns the groups of types that the base type belongs to.
+func typeGroupsOf(base ActivityVocabularyType) []*ActivityVocabularyTypes {
+	groups := []struct {
+		generic ActivityVocabularyType
+		types   *ActivityVocabularyTypes
+	}{
+		{generic: ObjectType, types: &ObjectTypes},
+		{types: &LinkTypes},
+		{generic: ActorType, types: &ActorTypes},
+		{generic: ActivityType, types: &ActivityTypes},
+		{generic: IntransitiveActivityType, types: &IntransitiveActivityTypes},
+		{types: &CollectionTypes},
+	}
+	result := make([]*ActivityVocabularyTypes, 0)
+	for _, g := range groups {
+		if (len(g.generic) > 0 && base == g.generic) || slices.Contains(*g.types, base) {
+			result = append(result, g.types)
+		}
+	}
+	return result
+}
+
+func (d TypeDefinition) newItem() (Item, error) {
+	if d.New != nil {
+		return d.New(), nil
+	}
+	it, err := GetItemByType(d.Base)
+	if err != nil {
+		return nil, err
+	}
+	if LinkTypes.Match(d.Base) {
+		err = OnLink(it, func(l *Link) error {
+			l.Type = d.Type
+			return nil
+		})
+	} else {
+		err = OnObject(it, func(o *Object) error {
+			o.Type = d.Type
+			return nil
+		})
+	}
+	return it, err
+}
@@ -0,0 +1,233 @@
+package activitypub
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/valyala/fastjson"
+)
+
+// mockCacheFile is a type which doesn't share the structure of Object, like the ones of the extension
+// packages which have their own structs.
+type mockCacheFile struct {
+	ID   ID                     `json:"id"`
+	Type ActivityVocabularyType `json:"type"`
+	Size uint                   `json:"size"`
+}
+
+func (c mockCacheFile) GetID() ID      { return c.ID }
+func (c mockCacheFile) GetType() Typer { return c.Type }
+func (c mockCacheFile) GetLink() IRI   { return IRI(c.ID) }
+
+var mockCacheFileDefinition = TypeDefinition{
+	Type: "CacheFile",
+	New:  func() Item { return &mockCacheFile{Type: "CacheFile"} },
+	LoadJSON: func(val *fastjson.Value, it Item) error {
+		c, ok := it.(*mockCacheFile)
+		if !ok {
+			return fmt.Errorf("unexpected %T", it)
+		}
+		c.ID = JSONGetID(val)
+		c.Size = uint(val.GetUint("size"))
+		return nil
+	},
+	MarshalJSON: func(it Item) ([]byte, error) {
+		return json.Marshal(it)
+	},
+	GobEncode: func(it Item) ([]byte, error) {
+		b := bytes.Buffer{}
+		err := gob.NewEncoder(&b).Encode(it)
+		return b.Bytes(), err
+	},
+	GobDecode: func(data []byte, it Item) error {
+		return gob.NewDecoder(bytes.NewReader(data)).Decode(it)
+	},
+}
+
+func registerMockTypes(t *testing.T, defs ...TypeDefinition) {
+	t.Helper()
+	for _, def := range defs {
+		if err := RegisterType(def); err != nil {
+			t.Fatalf("RegisterType(%s) error = %s", def.Type, err)
+		}
+		t.Cleanup(func() {
+			UnregisterType(def.Type)
+		})
+	}
+}
+
+func TestRegisterType(t *testing.T) {
+	registerMockTypes(t, TypeDefinition{Type: "Library", Base: ActorType})
+
+	tests := []struct {
+		name    string
+		def     TypeDefinition
+		wantErr error
+	}{
+		{
+			name:    "empty",
+			wantErr: ErrInvalidTypeDefinition,
+		},
+		{
+			name:    "no base and no functions",
+			def:     TypeDefinition{Type: "Track"},
+			wantErr: ErrInvalidTypeDefinition,
+		},
+		{
+			name:    "unknown base",
+			def:     TypeDefinition{Type: "Track", Base: "Recording"},
+			wantErr: ErrInvalidTypeDefinition,
+		},
+		{
+			name:    "registered base",
+			def:     TypeDefinition{Type: "Channel", Base: "Library"},
+			wantErr: ErrInvalidTypeDefinition,
+		},
+		{
+			name:    "already registered",
+			def:     TypeDefinition{Type: "Library", Base: ObjectType},
+			wantErr: ErrTypeRegistered,
+		},
+		{
+			name:    "vocabulary type",
+			def:     TypeDefinition{Type: NoteType, Base: ObjectType},
+			wantErr: ErrTypeRegistered,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RegisterType(tt.def)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("RegisterType() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+
+	def, ok := RegisteredType(ActivityVocabularyTypes{NoteType, "Library"})
+	if !ok || def.Type != "Library" {
+		t.Errorf("RegisteredType() = %v, %t, want the Library definition", def, ok)
+	}
+	if !ActorTypes.Match(ActivityVocabularyType("Library")) || !slices.Contains(Types, "Library") {
+		t.Errorf("RegisterType() didn't add the type to the ActorTypes and Types groups")
+	}
+	if ObjectTypes.Match(ActivityVocabularyType("Library")) {
+		t.Errorf("RegisterType() added the type to the ObjectTypes group")
+	}
+
+	UnregisterType("Library")
+	if _, ok = RegisteredType(ActivityVocabularyType("Library")); ok {
+		t.Errorf("UnregisterType() kept the type in the registry")
+	}
+	if ActorTypes.Match(ActivityVocabularyType("Library")) || slices.Contains(Types, "Library") {
+		t.Errorf("UnregisterType() kept the type in the ActorTypes and Types groups")
+	}
+}
+
+func TestRegisterType_baseTypes(t *testing.T) {
+	registerMockTypes(t,
+		TypeDefinition{Type: "Library", Base: ActorType},
+		TypeDefinition{Type: "https://funkwhale.audio/ns#Track", Base: AudioType},
+		TypeDefinition{Type: "Bite", Base: ActivityType},
+	)
+
+	tests := []struct {
+		name string
+		data string
+		want Item
+	}{
+		{
+			name: "actor",
+			data: `{"id":"https://example.com/library","type":"Library","inbox":"https://example.com/library/inbox"}`,
+			want: &Actor{ID: "https://example.com/library", Type: ActivityVocabularyType("Library"), Inbox: IRI("https://example.com/library/inbox")},
+		},
+		{
+			name: "object with namespaced type",
+			data: `{"id":"https://example.com/1","type":"https://funkwhale.audio/ns#Track","name":"intro"}`,
+			want: &Object{ID: "https://example.com/1", Type: ActivityVocabularyType("https://funkwhale.audio/ns#Track"), Name: DefaultNaturalLanguage("intro")},
+		},
+		{
+			name: "activity",
+			data: `{"type":"Bite","actor":"https://example.com/~jdoe","object":{"id":"https://example.com/library","type":"Library"}}`,
+			want: &Activity{
+				Type:   ActivityVocabularyType("Bite"),
+				Actor:  IRI("https://example.com/~jdoe"),
+				Object: &Actor{ID: "https://example.com/library", Type: ActivityVocabularyType("Library")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalJSONStrict([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("UnmarshalJSONStrict() error = %s", err)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Fatalf("UnmarshalJSONStrict() got = %s", cmp.Diff(tt.want, got))
+			}
+
+			raw, err := MarshalJSON(got)
+			if err != nil {
+				t.Fatalf("MarshalJSON() error = %s", err)
+			}
+			if string(raw) != tt.data {
+				t.Errorf("MarshalJSON() got = %s, want %s", raw, tt.data)
+			}
+
+			g, err := GobEncode(got)
+			if err != nil {
+				t.Fatalf("GobEncode() error = %s", err)
+			}
+			fromGob, err := GobDecode(g)
+			if err != nil {
+				t.Fatalf("GobDecode() error = %s", err)
+			}
+			if !cmp.Equal(fromGob, tt.want) {
+				t.Errorf("GobDecode() got = %s", cmp.Diff(tt.want, fromGob))
+			}
+		})
+	}
+}
+
+func TestRegisterType_customStruct(t *testing.T) {
+	registerMockTypes(t, mockCacheFileDefinition)
+
+	data := `{"type":"Create","object":{"id":"https://example.com/cache/1","type":"CacheFile","size":42}}`
+	want := &Activity{
+		Type:   CreateType,
+		Object: &mockCacheFile{ID: "https://example.com/cache/1", Type: "CacheFile", Size: 42},
+	}
+
+	got, err := UnmarshalJSON([]byte(data))
+	if err != nil {
+		t.Fatalf("UnmarshalJSON() error = %s", err)
+	}
+	if !cmp.Equal(got, want) {
+		t.Fatalf("UnmarshalJSON() got = %s", cmp.Diff(want, got))
+	}
+
+	raw, err := MarshalJSON(got)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %s", err)
+	}
+	if string(raw) != data {
+		t.Errorf("MarshalJSON() got = %s, want %s", raw, data)
+	}
+
+	g, err := GobEncode(want.Object)
+	if err != nil {
+		t.Fatalf("GobEncode() error = %s", err)
+	}
+	fromGob, err := GobDecode(g)
+	if err != nil {
+		t.Fatalf("GobDecode() error = %s", err)
+	}
+	if !cmp.Equal(fromGob, want.Object) {
+		t.Errorf("GobDecode() got = %s", cmp.Diff(want.Object, fromGob))
+	}
+}
@@ -5,8 +5,6 @@ import (
 	"encoding/gob"
 	"fmt"
 	"time"
-
-	"github.com/valyala/fastjson"
 )
 
 // Relationship describes a relationship between two individuals.
@@ -110,6 +108,21 @@ type Relationship struct {
 	// as a form of provenance, or to support future editing by clients.
 	// In general, clients do the conversion from source to content, not the other way around.
 	Source Source `jsonld:"source,omitempty"`
+	// Sensitive marks the content of the object as not safe for all audiences, so it should be hidden
+	// behind its Summary. Like FocalPoint and Blurhash, it is a Mastodon extension to the vocabulary.
+	Sensitive bool `jsonld:"sensitive,omitempty"`
+	// FocalPoint is the point of an image which must stay visible when it is cropped, as x and y
+	// coordinates between -1.0 and 1.0.
+	FocalPoint []float64 `jsonld:"focalPoint,omitempty"`
+	// Blurhash is a compact representation of a blurred placeholder for an image.
+	Blurhash string `jsonld:"blurhash,omitempty"`
+	// Proof holds the Data Integrity proof of the document, like the eddsa-jcs-2022 ones of FEP-8b32.
+	// The proof sets, which hold more than one proof, are kept unchanged in the Extensions.
+	Proof *DataIntegrityProof `jsonld:"proof,omitempty"`
+	// Extensions holds the properties of the document that are not part of the vocabulary known to this package,
+	// like the ones coming from ActivityStreams extensions or custom namespaces.
+	// They are kept as raw JSON values so they can be written back unchanged.
+	Extensions Extensions `jsonld:"-"`
 	// Subject property identifies one of the connected individuals.
 	// For instance, for a Relationship object describing "John is related to Sally", subject would refer to John.
 	Subject Item `jsonld:"subject,omitempty"`
@@ -142,8 +155,7 @@ func (r Relationship) Match(tt ...ActivityVocabularyType) bool {
 
 // UnmarshalJSON decodes an incoming JSON document into the receiver object.
 func (r *Relationship) UnmarshalJSON(data []byte) error {
-	par := fastjson.Parser{}
-	val, err := par.ParseBytes(data)
+	val, err := parseJSON(data)
 	if err != nil {
 		return err
 	}
@@ -152,30 +164,38 @@ func (r *Relationship) UnmarshalJSON(data []byte) error {
 
 // MarshalJSON encodes the receiver object to a JSON document.
 func (r Relationship) MarshalJSON() ([]byte, error) {
-	b := bytes.Buffer{}
+	return jsonMarshal(r)
+}
+
+func (r Relationship) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
 	notEmpty := false
-	JSONWrite(&b, '{')
+	start := b.Len()
+	JSONWrite(b, '{')
 
 	_ = OnObject(r, func(o *Object) error {
-		notEmpty = JSONWriteObjectValue(&b, *o)
+		notEmpty = jsonWriteObjectValue(b, *o, opts)
 		return nil
 	})
 
 	if r.Subject != nil {
-		notEmpty = JSONWriteItemProp(&b, "subject", r.Subject, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "subject", r.Subject, notEmpty, opts) || notEmpty
 	}
 	if r.Object != nil {
-		notEmpty = JSONWriteItemProp(&b, "object", r.Object, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "object", r.Object, notEmpty, opts) || notEmpty
 	}
 	if r.Relationship != nil {
-		notEmpty = JSONWriteItemProp(&b, "relationship", r.Relationship, notEmpty) || notEmpty
+		notEmpty = jsonWriteItemProp(b, "relationship", r.Relationship, notEmpty, opts) || notEmpty
 	}
 
+	if len(r.Extensions) > 0 {
+		notEmpty = JSONWriteExtensions(b, r.Extensions, notEmpty, objectProperties, relationshipProperties) || notEmpty
+	}
 	if !notEmpty {
-		return nil, nil
+		b.Truncate(start)
+		return false
 	}
-	JSONWrite(&b, '}')
-	return b.Bytes(), nil
+	JSONWrite(b, '}')
+	return true
 }
 
 // UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
@@ -6,8 +6,6 @@ import (
 	"fmt"
 	"slices"
 	"strings"
-
-	"github.com/valyala/fastjson"
 )
 
 // ActivityVocabularyType is the data type for an Activity type object
@@ -20,6 +18,7 @@ type ActivityVocabularyTypes []ActivityVocabularyType
 var Types = ActivityVocabularyTypes{
 	LinkType,
 	MentionType,
+	HashtagType,
 
 	ArticleType,
 	AudioType,
@@ -33,6 +32,12 @@ var Types = ActivityVocabularyTypes{
 	RelationshipType,
 	TombstoneType,
 	VideoType,
+	EmojiType,
+	TicketType,
+	PatchType,
+	CommitType,
+	BranchType,
+	TicketDependencyType,
 
 	QuestionType,
 
@@ -46,6 +51,7 @@ var Types = ActivityVocabularyTypes{
 	OrganizationType,
 	PersonType,
 	ServiceType,
+	RepositoryType,
 
 	AcceptType,
 	AddType,
@@ -72,6 +78,7 @@ var Types = ActivityVocabularyTypes{
 	UndoType,
 	UpdateType,
 	ViewType,
+	PushType,
 
 	ArriveType,
 	TravelType,
@@ -104,13 +111,21 @@ func (at ActivityVocabularyTypes) MarshalJSON() ([]byte, error) {
 	return b.Bytes(), nil
 }
 
+func (at ActivityVocabularyTypes) writeJSON(b *bytes.Buffer, opts EncodeOptions) bool {
+	start := b.Len()
+	if !JSONWriteActivityVocabularyTypes(b, at) {
+		b.Truncate(start)
+		return false
+	}
+	return true
+}
+
 // UnmarshalJSON decodes the receiver type from the JSON document.
 func (at *ActivityVocabularyTypes) UnmarshalJSON(b []byte) error {
 	if at == nil {
 		return fmt.Errorf("nil ActivityVocabularyTypes receiver")
 	}
-	p := fastjson.Parser{}
-	val, err := p.ParseBytes(b)
+	val, err := parseJSON(b)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,107 @@
+package activitypub
+
+// Visibility describes who can see an object or an activity, as deduced from its recipients, following the
+// conventions that Mastodon uses.
+type Visibility string
+
+const (
+	// VisibilityNone is the Visibility of the items without recipients.
+	VisibilityNone Visibility = ""
+	// VisibilityPublic is the Visibility of the items addressed to the Public collection, which are shown on
+	// the public timelines.
+	VisibilityPublic Visibility = "public"
+	// VisibilityUnlisted is the Visibility of the items which only CC the Public collection, which anyone can see,
+	// but which aren't shown on the public timelines.
+	VisibilityUnlisted Visibility = "unlisted"
+	// VisibilityFollowers is the Visibility of the items addressed to the followers of their author.
+	VisibilityFollowers Visibility = "followers"
+	// VisibilityLimited is the Visibility of the items addressed to collections which are not the followers of their
+	// author, like the members of a group, or to a list of actors.
+	VisibilityLimited Visibility = "limited"
+	// VisibilityDirect is the Visibility of the items addressed only to some actors, usually the ones mentioned
+	// in them.
+	VisibilityDirect Visibility = "direct"
+)
+
+// VisibilityOf classifies the "it" Item using its To, CC, Bto, BCC and Audience recipients.
+//
+// The item is public when the Public collection, in any of its forms: the PublicNS IRI, "as:Public" or "Public",
+// is in its To, Bto or Audience, and unlisted when it's only in its CC or BCC.
+// It is followers only when it's addressed to the followers of its author, which is the Actor of the activities,
+// or the AttributedTo of the other objects.
+// When the author is just an IRI, its followers are assumed to be at the Followers path of the IRI, like
+// Mastodon and most other implementations do.
+//
+// It works on the types which implement HasRecipients, but also on their values. Items which don't have
+// recipients, like IRIs, Links or ItemCollections, have VisibilityNone.
+func VisibilityOf(it Item) Visibility {
+	if IsNil(it) || !IsObject(it) {
+		return VisibilityNone
+	}
+
+	var author Item
+	if ActivityTypes.Match(it.GetType()) || IntransitiveActivityTypes.Match(it.GetType()) {
+		_ = OnIntransitiveActivity(it, func(act *IntransitiveActivity) error {
+			author = act.Actor
+			return nil
+		})
+	}
+
+	vis := VisibilityNone
+	_ = OnObject(it, func(ob *Object) error {
+		if IsNil(author) {
+			author = ob.AttributedTo
+		}
+		to := make(ItemCollection, 0)
+		to = append(append(append(to, ob.To...), ob.Bto...), ob.Audience...)
+		cc := make(ItemCollection, 0)
+		cc = append(append(cc, ob.CC...), ob.BCC...)
+		vis = classifyRecipients(to, cc, followersOf(author))
+		return nil
+	})
+	return vis
+}
+
+func classifyRecipients(to, cc ItemCollection, followers IRIs) Visibility {
+	if len(to)+len(cc) == 0 {
+		return VisibilityNone
+	}
+	if containsPublic(to) {
+		return VisibilityPublic
+	}
+	if containsPublic(cc) {
+		return VisibilityUnlisted
+	}
+	all := append(to, cc...)
+	for _, rec := range all {
+		if !IsNil(rec) && followers.Contains(rec.GetLink()) {
+			return VisibilityFollowers
+		}
+	}
+	for _, rec := range all {
+		if !IsNil(rec) && (CollectionTypes.Match(rec.GetType()) || ValidCollectionIRI(rec.GetLink())) {
+			return VisibilityLimited
+		}
+	}
+	return VisibilityDirect
+}
+
+func containsPublic(col ItemCollection) bool {
+	for _, rec := range col {
+		if !IsNil(rec) && isPublicNS(rec.GetLink()) {
+			return true
+		}
+	}
+	return false
+}
+
+// followersOf returns the IRIs of the followers collections of the author, which can be a collection of actors.
+func followersOf(author Item) IRIs {
+	followers := make(IRIs, 0)
+	for _, a := range DerefItem(author) {
+		if !IsNil(a) && len(a.GetLink()) > 0 {
+			followers = append(followers, Followers.IRI(a))
+		}
+	}
+	return followers
+}
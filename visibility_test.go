@@ -0,0 +1,123 @@
+package activitypub
+
+import "testing"
+
+func TestVisibilityOf(t *testing.T) {
+	alice := IRI("https://mastodon.example/users/alice")
+	aliceFollowers := IRI("https://mastodon.example/users/alice/followers")
+	bob := IRI("https://other.example/users/bob")
+	bobFollowers := IRI("https://other.example/users/bob/followers")
+	group := IRI("https://lemmy.example/c/tenforward")
+
+	tests := []struct {
+		name string
+		it   Item
+		want Visibility
+	}{
+		{
+			name: "nil",
+			want: VisibilityNone,
+		},
+		{
+			name: "IRI",
+			it:   alice,
+			want: VisibilityNone,
+		},
+		{
+			name: "collection",
+			it:   ItemCollection{&Object{To: ItemCollection{PublicNS}}},
+			want: VisibilityNone,
+		},
+		{
+			name: "no recipients",
+			it:   &Object{Type: NoteType, AttributedTo: alice},
+			want: VisibilityNone,
+		},
+		{
+			name: "public",
+			it:   &Object{Type: NoteType, AttributedTo: alice, To: ItemCollection{PublicNS}, CC: ItemCollection{aliceFollowers}},
+			want: VisibilityPublic,
+		},
+		{
+			name: "public as:Public",
+			it:   &Object{Type: NoteType, AttributedTo: alice, To: ItemCollection{IRI("as:Public")}},
+			want: VisibilityPublic,
+		},
+		{
+			name: "public Public",
+			it:   Object{Type: NoteType, AttributedTo: alice, To: ItemCollection{IRI("Public")}},
+			want: VisibilityPublic,
+		},
+		{
+			name: "public audience",
+			it:   &Object{Type: NoteType, AttributedTo: alice, Audience: ItemCollection{PublicNS}},
+			want: VisibilityPublic,
+		},
+		{
+			name: "public embedded collection",
+			it:   &Object{Type: NoteType, To: ItemCollection{&Collection{ID: PublicNS, Type: CollectionType}}},
+			want: VisibilityPublic,
+		},
+		{
+			name: "unlisted",
+			it:   &Object{Type: NoteType, AttributedTo: alice, To: ItemCollection{aliceFollowers}, CC: ItemCollection{IRI("as:Public")}},
+			want: VisibilityUnlisted,
+		},
+		{
+			name: "unlisted bcc",
+			it:   &Object{Type: NoteType, AttributedTo: alice, To: ItemCollection{aliceFollowers}, BCC: ItemCollection{PublicNS}},
+			want: VisibilityUnlisted,
+		},
+		{
+			name: "followers",
+			it:   &Object{Type: NoteType, AttributedTo: alice, To: ItemCollection{aliceFollowers}, CC: ItemCollection{bob}},
+			want: VisibilityFollowers,
+		},
+		{
+			name: "followers of an embedded author",
+			it: &Object{
+				Type:         NoteType,
+				AttributedTo: &Actor{ID: "https://pleroma.example/users/alice", Type: PersonType, Followers: IRI("https://pleroma.example/followers/alice")},
+				To:           ItemCollection{IRI("https://pleroma.example/followers/alice")},
+			},
+			want: VisibilityFollowers,
+		},
+		{
+			name: "activity followers",
+			it:   &Activity{Type: CreateType, Actor: alice, To: ItemCollection{aliceFollowers}, Object: &Object{To: ItemCollection{PublicNS}}},
+			want: VisibilityFollowers,
+		},
+		{
+			name: "question followers",
+			it:   &Question{Type: QuestionType, Actor: alice, CC: ItemCollection{aliceFollowers}},
+			want: VisibilityFollowers,
+		},
+		{
+			name: "limited to the followers of another actor",
+			it:   &Object{Type: NoteType, AttributedTo: alice, To: ItemCollection{bobFollowers}},
+			want: VisibilityLimited,
+		},
+		{
+			name: "limited to an embedded collection",
+			it:   &Object{Type: NoteType, AttributedTo: alice, To: ItemCollection{&Collection{ID: "https://mastodon.example/lists/1", Type: CollectionType}}},
+			want: VisibilityLimited,
+		},
+		{
+			name: "direct",
+			it:   &Object{Type: NoteType, AttributedTo: alice, To: ItemCollection{bob, group}},
+			want: VisibilityDirect,
+		},
+		{
+			name: "direct without author",
+			it:   &Object{Type: NoteType, To: ItemCollection{bob}, Bto: ItemCollection{nil}},
+			want: VisibilityDirect,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VisibilityOf(tt.it); got != tt.want {
+				t.Errorf("VisibilityOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}